@@ -0,0 +1,259 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client is a small, stable client library for projects
+// embedding Teleport access.
+//
+// Unlike the packages under lib, which are internal and change between
+// minor releases without notice, the types and functions exported here
+// follow semantic versioning: breaking changes only happen on major
+// releases.
+//
+// A typical embedding looks like:
+//
+//   clt, err := client.Login(ctx, client.Config{
+//       ProxyAddr: "proxy.example.com:3080",
+//       Username:  "alice",
+//   })
+//   ...
+//   nodes, err := clt.ListNodes(ctx)
+//   ...
+//   err = clt.RunCommand(ctx, "db-1", []string{"uptime"}, os.Stdout, os.Stderr)
+//
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/defaults"
+
+	"github.com/gravitational/trace"
+)
+
+// Config describes how to reach and authenticate to a cluster,
+// fields not marked optional are required
+type Config struct {
+	// ProxyAddr is the host:port of the cluster proxy web endpoint,
+	// for example "proxy.example.com:3080"
+	ProxyAddr string
+	// Username is the Teleport user to authenticate as
+	Username string
+	// Login is the remote host login commands and sessions run as,
+	// defaults to Username (optional)
+	Login string
+	// KeysDir is the directory client keys are cached in,
+	// "~/.tsh" by default (optional)
+	KeysDir string
+	// Insecure skips verification of the proxy TLS certificate,
+	// only use this in tests (optional)
+	Insecure bool
+	// Stdin is the input stream used during login for password
+	// and second factor prompts, os.Stdin by default (optional)
+	Stdin io.Reader
+}
+
+// Node describes a cluster node, the zero value of every
+// field means the attribute is not set
+type Node struct {
+	// Name is the unique node identifier
+	Name string
+	// Hostname is the node hostname
+	Hostname string
+	// Addr is the host:port the node can be reached at
+	Addr string
+	// Labels combines the static and dynamic labels of the node
+	Labels map[string]string
+}
+
+// Client is an authenticated client bound to a single user
+// and cluster. Methods on Client are safe for concurrent use.
+type Client struct {
+	tc *client.TeleportClient
+}
+
+// Login authenticates against the cluster proxy and returns a client
+// with an activated session key. Depending on the cluster settings the
+// user is prompted for a password and second factor on Stdin.
+func Login(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.ProxyAddr == "" {
+		return nil, trace.BadParameter("missing parameter ProxyAddr")
+	}
+	if cfg.Username == "" {
+		return nil, trace.BadParameter("missing parameter Username")
+	}
+	login := cfg.Login
+	if login == "" {
+		login = cfg.Username
+	}
+	tc, err := client.NewClient(&client.Config{
+		Username:           cfg.Username,
+		HostLogin:          login,
+		WebProxyAddr:       cfg.ProxyAddr,
+		KeysDir:            cfg.KeysDir,
+		InsecureSkipVerify: cfg.Insecure,
+		Stdin:              cfg.Stdin,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// activating the key also fetches the cluster certificate
+	// authorities, so host certificates presented by nodes are trusted
+	if _, err := tc.Login(ctx, true); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Client{tc: tc}, nil
+}
+
+// ListNodes returns the nodes of the cluster the user has access to
+func (c *Client) ListNodes(ctx context.Context) ([]Node, error) {
+	servers, err := c.tc.ListNodes(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	nodes := make([]Node, 0, len(servers))
+	for _, server := range servers {
+		nodes = append(nodes, Node{
+			Name:     server.GetName(),
+			Hostname: server.GetHostname(),
+			Addr:     server.GetAddr(),
+			Labels:   server.GetAllLabels(),
+		})
+	}
+	return nodes, nil
+}
+
+// RunCommand executes a command on the host (a hostname or node name
+// as returned by ListNodes) and streams its output to the supplied
+// writers, it returns an error if the command exits non-zero
+func (c *Client) RunCommand(ctx context.Context, host string, command []string, stdout, stderr io.Writer) error {
+	if len(command) == 0 {
+		return trace.BadParameter("missing command to run")
+	}
+	tc, err := c.sessionClient(host, nil, stdout, stderr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(tc.SSH(ctx, command, false))
+}
+
+// OpenSession opens an interactive shell on the host wired to the
+// supplied streams, it blocks until the session ends
+func (c *Client) OpenSession(ctx context.Context, host string, stdin io.Reader, stdout io.Writer) error {
+	tc, err := c.sessionClient(host, stdin, stdout, stdout)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(tc.SSH(ctx, nil, false))
+}
+
+// StreamSession attaches to a session in read-only mode and copies
+// its terminal output to w, blocking until the session ends. It works
+// both for live sessions, which are mirrored as they happen, and for
+// finished recordings.
+func (c *Client) StreamSession(ctx context.Context, sessionID string, w io.Writer) error {
+	return trace.Wrap(c.tc.StreamSession(ctx, defaults.Namespace, sessionID, client.SessionStreamHandler{
+		Output: w,
+	}))
+}
+
+// DialTCP dials the address addr (host:port) from the host, similar
+// to openssh direct-tcpip forwarding, the returned connection is
+// tunneled through the proxy and the node
+func (c *Client) DialTCP(ctx context.Context, host, addr string) (net.Conn, error) {
+	proxy, err := c.tc.ConnectToProxy(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	nodeAddr, err := c.resolveNode(ctx, proxy, host)
+	if err != nil {
+		proxy.Close()
+		return nil, trace.Wrap(err)
+	}
+	nodeClient, err := proxy.ConnectToNode(ctx, client.NodeAddr{
+		Addr:      nodeAddr,
+		Namespace: defaults.Namespace,
+		Cluster:   c.tc.SiteName,
+	}, c.tc.HostLogin, false)
+	if err != nil {
+		proxy.Close()
+		return nil, trace.Wrap(err)
+	}
+	conn, err := nodeClient.Client.Dial("tcp", addr)
+	if err != nil {
+		nodeClient.Close()
+		proxy.Close()
+		return nil, trace.Wrap(err)
+	}
+	return conn, nil
+}
+
+// Close releases client resources, it does not log the user out
+func (c *Client) Close() error {
+	return nil
+}
+
+// Logout removes the session keys of this user from the local cache
+func (c *Client) Logout() error {
+	return trace.Wrap(c.tc.Logout())
+}
+
+// sessionClient clones the underlying client with the target host
+// and streams configured, keeping the authenticated key store shared
+func (c *Client) sessionClient(host string, stdin io.Reader, stdout, stderr io.Writer) (*client.TeleportClient, error) {
+	conf := c.tc.Config
+	conf.Host = host
+	if stdin != nil {
+		conf.Stdin = stdin
+	}
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = stdout
+	}
+	conf.Stdout = stdout
+	conf.Stderr = stderr
+	tc, err := client.NewClient(&conf)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return tc, nil
+}
+
+// resolveNode matches host against node names and hostnames known
+// to the cluster and returns the dial address of the matching node,
+// an unknown host is passed through with the default SSH port so
+// that direct addresses keep working
+func (c *Client) resolveNode(ctx context.Context, proxy *client.ProxyClient, host string) (string, error) {
+	servers, err := proxy.FindServersByLabels(ctx, defaults.Namespace, nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	for _, server := range servers {
+		if server.GetName() == host || server.GetHostname() == host {
+			return server.GetAddr(), nil
+		}
+	}
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host, nil
+	}
+	return net.JoinHostPort(host, strconv.Itoa(defaults.SSHServerListenPort)), nil
+}
@@ -49,6 +49,10 @@ const (
 
 	// SSHSessionID is the UUID of the current session.
 	SSHSessionID = "SSH_SESSION_ID"
+
+	// SSHTeleportTraceID is the correlation ID of the current connection,
+	// shared across proxy, auth and node log lines.
+	SSHTeleportTraceID = "SSH_TELEPORT_TRACE_ID"
 )
 
 const (
@@ -516,6 +520,11 @@ const Root = "root"
 // another role is not explicitly assigned (Enterprise only).
 const AdminRoleName = "admin"
 
+// AuditorRoleName is the name of the built-in read-only auditor role that
+// grants access to audit events and session recordings but no ability to
+// connect to nodes or mutate resources.
+const AuditorRoleName = "auditor"
+
 // DefaultImplicitRole is implicit role that gets added to all service.RoleSet
 // objects.
 const DefaultImplicitRole = "default-implicit-role"
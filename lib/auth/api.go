@@ -91,6 +91,12 @@ type ReadAccessPoint interface {
 	// GetRoles returns a list of roles
 	GetRoles() ([]services.Role, error)
 
+	// GetLocks returns a list of locks
+	GetLocks() ([]services.Lock, error)
+
+	// GetLock returns lock by name
+	GetLock(name string) (services.Lock, error)
+
 	// GetAllTunnelConnections returns all tunnel connections
 	GetAllTunnelConnections(opts ...services.MarshalOption) ([]services.TunnelConnection, error)
 
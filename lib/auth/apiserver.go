@@ -23,10 +23,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gravitational/teleport"
@@ -34,6 +36,7 @@ import (
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/httplib"
+	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/utils"
@@ -73,6 +76,11 @@ type APIServer struct {
 	APIConfig
 	httprouter.Router
 	clockwork.Clock
+	idempotency *idempotencyCache
+
+	// clusterLimiter rate limits login and join attempts cluster-wide,
+	// counting them in the backend shared by all auth servers
+	clusterLimiter *limiter.ClusterLimiter
 }
 
 // NewAPIServer returns a new instance of APIServer HTTP handler
@@ -81,6 +89,20 @@ func NewAPIServer(config *APIConfig) http.Handler {
 		APIConfig: *config,
 		Clock:     clockwork.NewRealClock(),
 	}
+	srv.idempotency = newIdempotencyCache(srv.Clock)
+	if config.AuthServer != nil && config.AuthServer.bk != nil {
+		clusterLimiter, err := limiter.NewClusterLimiter(limiter.ClusterLimiterConfig{
+			Backend: config.AuthServer.bk,
+			Prefix:  "login",
+			Period:  defaults.LimiterClusterLoginPeriod,
+			Max:     defaults.LimiterClusterLoginMax,
+		})
+		if err != nil {
+			log.Warningf("Failed to create cluster rate limiter: %v.", err)
+		} else {
+			srv.clusterLimiter = clusterLimiter
+		}
+	}
 	srv.Router = *httprouter.New()
 
 	// Kubernetes extensions
@@ -88,11 +110,13 @@ func NewAPIServer(config *APIConfig) http.Handler {
 
 	// Operations on certificate authorities
 	srv.GET("/:version/domain", srv.withAuth(srv.getDomainName))
+	srv.GET("/:version/servertime", srv.withAuth(srv.getServerTime))
 	srv.GET("/:version/cacert", srv.withAuth(srv.getClusterCACert))
 
 	srv.POST("/:version/authorities/:type", srv.withAuth(srv.upsertCertAuthority))
 	srv.POST("/:version/authorities/:type/rotate", srv.withAuth(srv.rotateCertAuthority))
 	srv.POST("/:version/authorities/:type/rotate/external", srv.withAuth(srv.rotateExternalCertAuthority))
+	srv.POST("/:version/recordingkeys/rotate", srv.withAuth(srv.rotateRecordingKey))
 	srv.DELETE("/:version/authorities/:type/:domain", srv.withAuth(srv.deleteCertAuthority))
 	srv.GET("/:version/authorities/:type/:domain", srv.withAuth(srv.getCertAuthority))
 	srv.GET("/:version/authorities/:type", srv.withAuth(srv.getCertAuthorities))
@@ -115,8 +139,8 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/users/:user/web/password", srv.withAuth(srv.upsertPassword))
 	srv.POST("/:version/users/:user/web/password/check", srv.withRate(srv.withAuth(srv.checkPassword)))
 	srv.POST("/:version/users/:user/web/sessions", srv.withAuth(srv.createWebSession))
-	srv.POST("/:version/users/:user/web/authenticate", srv.withAuth(srv.authenticateWebUser))
-	srv.POST("/:version/users/:user/ssh/authenticate", srv.withAuth(srv.authenticateSSHUser))
+	srv.POST("/:version/users/:user/web/authenticate", srv.withClusterRate(srv.withAuth(srv.authenticateWebUser)))
+	srv.POST("/:version/users/:user/ssh/authenticate", srv.withClusterRate(srv.withAuth(srv.authenticateSSHUser)))
 	srv.GET("/:version/users/:user/web/sessions/:sid", srv.withAuth(srv.getWebSession))
 	srv.DELETE("/:version/users/:user/web/sessions/:sid", srv.withAuth(srv.deleteWebSession))
 	srv.POST("/:version/web/password/token", srv.withRate(srv.withAuth(srv.changePasswordWithToken)))
@@ -125,7 +149,7 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/namespaces/:namespace/nodes", srv.withAuth(srv.upsertNode))
 	srv.POST("/:version/namespaces/:namespace/nodes/keepalive", srv.withAuth(srv.keepAliveNode))
 	srv.PUT("/:version/namespaces/:namespace/nodes", srv.withAuth(srv.upsertNodes))
-	srv.GET("/:version/namespaces/:namespace/nodes", srv.withAuth(srv.getNodes))
+	srv.GET("/:version/namespaces/:namespace/nodes", httplib.MakeGzipHandler(srv.withAuth(srv.getNodes)))
 	srv.DELETE("/:version/namespaces/:namespace/nodes", srv.withAuth(srv.deleteAllNodes))
 	srv.DELETE("/:version/namespaces/:namespace/nodes/:name", srv.withAuth(srv.deleteNode))
 	srv.POST("/:version/authservers", srv.withAuth(srv.upsertAuthServer))
@@ -164,7 +188,7 @@ func NewAPIServer(config *APIConfig) http.Handler {
 
 	// Tokens
 	srv.POST("/:version/tokens", srv.withAuth(srv.generateToken))
-	srv.POST("/:version/tokens/register", srv.withAuth(srv.registerUsingToken))
+	srv.POST("/:version/tokens/register", srv.withClusterRate(srv.withAuth(srv.registerUsingToken)))
 	srv.POST("/:version/tokens/register/auth", srv.withAuth(srv.registerNewAuthServer))
 
 	// active sesssions
@@ -184,12 +208,27 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.GET("/:version/namespaces/:namespace", srv.withAuth(srv.getNamespace))
 	srv.DELETE("/:version/namespaces/:namespace", srv.withAuth(srv.deleteNamespace))
 
+	// Cluster notices
+	srv.POST("/:version/notices", srv.withAuth(srv.upsertClusterNotice))
+	srv.GET("/:version/notices", srv.withAuth(srv.getClusterNotices))
+	srv.DELETE("/:version/notices/:name", srv.withAuth(srv.deleteClusterNotice))
+
+	// Join failures recorded by the auth server
+	srv.GET("/:version/joinfailures", srv.withAuth(srv.getJoinFailures))
+
 	// Roles
 	srv.POST("/:version/roles", srv.withAuth(srv.upsertRole))
+	srv.POST("/:version/roles/:role/compare-and-swap", srv.withAuth(srv.compareAndSwapRole))
 	srv.GET("/:version/roles", srv.withAuth(srv.getRoles))
 	srv.GET("/:version/roles/:role", srv.withAuth(srv.getRole))
 	srv.DELETE("/:version/roles/:role", srv.withAuth(srv.deleteRole))
 
+	// Locks
+	srv.POST("/:version/locks", srv.withAuth(srv.upsertLock))
+	srv.GET("/:version/locks", srv.withAuth(srv.getLocks))
+	srv.GET("/:version/locks/:lock", srv.withAuth(srv.getLock))
+	srv.DELETE("/:version/locks/:lock", srv.withAuth(srv.deleteLock))
+
 	// cluster configuration
 	srv.GET("/:version/configuration", srv.withAuth(srv.getClusterConfig))
 	srv.POST("/:version/configuration", srv.withAuth(srv.setClusterConfig))
@@ -229,7 +268,7 @@ func NewAPIServer(config *APIConfig) http.Handler {
 
 	// U2F
 	srv.GET("/:version/u2f/signuptokens/:token", srv.withAuth(srv.getSignupU2FRegisterRequest))
-	srv.POST("/:version/u2f/users/:user/sign", srv.withAuth(srv.u2fSignRequest))
+	srv.POST("/:version/u2f/users/:user/sign", srv.withClusterRate(srv.withAuth(srv.u2fSignRequest)))
 	srv.GET("/:version/u2f/appid", srv.withAuth(srv.getU2FAppID))
 
 	// Provisioning tokens
@@ -241,6 +280,11 @@ func NewAPIServer(config *APIConfig) http.Handler {
 	srv.POST("/:version/events", srv.withAuth(srv.emitAuditEvent))
 	srv.GET("/:version/events", srv.withAuth(srv.searchEvents))
 	srv.GET("/:version/events/session", srv.withAuth(srv.searchSessionEvents))
+	srv.GET("/:version/lastconnected", srv.withAuth(srv.getLastConnected))
+
+	// Operations on issued certificate records and device revocation
+	srv.GET("/:version/devices/certs", srv.withAuth(srv.getIssuedCerts))
+	srv.POST("/:version/devices/revoke", srv.withAuth(srv.revokeDevice))
 
 	if plugin := GetPlugin(); plugin != nil {
 		plugin.AddHandlers(&srv)
@@ -285,10 +329,95 @@ func (s *APIServer) withAuth(handler HandlerWithAuthFunc) httprouter.Handle {
 		if version == "" {
 			return nil, trace.BadParameter("missing version")
 		}
-		return handler(auth, w, r, p, version)
+		// Registered authorization middleware gets a chance to deny the
+		// request after the built-in authorizer has admitted it.
+		if err := authzMiddleware.check(r.Context(), AuthzRequest{
+			User:   authContext.User.GetName(),
+			Class:  classifyEndpoint(r),
+			Method: r.Method,
+			Path:   r.URL.Path,
+		}); err != nil {
+			s.emitAuthzDenied(r, authContext.User.GetName(), err)
+			return nil, trace.AccessDenied(accessDeniedMsg + "[01]")
+		}
+		// Mutating requests retried by the client carry an idempotency
+		// key: answer repeated keys with the remembered response instead
+		// of executing the call twice.
+		key := r.Header.Get(IdempotencyKeyHeader)
+		replayable := key != "" && r.Method != http.MethodGet && r.Method != http.MethodHead
+		if replayable {
+			// scope the key by the calling identity so one user can not
+			// replay responses of another
+			key = authContext.User.GetName() + "/" + key
+			if cached, ok := s.idempotency.get(key); ok {
+				return cached, nil
+			}
+		}
+		out, err := handler(auth, w, r, p, version)
+		if replayable && err == nil {
+			s.idempotency.put(key, out)
+		}
+		return out, err
 	})
 }
 
+const (
+	// idempotencyCacheTTL is how long responses to mutating calls are
+	// remembered for replay detection
+	idempotencyCacheTTL = time.Minute
+	// idempotencyCacheSize caps the number of remembered responses
+	idempotencyCacheSize = 1000
+)
+
+type cachedResponse struct {
+	value   interface{}
+	expires time.Time
+}
+
+// idempotencyCache remembers responses of recently executed mutating calls
+// keyed by their idempotency key, so a request retried by the client after
+// a transient failure is answered with the original response instead of
+// being executed a second time.
+type idempotencyCache struct {
+	sync.Mutex
+	clock   clockwork.Clock
+	entries map[string]cachedResponse
+}
+
+func newIdempotencyCache(clock clockwork.Clock) *idempotencyCache {
+	return &idempotencyCache{
+		clock:   clock,
+		entries: make(map[string]cachedResponse),
+	}
+}
+
+// get returns the remembered response for the key, if any.
+func (c *idempotencyCache) get(key string) (interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || c.clock.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// put remembers the response for the key, evicting expired entries.
+func (c *idempotencyCache) put(key string, value interface{}) {
+	c.Lock()
+	defer c.Unlock()
+	now := c.clock.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) >= idempotencyCacheSize {
+		return
+	}
+	c.entries[key] = cachedResponse{value: value, expires: now.Add(idempotencyCacheTTL)}
+}
+
 // withRate wrap a rate limiter around the passed in httprouter.Handle and
 // returns a httprouter.Handle. Because the rate limiter wraps a http.Handler,
 // internally withRate converts to the standard handler and back.
@@ -316,6 +445,32 @@ func (s *APIServer) withRate(handle httprouter.Handle) httprouter.Handle {
 	return fromStandard(limiter)
 }
 
+// withClusterRate wraps the cluster-wide rate limiter around the passed in
+// httprouter.Handle. Unlike withRate, which counts requests in process
+// memory, the counters live in the backend and are shared by every auth
+// server, so the limit cannot be sidestepped by spreading requests across
+// replicas.
+func (s *APIServer) withClusterRate(handle httprouter.Handle) httprouter.Handle {
+	if s.clusterLimiter == nil {
+		return handle
+	}
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		token := p.ByName("user")
+		if token == "" {
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				token = host
+			} else {
+				token = r.RemoteAddr
+			}
+		}
+		if err := s.clusterLimiter.RegisterRequest(r.Context(), token); err != nil {
+			trace.WriteError(w, trace.Wrap(err))
+			return
+		}
+		handle(w, r, p)
+	}
+}
+
 type upsertServerRawReq struct {
 	Server json.RawMessage `json:"server"`
 	TTL    time.Duration   `json:"ttl"`
@@ -425,6 +580,37 @@ func (s *APIServer) getNodes(auth ClientI, w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	sinceRevision, delta, err := httplib.ParseInt64(r.URL.Query(), "since_revision")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	limit, paged, err := httplib.ParseInt64(r.URL.Query(), "limit")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if paged {
+		var labels map[string]string
+		if rawLabels := r.URL.Query().Get("labels"); rawLabels != "" {
+			if err := json.Unmarshal([]byte(rawLabels), &labels); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
+		servers, nextKey, err := auth.ListNodes(services.ListNodesRequest{
+			Namespace: namespace,
+			Limit:     int(limit),
+			StartKey:  r.URL.Query().Get("start_key"),
+			Labels:    labels,
+			Selector:  r.URL.Query().Get("selector"),
+		})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		items, err := marshalServers(servers, version)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return listNodesRawResponse{Servers: items, NextKey: nextKey}, nil
+	}
 	var opts []services.MarshalOption
 	if skipValidation {
 		opts = append(opts, services.SkipValidation())
@@ -434,9 +620,54 @@ func (s *APIServer) getNodes(auth ClientI, w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if delta {
+		return marshalNodesDelta(servers, sinceRevision, version)
+	}
 	return marshalServers(servers, version)
 }
 
+// listNodesRawResponse is a single page of nodes and the continuation key
+// to request the next page, empty on the last page.
+type listNodesRawResponse struct {
+	Servers interface{} `json:"servers"`
+	NextKey string      `json:"next_key,omitempty"`
+}
+
+// nodesDeltaRawResponse lists the nodes changed since the revision the
+// client has already synced to, the latest revision and the names of all
+// present nodes so the client can prune deleted ones.
+type nodesDeltaRawResponse struct {
+	Revision int64             `json:"revision"`
+	Present  []string          `json:"present"`
+	Nodes    []json.RawMessage `json:"nodes"`
+}
+
+// marshalNodesDelta builds the delta response: only nodes with a revision
+// past sinceRevision carry full payloads, the rest contribute their name
+// to the presence list, so steady-state sync traffic is proportional to
+// churn instead of cluster size.
+func marshalNodesDelta(servers []services.Server, sinceRevision int64, version string) (interface{}, error) {
+	out := nodesDeltaRawResponse{
+		Revision: sinceRevision,
+		Present:  make([]string, 0, len(servers)),
+	}
+	for _, server := range servers {
+		out.Present = append(out.Present, server.GetName())
+		if server.GetResourceID() > out.Revision {
+			out.Revision = server.GetResourceID()
+		}
+		if server.GetResourceID() <= sinceRevision {
+			continue
+		}
+		data, err := services.GetServerMarshaler().MarshalServer(server, services.WithVersion(version), services.PreserveResourceID())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out.Nodes = append(out.Nodes, data)
+	}
+	return &out, nil
+}
+
 // deleteAllNodes deletes all nodes
 func (s *APIServer) deleteAllNodes(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	namespace := p.ByName("namespace")
@@ -1040,6 +1271,19 @@ func (s *APIServer) rotateCertAuthority(auth ClientI, w http.ResponseWriter, r *
 	return message("ok"), nil
 }
 
+type rotateRecordingKeyRawResponse struct {
+	// KeyID is the ID of the newly generated cluster recording key.
+	KeyID string `json:"key_id"`
+}
+
+func (s *APIServer) rotateRecordingKey(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	keyID, err := auth.RotateRecordingKey()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &rotateRecordingKeyRawResponse{KeyID: keyID}, nil
+}
+
 type upsertCertAuthorityRawReq struct {
 	CA  json.RawMessage `json:"ca"`
 	TTL time.Duration   `json:"ttl"`
@@ -1127,6 +1371,16 @@ func (s *APIServer) getDomainName(auth ClientI, w http.ResponseWriter, r *http.R
 	return domain, nil
 }
 
+// getServerTime returns the current time of the auth server, used by
+// connecting agents to detect local clock drift.
+func (s *APIServer) getServerTime(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	serverTime, err := auth.GetServerTime()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return serverTime, nil
+}
+
 // getClusterCACert returns the CAs for the local cluster without signing keys.
 func (s *APIServer) getClusterCACert(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	localCA, err := auth.GetClusterCACert()
@@ -1805,6 +2059,48 @@ func (s *APIServer) searchEvents(auth ClientI, w http.ResponseWriter, r *http.Re
 	return eventsList, nil
 }
 
+// HTTP GET /:version/lastconnected?user=alice
+//
+// Returns last connected summaries aggregated from session start events,
+// optionally filtered by user.
+func (s *APIServer) getLastConnected(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	connected, err := auth.GetLastConnected(r.URL.Query().Get("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return connected, nil
+}
+
+// HTTP GET /:version/devices/certs?user=alice
+//
+// Returns records of issued user certificates, optionally filtered by user.
+func (s *APIServer) getIssuedCerts(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	issued, err := auth.GetIssuedCerts(r.URL.Query().Get("user"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return issued, nil
+}
+
+type revokeDeviceReq struct {
+	// Fingerprint is the client key fingerprint to revoke.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// HTTP POST /:version/devices/revoke
+//
+// Revokes a device by its client key fingerprint.
+func (s *APIServer) revokeDevice(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req revokeDeviceReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.RevokeDevice(req.Fingerprint); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
 // searchSessionEvents only allows searching audit log for events related to session playback.
 func (s *APIServer) searchSessionEvents(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	var err error
@@ -2052,6 +2348,44 @@ func (s *APIServer) getSessionEvents(auth ClientI, w http.ResponseWriter, r *htt
 	return auth.GetSessionEvents(namespace, *sid, afterN, includePrintEvents)
 }
 
+type upsertClusterNoticeReq struct {
+	Notice ClusterNotice `json:"notice"`
+}
+
+func (s *APIServer) upsertClusterNotice(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req *upsertClusterNoticeReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := auth.UpsertClusterNotice(req.Notice); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getClusterNotices(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	notices, err := auth.GetClusterNotices()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return notices, nil
+}
+
+func (s *APIServer) deleteClusterNotice(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	if err := auth.DeleteClusterNotice(p.ByName("name")); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message("ok"), nil
+}
+
+func (s *APIServer) getJoinFailures(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	failures, err := auth.GetJoinFailures()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return failures, nil
+}
+
 type upsertNamespaceReq struct {
 	Namespace services.Namespace `json:"namespace"`
 }
@@ -2121,6 +2455,31 @@ func (s *APIServer) upsertRole(auth ClientI, w http.ResponseWriter, r *http.Requ
 	return message(fmt.Sprintf("'%v' role upserted", role.GetName())), nil
 }
 
+type compareAndSwapRoleRawReq struct {
+	New      json.RawMessage `json:"new"`
+	Existing json.RawMessage `json:"existing"`
+}
+
+func (s *APIServer) compareAndSwapRole(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req *compareAndSwapRoleRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	new, err := services.GetRoleMarshaler().UnmarshalRole(req.New)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	existing, err := services.GetRoleMarshaler().UnmarshalRole(req.Existing)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = auth.CompareAndSwapRole(r.Context(), new, existing)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("'%v' role updated", new.GetName())), nil
+}
+
 func (s *APIServer) getRole(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	role, err := auth.GetRole(p.ByName("role"))
 	if err != nil {
@@ -2153,6 +2512,58 @@ func (s *APIServer) deleteRole(auth ClientI, w http.ResponseWriter, r *http.Requ
 	return message(fmt.Sprintf("role %q deleted", role)), nil
 }
 
+type upsertLockRawReq struct {
+	Lock json.RawMessage `json:"lock"`
+}
+
+func (s *APIServer) upsertLock(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	var req *upsertLockRawReq
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	lock, err := services.GetLockMarshaler().Unmarshal(req.Lock)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	err = auth.UpsertLock(r.Context(), lock)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("'%v' lock upserted", lock.GetName())), nil
+}
+
+func (s *APIServer) getLock(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	lock, err := auth.GetLock(p.ByName("lock"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return rawMessage(services.GetLockMarshaler().Marshal(lock, services.WithVersion(version), services.PreserveResourceID()))
+}
+
+func (s *APIServer) getLocks(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	locks, err := auth.GetLocks()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]json.RawMessage, len(locks))
+	for i, lock := range locks {
+		raw, err := services.GetLockMarshaler().Marshal(lock, services.WithVersion(version), services.PreserveResourceID())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out[i] = raw
+	}
+	return out, nil
+}
+
+func (s *APIServer) deleteLock(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
+	lock := p.ByName("lock")
+	if err := auth.DeleteLock(r.Context(), lock); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return message(fmt.Sprintf("lock %q deleted", lock)), nil
+}
+
 func (s *APIServer) getClusterConfig(auth ClientI, w http.ResponseWriter, r *http.Request, p httprouter.Params, version string) (interface{}, error) {
 	cc, err := auth.GetClusterConfig()
 	if err != nil {
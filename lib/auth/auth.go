@@ -20,7 +20,6 @@ limitations under the License.
 // * Authority server itself that implements signing and acl logic
 // * HTTP server wrapper for authority server
 // * HTTP client wrapper
-//
 package auth
 
 import (
@@ -118,6 +117,8 @@ func NewServer(cfg *InitConfig, opts ...ServerOption) (*Server, error) {
 		closeCtx:        closeCtx,
 		emitter:         cfg.Emitter,
 		streamer:        cfg.Streamer,
+
+		recordingEncryptor: cfg.RecordingEncryptor,
 		Services: Services{
 			Trust:                cfg.Trust,
 			Presence:             cfg.Presence,
@@ -185,8 +186,8 @@ var (
 // Server keeps the cluster together. It acts as a certificate authority (CA) for
 // a cluster and:
 //   - generates the keypair for the node it's running on
-//	 - invites other SSH nodes to a cluster, by issuing invite tokens
-//	 - adds other SSH nodes to a cluster, by checking their token and signing their keys
+//   - invites other SSH nodes to a cluster, by issuing invite tokens
+//   - adds other SSH nodes to a cluster, by checking their token and signing their keys
 //   - same for users and their sessions
 //   - checks public keys to see if they're signed by it (can be trusted or not)
 type Server struct {
@@ -233,6 +234,14 @@ type Server struct {
 	// streamer is events sessionstreamer, used to create continuous
 	// session related streams
 	streamer events.Streamer
+
+	// recordingEncryptor seals session recordings at rest, nil unless
+	// session recording encryption is enabled.
+	recordingEncryptor *events.RecordingEncryptor
+
+	// certExpiryNotify is an optional callback invoked when a stored
+	// certificate is approaching expiry.
+	certExpiryNotify func(CertExpiryWarning)
 }
 
 // SetCache sets cache used by auth server
@@ -318,6 +327,12 @@ func (a *Server) GetClusterName(opts ...services.MarshalOption) (services.Cluste
 	return a.GetCache().GetClusterName(opts...)
 }
 
+// GetServerTime returns the current time of the auth server, used by
+// connecting agents to detect local clock drift.
+func (a *Server) GetServerTime() (time.Time, error) {
+	return a.clock.Now().UTC(), nil
+}
+
 // GetDomainName returns the domain name that identifies this authority server.
 // Also known as "cluster name"
 func (a *Server) GetDomainName() (string, error) {
@@ -441,6 +456,12 @@ type certRequest struct {
 	// activeRequests tracks privilege escalation requests applied
 	// during the construction of the certificate.
 	activeRequests services.RequestIDs
+	// clientVersion is the version of the client that requested the
+	// certificate, recorded with the issued certificate when set.
+	clientVersion string
+	// clientAddr is the address the certificate request originated from,
+	// recorded with the issued certificate when set.
+	clientAddr string
 }
 
 // GenerateUserTestCerts is used to generate user certificate, used internally for tests
@@ -470,6 +491,15 @@ func (a *Server) GenerateUserTestCerts(key []byte, username string, ttl time.Dur
 
 // generateUserCert generates user certificates
 func (a *Server) generateUserCert(req certRequest) (*certs, error) {
+	// refuse to issue certificates for revoked devices
+	revoked, err := a.isDeviceRevoked(req.publicKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if revoked {
+		return nil, trace.AccessDenied("the client key has been revoked")
+	}
+
 	// reuse the same RSA keys for SSH and TLS keys
 	cryptoPubKey, err := sshutils.CryptoPublicKey(req.publicKey)
 	if err != nil {
@@ -598,6 +628,18 @@ func (a *Server) generateUserCert(req certRequest) (*certs, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+
+	if fingerprint, err := sshutils.AuthorizedKeyFingerprint(req.publicKey); err == nil {
+		a.recordIssuedCert(IssuedCert{
+			User:          req.user.GetName(),
+			Fingerprint:   fingerprint,
+			ClientVersion: req.clientVersion,
+			ClientAddr:    req.clientAddr,
+			Issued:        a.clock.Now().UTC(),
+			Expires:       a.clock.Now().UTC().Add(sessionTTL),
+		})
+	}
+
 	return &certs{ssh: sshCert, tls: tlsCert}, nil
 }
 
@@ -844,6 +886,9 @@ type GenerateTokenRequest struct {
 	Roles teleport.Roles `json:"roles"`
 	// TTL is a time to live for token
 	TTL time.Duration `json:"ttl"`
+	// SingleUse, if set, revokes the token right after its first
+	// successful use.
+	SingleUse bool `json:"single_use,omitempty"`
 }
 
 // CheckAndSetDefaults checks and sets default values of request
@@ -875,6 +920,9 @@ func (a *Server) GenerateToken(ctx context.Context, req GenerateTokenRequest) (s
 	if err != nil {
 		return "", trace.Wrap(err)
 	}
+	if req.SingleUse {
+		services.MarkTokenSingleUse(token)
+	}
 	if err := a.Provisioner.UpsertToken(token); err != nil {
 		return "", trace.Wrap(err)
 	}
@@ -1226,6 +1274,7 @@ func (a *Server) RegisterUsingToken(req RegisterUsingTokenRequest) (*PackedKeys,
 	roles, err := a.ValidateToken(req.Token)
 	if err != nil {
 		log.Warningf("%q [%v] can not join the cluster with role %s, token error: %v", req.NodeName, req.HostID, req.Role, err)
+		a.recordJoinFailure(req, trace.Wrap(err, "the token is not valid"))
 		return nil, trace.AccessDenied(fmt.Sprintf("%q [%v] can not join the cluster with role %s, the token is not valid", req.NodeName, req.HostID, req.Role))
 	}
 
@@ -1233,6 +1282,7 @@ func (a *Server) RegisterUsingToken(req RegisterUsingTokenRequest) (*PackedKeys,
 	if !roles.Include(req.Role) {
 		msg := fmt.Sprintf("node %q [%v] can not join the cluster, the token does not allow %q role", req.NodeName, req.HostID, req.Role)
 		log.Warn(msg)
+		a.recordJoinFailure(req, trace.BadParameter("the token does not allow %q role", req.Role))
 		return nil, trace.BadParameter(msg)
 	}
 
@@ -1248,8 +1298,17 @@ func (a *Server) RegisterUsingToken(req RegisterUsingTokenRequest) (*PackedKeys,
 		DNSNames:             req.DNSNames,
 	})
 	if err != nil {
+		a.recordJoinFailure(req, err)
 		return nil, trace.Wrap(err)
 	}
+
+	// single-use tokens are revoked right after the first successful join
+	if tok, err := a.GetCache().GetToken(req.Token); err == nil && services.IsSingleUseToken(tok) {
+		if err := a.DeleteToken(req.Token); err != nil && !trace.IsNotFound(err) {
+			log.WithError(err).Warn("Unable to revoke single-use token.")
+		}
+	}
+
 	log.Infof("Node %q [%v] has joined the cluster.", req.NodeName, req.HostID)
 	return keys, nil
 }
@@ -1385,6 +1444,7 @@ func (a *Server) GetWebSessionInfo(userName string, id string) (services.WebSess
 	return sess.WithoutSecrets(), nil
 }
 
+// DeleteNamespace deletes a namespace along with the nodes registered in it.
 func (a *Server) DeleteNamespace(namespace string) error {
 	if namespace == defaults.Namespace {
 		return trace.AccessDenied("can't delete default namespace")
@@ -1393,12 +1453,72 @@ func (a *Server) DeleteNamespace(namespace string) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	if len(nodes) != 0 {
-		return trace.BadParameter("can't delete namespace %v that has %v registered nodes", namespace, len(nodes))
+	for _, node := range nodes {
+		if err := a.Presence.DeleteNode(namespace, node.GetName()); err != nil && !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
 	}
 	return a.Presence.DeleteNamespace(namespace)
 }
 
+// UpsertNode registers a node, enforcing the node quota of its namespace
+// when one is configured.
+func (a *Server) UpsertNode(server services.Server) (*services.KeepAlive, error) {
+	if err := a.checkNamespaceNodeQuota(server); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	a.warnOnHostnameCollision(server)
+	return a.Presence.UpsertNode(server)
+}
+
+// warnOnHostnameCollision logs a warning when a registering node shares its
+// hostname with an already registered node, connecting to the shared
+// hostname is ambiguous and listings fall back to the "hostname-shortid"
+// form. The check reads from the cache and never fails the registration.
+func (a *Server) warnOnHostnameCollision(server services.Server) {
+	nodes, err := a.GetCache().GetNodes(server.GetNamespace(), services.SkipValidation())
+	if err != nil {
+		return
+	}
+	for _, node := range nodes {
+		if node.GetName() != server.GetName() && node.GetHostname() == server.GetHostname() {
+			log.Warningf("Node %v shares hostname %q with node %v, use %q to connect to it unambiguously.",
+				server.GetName(), server.GetHostname(), node.GetName(),
+				fmt.Sprintf("%v-%v", server.GetHostname(), services.ShortNodeID(server.GetName())))
+			return
+		}
+	}
+}
+
+// checkNamespaceNodeQuota makes sure registering a node does not push its
+// namespace over the max_nodes quota. Heartbeats of already registered
+// nodes always pass.
+func (a *Server) checkNamespaceNodeQuota(server services.Server) error {
+	ns, err := a.Presence.GetNamespace(server.GetNamespace())
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+	if ns.Spec.MaxNodes <= 0 {
+		return nil
+	}
+	nodes, err := a.Presence.GetNodes(server.GetNamespace(), services.SkipValidation())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, node := range nodes {
+		if node.GetName() == server.GetName() {
+			return nil
+		}
+	}
+	if int64(len(nodes)) >= ns.Spec.MaxNodes {
+		return trace.LimitExceeded("namespace %q has reached its quota of %v nodes", ns.Metadata.Name, ns.Spec.MaxNodes)
+	}
+	return nil
+}
+
 func (a *Server) DeleteWebSession(user string, id string) error {
 	return trace.Wrap(a.Identity.DeleteWebSession(user, id))
 }
@@ -1491,6 +1611,61 @@ func (a *Server) upsertRole(ctx context.Context, role services.Role) error {
 	return nil
 }
 
+// compareAndSwapRole updates the role if the stored revision matches the
+// existing parameter and emits the audit event.
+func (a *Server) compareAndSwapRole(ctx context.Context, new, existing services.Role) error {
+	if err := a.CompareAndSwapRole(ctx, new, existing); err != nil {
+		return trace.Wrap(err)
+	}
+
+	err := a.emitter.EmitAuditEvent(a.closeCtx, &events.RoleCreate{
+		Metadata: events.Metadata{
+			Type: events.RoleCreatedEvent,
+			Code: events.RoleCreatedCode,
+		},
+		UserMetadata: events.UserMetadata{
+			User: clientUsername(ctx),
+		},
+		ResourceMetadata: events.ResourceMetadata{
+			Name: new.GetName(),
+		},
+	})
+	if err != nil {
+		log.WithError(err).Warnf("Failed to emit role create event.")
+	}
+	return nil
+}
+
+// upsertLock stores a lock and emits an audit event, the lock itself
+// propagates to proxies and nodes through the event channel.
+func (a *Server) upsertLock(ctx context.Context, lock services.Lock) error {
+	if err := a.UpsertLock(ctx, lock); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.EmitAuditEventLegacy(events.LockCreatedE, events.EventFields{
+		events.EventUser: clientUsername(ctx),
+		"name":           lock.GetName(),
+		"target":         lock.Target().String(),
+	}); err != nil {
+		log.WithError(err).Warnf("Failed to emit lock create event.")
+	}
+	return nil
+}
+
+// deleteLock deletes a lock and emits an audit event.
+func (a *Server) deleteLock(ctx context.Context, name string) error {
+	if err := a.DeleteLock(ctx, name); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.EmitAuditEventLegacy(events.LockDeletedE, events.EventFields{
+		events.EventUser: clientUsername(ctx),
+		"name":           name,
+	}); err != nil {
+		log.WithError(err).Warnf("Failed to emit lock delete event.")
+	}
+	return nil
+}
+
 func (a *Server) CreateAccessRequest(ctx context.Context, req services.AccessRequest) error {
 	if err := services.ValidateAccessRequest(a, req); err != nil {
 		return trace.Wrap(err)
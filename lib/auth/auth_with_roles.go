@@ -164,6 +164,21 @@ func (a *ServerWithRoles) CreateSession(s session.Session) error {
 	if err := a.action(s.Namespace, services.KindSSHSession, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
 	}
+	// enforce the max_sessions quota of the namespace when one is set
+	ns, err := a.authServer.GetNamespace(s.Namespace)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	} else if ns.Spec.MaxSessions > 0 {
+		active, err := a.sessions.GetSessions(s.Namespace)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if int64(len(active)) >= ns.Spec.MaxSessions {
+			return trace.LimitExceeded("namespace %q has reached its quota of %v active sessions", s.Namespace, ns.Spec.MaxSessions)
+		}
+	}
 	return a.sessions.CreateSession(s)
 }
 
@@ -273,6 +288,11 @@ func (a *ServerWithRoles) GetDomainName() (string, error) {
 	return a.authServer.GetDomainName()
 }
 
+func (a *ServerWithRoles) GetServerTime() (time.Time, error) {
+	// anyone can read it, no harm in that
+	return a.authServer.GetServerTime()
+}
+
 func (a *ServerWithRoles) GetLocalClusterName() (string, error) {
 	// anyone can read it, no harm in that
 	return a.authServer.GetLocalClusterName()
@@ -391,6 +411,24 @@ func (a *ServerWithRoles) KeepAliveNode(ctx context.Context, handle services.Kee
 	return a.authServer.KeepAliveNode(ctx, handle)
 }
 
+// WatchPresence returns a watcher streaming add/update/delete events for
+// servers of the given kind, subject to the same access checks as listing
+// servers of that kind.
+func (a *ServerWithRoles) WatchPresence(kind string) (services.PresenceWatcher, error) {
+	switch kind {
+	case services.KindNode, services.KindProxy, services.KindAuthServer:
+	default:
+		return nil, trace.BadParameter("unsupported presence kind %q", kind)
+	}
+	if err := a.action(defaults.Namespace, kind, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, kind, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.WatchPresence(kind)
+}
+
 // NewWatcher returns a new event watcher
 func (a *ServerWithRoles) NewWatcher(ctx context.Context, watch services.Watch) (services.Watcher, error) {
 	if len(watch.Kinds) == 0 {
@@ -570,6 +608,25 @@ func (a *ServerWithRoles) GetNodes(namespace string, opts ...services.MarshalOpt
 	return filteredNodes, nil
 }
 
+// ListNodes returns a page of registered servers, filtered to the ones the
+// connected identity is allowed to see. RBAC filtering happens after
+// pagination, so a page can come back shorter than the requested limit even
+// when more pages follow.
+func (a *ServerWithRoles) ListNodes(req services.ListNodesRequest) ([]services.Server, string, error) {
+	if err := a.action(req.Namespace, services.KindNode, services.VerbList); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	nodes, nextKey, err := a.authServer.ListNodes(req)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	filteredNodes, err := a.filterNodes(nodes)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	return filteredNodes, nextKey, nil
+}
+
 func (a *ServerWithRoles) UpsertAuthServer(s services.Server) error {
 	if err := a.action(defaults.Namespace, services.KindAuthServer, services.VerbCreate); err != nil {
 		return trace.Wrap(err)
@@ -1481,6 +1538,7 @@ func (a *ServerWithRoles) EmitAuditEventLegacy(event events.Event, fields events
 	if err := a.action(defaults.Namespace, services.KindEvent, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	a.authServer.updateLastConnectedFromLegacyEvent(event, fields)
 	return a.alog.EmitAuditEventLegacy(event, fields)
 }
 
@@ -1491,6 +1549,16 @@ func (a *ServerWithRoles) PostSessionSlice(slice events.SessionSlice) error {
 	if err := a.action(slice.Namespace, services.KindEvent, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
+	for _, chunk := range slice.Chunks {
+		if chunk.EventType != events.SessionStartEvent {
+			continue
+		}
+		var fields events.EventFields
+		if err := utils.FastUnmarshal(chunk.Data, &fields); err != nil {
+			continue
+		}
+		a.authServer.updateLastConnectedFromLegacyEvent(events.Event{Name: chunk.EventType}, fields)
+	}
 	return a.alog.PostSessionSlice(slice)
 }
 
@@ -1508,7 +1576,7 @@ func (a *ServerWithRoles) UploadSessionRecording(r events.SessionRecording) erro
 }
 
 func (a *ServerWithRoles) GetSessionChunk(namespace string, sid session.ID, offsetBytes, maxBytes int) ([]byte, error) {
-	if err := a.action(namespace, services.KindSession, services.VerbRead); err != nil {
+	if err := a.actionForSessionPlayback(namespace); err != nil {
 		return nil, trace.Wrap(err)
 	}
 
@@ -1516,13 +1584,38 @@ func (a *ServerWithRoles) GetSessionChunk(namespace string, sid session.ID, offs
 }
 
 func (a *ServerWithRoles) GetSessionEvents(namespace string, sid session.ID, afterN int, includePrintEvents bool) ([]events.EventFields, error) {
-	if err := a.action(namespace, services.KindSession, services.VerbRead); err != nil {
+	if err := a.actionForSessionPlayback(namespace); err != nil {
 		return nil, trace.Wrap(err)
 	}
 
 	return a.alog.GetSessionEvents(namespace, sid, afterN, includePrintEvents)
 }
 
+// actionForSessionPlayback checks access to play back session recordings.
+// When session recording encryption is enabled, decrypting a recording
+// additionally requires the "play" verb on sessions.
+func (a *ServerWithRoles) actionForSessionPlayback(namespace string) error {
+	if err := a.action(namespace, services.KindSession, services.VerbRead); err != nil {
+		return trace.Wrap(err)
+	}
+	if a.authServer.recordingEncryptor != nil {
+		if err := a.action(namespace, services.KindSession, services.VerbPlay); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// GetLastConnected returns last connected summaries aggregated from
+// session start events, for a single user when user is set or for all
+// users otherwise.
+func (a *ServerWithRoles) GetLastConnected(user string) ([]LastConnected, error) {
+	if err := a.action(defaults.Namespace, services.KindEvent, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetLastConnected(user)
+}
+
 func (a *ServerWithRoles) SearchEvents(from, to time.Time, query string, limit int) ([]events.EventFields, error) {
 	if err := a.action(defaults.Namespace, services.KindEvent, services.VerbList); err != nil {
 		return nil, trace.Wrap(err)
@@ -1605,6 +1698,19 @@ func (a *ServerWithRoles) UpsertRole(ctx context.Context, role services.Role) er
 	return a.authServer.upsertRole(ctx, role)
 }
 
+// CompareAndSwapRole updates the role if the stored revision matches the
+// existing parameter, returns trace.CompareFailed otherwise.
+func (a *ServerWithRoles) CompareAndSwapRole(ctx context.Context, new, existing services.Role) error {
+	if err := a.action(defaults.Namespace, services.KindRole, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindRole, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return a.authServer.compareAndSwapRole(ctx, new, existing)
+}
+
 // GetRole returns role by name
 func (a *ServerWithRoles) GetRole(name string) (services.Role, error) {
 	// Current-user exception: we always allow users to read roles
@@ -1626,6 +1732,49 @@ func (a *ServerWithRoles) DeleteRole(ctx context.Context, name string) error {
 	return a.authServer.DeleteRole(ctx, name)
 }
 
+// GetLocks returns a list of locks
+func (a *ServerWithRoles) GetLocks() ([]services.Lock, error) {
+	if err := a.action(defaults.Namespace, services.KindLock, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindLock, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetLocks()
+}
+
+// GetLock returns lock by name
+func (a *ServerWithRoles) GetLock(name string) (services.Lock, error) {
+	if err := a.action(defaults.Namespace, services.KindLock, services.VerbRead); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetLock(name)
+}
+
+// UpsertLock creates or updates a lock.
+func (a *ServerWithRoles) UpsertLock(ctx context.Context, lock services.Lock) error {
+	if err := a.action(defaults.Namespace, services.KindLock, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindLock, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.upsertLock(ctx, lock)
+}
+
+// DeleteLock deletes lock by name
+func (a *ServerWithRoles) DeleteLock(ctx context.Context, name string) error {
+	if err := a.action(defaults.Namespace, services.KindLock, services.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.deleteLock(ctx, name)
+}
+
+// DeleteAllLocks not implemented: can only be called locally.
+func (a *ServerWithRoles) DeleteAllLocks() error {
+	return trace.NotImplemented("not implemented")
+}
+
 // GetClusterConfig gets cluster level configuration.
 func (a *ServerWithRoles) GetClusterConfig(opts ...services.MarshalOption) (services.ClusterConfig, error) {
 	if err := a.action(defaults.Namespace, services.KindClusterConfig, services.VerbRead); err != nil {
@@ -1666,7 +1815,43 @@ func (a *ServerWithRoles) SetClusterConfig(c services.ClusterConfig) error {
 	if err := a.action(defaults.Namespace, services.KindClusterConfig, services.VerbUpdate); err != nil {
 		return trace.Wrap(err)
 	}
-	return a.authServer.SetClusterConfig(c)
+	if err := a.authServer.SetClusterConfig(c); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.alog.EmitAuditEventLegacy(events.ClusterConfigUpdateE, events.EventFields{
+		events.EventUser: a.context.User.GetName(),
+	}); err != nil {
+		log.WithError(err).Warnf("Failed to emit cluster config update event.")
+	}
+	return nil
+}
+
+// GetIssuedCerts returns records of issued user certificates, for a
+// single user when user is set or for all users otherwise.
+func (a *ServerWithRoles) GetIssuedCerts(user string) ([]IssuedCert, error) {
+	if err := a.action(defaults.Namespace, services.KindUser, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetIssuedCerts(user)
+}
+
+// RevokeDevice revokes a device by its client key fingerprint: no new
+// certificates will be issued for keys with this fingerprint.
+func (a *ServerWithRoles) RevokeDevice(fingerprint string) error {
+	if err := a.action(defaults.Namespace, services.KindUser, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.RevokeDevice(fingerprint)
+}
+
+// RotateRecordingKey generates a new cluster recording key for session
+// recording encryption. New recordings are wrapped by the new key, older
+// keys are kept so existing recordings stay playable.
+func (a *ServerWithRoles) RotateRecordingKey() (string, error) {
+	if err := a.action(defaults.Namespace, services.KindClusterConfig, services.VerbUpdate); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return a.authServer.RotateRecordingKey()
 }
 
 // GetClusterName gets the name of the cluster.
@@ -1734,7 +1919,15 @@ func (a *ServerWithRoles) SetAuthPreference(cap services.AuthPreference) error {
 		return trace.Wrap(err)
 	}
 
-	return a.authServer.SetAuthPreference(cap)
+	if err := a.authServer.SetAuthPreference(cap); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.alog.EmitAuditEventLegacy(events.AuthPreferenceUpdateE, events.EventFields{
+		events.EventUser: a.context.User.GetName(),
+	}); err != nil {
+		log.WithError(err).Warnf("Failed to emit auth preference update event.")
+	}
+	return nil
 }
 
 // DeleteAllTokens deletes all tokens
@@ -1905,6 +2098,41 @@ func (a *ServerWithRoles) DeleteAllRemoteClusters() error {
 	return a.authServer.DeleteAllRemoteClusters()
 }
 
+// UpsertClusterNotice creates or updates a cluster notice.
+func (a *ServerWithRoles) UpsertClusterNotice(notice ClusterNotice) error {
+	if err := a.action(defaults.Namespace, services.KindClusterNotice, services.VerbCreate); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := a.action(defaults.Namespace, services.KindClusterNotice, services.VerbUpdate); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.UpsertClusterNotice(notice)
+}
+
+// GetClusterNotices returns all cluster notices.
+func (a *ServerWithRoles) GetClusterNotices() ([]ClusterNotice, error) {
+	if err := a.action(defaults.Namespace, services.KindClusterNotice, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetClusterNotices()
+}
+
+// DeleteClusterNotice deletes a cluster notice by name.
+func (a *ServerWithRoles) DeleteClusterNotice(name string) error {
+	if err := a.action(defaults.Namespace, services.KindClusterNotice, services.VerbDelete); err != nil {
+		return trace.Wrap(err)
+	}
+	return a.authServer.DeleteClusterNotice(name)
+}
+
+// GetJoinFailures returns the join failures recorded over the last hour.
+func (a *ServerWithRoles) GetJoinFailures() ([]JoinFailure, error) {
+	if err := a.action(defaults.Namespace, services.KindJoinFailure, services.VerbList); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return a.authServer.GetJoinFailures()
+}
+
 // AcquireSemaphore acquires lease with requested resources from semaphore.
 func (a *ServerWithRoles) AcquireSemaphore(ctx context.Context, params services.AcquireSemaphoreRequest) (*services.SemaphoreLease, error) {
 	if err := a.action(defaults.Namespace, services.KindSemaphore, services.VerbCreate); err != nil {
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/teleport/lib/events"
+
+	"github.com/gravitational/trace"
+)
+
+// EndpointClass groups auth API endpoints for the purpose of external
+// authorization checks.
+type EndpointClass string
+
+const (
+	// EndpointClassCredentials covers endpoints that mint or modify
+	// credentials: certificate authorities, certificates, keypairs,
+	// passwords and join tokens.
+	EndpointClassCredentials EndpointClass = "credentials"
+	// EndpointClassSessions covers authentication and session endpoints.
+	EndpointClassSessions EndpointClass = "sessions"
+	// EndpointClassPresence covers node, proxy, auth server and reverse
+	// tunnel presence endpoints.
+	EndpointClassPresence EndpointClass = "presence"
+	// EndpointClassGeneral covers all remaining endpoints.
+	EndpointClassGeneral EndpointClass = "general"
+)
+
+// AuthzRequest describes an API request that passed the built-in
+// authorizer and is about to be executed.
+type AuthzRequest struct {
+	// User is the name of the authenticated identity making the request
+	User string
+	// Class is the endpoint class of the request
+	Class EndpointClass
+	// Method is the HTTP method of the request
+	Method string
+	// Path is the URL path of the request
+	Path string
+}
+
+// AuthzMiddleware is an extra authorization check consulted for every
+// admitted API request of the endpoint classes it registered for, e.g.
+// a call out to an external policy engine. Returning an error denies the
+// request and the error message is recorded in the audit log as the
+// deny reason.
+type AuthzMiddleware interface {
+	// CheckAccess returns an error if the request should be denied
+	CheckAccess(ctx context.Context, req AuthzRequest) error
+}
+
+// RegisterAuthzMiddleware registers an additional authorization check
+// consulted by all auth API servers in this process. The middleware is
+// only consulted for requests of the given endpoint classes, or for all
+// requests if no classes are given. Middleware can not be unregistered.
+func RegisterAuthzMiddleware(m AuthzMiddleware, classes ...EndpointClass) {
+	authzMiddleware.register(m, classes...)
+}
+
+// authzMiddleware holds the process-wide middleware chain, mirroring the
+// Plugin registration above it in this package.
+var authzMiddleware = &authzMiddlewareRegistry{}
+
+type authzMiddlewareEntry struct {
+	middleware AuthzMiddleware
+	// classes the middleware registered for, empty means all
+	classes map[EndpointClass]bool
+}
+
+// authzMiddlewareRegistry is a chain of registered authorization
+// middleware consulted in registration order.
+type authzMiddlewareRegistry struct {
+	sync.Mutex
+	entries []authzMiddlewareEntry
+}
+
+func (r *authzMiddlewareRegistry) register(m AuthzMiddleware, classes ...EndpointClass) {
+	entry := authzMiddlewareEntry{middleware: m}
+	if len(classes) != 0 {
+		entry.classes = make(map[EndpointClass]bool, len(classes))
+		for _, class := range classes {
+			entry.classes[class] = true
+		}
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+// check consults the registered middleware in registration order and
+// returns the first deny error, if any.
+func (r *authzMiddlewareRegistry) check(ctx context.Context, req AuthzRequest) error {
+	r.Lock()
+	entries := make([]authzMiddlewareEntry, len(r.entries))
+	copy(entries, r.entries)
+	r.Unlock()
+	for _, entry := range entries {
+		if entry.classes != nil && !entry.classes[req.Class] {
+			continue
+		}
+		if err := entry.middleware.CheckAccess(ctx, req); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// classifyEndpoint maps the request to its endpoint class. Unrecognized
+// endpoints fall into the general class.
+func classifyEndpoint(r *http.Request) EndpointClass {
+	path := r.URL.Path
+	switch {
+	case strings.Contains(path, "/authorities") ||
+		strings.Contains(path, "/ca/") ||
+		strings.Contains(path, "/keypair") ||
+		strings.Contains(path, "/password") ||
+		strings.Contains(path, "/tokens") ||
+		strings.Contains(path, "/recordingkeys"):
+		return EndpointClassCredentials
+	case strings.Contains(path, "/sessions") ||
+		strings.HasSuffix(path, "/authenticate"):
+		return EndpointClassSessions
+	case strings.Contains(path, "/nodes") ||
+		strings.Contains(path, "/authservers") ||
+		strings.Contains(path, "/proxies") ||
+		strings.Contains(path, "/reversetunnels") ||
+		strings.Contains(path, "/tunnelconnections"):
+		return EndpointClassPresence
+	}
+	return EndpointClassGeneral
+}
+
+// emitAuthzDenied records a request denied by registered authorization
+// middleware in the audit log together with the deny reason.
+func (s *APIServer) emitAuthzDenied(r *http.Request, user string, reason error) {
+	if s.Emitter == nil {
+		return
+	}
+	if err := s.Emitter.EmitAuditEvent(r.Context(), &events.AuthAttempt{
+		Metadata: events.Metadata{
+			Type: events.AuthAttemptEvent,
+			Code: events.AuthAttemptFailureCode,
+		},
+		UserMetadata: events.UserMetadata{
+			User: user,
+		},
+		ConnectionMetadata: events.ConnectionMetadata{
+			RemoteAddr: r.RemoteAddr,
+		},
+		Status: events.Status{
+			Success: false,
+			Error:   reason.Error(),
+		},
+	}); err != nil {
+		log.WithError(err).Warn("Failed to emit authorization denied event.")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type AuthzMiddlewareSuite struct{}
+
+var _ = check.Suite(&AuthzMiddlewareSuite{})
+
+type fakeAuthzMiddleware struct {
+	requests []AuthzRequest
+	deny     error
+}
+
+func (m *fakeAuthzMiddleware) CheckAccess(ctx context.Context, req AuthzRequest) error {
+	m.requests = append(m.requests, req)
+	return m.deny
+}
+
+func (s *AuthzMiddlewareSuite) TestClassifyEndpoint(c *check.C) {
+	tests := []struct {
+		path  string
+		class EndpointClass
+	}{
+		{path: "/v1/ca/host/certs", class: EndpointClassCredentials},
+		{path: "/v1/authorities/host", class: EndpointClassCredentials},
+		{path: "/v1/users/bob/web/password/check", class: EndpointClassCredentials},
+		{path: "/v1/users/bob/web/sessions", class: EndpointClassSessions},
+		{path: "/v1/users/bob/ssh/authenticate", class: EndpointClassSessions},
+		{path: "/v1/namespaces/default/nodes", class: EndpointClassPresence},
+		{path: "/v1/reversetunnels", class: EndpointClassPresence},
+		{path: "/v1/users", class: EndpointClassGeneral},
+		{path: "/v1/domain", class: EndpointClassGeneral},
+	}
+	for _, tt := range tests {
+		r := &http.Request{URL: &url.URL{Path: tt.path}}
+		c.Assert(classifyEndpoint(r), check.Equals, tt.class,
+			check.Commentf("path: %q", tt.path))
+	}
+}
+
+func (s *AuthzMiddlewareSuite) TestMiddlewareChain(c *check.C) {
+	registry := &authzMiddlewareRegistry{}
+
+	all := &fakeAuthzMiddleware{}
+	registry.register(all)
+	credentials := &fakeAuthzMiddleware{}
+	registry.register(credentials, EndpointClassCredentials)
+
+	ctx := context.Background()
+	req := AuthzRequest{User: "bob", Class: EndpointClassSessions, Method: "POST"}
+	c.Assert(registry.check(ctx, req), check.IsNil)
+	// middleware without classes sees every request, the scoped one
+	// is only consulted for its own class
+	c.Assert(all.requests, check.HasLen, 1)
+	c.Assert(credentials.requests, check.HasLen, 0)
+
+	req.Class = EndpointClassCredentials
+	c.Assert(registry.check(ctx, req), check.IsNil)
+	c.Assert(all.requests, check.HasLen, 2)
+	c.Assert(credentials.requests, check.HasLen, 1)
+
+	// a deny from any middleware in the chain fails the check
+	credentials.deny = trace.AccessDenied("policy engine says no")
+	err := registry.check(ctx, req)
+	c.Assert(err, check.NotNil)
+	c.Assert(err.Error(), check.Equals, "policy engine says no")
+}
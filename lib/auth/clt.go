@@ -29,6 +29,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -144,25 +145,96 @@ func DecodeClusterName(serverName string) (string, error) {
 	return string(decoded), nil
 }
 
-// NewAddrDialer returns new dialer from a list of addresses
+// NewAddrDialer returns new dialer from a list of addresses, the dialer
+// tracks the health of every address and prefers the one with the lowest
+// observed dial latency, failing over to the remaining addresses when a
+// dial fails.
 func NewAddrDialer(addrs []utils.NetAddr, keepAliveInterval time.Duration) ContextDialer {
-	dialer := net.Dialer{
-		Timeout:   defaults.DefaultDialTimeout,
-		KeepAlive: keepAliveInterval,
+	dialer := &addrDialer{
+		dialer: net.Dialer{
+			Timeout:   defaults.DefaultDialTimeout,
+			KeepAlive: keepAliveInterval,
+		},
+		clock: clockwork.NewRealClock(),
+	}
+	for _, addr := range addrs {
+		dialer.targets = append(dialer.targets, &dialTarget{addr: addr})
 	}
-	return ContextDialerFunc(func(in context.Context, network, _ string) (net.Conn, error) {
-		var err error
+	return dialer
+}
+
+// dialTarget is a single auth server address together with its last
+// observed health, used by addrDialer to rank addresses.
+type dialTarget struct {
+	addr utils.NetAddr
+	// latency is the latency of the last successful dial.
+	latency time.Duration
+	// lastFailure is the time of the last failed dial, zero if the last
+	// dial succeeded.
+	lastFailure time.Time
+}
+
+// addrDialer dials one of the listed auth servers, healthy addresses are
+// tried in the order of their last observed dial latency, addresses that
+// failed recently are tried last.
+type addrDialer struct {
+	sync.Mutex
+	dialer  net.Dialer
+	clock   clockwork.Clock
+	targets []*dialTarget
+}
+
+// DialContext dials the auth server address with the best observed health,
+// falling back to the remaining addresses in the order of preference.
+func (d *addrDialer) DialContext(in context.Context, network, _ string) (net.Conn, error) {
+	var err error
+	for _, target := range d.rankedTargets() {
+		start := d.clock.Now()
 		var conn net.Conn
-		for _, addr := range addrs {
-			conn, err = dialer.DialContext(in, network, addr.Addr)
-			if err == nil {
-				return conn, nil
-			}
-			log.Errorf("Failed to dial auth server %v: %v.", addr.Addr, err)
+		conn, err = d.dialer.DialContext(in, network, target.addr.Addr)
+		if err == nil {
+			d.markSuccess(target, d.clock.Now().Sub(start))
+			return conn, nil
 		}
-		// not wrapping on purpose to preserve the original error
-		return nil, err
+		d.markFailure(target)
+		log.Errorf("Failed to dial auth server %v: %v.", target.addr.Addr, err)
+	}
+	// not wrapping on purpose to preserve the original error
+	return nil, err
+}
+
+// rankedTargets returns the addresses in the order they should be tried:
+// addresses without recent failures sorted by last dial latency first,
+// recently failed addresses last, so an unhealthy auth server is retried
+// once the backoff expires or all other addresses fail too.
+func (d *addrDialer) rankedTargets() []*dialTarget {
+	d.Lock()
+	defer d.Unlock()
+	out := make([]*dialTarget, len(d.targets))
+	copy(out, d.targets)
+	now := d.clock.Now()
+	sort.SliceStable(out, func(i, j int) bool {
+		healthyI := now.Sub(out[i].lastFailure) > defaults.NetworkBackoffDuration
+		healthyJ := now.Sub(out[j].lastFailure) > defaults.NetworkBackoffDuration
+		if healthyI != healthyJ {
+			return healthyI
+		}
+		return out[i].latency < out[j].latency
 	})
+	return out
+}
+
+func (d *addrDialer) markSuccess(target *dialTarget, latency time.Duration) {
+	d.Lock()
+	defer d.Unlock()
+	target.latency = latency
+	target.lastFailure = time.Time{}
+}
+
+func (d *addrDialer) markFailure(target *dialTarget) {
+	d.Lock()
+	defer d.Unlock()
+	target.lastFailure = d.clock.Now()
 }
 
 // ClientTimeout sets idle and dial timeouts of the HTTP transport
@@ -268,7 +340,7 @@ func NewTLSClient(cfg ClientConfig, params ...roundtrip.ClientParam) (*Client, e
 
 	clientParams := append(
 		[]roundtrip.ClientParam{
-			roundtrip.HTTPClient(&http.Client{Transport: transport}),
+			roundtrip.HTTPClient(&http.Client{Transport: idempotencyTransport{RoundTripper: transport}}),
 			roundtrip.SanitizerEnabled(true),
 		},
 		params...,
@@ -338,27 +410,37 @@ func (c *Client) GetTransport() *http.Transport {
 
 // PostJSON is a generic method that issues http POST request to the server
 func (c *Client) PostJSON(endpoint string, val interface{}) (*roundtrip.Response, error) {
-	return httplib.ConvertResponse(c.Client.PostJSON(context.TODO(), endpoint, val))
+	return c.retryRequest(true, func(ctx context.Context) (*roundtrip.Response, error) {
+		return c.Client.PostJSON(ctx, endpoint, val)
+	})
 }
 
 // PutJSON is a generic method that issues http PUT request to the server
 func (c *Client) PutJSON(endpoint string, val interface{}) (*roundtrip.Response, error) {
-	return httplib.ConvertResponse(c.Client.PutJSON(context.TODO(), endpoint, val))
+	return c.retryRequest(true, func(ctx context.Context) (*roundtrip.Response, error) {
+		return c.Client.PutJSON(ctx, endpoint, val)
+	})
 }
 
-// PostForm is a generic method that issues http POST request to the server
+// PostForm is a generic method that issues http POST request to the server.
+// Requests with file attachments are not retried because the attachment
+// streams are consumed by the first attempt.
 func (c *Client) PostForm(endpoint string, vals url.Values, files ...roundtrip.File) (*roundtrip.Response, error) {
 	return httplib.ConvertResponse(c.Client.PostForm(context.TODO(), endpoint, vals, files...))
 }
 
 // Get issues http GET request to the server
 func (c *Client) Get(u string, params url.Values) (*roundtrip.Response, error) {
-	return httplib.ConvertResponse(c.Client.Get(context.TODO(), u, params))
+	return c.retryRequest(false, func(ctx context.Context) (*roundtrip.Response, error) {
+		return c.Client.Get(ctx, u, params)
+	})
 }
 
 // Delete issues http Delete Request to the server
 func (c *Client) Delete(u string) (*roundtrip.Response, error) {
-	return httplib.ConvertResponse(c.Client.Delete(context.TODO(), u))
+	return c.retryRequest(true, func(ctx context.Context) (*roundtrip.Response, error) {
+		return c.Client.Delete(ctx, u)
+	})
 }
 
 // ProcessKubeCSR processes CSR request against Kubernetes CA, returns
@@ -455,6 +537,20 @@ func (c *Client) GetDomainName() (string, error) {
 	return domain, nil
 }
 
+// GetServerTime returns the current time of the auth server, used to detect
+// local clock drift.
+func (c *Client) GetServerTime() (time.Time, error) {
+	out, err := c.Get(c.Endpoint("servertime"), url.Values{})
+	if err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	var serverTime time.Time
+	if err := json.Unmarshal(out.Bytes(), &serverTime); err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	return serverTime, nil
+}
+
 // GetClusterCACert returns the CAs for the local cluster without signing keys.
 func (c *Client) GetClusterCACert() (*LocalCAResponse, error) {
 	out, err := c.Get(c.Endpoint("cacert"), url.Values{})
@@ -518,6 +614,20 @@ func (c *Client) RotateExternalCertAuthority(ca services.CertAuthority) error {
 	return trace.Wrap(err)
 }
 
+// RotateRecordingKey generates a new cluster recording key for session
+// recording encryption and returns its ID.
+func (c *Client) RotateRecordingKey() (string, error) {
+	out, err := c.PostJSON(c.Endpoint("recordingkeys", "rotate"), nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	var re rotateRecordingKeyRawResponse
+	if err := json.Unmarshal(out.Bytes(), &re); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return re.KeyID, nil
+}
+
 // UpsertCertAuthority updates or inserts new cert authority
 func (c *Client) UpsertCertAuthority(ca services.CertAuthority) error {
 	if err := ca.Check(); err != nil {
@@ -732,6 +842,12 @@ func (c *Client) KeepAliveNode(ctx context.Context, keepAlive services.KeepAlive
 	return trace.BadParameter("not implemented, use StreamKeepAlives instead")
 }
 
+// WatchPresence returns a watcher streaming add/update/delete events for
+// servers of the given kind, built on top of the event watcher API.
+func (c *Client) WatchPresence(kind string) (services.PresenceWatcher, error) {
+	return services.NewPresenceWatcher(context.TODO(), c, kind)
+}
+
 // NewKeepAliver returns a new instance of keep aliver
 func (c *Client) NewKeepAliver(ctx context.Context) (services.KeepAliver, error) {
 	clt, err := c.grpc()
@@ -977,6 +1093,122 @@ func (c *Client) GetNodes(namespace string, opts ...services.MarshalOption) ([]s
 	return re, nil
 }
 
+// ListNodes returns a single page of nodes and the key to pass as StartKey
+// to fetch the next page, so callers in large clusters only transfer the
+// nodes they actually need. An empty key means the last page was reached.
+func (c *Client) ListNodes(req services.ListNodesRequest) ([]services.Server, string, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	params := url.Values{
+		"limit": []string{fmt.Sprintf("%v", req.Limit)},
+	}
+	if req.StartKey != "" {
+		params.Set("start_key", req.StartKey)
+	}
+	if len(req.Labels) != 0 {
+		labels, err := json.Marshal(req.Labels)
+		if err != nil {
+			return nil, "", trace.Wrap(err)
+		}
+		params.Set("labels", string(labels))
+	}
+	if req.Selector != "" {
+		params.Set("selector", req.Selector)
+	}
+
+	out, err := c.Get(c.Endpoint("namespaces", req.Namespace, "nodes"), params)
+	if err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+
+	var response struct {
+		Servers []json.RawMessage `json:"servers"`
+		NextKey string            `json:"next_key"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &response); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	servers := make([]services.Server, len(response.Servers))
+	for i, raw := range response.Servers {
+		server, err := services.GetServerMarshaler().UnmarshalServer(
+			raw, services.KindNode, services.SkipValidation())
+		if err != nil {
+			return nil, "", trace.Wrap(err)
+		}
+		servers[i] = server
+	}
+	return servers, response.NextKey, nil
+}
+
+// NodesDelta is the set of node changes since a revision the caller has
+// already synced to. Nodes holds the nodes added or updated since that
+// revision, Present names every node currently registered so deleted
+// nodes can be pruned, and Revision is the revision to pass to the next
+// delta request.
+type NodesDelta struct {
+	// Revision is the latest node revision covered by the delta
+	Revision int64
+	// Present names all nodes currently registered in the namespace
+	Present []string
+	// Nodes lists the nodes changed since the requested revision
+	Nodes []services.Server
+}
+
+// Apply merges the delta into the previously synced node list and
+// returns the updated list.
+func (d *NodesDelta) Apply(nodes []services.Server) []services.Server {
+	merged := make(map[string]services.Server, len(nodes)+len(d.Nodes))
+	for _, node := range nodes {
+		merged[node.GetName()] = node
+	}
+	for _, node := range d.Nodes {
+		merged[node.GetName()] = node
+	}
+	out := make([]services.Server, 0, len(d.Present))
+	for _, name := range d.Present {
+		if node, ok := merged[name]; ok {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+// GetNodesDelta returns the nodes changed since sinceRevision instead of
+// the full list, so repeated syncs transfer data proportional to churn
+// rather than cluster size. Pass 0 on the first call to fetch everything,
+// then the Revision of the previous delta.
+func (c *Client) GetNodesDelta(namespace string, sinceRevision int64) (*NodesDelta, error) {
+	if namespace == "" {
+		return nil, trace.BadParameter(MissingNamespaceError)
+	}
+	out, err := c.Get(c.Endpoint("namespaces", namespace, "nodes"), url.Values{
+		"since_revision": []string{fmt.Sprintf("%v", sinceRevision)},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var re nodesDeltaRawResponse
+	if err := json.Unmarshal(out.Bytes(), &re); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	delta := &NodesDelta{
+		Revision: re.Revision,
+		Present:  re.Present,
+		Nodes:    make([]services.Server, len(re.Nodes)),
+	}
+	for i, raw := range re.Nodes {
+		s, err := services.GetServerMarshaler().UnmarshalServer(
+			raw, services.KindNode, services.SkipValidation())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		delta.Nodes[i] = s
+	}
+	return delta, nil
+}
+
 // UpsertReverseTunnel is used by admins to create a new reverse tunnel
 // to the remote proxy to bypass firewall restrictions
 func (c *Client) UpsertReverseTunnel(tunnel services.ReverseTunnel) error {
@@ -2323,6 +2555,50 @@ func (c *Client) SearchEvents(from, to time.Time, query string, limit int) ([]ev
 	return retval, nil
 }
 
+// GetLastConnected returns last connected summaries aggregated from
+// session start events, for a single user when user is set or for all
+// users otherwise.
+func (c *Client) GetLastConnected(user string) ([]LastConnected, error) {
+	query := url.Values{}
+	if user != "" {
+		query.Set("user", user)
+	}
+	response, err := c.Get(c.Endpoint("lastconnected"), query)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var connected []LastConnected
+	if err := json.Unmarshal(response.Bytes(), &connected); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return connected, nil
+}
+
+// GetIssuedCerts returns records of issued user certificates, for a
+// single user when user is set or for all users otherwise.
+func (c *Client) GetIssuedCerts(user string) ([]IssuedCert, error) {
+	query := url.Values{}
+	if user != "" {
+		query.Set("user", user)
+	}
+	response, err := c.Get(c.Endpoint("devices", "certs"), query)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var issued []IssuedCert
+	if err := json.Unmarshal(response.Bytes(), &issued); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return issued, nil
+}
+
+// RevokeDevice revokes a device by its client key fingerprint: no new
+// certificates will be issued for keys with this fingerprint.
+func (c *Client) RevokeDevice(fingerprint string) error {
+	_, err := c.PostJSON(c.Endpoint("devices", "revoke"), revokeDeviceReq{Fingerprint: fingerprint})
+	return trace.Wrap(err)
+}
+
 // SearchSessionEvents returns session related events to find completed sessions.
 func (c *Client) SearchSessionEvents(from, to time.Time, limit int) ([]events.EventFields, error) {
 	query := url.Values{
@@ -2381,6 +2657,47 @@ func (c *Client) DeleteNamespace(name string) error {
 	return trace.Wrap(err)
 }
 
+// UpsertClusterNotice creates or updates a cluster notice.
+func (c *Client) UpsertClusterNotice(notice ClusterNotice) error {
+	_, err := c.PostJSON(c.Endpoint("notices"), upsertClusterNoticeReq{Notice: notice})
+	return trace.Wrap(err)
+}
+
+// GetClusterNotices returns all cluster notices.
+func (c *Client) GetClusterNotices() ([]ClusterNotice, error) {
+	out, err := c.Get(c.Endpoint("notices"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var notices []ClusterNotice
+	if err := utils.FastUnmarshal(out.Bytes(), &notices); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return notices, nil
+}
+
+// DeleteClusterNotice deletes a cluster notice by name.
+func (c *Client) DeleteClusterNotice(name string) error {
+	if name == "" {
+		return trace.BadParameter("missing notice name")
+	}
+	_, err := c.Delete(c.Endpoint("notices", name))
+	return trace.Wrap(err)
+}
+
+// GetJoinFailures returns the join failures recorded over the last hour.
+func (c *Client) GetJoinFailures() ([]JoinFailure, error) {
+	out, err := c.Get(c.Endpoint("joinfailures"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var failures []JoinFailure
+	if err := utils.FastUnmarshal(out.Bytes(), &failures); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return failures, nil
+}
+
 // GetRoles returns a list of roles
 func (c *Client) GetRoles() ([]services.Role, error) {
 	out, err := c.Get(c.Endpoint("roles"), url.Values{})
@@ -2417,6 +2734,24 @@ func (c *Client) UpsertRole(ctx context.Context, role services.Role) error {
 	return trace.Wrap(err)
 }
 
+// CompareAndSwapRole updates the role if the stored revision matches the
+// existing parameter, returns trace.CompareFailed otherwise.
+func (c *Client) CompareAndSwapRole(ctx context.Context, new, existing services.Role) error {
+	newData, err := services.GetRoleMarshaler().MarshalRole(new)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	existingData, err := services.GetRoleMarshaler().MarshalRole(existing, services.PreserveResourceID())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PostJSON(c.Endpoint("roles", new.GetName(), "compare-and-swap"), &compareAndSwapRoleRawReq{
+		New:      newData,
+		Existing: existingData,
+	})
+	return trace.Wrap(err)
+}
+
 // GetRole returns role by name
 func (c *Client) GetRole(name string) (services.Role, error) {
 	if name == "" {
@@ -2439,6 +2774,64 @@ func (c *Client) DeleteRole(ctx context.Context, name string) error {
 	return trace.Wrap(err)
 }
 
+// GetLocks returns a list of locks
+func (c *Client) GetLocks() ([]services.Lock, error) {
+	out, err := c.Get(c.Endpoint("locks"), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	locks := make([]services.Lock, len(items))
+	for i, lockBytes := range items {
+		lock, err := services.GetLockMarshaler().Unmarshal(lockBytes, services.SkipValidation())
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		locks[i] = lock
+	}
+	return locks, nil
+}
+
+// GetLock returns lock by name
+func (c *Client) GetLock(name string) (services.Lock, error) {
+	if name == "" {
+		return nil, trace.BadParameter("missing name")
+	}
+	out, err := c.Get(c.Endpoint("locks", name), url.Values{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	lock, err := services.GetLockMarshaler().Unmarshal(out.Bytes(), services.SkipValidation())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return lock, nil
+}
+
+// UpsertLock creates or updates a lock
+func (c *Client) UpsertLock(ctx context.Context, lock services.Lock) error {
+	data, err := services.GetLockMarshaler().Marshal(lock)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = c.PostJSON(c.Endpoint("locks"), &upsertLockRawReq{Lock: data})
+	return trace.Wrap(err)
+}
+
+// DeleteLock deletes lock by name
+func (c *Client) DeleteLock(ctx context.Context, name string) error {
+	_, err := c.Delete(c.Endpoint("locks", name))
+	return trace.Wrap(err)
+}
+
+// DeleteAllLocks not implemented: can only be called locally.
+func (c *Client) DeleteAllLocks() error {
+	return trace.NotImplemented("not implemented")
+}
+
 // GetClusterConfig returns cluster level configuration information.
 func (c *Client) GetClusterConfig(opts ...services.MarshalOption) (services.ClusterConfig, error) {
 	out, err := c.Get(c.Endpoint("configuration"), url.Values{})
@@ -3110,6 +3503,18 @@ type ClientI interface {
 	// NewKeepAliver returns a new instance of keep aliver
 	NewKeepAliver(ctx context.Context) (services.KeepAliver, error)
 
+	// UpsertClusterNotice creates or updates a cluster notice.
+	UpsertClusterNotice(notice ClusterNotice) error
+
+	// GetClusterNotices returns all cluster notices.
+	GetClusterNotices() ([]ClusterNotice, error)
+
+	// DeleteClusterNotice deletes a cluster notice by name.
+	DeleteClusterNotice(name string) error
+
+	// GetJoinFailures returns the join failures recorded over the last hour.
+	GetJoinFailures() ([]JoinFailure, error)
+
 	// RotateCertAuthority starts or restarts certificate authority rotation process.
 	RotateCertAuthority(req RotateRequest) error
 
@@ -3118,6 +3523,23 @@ type ClientI interface {
 	// the certificate authorities of trusted clusters.
 	RotateExternalCertAuthority(ca services.CertAuthority) error
 
+	// RotateRecordingKey generates a new cluster recording key for session
+	// recording encryption and returns its ID.
+	RotateRecordingKey() (string, error)
+
+	// GetLastConnected returns last connected summaries aggregated from
+	// session start events, for a single user when user is set or for all
+	// users otherwise.
+	GetLastConnected(user string) ([]LastConnected, error)
+
+	// GetIssuedCerts returns records of issued user certificates, for a
+	// single user when user is set or for all users otherwise.
+	GetIssuedCerts(user string) ([]IssuedCert, error)
+
+	// RevokeDevice revokes a device by its client key fingerprint: no new
+	// certificates will be issued for keys with this fingerprint.
+	RevokeDevice(fingerprint string) error
+
 	// ValidateTrustedCluster validates trusted cluster token with
 	// main cluster, in case if validation is successful, main cluster
 	// adds remote cluster
@@ -3126,6 +3548,10 @@ type ClientI interface {
 	// GetDomainName returns auth server cluster name
 	GetDomainName() (string, error)
 
+	// GetServerTime returns the current time of the auth server, used by
+	// connecting agents to detect local clock drift
+	GetServerTime() (time.Time, error)
+
 	// GetClusterCACert returns the CAs for the local cluster without signing keys.
 	GetClusterCACert() (*LocalCAResponse, error)
 
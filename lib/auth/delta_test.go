@@ -0,0 +1,94 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"gopkg.in/check.v1"
+)
+
+type DeltaSuite struct{}
+
+var _ = check.Suite(&DeltaSuite{})
+
+func deltaNode(name string, revision int64) services.Server {
+	return &services.ServerV2{
+		Kind:    services.KindNode,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+			ID:        revision,
+		},
+	}
+}
+
+func (s *DeltaSuite) TestMarshalNodesDelta(c *check.C) {
+	servers := []services.Server{
+		deltaNode("node-1", 10),
+		deltaNode("node-2", 20),
+		deltaNode("node-3", 30),
+	}
+
+	// the first sync carries everything
+	out, err := marshalNodesDelta(servers, 0, services.V2)
+	c.Assert(err, check.IsNil)
+	delta := out.(*nodesDeltaRawResponse)
+	c.Assert(delta.Revision, check.Equals, int64(30))
+	c.Assert(delta.Present, check.DeepEquals, []string{"node-1", "node-2", "node-3"})
+	c.Assert(delta.Nodes, check.HasLen, 3)
+
+	// later syncs carry only the nodes changed since the revision
+	out, err = marshalNodesDelta(servers, 20, services.V2)
+	c.Assert(err, check.IsNil)
+	delta = out.(*nodesDeltaRawResponse)
+	c.Assert(delta.Revision, check.Equals, int64(30))
+	c.Assert(delta.Present, check.DeepEquals, []string{"node-1", "node-2", "node-3"})
+	c.Assert(delta.Nodes, check.HasLen, 1)
+
+	// a sync with no changes carries names only
+	out, err = marshalNodesDelta(servers, 30, services.V2)
+	c.Assert(err, check.IsNil)
+	delta = out.(*nodesDeltaRawResponse)
+	c.Assert(delta.Revision, check.Equals, int64(30))
+	c.Assert(delta.Nodes, check.HasLen, 0)
+}
+
+func (s *DeltaSuite) TestApplyNodesDelta(c *check.C) {
+	synced := []services.Server{
+		deltaNode("node-1", 10),
+		deltaNode("node-2", 20),
+	}
+
+	// node-2 was updated, node-3 was added and node-1 was deleted
+	delta := &NodesDelta{
+		Revision: 40,
+		Present:  []string{"node-2", "node-3"},
+		Nodes: []services.Server{
+			deltaNode("node-2", 35),
+			deltaNode("node-3", 40),
+		},
+	}
+
+	merged := delta.Apply(synced)
+	c.Assert(merged, check.HasLen, 2)
+	c.Assert(merged[0].GetName(), check.Equals, "node-2")
+	c.Assert(merged[0].GetResourceID(), check.Equals, int64(35))
+	c.Assert(merged[1].GetName(), check.Equals, "node-3")
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/sshutils"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// issuedCertsPrefix is the backend prefix issued certificate records
+	// are stored under.
+	issuedCertsPrefix = "issued_certs"
+	// revokedDevicesPrefix is the backend prefix revoked device
+	// fingerprints are stored under.
+	revokedDevicesPrefix = "revoked_devices"
+)
+
+// IssuedCert records the device a user certificate was issued to: the
+// client key fingerprint plus the client version and source address of
+// the login request when known.
+type IssuedCert struct {
+	// User is the Teleport user the certificate was issued to.
+	User string `json:"user"`
+	// Fingerprint is the fingerprint of the client key the certificate
+	// was issued for.
+	Fingerprint string `json:"fingerprint"`
+	// ClientVersion is the version of the client that requested the
+	// certificate, empty when not reported.
+	ClientVersion string `json:"client_version,omitempty"`
+	// ClientAddr is the address the certificate request originated from,
+	// empty when not reported.
+	ClientAddr string `json:"client_addr,omitempty"`
+	// Issued is the time the certificate was issued.
+	Issued time.Time `json:"issued"`
+	// Expires is the time the certificate expires.
+	Expires time.Time `json:"expires"`
+}
+
+// escapeDeviceKey makes a fingerprint safe to use as a backend key part,
+// fingerprints look like "SHA256:base64" and base64 may contain slashes.
+func escapeDeviceKey(fingerprint string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "+", "-").Replace(fingerprint)
+}
+
+// recordIssuedCert stores the record of an issued user certificate, the
+// record expires together with the certificate.
+func (a *Server) recordIssuedCert(issued IssuedCert) {
+	value, err := utils.FastMarshal(issued)
+	if err != nil {
+		log.Warningf("Failed to marshal issued certificate record: %v.", err)
+		return
+	}
+	_, err = a.bk.Put(context.TODO(), backend.Item{
+		Key: backend.Key(issuedCertsPrefix, issued.User,
+			fmt.Sprintf("%v-%v", escapeDeviceKey(issued.Fingerprint), issued.Issued.UnixNano())),
+		Value:   value,
+		Expires: issued.Expires,
+	})
+	if err != nil {
+		log.Warningf("Failed to store issued certificate record: %v.", err)
+	}
+}
+
+// GetIssuedCerts returns records of issued user certificates, for a
+// single user when user is set or for all users otherwise. Records of
+// expired certificates are removed automatically.
+func (a *Server) GetIssuedCerts(user string) ([]IssuedCert, error) {
+	startKey := backend.Key(issuedCertsPrefix)
+	if user != "" {
+		startKey = backend.Key(issuedCertsPrefix, user)
+	}
+	result, err := a.bk.GetRange(context.TODO(), startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]IssuedCert, 0, len(result.Items))
+	for _, item := range result.Items {
+		var issued IssuedCert
+		if err := utils.FastUnmarshal(item.Value, &issued); err != nil {
+			return nil, trace.BadParameter("failed to parse issued certificate record %q: %v", string(item.Key), err)
+		}
+		out = append(out, issued)
+	}
+	return out, nil
+}
+
+// RevokeDevice revokes a device by its client key fingerprint: no new
+// certificates will be issued for keys with this fingerprint.
+func (a *Server) RevokeDevice(fingerprint string) error {
+	if fingerprint == "" {
+		return trace.BadParameter("missing parameter fingerprint")
+	}
+	_, err := a.bk.Put(context.TODO(), backend.Item{
+		Key:   backend.Key(revokedDevicesPrefix, escapeDeviceKey(fingerprint)),
+		Value: []byte(a.GetClock().Now().UTC().Format(time.RFC3339)),
+	})
+	return trace.Wrap(err)
+}
+
+// isDeviceRevoked reports whether the fingerprint of the public key has
+// been revoked. An unparsable key is not treated as revoked, certificate
+// generation will reject it anyway.
+func (a *Server) isDeviceRevoked(publicKey []byte) (bool, error) {
+	fingerprint, err := sshutils.AuthorizedKeyFingerprint(publicKey)
+	if err != nil {
+		return false, nil
+	}
+	_, err = a.bk.Get(context.TODO(), backend.Key(revokedDevicesPrefix, escapeDeviceKey(fingerprint)))
+	if err == nil {
+		return true, nil
+	}
+	if trace.IsNotFound(err) {
+		return false, nil
+	}
+	return false, trace.Wrap(err)
+}
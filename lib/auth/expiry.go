@@ -0,0 +1,156 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/tlsca"
+
+	"github.com/gravitational/trace"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var certExpiryGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: teleport.MetricCertExpirySeconds,
+		Help: "Seconds until the soonest stored certificate expiry per cert authority",
+	},
+	[]string{"type", teleport.TagCluster},
+)
+
+func init() {
+	prometheus.MustRegister(certExpiryGauge)
+}
+
+// CertExpiryWarning describes a stored certificate approaching expiry,
+// passed to the optional notification callback.
+type CertExpiryWarning struct {
+	// Type is the cert authority type, e.g. "host" or "user"
+	Type string
+	// Cluster is the name of the cluster the cert authority belongs to
+	Cluster string
+	// NotAfter is when the soonest certificate of the authority expires
+	NotAfter time.Time
+}
+
+// SetCertExpiryNotify sets the callback invoked when a stored certificate
+// is approaching expiry, used to fan warnings out to notification hooks.
+func (a *Server) SetCertExpiryNotify(fn func(CertExpiryWarning)) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.certExpiryNotify = fn
+}
+
+func (a *Server) getCertExpiryNotify() func(CertExpiryWarning) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.certExpiryNotify
+}
+
+// runCertExpiryChecks periodically inspects the TLS certificates of all
+// stored cert authorities for approaching expiry and raises warnings via
+// metrics, audit events and the notification callback well before the
+// certificates become invalid.
+func (a *Server) runCertExpiryChecks() {
+	ticker := time.NewTicker(defaults.CertExpiryCheckPeriod)
+	defer ticker.Stop()
+	// warned tracks the expiry date a warning was last raised for, so
+	// each certificate is warned about once until it is rotated.
+	warned := make(map[string]time.Time)
+	for {
+		select {
+		case <-a.closeCtx.Done():
+			return
+		case <-ticker.C:
+			if err := a.checkCertExpiry(warned); err != nil {
+				log.Errorf("Failed to perform cert expiry check: %v.", err)
+			}
+		}
+	}
+}
+
+// checkCertExpiry performs a single pass over the stored cert authorities.
+func (a *Server) checkCertExpiry(warned map[string]time.Time) error {
+	for _, caType := range []services.CertAuthType{services.HostCA, services.UserCA} {
+		authorities, err := a.GetCertAuthorities(caType, false)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, ca := range authorities {
+			notAfter, err := caSoonestExpiry(ca)
+			if err != nil {
+				log.Warningf("Failed to parse TLS certificates of %v CA %q: %v.", caType, ca.GetClusterName(), err)
+				continue
+			}
+			if notAfter.IsZero() {
+				// the authority has no TLS key pairs to check
+				continue
+			}
+			timeLeft := notAfter.Sub(a.GetClock().Now())
+			certExpiryGauge.WithLabelValues(string(caType), ca.GetClusterName()).Set(timeLeft.Seconds())
+
+			key := string(caType) + "/" + ca.GetClusterName()
+			if timeLeft > defaults.CertExpiryWarningThreshold {
+				delete(warned, key)
+				continue
+			}
+			if warned[key].Equal(notAfter) {
+				continue
+			}
+			warned[key] = notAfter
+
+			log.Warningf("TLS certificate of %v CA %q expires at %v, rotate the certificate authority before it becomes invalid.",
+				caType, ca.GetClusterName(), notAfter)
+			if err := a.EmitAuditEventLegacy(events.CertificateExpiringE, events.EventFields{
+				"type":      string(caType),
+				"cluster":   ca.GetClusterName(),
+				"not_after": notAfter,
+			}); err != nil {
+				log.Warningf("Failed to emit certificate expiring event: %v.", err)
+			}
+			if notify := a.getCertExpiryNotify(); notify != nil {
+				notify(CertExpiryWarning{
+					Type:     string(caType),
+					Cluster:  ca.GetClusterName(),
+					NotAfter: notAfter,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// caSoonestExpiry returns the earliest expiry date among the TLS
+// certificates of the authority, or the zero time if it has none.
+func caSoonestExpiry(ca services.CertAuthority) (time.Time, error) {
+	var notAfter time.Time
+	for _, keyPair := range ca.GetTLSKeyPairs() {
+		cert, err := tlsca.ParseCertificatePEM(keyPair.Cert)
+		if err != nil {
+			return time.Time{}, trace.Wrap(err)
+		}
+		if notAfter.IsZero() || cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	return notAfter, nil
+}
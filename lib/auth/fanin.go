@@ -0,0 +1,211 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// HeartbeatFanInClient is the subset of the auth API used by the
+// heartbeat fan-in: proxy and auth server announces are forwarded
+// as is, node announces are batched into bulk upserts.
+type HeartbeatFanInClient interface {
+	Announcer
+	// UpsertNodes bulk inserts nodes
+	UpsertNodes(namespace string, servers []services.Server) error
+}
+
+// HeartbeatFanInConfig configures a heartbeat fan-in.
+type HeartbeatFanInConfig struct {
+	// Client is the auth server client batches are forwarded to
+	Client HeartbeatFanInClient
+	// FlushPeriod is how often buffered heartbeats are flushed
+	FlushPeriod time.Duration
+	// MaxBatchSize flushes the buffer early once it collects
+	// this many distinct nodes
+	MaxBatchSize int
+	// Clock is a clock override used in tests
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults checks and sets default values.
+func (c *HeartbeatFanInConfig) CheckAndSetDefaults() error {
+	if c.Client == nil {
+		return trace.BadParameter("missing parameter Client")
+	}
+	if c.FlushPeriod == 0 {
+		c.FlushPeriod = defaults.HeartbeatFanInFlushPeriod
+	}
+	if c.MaxBatchSize == 0 {
+		c.MaxBatchSize = defaults.HeartbeatFanInBatchSize
+	}
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// NewHeartbeatFanIn returns an announcer that aggregates node heartbeats
+// and forwards them to the auth server in batches over a single client
+// connection. Deploying it on proxies in front of large node fleets
+// replaces thousands of per-node auth server connections with one.
+func NewHeartbeatFanIn(cfg HeartbeatFanInConfig) (*HeartbeatFanIn, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	return &HeartbeatFanIn{
+		HeartbeatFanInConfig: cfg,
+		cancelCtx:            ctx,
+		cancel:               cancel,
+		pending:              make(map[string]map[string]services.Server),
+	}, nil
+}
+
+// HeartbeatFanIn implements Announcer by buffering node heartbeats and
+// periodically flushing them with bulk upserts. Repeated heartbeats of
+// the same node within one flush period coalesce into the last one seen.
+type HeartbeatFanIn struct {
+	HeartbeatFanInConfig
+
+	cancelCtx context.Context
+	cancel    context.CancelFunc
+
+	sync.Mutex
+	// pending maps namespace to node name to the latest heartbeat
+	pending map[string]map[string]services.Server
+}
+
+// UpsertNode buffers the node heartbeat until the next flush.
+func (f *HeartbeatFanIn) UpsertNode(s services.Server) (*services.KeepAlive, error) {
+	if s.GetNamespace() == "" {
+		return nil, trace.BadParameter("missing node namespace")
+	}
+	f.Lock()
+	nodes, ok := f.pending[s.GetNamespace()]
+	if !ok {
+		nodes = make(map[string]services.Server)
+		f.pending[s.GetNamespace()] = nodes
+	}
+	nodes[s.GetName()] = s
+	flushNow := f.size() >= f.MaxBatchSize
+	f.Unlock()
+	if flushNow {
+		if err := f.Flush(); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+	// batched nodes are not issued leases, same as nodes
+	// announced without an expiry
+	return &services.KeepAlive{}, nil
+}
+
+// UpsertProxy forwards the proxy announce without batching.
+func (f *HeartbeatFanIn) UpsertProxy(s services.Server) error {
+	return f.Client.UpsertProxy(s)
+}
+
+// UpsertAuthServer forwards the auth server announce without batching.
+func (f *HeartbeatFanIn) UpsertAuthServer(s services.Server) error {
+	return f.Client.UpsertAuthServer(s)
+}
+
+// NewKeepAliver returns a new instance of keep aliver
+func (f *HeartbeatFanIn) NewKeepAliver(ctx context.Context) (services.KeepAliver, error) {
+	return f.Client.NewKeepAliver(ctx)
+}
+
+// size returns the number of buffered nodes, callers must hold the lock.
+func (f *HeartbeatFanIn) size() int {
+	count := 0
+	for _, nodes := range f.pending {
+		count += len(nodes)
+	}
+	return count
+}
+
+// Flush forwards all buffered heartbeats to the auth server. Heartbeats
+// of a failed batch are requeued so the next flush retries them.
+func (f *HeartbeatFanIn) Flush() error {
+	f.Lock()
+	pending := f.pending
+	f.pending = make(map[string]map[string]services.Server)
+	f.Unlock()
+
+	var errors []error
+	for namespace, nodes := range pending {
+		servers := make([]services.Server, 0, len(nodes))
+		for _, server := range nodes {
+			servers = append(servers, server)
+		}
+		if err := f.Client.UpsertNodes(namespace, servers); err != nil {
+			f.requeue(namespace, nodes)
+			errors = append(errors, trace.Wrap(err))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// requeue puts heartbeats of a failed batch back in the buffer unless a
+// newer heartbeat of the same node has arrived in the meantime.
+func (f *HeartbeatFanIn) requeue(namespace string, nodes map[string]services.Server) {
+	f.Lock()
+	defer f.Unlock()
+	buffered, ok := f.pending[namespace]
+	if !ok {
+		buffered = make(map[string]services.Server)
+		f.pending[namespace] = buffered
+	}
+	for name, server := range nodes {
+		if _, ok := buffered[name]; !ok {
+			buffered[name] = server
+		}
+	}
+}
+
+// Run periodically flushes buffered heartbeats until Close is called.
+func (f *HeartbeatFanIn) Run() error {
+	ticker := time.NewTicker(f.FlushPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.Flush(); err != nil {
+				log.Warningf("Failed to flush heartbeats: %v.", err)
+			}
+		case <-f.cancelCtx.Done():
+			if err := f.Flush(); err != nil {
+				log.Warningf("Failed to flush heartbeats: %v.", err)
+			}
+			return nil
+		}
+	}
+}
+
+// Close stops the flush loop after one final flush.
+func (f *HeartbeatFanIn) Close() error {
+	f.cancel()
+	return nil
+}
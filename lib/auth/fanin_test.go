@@ -0,0 +1,121 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type FanInSuite struct{}
+
+var _ = check.Suite(&FanInSuite{})
+
+// fakeFanInClient records batches forwarded by the fan-in.
+type fakeFanInClient struct {
+	batches [][]services.Server
+	err     error
+}
+
+func (c *fakeFanInClient) UpsertNode(s services.Server) (*services.KeepAlive, error) {
+	return nil, trace.NotImplemented("not implemented")
+}
+
+func (c *fakeFanInClient) UpsertProxy(s services.Server) error {
+	return trace.NotImplemented("not implemented")
+}
+
+func (c *fakeFanInClient) UpsertAuthServer(s services.Server) error {
+	return trace.NotImplemented("not implemented")
+}
+
+func (c *fakeFanInClient) NewKeepAliver(ctx context.Context) (services.KeepAliver, error) {
+	return nil, trace.NotImplemented("not implemented")
+}
+
+func (c *fakeFanInClient) UpsertNodes(namespace string, servers []services.Server) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.batches = append(c.batches, servers)
+	return nil
+}
+
+func fanInNode(name string) services.Server {
+	return &services.ServerV2{
+		Kind:    services.KindNode,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+	}
+}
+
+func (s *FanInSuite) TestBatchesHeartbeats(c *check.C) {
+	clt := &fakeFanInClient{}
+	fanin, err := NewHeartbeatFanIn(HeartbeatFanInConfig{
+		Client:       clt,
+		MaxBatchSize: 3,
+	})
+	c.Assert(err, check.IsNil)
+
+	// repeated heartbeats of the same node coalesce, nothing is
+	// forwarded until the buffer is flushed
+	for i := 0; i < 5; i++ {
+		keepAlive, err := fanin.UpsertNode(fanInNode("node-1"))
+		c.Assert(err, check.IsNil)
+		c.Assert(keepAlive, check.NotNil)
+	}
+	_, err = fanin.UpsertNode(fanInNode("node-2"))
+	c.Assert(err, check.IsNil)
+	c.Assert(clt.batches, check.HasLen, 0)
+
+	c.Assert(fanin.Flush(), check.IsNil)
+	c.Assert(clt.batches, check.HasLen, 1)
+	c.Assert(clt.batches[0], check.HasLen, 2)
+
+	// reaching the batch size flushes without waiting for the period
+	for i := 0; i < 3; i++ {
+		_, err = fanin.UpsertNode(fanInNode(fmt.Sprintf("node-%v", i)))
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(clt.batches, check.HasLen, 2)
+	c.Assert(clt.batches[1], check.HasLen, 3)
+}
+
+func (s *FanInSuite) TestRequeuesFailedBatch(c *check.C) {
+	clt := &fakeFanInClient{err: trace.ConnectionProblem(nil, "auth server is down")}
+	fanin, err := NewHeartbeatFanIn(HeartbeatFanInConfig{Client: clt})
+	c.Assert(err, check.IsNil)
+
+	_, err = fanin.UpsertNode(fanInNode("node-1"))
+	c.Assert(err, check.IsNil)
+	c.Assert(fanin.Flush(), check.NotNil)
+
+	// the heartbeat is kept and forwarded once the auth server is back
+	clt.err = nil
+	c.Assert(fanin.Flush(), check.IsNil)
+	c.Assert(clt.batches, check.HasLen, 1)
+	c.Assert(clt.batches[0], check.HasLen, 1)
+}
@@ -189,6 +189,7 @@ func (g *GRPCServer) CreateAuditStream(stream proto.AuthService_CreateAuditStrea
 			if err != nil {
 				return trail.ToGRPC(err)
 			}
+			g.AuthServer.updateLastConnectedFromEvent(event)
 			event.Size()
 			processed += int64(event.Size())
 			seconds := time.Since(streamStart) / time.Second
@@ -801,6 +802,10 @@ func eventToGRPC(in services.Event) (*proto.Event, error) {
 		out.Resource = &proto.Event_AccessRequest{
 			AccessRequest: r,
 		}
+	case *services.LockV2:
+		out.Resource = &proto.Event_Lock{
+			Lock: r,
+		}
 	default:
 		return nil, trace.BadParameter("resource type %T is not supported", in.Resource)
 	}
@@ -870,6 +875,9 @@ func eventFromGRPC(in proto.Event) (*services.Event, error) {
 	} else if r := in.GetAccessRequest(); r != nil {
 		out.Resource = r
 		return &out, nil
+	} else if r := in.GetLock(); r != nil {
+		out.Resource = r
+		return &out, nil
 	} else {
 		return nil, trace.BadParameter("received unsupported resource %T", in.Resource)
 	}
@@ -74,6 +74,11 @@ type InitConfig struct {
 	// bootstrap backend on first start.
 	Resources []services.Resource
 
+	// ApplyOnStartupResources is a list of declared resources reconciled
+	// into the backend on every start: missing resources are created,
+	// changed ones overwritten and identical ones left untouched.
+	ApplyOnStartupResources []services.Resource
+
 	// AuthServiceName is a human-readable name of this CA. If several Auth services are running
 	// (managing multiple teleport clusters) this field is used to tell them apart in UIs
 	// It usually defaults to the hostname of the machine the Auth service runs on.
@@ -150,6 +155,10 @@ type InitConfig struct {
 	// Streamer is events sessionstreamer, used to create continuous
 	// session related streams
 	Streamer events.Streamer
+
+	// RecordingEncryptor seals session recordings at rest, set when
+	// session recording encryption is enabled.
+	RecordingEncryptor *events.RecordingEncryptor
 }
 
 // Init instantiates and configures an instance of AuthServer
@@ -196,6 +205,20 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 		}
 	}
 
+	// declared resources are reconciled on every startup
+	if len(cfg.ApplyOnStartupResources) > 0 {
+		if err := checkResourceConsistency(domainName, cfg.ApplyOnStartupResources...); err != nil {
+			return nil, trace.Wrap(err, "refusing to apply declared resources")
+		}
+		results, err := local.ApplyResources(ctx, cfg.Backend, cfg.ApplyOnStartupResources...)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed to apply declared resources")
+		}
+		for _, result := range results {
+			log.Infof("Applied %v %q: %v.", result.Kind, result.Name, result.Status)
+		}
+	}
+
 	// Set the ciphersuites that this auth server supports.
 	asrv.cipherSuites = cfg.CipherSuites
 
@@ -244,6 +267,14 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 	} else {
 		cfg.ClusterConfig.SetClusterID(uuid.New())
 	}
+	// the session recording policy is switchable at runtime via the
+	// session_recording_config resource, so once the cluster config exists
+	// in the backend it stays authoritative for recording settings across
+	// restarts; the file configuration only seeds them on first start.
+	if clusterConfig != nil {
+		cfg.ClusterConfig.SetSessionRecording(clusterConfig.GetSessionRecording())
+		cfg.ClusterConfig.SetProxyChecksHostKeys(clusterConfig.GetProxyChecksHostKeys())
+	}
 	err = asrv.SetClusterConfig(cfg.ClusterConfig)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -254,8 +285,9 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 	if err != nil && !trace.IsAlreadyExists(err) {
 		return nil, trace.Wrap(err)
 	}
-	// If the cluster name has already been set, log a warning if the user
-	// is trying to change the name.
+	// If the cluster name has already been set, refuse to start if the user
+	// is trying to change the name. Silently continuing under the new name
+	// would invalidate every certificate issued by this cluster.
 	if trace.IsAlreadyExists(err) {
 		// Get current name of cluster from the backend.
 		cn, err := asrv.ClusterConfiguration.GetClusterName()
@@ -263,17 +295,13 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 			return nil, trace.Wrap(err)
 		}
 		if cn.GetClusterName() != cfg.ClusterName.GetClusterName() {
-			warnMessage := "Cannot rename cluster to %q: continuing with %q. Teleport " +
-				"clusters can not be renamed once they are created. You are seeing this " +
-				"warning for one of two reasons. Either you have not set \"cluster_name\" in " +
-				"Teleport configuration and changed the hostname of the auth server or you " +
-				"are trying to change the value of \"cluster_name\"."
-			log.Warnf(warnMessage,
-				cfg.ClusterName.GetClusterName(),
-				cn.GetClusterName())
-
-			// Override user passed in cluster name with what is in the backend.
-			cfg.ClusterName = cn
+			return nil, trace.BadParameter("the cluster is named %q but the configuration requests %q: "+
+				"Teleport clusters can not be renamed once they are created, all issued certificates "+
+				"embed the cluster name. Either you have not set \"cluster_name\" in the Teleport "+
+				"configuration and changed the hostname of the auth server or you are trying to change "+
+				"the value of \"cluster_name\". Restore the original name, or stop all Teleport processes "+
+				"and run \"teleport rename-cluster\" to migrate the cluster to the new name",
+				cn.GetClusterName(), cfg.ClusterName.GetClusterName())
 		}
 	}
 	log.Debugf("Cluster configuration: %v.", cfg.ClusterName)
@@ -290,8 +318,12 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 	}
 	log.Infof("Updating cluster configuration: %v.", cfg.AuthPreference)
 
-	// always create the default namespace
-	err = asrv.UpsertNamespace(services.NewNamespace(defaults.Namespace))
+	// always make sure the default namespace is present, but do not
+	// overwrite an existing one: it may carry operator-set quotas
+	_, err = asrv.GetNamespace(defaults.Namespace)
+	if trace.IsNotFound(err) {
+		err = asrv.UpsertNamespace(services.NewNamespace(defaults.Namespace))
+	}
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -307,6 +339,16 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 		log.Infof("Created default admin role: %q.", defaultRole.GetName())
 	}
 
+	// always create a read-only auditor role
+	auditorRole := services.NewAuditorRole()
+	err = asrv.CreateRole(auditorRole)
+	if err != nil && !trace.IsAlreadyExists(err) {
+		return nil, trace.Wrap(err)
+	}
+	if !trace.IsAlreadyExists(err) {
+		log.Infof("Created default auditor role: %q.", auditorRole.GetName())
+	}
+
 	// generate a user certificate authority if it doesn't exist
 	userCA, err := asrv.GetCertAuthority(services.CertAuthID{DomainName: cfg.ClusterName.GetClusterName(), Type: services.UserCA}, true)
 	if err != nil {
@@ -450,6 +492,7 @@ func Init(cfg InitConfig, opts ...ServerOption) (*Server, error) {
 	if !cfg.SkipPeriodicOperations {
 		log.Infof("Auth server is running periodic operations.")
 		go asrv.runPeriodicOperations()
+		go asrv.runCertExpiryChecks()
 	} else {
 		log.Infof("Auth server is skipping periodic operations.")
 	}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// joinFailuresPrefix is the backend prefix join failures are stored under.
+const joinFailuresPrefix = "joinfailures"
+
+const (
+	// joinFailureTTL is how long a recorded join failure is kept before
+	// it expires from the backend
+	joinFailureTTL = time.Hour
+	// joinFailureMinInterval rate limits recording: at most one failure
+	// per host is remembered within this interval, so a node stuck in a
+	// retry loop does not hammer the backend
+	joinFailureMinInterval = time.Minute
+)
+
+// JoinFailure describes a failed attempt of a host to join the cluster,
+// recorded by the auth server so fleet onboarding problems are debuggable
+// centrally instead of only in the joining node's local log.
+type JoinFailure struct {
+	// HostID is the unique host ID of the host that attempted to join
+	HostID string `json:"host_id"`
+	// NodeName is the name the host attempted to join with
+	NodeName string `json:"node_name,omitempty"`
+	// Role is the role the host requested
+	Role string `json:"role"`
+	// RemoteAddr is the address the attempt came from, if known
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	// Reason is the failure reason
+	Reason string `json:"reason"`
+	// Time is the time of the attempt
+	Time time.Time `json:"time"`
+}
+
+// recordJoinFailure remembers a failed join attempt in the backend where
+// GetJoinFailures can find it. Recording is rate limited per host and
+// never fails the join path: errors are logged and swallowed.
+func (a *Server) recordJoinFailure(req RegisterUsingTokenRequest, reason error) {
+	failure := JoinFailure{
+		HostID:     req.HostID,
+		NodeName:   req.NodeName,
+		Role:       req.Role.String(),
+		RemoteAddr: req.RemoteAddr,
+		Reason:     reason.Error(),
+		Time:       a.clock.Now().UTC(),
+	}
+	key := backend.Key(joinFailuresPrefix, req.HostID)
+	if item, err := a.bk.Get(a.closeCtx, key); err == nil {
+		var last JoinFailure
+		if err := utils.FastUnmarshal(item.Value, &last); err == nil &&
+			failure.Time.Sub(last.Time) < joinFailureMinInterval {
+			return
+		}
+	}
+	value, err := utils.FastMarshal(failure)
+	if err != nil {
+		log.Warningf("Failed to marshal join failure for host %v: %v.", req.HostID, err)
+		return
+	}
+	if _, err := a.bk.Put(a.closeCtx, backend.Item{
+		Key:     key,
+		Value:   value,
+		Expires: failure.Time.Add(joinFailureTTL),
+	}); err != nil {
+		log.Warningf("Failed to record join failure for host %v: %v.", req.HostID, err)
+	}
+}
+
+// GetJoinFailures returns the join failures recorded over the last hour,
+// most recent first.
+func (a *Server) GetJoinFailures() ([]JoinFailure, error) {
+	startKey := backend.Key(joinFailuresPrefix)
+	result, err := a.bk.GetRange(a.closeCtx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]JoinFailure, 0, len(result.Items))
+	for _, item := range result.Items {
+		var failure JoinFailure
+		if err := utils.FastUnmarshal(item.Value, &failure); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, failure)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Time.After(out[j].Time)
+	})
+	return out, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// lastConnectedPrefix is the backend prefix last connected summaries are
+// stored under.
+const lastConnectedPrefix = "last_connected"
+
+// LastConnected records the most recent time a user started a session on
+// a node, aggregated from session start audit events so access reviews
+// can find dormant grants without crunching raw logs.
+type LastConnected struct {
+	// User is the Teleport user that connected.
+	User string `json:"user"`
+	// ServerID is the UUID of the node the user connected to.
+	ServerID string `json:"server_id"`
+	// ServerHostname is the hostname of the node the user connected to.
+	ServerHostname string `json:"server_hostname,omitempty"`
+	// Time is the time the user last started a session on the node.
+	Time time.Time `json:"time"`
+}
+
+// UpdateLastConnected records that a user started a session on a node,
+// keeping only the most recent time per user/node pair.
+func (a *Server) UpdateLastConnected(connected LastConnected) error {
+	if connected.User == "" || connected.ServerID == "" {
+		return trace.BadParameter("missing user or server ID")
+	}
+	key := backend.Key(lastConnectedPrefix, connected.User, connected.ServerID)
+	item, err := a.bk.Get(context.TODO(), key)
+	if err == nil {
+		var existing LastConnected
+		if err := utils.FastUnmarshal(item.Value, &existing); err == nil && existing.Time.After(connected.Time) {
+			// an out of order or replayed event, keep the newer record
+			return nil
+		}
+	} else if !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	value, err := utils.FastMarshal(connected)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(context.TODO(), backend.Item{
+		Key:   key,
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// GetLastConnected returns last connected summaries, for a single user
+// when user is set or for all users otherwise.
+func (a *Server) GetLastConnected(user string) ([]LastConnected, error) {
+	startKey := backend.Key(lastConnectedPrefix)
+	if user != "" {
+		startKey = backend.Key(lastConnectedPrefix, user)
+	}
+	result, err := a.bk.GetRange(context.TODO(), startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]LastConnected, 0, len(result.Items))
+	for _, item := range result.Items {
+		var connected LastConnected
+		if err := utils.FastUnmarshal(item.Value, &connected); err != nil {
+			return nil, trace.BadParameter("failed to parse last connected record %q: %v", string(item.Key), err)
+		}
+		out = append(out, connected)
+	}
+	return out, nil
+}
+
+// updateLastConnectedFromEvent updates the last connected summary from a
+// session start audit event, other event types are ignored.
+func (a *Server) updateLastConnectedFromEvent(event events.AuditEvent) {
+	start, ok := event.(*events.SessionStart)
+	if !ok {
+		return
+	}
+	err := a.UpdateLastConnected(LastConnected{
+		User:           start.User,
+		ServerID:       start.ServerID,
+		ServerHostname: start.ServerHostname,
+		Time:           start.Time,
+	})
+	if err != nil {
+		log.Warningf("Failed to update last connected record: %v.", err)
+	}
+}
+
+// updateLastConnectedFromLegacyEvent updates the last connected summary
+// from a legacy session start audit event.
+func (a *Server) updateLastConnectedFromLegacyEvent(event events.Event, fields events.EventFields) {
+	if event.Name != events.SessionStartEvent {
+		return
+	}
+	eventTime := fields.GetTime(events.EventTime)
+	if eventTime.IsZero() {
+		eventTime = a.GetClock().Now().UTC()
+	}
+	err := a.UpdateLastConnected(LastConnected{
+		User:           fields.GetString(events.EventUser),
+		ServerID:       fields.GetString(events.SessionServerID),
+		ServerHostname: fields.GetString(events.SessionServerHostname),
+		Time:           eventTime,
+	})
+	if err != nil {
+		log.Warningf("Failed to update last connected record: %v.", err)
+	}
+}
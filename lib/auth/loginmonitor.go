@@ -0,0 +1,137 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/sshutils"
+
+	"github.com/gravitational/trace"
+)
+
+// seenLoginsPrefix is the backend prefix that records which client key
+// fingerprints and source networks have been seen per user.
+const seenLoginsPrefix = "seen_logins"
+
+const (
+	// loginFactorDevice marks records of client key fingerprints.
+	loginFactorDevice = "device"
+	// loginFactorNetwork marks records of login source networks.
+	loginFactorNetwork = "network"
+)
+
+// detectNewLoginFactors checks a successful login against the client key
+// fingerprints and source networks previously seen for the user, records
+// new ones and emits a distinct audit event when any were not seen before.
+// The event goes through the regular emitter, so a notification webhook
+// subscribed to it will fire as well. The first login of a user seeds the
+// store and is reported as new.
+func (s *Server) detectNewLoginFactors(username string, publicKey []byte, clientAddr string) {
+	var unseen []string
+	if len(publicKey) != 0 {
+		fingerprint, err := sshutils.AuthorizedKeyFingerprint(publicKey)
+		if err != nil {
+			log.Warningf("Failed to fingerprint client key of %q: %v.", username, err)
+		} else {
+			seen, err := s.seenLoginFactor(username, loginFactorDevice, fingerprint)
+			if err != nil {
+				log.Warningf("Failed to check login fingerprint of %q: %v.", username, err)
+			} else if !seen {
+				unseen = append(unseen, "new client key "+fingerprint)
+			}
+		}
+	}
+	if network := loginNetwork(clientAddr); network != "" {
+		seen, err := s.seenLoginFactor(username, loginFactorNetwork, network)
+		if err != nil {
+			log.Warningf("Failed to check login network of %q: %v.", username, err)
+		} else if !seen {
+			unseen = append(unseen, "new source network "+network)
+		}
+	}
+	if len(unseen) == 0 {
+		return
+	}
+	event := &events.UserLogin{
+		Metadata: events.Metadata{
+			Type: events.UserLoginNewDeviceEvent,
+			Code: events.UserLoginNewDeviceCode,
+		},
+		UserMetadata: events.UserMetadata{
+			User: username,
+		},
+		Status: events.Status{
+			Success:     true,
+			UserMessage: strings.Join(unseen, ", "),
+		},
+		Method: events.LoginMethodLocal,
+	}
+	if err := s.emitter.EmitAuditEvent(s.closeCtx, event); err != nil {
+		log.WithError(err).Warn("Failed to emit new device login event.")
+	}
+}
+
+// seenLoginFactor reports whether the factor value has been seen for the
+// user before and records it if not.
+func (s *Server) seenLoginFactor(username, factor, value string) (bool, error) {
+	// fingerprints look like "SHA256:base64" and networks like
+	// "10.0.0.0/24", escape the separators so they make valid backend keys
+	value = strings.NewReplacer("/", "_", ":", "_").Replace(value)
+	key := backend.Key(seenLoginsPrefix, username, factor, value)
+	_, err := s.bk.Get(context.TODO(), key)
+	if err == nil {
+		return true, nil
+	}
+	if !trace.IsNotFound(err) {
+		return false, trace.Wrap(err)
+	}
+	_, err = s.bk.Put(context.TODO(), backend.Item{
+		Key:   key,
+		Value: []byte(s.GetClock().Now().UTC().Format(time.RFC3339)),
+	})
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return false, nil
+}
+
+// loginNetwork reduces a client address to the network logins are grouped
+// by: a /24 for IPv4 and a /64 for IPv6. Returns an empty string when the
+// address cannot be parsed.
+func loginNetwork(clientAddr string) string {
+	if clientAddr == "" {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(clientAddr)
+	if err != nil {
+		host = clientAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
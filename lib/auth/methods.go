@@ -42,6 +42,9 @@ type AuthenticateUserRequest struct {
 	OTP *OTPCreds `json:"otp,omitempty"`
 	// Session is a web session credential used to authenticate web sessions
 	Session *SessionCreds `json:"session,omitempty"`
+	// ClientAddr is the address the login request originated from, set by
+	// the proxy and used to detect logins from new locations.
+	ClientAddr string `json:"client_addr,omitempty"`
 }
 
 // CheckAndSetDefaults checks and sets defaults
@@ -195,6 +198,7 @@ func (s *Server) AuthenticateWebUser(req AuthenticateUserRequest) (services.WebS
 	if err := s.AuthenticateUser(req); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	s.detectNewLoginFactors(req.Username, nil, req.ClientAddr)
 
 	user, err := s.GetUser(req.Username, false)
 	if err != nil {
@@ -227,6 +231,9 @@ type AuthenticateSSHRequest struct {
 	// KubernetesCluster sets the target kubernetes cluster for the TLS
 	// certificate. This can be empty on older clients.
 	KubernetesCluster string `json:"kubernetes_cluster"`
+	// ClientVersion is the version of the client requesting the
+	// certificates, recorded with the issued certificate when set.
+	ClientVersion string `json:"client_version,omitempty"`
 }
 
 // CheckAndSetDefaults checks and sets default certificate values
@@ -319,6 +326,7 @@ func (s *Server) AuthenticateSSHUser(req AuthenticateSSHRequest) (*SSHLoginRespo
 	if err := s.AuthenticateUser(req.AuthenticateUserRequest); err != nil {
 		return nil, trace.Wrap(err)
 	}
+	s.detectNewLoginFactors(req.Username, req.PublicKey, req.ClientAddr)
 
 	// It's safe to extract the roles and traits directly from services.User as
 	// this endpoint is only used for local accounts.
@@ -352,6 +360,8 @@ func (s *Server) AuthenticateSSHUser(req AuthenticateSSHRequest) (*SSHLoginRespo
 		traits:            user.GetTraits(),
 		routeToCluster:    req.RouteToCluster,
 		kubernetesCluster: req.KubernetesCluster,
+		clientVersion:     req.ClientVersion,
+		clientAddr:        req.ClientAddr,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
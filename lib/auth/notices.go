@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// noticesPrefix is the backend prefix cluster notices are stored under.
+const noticesPrefix = "notices"
+
+const (
+	// NoticeSeverityInfo marks an informational cluster notice.
+	NoticeSeverityInfo = "info"
+	// NoticeSeverityWarning marks a cluster notice that requires attention.
+	NoticeSeverityWarning = "warning"
+	// NoticeSeverityError marks a cluster notice about a broken component.
+	NoticeSeverityError = "error"
+)
+
+// ClusterNotice is a message shown to cluster users by tools like tctl,
+// for example a scheduled maintenance announcement. Notices are stored in
+// the backend and expire with their TTL.
+type ClusterNotice struct {
+	// Name uniquely identifies the notice so it can be updated or removed.
+	Name string `json:"name"`
+	// Message is the notice text.
+	Message string `json:"message"`
+	// Severity is one of "info", "warning" or "error".
+	Severity string `json:"severity"`
+	// Created is the time the notice was created or last updated.
+	Created time.Time `json:"created"`
+	// Expires is an optional time after which the notice is removed.
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// CheckAndSetDefaults makes sure the notice has the required fields.
+func (n *ClusterNotice) CheckAndSetDefaults() error {
+	if n.Name == "" {
+		return trace.BadParameter("missing parameter Name")
+	}
+	if n.Message == "" {
+		return trace.BadParameter("missing parameter Message")
+	}
+	if n.Severity == "" {
+		n.Severity = NoticeSeverityInfo
+	}
+	switch n.Severity {
+	case NoticeSeverityInfo, NoticeSeverityWarning, NoticeSeverityError:
+	default:
+		return trace.BadParameter("unsupported severity %q", n.Severity)
+	}
+	return nil
+}
+
+// UpsertClusterNotice creates or updates a cluster notice.
+func (a *Server) UpsertClusterNotice(notice ClusterNotice) error {
+	if err := notice.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	notice.Created = a.clock.Now().UTC()
+	value, err := utils.FastMarshal(notice)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = a.bk.Put(a.closeCtx, backend.Item{
+		Key:     backend.Key(noticesPrefix, notice.Name),
+		Value:   value,
+		Expires: notice.Expires,
+	})
+	return trace.Wrap(err)
+}
+
+// GetClusterNotices returns all cluster notices that have not expired.
+func (a *Server) GetClusterNotices() ([]ClusterNotice, error) {
+	startKey := backend.Key(noticesPrefix)
+	result, err := a.bk.GetRange(a.closeCtx, startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]ClusterNotice, 0, len(result.Items))
+	for _, item := range result.Items {
+		var notice ClusterNotice
+		if err := utils.FastUnmarshal(item.Value, &notice); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, notice)
+	}
+	return out, nil
+}
+
+// DeleteClusterNotice deletes a cluster notice by name.
+func (a *Server) DeleteClusterNotice(name string) error {
+	if name == "" {
+		return trace.BadParameter("missing parameter name")
+	}
+	return trace.Wrap(a.bk.Delete(a.closeCtx, backend.Key(noticesPrefix, name)))
+}
@@ -84,6 +84,7 @@ type Event struct {
 	//	*Event_ReverseTunnel
 	//	*Event_TunnelConnection
 	//	*Event_AccessRequest
+	//	*Event_Lock
 	Resource             isEvent_Resource `protobuf_oneof:"Resource"`
 	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
 	XXX_unrecognized     []byte           `json:"-"`
@@ -168,6 +169,9 @@ type Event_TunnelConnection struct {
 type Event_AccessRequest struct {
 	AccessRequest *services.AccessRequestV3 `protobuf:"bytes,14,opt,name=AccessRequest,oneof"`
 }
+type Event_Lock struct {
+	Lock *services.LockV2 `protobuf:"bytes,15,opt,name=Lock,oneof"`
+}
 
 func (*Event_ResourceHeader) isEvent_Resource()   {}
 func (*Event_CertAuthority) isEvent_Resource()    {}
@@ -182,6 +186,7 @@ func (*Event_Server) isEvent_Resource()           {}
 func (*Event_ReverseTunnel) isEvent_Resource()    {}
 func (*Event_TunnelConnection) isEvent_Resource() {}
 func (*Event_AccessRequest) isEvent_Resource()    {}
+func (*Event_Lock) isEvent_Resource()             {}
 
 func (m *Event) GetResource() isEvent_Resource {
 	if m != nil {
@@ -288,6 +293,13 @@ func (m *Event) GetAccessRequest() *services.AccessRequestV3 {
 	return nil
 }
 
+func (m *Event) GetLock() *services.LockV2 {
+	if x, ok := m.GetResource().(*Event_Lock); ok {
+		return x.Lock
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*Event) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _Event_OneofMarshaler, _Event_OneofUnmarshaler, _Event_OneofSizer, []interface{}{
@@ -304,6 +316,7 @@ func (*Event) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error,
 		(*Event_ReverseTunnel)(nil),
 		(*Event_TunnelConnection)(nil),
 		(*Event_AccessRequest)(nil),
+		(*Event_Lock)(nil),
 	}
 }
 
@@ -376,6 +389,11 @@ func _Event_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.AccessRequest); err != nil {
 			return err
 		}
+	case *Event_Lock:
+		_ = b.EncodeVarint(15<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Lock); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("Event.Resource has unexpected type %T", x)
@@ -490,6 +508,14 @@ func _Event_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer)
 		err := b.DecodeMessage(msg)
 		m.Resource = &Event_AccessRequest{msg}
 		return true, err
+	case 15: // Resource.Lock
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(services.LockV2)
+		err := b.DecodeMessage(msg)
+		m.Resource = &Event_Lock{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -564,6 +590,11 @@ func _Event_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Event_Lock:
+		s := proto.Size(x.Lock)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -3360,6 +3391,20 @@ func (m *Event_AccessRequest) MarshalTo(dAtA []byte) (int, error) {
 	}
 	return i, nil
 }
+func (m *Event_Lock) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.Lock != nil {
+		dAtA[i] = 0x7a
+		i++
+		i = encodeVarintAuthservice(dAtA, i, uint64(m.Lock.Size()))
+		n15, err := m.Lock.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n15
+	}
+	return i, nil
+}
 func (m *Watch) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -4333,6 +4378,15 @@ func (m *Event_AccessRequest) Size() (n int) {
 	}
 	return n
 }
+func (m *Event_Lock) Size() (n int) {
+	var l int
+	_ = l
+	if m.Lock != nil {
+		l = m.Lock.Size()
+		n += 1 + l + sovAuthservice(uint64(l))
+	}
+	return n
+}
 func (m *Watch) Size() (n int) {
 	var l int
 	_ = l
@@ -5216,6 +5270,38 @@ func (m *Event) Unmarshal(dAtA []byte) error {
 			}
 			m.Resource = &Event_AccessRequest{v}
 			iNdEx = postIndex
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Lock", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAuthservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAuthservice
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &services.LockV2{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Resource = &Event_Lock{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAuthservice(dAtA[iNdEx:])
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// recordingKeysPrefix is the backend prefix cluster recording keys are
+// stored under.
+const recordingKeysPrefix = "recording_keys"
+
+// LoadRecordingEncryptor reads the cluster recording keys from the backend
+// and builds an encryptor from them, generating and storing the first key
+// if none exist yet.
+func LoadRecordingEncryptor(bk backend.Backend) (*events.RecordingEncryptor, error) {
+	startKey := backend.Key(recordingKeysPrefix)
+	result, err := bk.GetRange(context.TODO(), startKey, backend.RangeEnd(startKey), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var keys []*events.RecordingKey
+	for _, item := range result.Items {
+		var key events.RecordingKey
+		if err := utils.FastUnmarshal(item.Value, &key); err != nil {
+			return nil, trace.BadParameter("failed to parse recording key %q: %v", string(item.Key), err)
+		}
+		keys = append(keys, &key)
+	}
+	if len(keys) == 0 {
+		key, err := events.NewRecordingKey()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if err := upsertRecordingKey(bk, key); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		keys = append(keys, key)
+	}
+	return events.NewRecordingEncryptor(keys...)
+}
+
+// upsertRecordingKey stores a cluster recording key in the backend.
+func upsertRecordingKey(bk backend.Backend, key *events.RecordingKey) error {
+	value, err := utils.FastMarshal(key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = bk.Put(context.TODO(), backend.Item{
+		Key:   backend.Key(recordingKeysPrefix, key.ID),
+		Value: value,
+	})
+	return trace.Wrap(err)
+}
+
+// RotateRecordingKey generates a new cluster recording key and makes it
+// the one wrapping new session recordings. Older keys are kept so
+// recordings sealed before the rotation stay playable. Returns the ID of
+// the new key.
+func (a *Server) RotateRecordingKey() (string, error) {
+	if a.recordingEncryptor == nil {
+		return "", trace.BadParameter("session recording encryption is not enabled")
+	}
+	key, err := events.NewRecordingKey()
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := upsertRecordingKey(a.bk, key); err != nil {
+		return "", trace.Wrap(err)
+	}
+	a.recordingEncryptor.AddKey(key)
+	return key.ID, nil
+}
@@ -160,11 +160,40 @@ func registerThroughProxy(token string, params RegisterParams) (*Identity, error
 	if err != nil {
 		return nil, trace.Unwrap(err)
 	}
+
+	// The proxy path has no way to verify the auth server before the token is
+	// submitted, so when a CA pin was supplied check the certificate
+	// authorities in the response against it before trusting the credentials.
+	if params.CAPin != "" {
+		if err := checkPackedKeysPin(keys, params.CAPin); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
 	keys.Key = params.PrivateKey
 
 	return ReadIdentityFromKeyPair(keys)
 }
 
+// checkPackedKeysPin checks the TLS certificate authorities returned during
+// registration against the supplied CA pin. If none of them match the pin,
+// the response was not produced by the expected cluster and the credentials
+// must not be used.
+func checkPackedKeysPin(keys *PackedKeys, caPin string) error {
+	for _, certBytes := range keys.TLSCACerts {
+		cert, err := tlsca.ParseCertificatePEM(certBytes)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if err := utils.CheckSPKI(caPin, cert); err == nil {
+			return nil
+		}
+	}
+	return trace.AccessDenied("none of the certificate authorities returned " +
+		"during registration match the supplied CA pin, refusing to use the " +
+		"credentials: the cluster might be compromised or the pin might be stale")
+}
+
 // registerThroughAuth is used to register through the auth server.
 func registerThroughAuth(token string, params RegisterParams) (*Identity, error) {
 	log.Debugf("Attempting to register through auth server.")
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/services/local"
+
+	"github.com/gravitational/trace"
+)
+
+// RenameCluster migrates the cluster stored in the backend to a new name.
+// It removes the certificate authorities issued under the old name so the
+// next auth server start re-generates them under the new name, then updates
+// the cluster name resource itself.
+//
+// The caller is expected to have exclusive access to the backend: all
+// Teleport processes in the cluster must be stopped. Every certificate
+// issued under the old name becomes invalid, so nodes and users have to
+// re-register with the renamed cluster.
+func RenameCluster(bk backend.Backend, newName string) (string, error) {
+	clusterConfig := local.NewClusterConfigurationService(bk)
+	cn, err := clusterConfig.GetClusterName()
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return "", trace.BadParameter("cluster name has not been initialized yet, start the auth server with the new name instead")
+		}
+		return "", trace.Wrap(err)
+	}
+	oldName := cn.GetClusterName()
+	if oldName == newName {
+		return "", trace.BadParameter("cluster is already named %q", newName)
+	}
+
+	// Delete the local certificate authorities issued under the old name.
+	// Fresh ones are generated under the new name on the next auth server
+	// start, the same way they are on the very first start.
+	caService := local.NewCAService(bk)
+	for _, caType := range []services.CertAuthType{services.UserCA, services.HostCA} {
+		err := caService.DeleteCertAuthority(services.CertAuthID{Type: caType, DomainName: oldName})
+		if err != nil && !trace.IsNotFound(err) {
+			return "", trace.Wrap(err)
+		}
+	}
+
+	clusterName, err := services.NewClusterName(services.ClusterNameSpecV2{
+		ClusterName: newName,
+	})
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if err := clusterConfig.UpsertClusterName(clusterName); err != nil {
+		return "", trace.Wrap(err)
+	}
+	return oldName, nil
+}
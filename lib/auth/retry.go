@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gravitational/teleport/lib/httplib"
+
+	"github.com/gravitational/roundtrip"
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader is the HTTP header carrying the idempotency key
+	// of a mutating API call. The client reuses the key for every retry of
+	// one logical call so the server can deduplicate repeated requests.
+	IdempotencyKeyHeader = "X-Teleport-Idempotency-Key"
+
+	// retryAttempts is how many times a transient request failure is retried
+	retryAttempts = 3
+	// retryBaseDelay is the backoff delay before the first retry
+	retryBaseDelay = 100 * time.Millisecond
+	// retryMaxDelay caps the exponential backoff delay
+	retryMaxDelay = 2 * time.Second
+)
+
+// idempotencyKeyContext is the context key the idempotency key travels
+// under from the retrying request wrapper to the HTTP transport.
+type idempotencyKeyContext struct{}
+
+// idempotencyTransport injects the idempotency key stored in the request
+// context into the request headers.
+type idempotencyTransport struct {
+	http.RoundTripper
+}
+
+func (t idempotencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if key, ok := req.Context().Value(idempotencyKeyContext{}).(string); ok && key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// isRetryableError returns true for transient transport failures where a
+// retry can succeed, and false for terminal errors like access denied or
+// bad parameter where it cannot.
+func isRetryableError(err error) bool {
+	return trace.IsConnectionProblem(err) || trace.IsLimitExceeded(err)
+}
+
+// retryRequest issues the request with exponential backoff, retrying
+// transient failures and giving up on terminal errors. Mutating requests
+// are assigned an idempotency key reused across the retries so the server
+// executes the call at most once.
+func (c *Client) retryRequest(mutating bool, fn func(ctx context.Context) (*roundtrip.Response, error)) (*roundtrip.Response, error) {
+	ctx := context.TODO()
+	if mutating {
+		ctx = context.WithValue(ctx, idempotencyKeyContext{}, uuid.New())
+	}
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := httplib.ConvertResponse(fn(ctx))
+		if err == nil || attempt >= retryAttempts || !isRetryableError(err) {
+			return resp, err
+		}
+		log.WithError(err).Debugf("Auth API request failed, retrying in %v.", delay)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// +build cgo
+
+/*
+Copyright 2018-2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lite
+
+import (
+	"database/sql"
+
+	"github.com/gravitational/trace"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	sql.Register(BackendName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return nil
+		},
+	})
+}
+
+func isConstraintError(err error) bool {
+	e, ok := trace.Unwrap(err).(sqlite3.Error)
+	if !ok {
+		return false
+	}
+	return e.Code == sqlite3.ErrConstraint
+}
+
+func isLockedError(err error) bool {
+	e, ok := trace.Unwrap(err).(sqlite3.Error)
+	if !ok {
+		return false
+	}
+	return e.Code == sqlite3.ErrBusy
+}
+
+func isInterrupt(err error) bool {
+	e, ok := trace.Unwrap(err).(sqlite3.Error)
+	if !ok {
+		return false
+	}
+	return e.Code == sqlite3.ErrInterrupt
+}
+
+func isReadonlyError(err error) bool {
+	e, ok := trace.Unwrap(err).(sqlite3.Error)
+	if !ok {
+		return false
+	}
+	return e.Code == sqlite3.ErrReadonly
+}
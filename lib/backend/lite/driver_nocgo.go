@@ -0,0 +1,39 @@
+// +build !cgo
+
+/*
+Copyright 2018-2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lite
+
+// Without cgo the sqlite3 driver is not linked in, the backend fails at
+// open time with "unknown driver" and none of the driver error codes can
+// occur.
+
+func isConstraintError(err error) bool {
+	return false
+}
+
+func isLockedError(err error) bool {
+	return false
+}
+
+func isInterrupt(err error) bool {
+	return false
+}
+
+func isReadonlyError(err error) bool {
+	return false
+}
@@ -33,7 +33,6 @@ import (
 
 	"github.com/gravitational/trace"
 	"github.com/jonboulle/clockwork"
-	sqlite3 "github.com/mattn/go-sqlite3"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -55,14 +54,6 @@ func GetName() string {
 	return BackendName
 }
 
-func init() {
-	sql.Register(BackendName, &sqlite3.SQLiteDriver{
-		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
-			return nil
-		},
-	})
-}
-
 // Config structure represents configuration section
 type Config struct {
 	// Path is a path to the database directory
@@ -887,38 +878,6 @@ func isClosedError(err error) bool {
 	return err.Error() == "sql: database is closed"
 }
 
-func isConstraintError(err error) bool {
-	e, ok := trace.Unwrap(err).(sqlite3.Error)
-	if !ok {
-		return false
-	}
-	return e.Code == sqlite3.ErrConstraint
-}
-
-func isLockedError(err error) bool {
-	e, ok := trace.Unwrap(err).(sqlite3.Error)
-	if !ok {
-		return false
-	}
-	return e.Code == sqlite3.ErrBusy
-}
-
-func isInterrupt(err error) bool {
-	e, ok := trace.Unwrap(err).(sqlite3.Error)
-	if !ok {
-		return false
-	}
-	return e.Code == sqlite3.ErrInterrupt
-}
-
-func isReadonlyError(err error) bool {
-	e, ok := trace.Unwrap(err).(sqlite3.Error)
-	if !ok {
-		return false
-	}
-	return e.Code == sqlite3.ErrReadonly
-}
-
 // NullTime represents a time.Time that may be null. NullTime implements the
 // sql.Scanner interface so it can be used as a scan destination, similar to
 // sql.NullString.
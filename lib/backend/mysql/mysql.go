@@ -0,0 +1,798 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/utils"
+
+	// blank import registers the "mysql" database/sql driver
+	// referenced by DriverName
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// BackendName is the name of this backend as it appears
+	// in `storage/type` section of Teleport YAML
+	BackendName = "mysql"
+	// DriverName is the name of the database/sql driver used to connect
+	// to the server, the driver has to be registered by the build
+	// (for example by importing github.com/go-sql-driver/mysql)
+	DriverName = "mysql"
+
+	slowTransactionThreshold = time.Second
+
+	// defaultMaxOpenConns caps the connection pool, MySQL servers
+	// commonly default to max_connections=151, leave headroom for
+	// other clients of the same cluster
+	defaultMaxOpenConns = 25
+	// defaultMaxIdleConns keeps a few warm connections around
+	// between bursts of activity
+	defaultMaxIdleConns = 5
+	// defaultConnMaxLifetime recycles connections before common
+	// server side wait_timeout values kick in
+	defaultConnMaxLifetime = 5 * time.Minute
+
+	// MySQL server error codes used to convert driver errors
+	// to trace errors, see
+	// https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html
+	errDupEntry        = 1062
+	errLockDeadlock    = 1213
+	errLockWaitTimeout = 1205
+)
+
+// GetName is a part of backend API and it returns MySQL backend type
+// as it appears in `storage/type` section of Teleport YAML
+func GetName() string {
+	return BackendName
+}
+
+// Config structure represents configuration section
+type Config struct {
+	// Addr is a host:port of the MySQL server or load balancer
+	Addr string `json:"addr,omitempty"`
+	// Database is the name of the database to use, "teleport" by default
+	Database string `json:"database,omitempty"`
+	// Username is the user to connect as
+	Username string `json:"username,omitempty"`
+	// Password is the password of the user
+	Password string `json:"password,omitempty"`
+	// MaxOpenConns caps the number of open connections in the pool
+	MaxOpenConns int `json:"max_open_conns,omitempty"`
+	// MaxIdleConns caps the number of idle connections kept in the pool
+	MaxIdleConns int `json:"max_idle_conns,omitempty"`
+	// ConnMaxLifetime is the maximum amount of time a connection
+	// may be reused before it is closed and reopened
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime,omitempty"`
+	// BufferSize is a default buffer size
+	// used to pull events
+	BufferSize int `json:"buffer_size,omitempty"`
+	// PollStreamPeriod is a polling period for event stream
+	PollStreamPeriod time.Duration `json:"poll_stream_period,omitempty"`
+	// EventsOff turns events off
+	EventsOff bool `json:"events_off,omitempty"`
+	// Clock allows to override clock used in the backend
+	Clock clockwork.Clock `json:"-"`
+	// Mirror turns on mirror mode for the backend,
+	// which will use record IDs for Put and PutRange passed from
+	// the resources, not generate a new one
+	Mirror bool `json:"mirror"`
+}
+
+// CheckAndSetDefaults is a helper returns an error if the supplied
+// configuration is not enough to connect to MySQL
+func (cfg *Config) CheckAndSetDefaults() error {
+	if cfg.Addr == "" {
+		return trace.BadParameter("specify MySQL server address using 'addr' parameter")
+	}
+	if cfg.Database == "" {
+		cfg.Database = "teleport"
+	}
+	if cfg.MaxOpenConns == 0 {
+		cfg.MaxOpenConns = defaultMaxOpenConns
+	}
+	if cfg.MaxIdleConns == 0 {
+		cfg.MaxIdleConns = defaultMaxIdleConns
+	}
+	if cfg.ConnMaxLifetime == 0 {
+		cfg.ConnMaxLifetime = defaultConnMaxLifetime
+	}
+	if cfg.BufferSize == 0 {
+		cfg.BufferSize = backend.DefaultBufferSize
+	}
+	if cfg.PollStreamPeriod == 0 {
+		cfg.PollStreamPeriod = backend.DefaultPollStreamPeriod
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// connectorURL builds a DSN in the format understood by
+// github.com/go-sql-driver/mysql, parseTime is required
+// to scan DATETIME columns into time.Time
+func (cfg *Config) connectorURL() string {
+	auth := cfg.Username
+	if cfg.Password != "" {
+		auth = fmt.Sprintf("%v:%v", cfg.Username, cfg.Password)
+	}
+	return fmt.Sprintf("%v@tcp(%v)/%v?parseTime=true", auth, cfg.Addr, cfg.Database)
+}
+
+// New returns a new instance of MySQL backend
+func New(ctx context.Context, params backend.Params) (*Backend, error) {
+	var cfg *Config
+	err := utils.ObjectToStruct(params, &cfg)
+	if err != nil {
+		return nil, trace.BadParameter("MySQL configuration is invalid: %v", err)
+	}
+	return NewWithConfig(ctx, *cfg)
+}
+
+// NewWithConfig returns a new instance of MySQL backend using
+// configuration struct as a parameter
+func NewWithConfig(ctx context.Context, cfg Config) (*Backend, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	db, err := sql.Open(DriverName, cfg.connectorURL())
+	if err != nil {
+		return nil, trace.Wrap(err, "error opening connection to %v", cfg.Addr)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	if err := db.PingContext(ctx); err != nil {
+		return nil, trace.ConnectionProblem(err, "failed to connect to MySQL at %v", cfg.Addr)
+	}
+	buf, err := backend.NewCircularBuffer(ctx, cfg.BufferSize)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	closeCtx, cancel := context.WithCancel(ctx)
+	watchStarted, signalWatchStart := context.WithCancel(ctx)
+	m := &Backend{
+		Config:           cfg,
+		db:               db,
+		Entry:            log.WithFields(log.Fields{trace.Component: BackendName}),
+		clock:            cfg.Clock,
+		buf:              buf,
+		ctx:              closeCtx,
+		cancel:           cancel,
+		watchStarted:     watchStarted,
+		signalWatchStart: signalWatchStart,
+	}
+	m.Debugf("Connected to: %v, database: %v, pool: %v open/%v idle, poll stream period: %v.",
+		cfg.Addr, cfg.Database, cfg.MaxOpenConns, cfg.MaxIdleConns, cfg.PollStreamPeriod)
+	if err := m.createSchema(); err != nil {
+		return nil, trace.Wrap(err, "error creating schema on %v", cfg.Addr)
+	}
+	go m.runPeriodicOperations()
+	return m, nil
+}
+
+// Backend uses MySQL to implement storage interfaces
+type Backend struct {
+	Config
+	*log.Entry
+	backend.NoMigrations
+	db *sql.DB
+	// clock is used to generate time,
+	// could be swapped in tests for fixed time
+	clock clockwork.Clock
+
+	buf              *backend.CircularBuffer
+	ctx              context.Context
+	cancel           context.CancelFunc
+	watchStarted     context.Context
+	signalWatchStart context.CancelFunc
+
+	// closedFlag is set to indicate that the database is closed
+	closedFlag int32
+}
+
+func (m *Backend) createSchema() error {
+	// kv_key is VARBINARY to get case-sensitive byte-wise ordering
+	// regardless of the server collation, 768 bytes is the InnoDB
+	// index prefix limit
+	schemas := []string{
+		`CREATE TABLE IF NOT EXISTS kv (
+           kv_key VARBINARY(768) NOT NULL PRIMARY KEY,
+           modified BIGINT NOT NULL,
+           expires DATETIME(6) NULL,
+           value MEDIUMBLOB,
+           INDEX kv_expires (expires)
+         ) ENGINE=InnoDB`,
+
+		`CREATE TABLE IF NOT EXISTS events (
+           id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+           type INT NOT NULL,
+           created DATETIME(6) NOT NULL,
+           kv_key VARBINARY(768) NOT NULL,
+           kv_modified BIGINT NOT NULL,
+           kv_expires DATETIME(6) NULL,
+           kv_value MEDIUMBLOB,
+           INDEX events_created (created)
+         ) ENGINE=InnoDB`,
+	}
+
+	for _, schema := range schemas {
+		if _, err := m.db.ExecContext(m.ctx, schema); err != nil {
+			m.Errorf("Failing schema step: %v, %v.", schema, err)
+			return trace.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Backend) newLease(item backend.Item) *backend.Lease {
+	var lease backend.Lease
+	if item.Expires.IsZero() {
+		return &lease
+	}
+	lease.Key = item.Key
+	return &lease
+}
+
+// SetClock sets internal backend clock
+func (m *Backend) SetClock(clock clockwork.Clock) {
+	m.clock = clock
+}
+
+// Clock returns clock used by the backend
+func (m *Backend) Clock() clockwork.Clock {
+	return m.clock
+}
+
+// id converts time to ID
+func id(t time.Time) int64 {
+	return t.UTC().UnixNano()
+}
+
+// Create creates item if it does not exist
+func (m *Backend) Create(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if len(i.Key) == 0 {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	err := m.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := m.clock.Now().UTC()
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO kv(kv_key, modified, expires, value) VALUES(?, ?, ?, ?)",
+			i.Key, id(created), expires(i.Expires), i.Value); err != nil {
+			return trace.Wrap(err)
+		}
+		if !m.EventsOff {
+			if err := m.emitEvent(ctx, tx, backend.OpPut, created, backend.Item{
+				Key: i.Key, ID: id(created), Expires: i.Expires, Value: i.Value,
+			}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return m.newLease(i), nil
+}
+
+// CompareAndSwap compares item with existing item
+// and replaces is with replaceWith item
+func (m *Backend) CompareAndSwap(ctx context.Context, expected backend.Item, replaceWith backend.Item) (*backend.Lease, error) {
+	if len(expected.Key) == 0 {
+		return nil, trace.BadParameter("missing parameter Key")
+	}
+	if len(replaceWith.Key) == 0 {
+		return nil, trace.BadParameter("missing parameter Key")
+	}
+	if !bytes.Equal(expected.Key, replaceWith.Key) {
+		return nil, trace.BadParameter("expected and replaceWith keys should match")
+	}
+	now := m.clock.Now().UTC()
+	err := m.inTransaction(ctx, func(tx *sql.Tx) error {
+		// FOR UPDATE takes a row lock held for the duration of the
+		// transaction, so concurrent swaps of the same key serialize
+		// instead of clobbering each other
+		row := tx.QueryRowContext(ctx,
+			"SELECT value FROM kv WHERE kv_key = ? AND (expires IS NULL OR expires > ?) LIMIT 1 FOR UPDATE",
+			expected.Key, now)
+		var value []byte
+		if err := row.Scan(&value); err != nil {
+			if err == sql.ErrNoRows {
+				return trace.CompareFailed("key %v is not found", string(expected.Key))
+			}
+			return trace.Wrap(err)
+		}
+
+		if !bytes.Equal(value, expected.Value) {
+			return trace.CompareFailed("current value does not match expected for %v", string(expected.Key))
+		}
+
+		created := m.clock.Now().UTC()
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE kv SET value = ?, expires = ?, modified = ? WHERE kv_key = ?",
+			replaceWith.Value, expires(replaceWith.Expires), id(created), replaceWith.Key); err != nil {
+			return trace.Wrap(err)
+		}
+		if !m.EventsOff {
+			if err := m.emitEvent(ctx, tx, backend.OpPut, created, backend.Item{
+				Key: replaceWith.Key, ID: id(created), Expires: replaceWith.Expires, Value: replaceWith.Value,
+			}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return m.newLease(replaceWith), nil
+}
+
+// Put puts value into backend (creates if it does not
+// exists, updates it otherwise)
+func (m *Backend) Put(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if i.Key == nil {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	err := m.inTransaction(ctx, func(tx *sql.Tx) error {
+		return m.putInTransaction(ctx, tx, i)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return m.newLease(i), nil
+}
+
+// PutRange puts range of items into backend (creates if items do not
+// exist, updates them otherwise)
+func (m *Backend) PutRange(ctx context.Context, items []backend.Item) error {
+	for i := range items {
+		if items[i].Key == nil {
+			return trace.BadParameter("missing parameter key in item %v", i)
+		}
+	}
+	err := m.inTransaction(ctx, func(tx *sql.Tx) error {
+		for i := range items {
+			if err := m.putInTransaction(ctx, tx, items[i]); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+func (m *Backend) putInTransaction(ctx context.Context, tx *sql.Tx, i backend.Item) error {
+	created := m.clock.Now().UTC()
+	recordID := i.ID
+	if !m.Mirror {
+		recordID = id(created)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO kv(kv_key, modified, expires, value) VALUES(?, ?, ?, ?)
+         ON DUPLICATE KEY UPDATE modified = VALUES(modified), expires = VALUES(expires), value = VALUES(value)`,
+		i.Key, recordID, expires(i.Expires), i.Value); err != nil {
+		return trace.Wrap(err)
+	}
+	if !m.EventsOff {
+		if err := m.emitEvent(ctx, tx, backend.OpPut, created, backend.Item{
+			Key: i.Key, ID: recordID, Expires: i.Expires, Value: i.Value,
+		}); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// emitEvent records an event in the events table within the
+// same transaction as the kv mutation it describes
+func (m *Backend) emitEvent(ctx context.Context, tx *sql.Tx, op backend.OpType, created time.Time, i backend.Item) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO events(type, created, kv_key, kv_modified, kv_expires, kv_value) VALUES(?, ?, ?, ?, ?, ?)",
+		int(op), created, i.Key, i.ID, expires(i.Expires), i.Value)
+	return trace.Wrap(err)
+}
+
+// Update updates value in the backend
+func (m *Backend) Update(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if i.Key == nil {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	err := m.inTransaction(ctx, func(tx *sql.Tx) error {
+		created := m.clock.Now().UTC()
+		result, err := tx.ExecContext(ctx,
+			"UPDATE kv SET value = ?, expires = ?, modified = ? WHERE kv_key = ?",
+			i.Value, expires(i.Expires), id(created), i.Key)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if rows == 0 {
+			return trace.NotFound("key %v is not found", string(i.Key))
+		}
+		if !m.EventsOff {
+			if err := m.emitEvent(ctx, tx, backend.OpPut, created, backend.Item{
+				Key: i.Key, ID: id(created), Expires: i.Expires, Value: i.Value,
+			}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return m.newLease(i), nil
+}
+
+// Get returns a single item or not found error
+func (m *Backend) Get(ctx context.Context, key []byte) (*backend.Item, error) {
+	if len(key) == 0 {
+		return nil, trace.BadParameter("missing parameter key")
+	}
+	var item backend.Item
+	err := m.inTransaction(ctx, func(tx *sql.Tx) error {
+		return m.getInTransaction(ctx, key, tx, &item)
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &item, nil
+}
+
+// getInTransaction returns an item, works in transaction
+func (m *Backend) getInTransaction(ctx context.Context, key []byte, tx *sql.Tx, item *backend.Item) error {
+	// When in mirror mode, don't set the current time so the SELECT query
+	// returns expired items.
+	var now time.Time
+	if !m.Mirror {
+		now = m.clock.Now().UTC()
+	}
+
+	row := tx.QueryRowContext(ctx,
+		"SELECT kv_key, value, expires, modified FROM kv WHERE kv_key = ? AND (expires IS NULL OR expires > ?) LIMIT 1",
+		key, now)
+	var expires NullTime
+	if err := row.Scan(&item.Key, &item.Value, &expires, &item.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return trace.NotFound("key %v is not found", string(key))
+		}
+		return trace.Wrap(err)
+	}
+	item.Expires = expires.Time
+	return nil
+}
+
+// GetRange returns query range
+func (m *Backend) GetRange(ctx context.Context, startKey []byte, endKey []byte, limit int) (*backend.GetResult, error) {
+	if len(startKey) == 0 {
+		return nil, trace.BadParameter("missing parameter startKey")
+	}
+	if len(endKey) == 0 {
+		return nil, trace.BadParameter("missing parameter endKey")
+	}
+	if limit <= 0 {
+		limit = backend.DefaultLargeLimit
+	}
+
+	// When in mirror mode, don't set the current time so the SELECT query
+	// returns expired items.
+	var now time.Time
+	if !m.Mirror {
+		now = m.clock.Now().UTC()
+	}
+
+	var result backend.GetResult
+	err := m.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			"SELECT kv_key, value, expires, modified FROM kv WHERE (kv_key >= ? AND kv_key <= ?) AND (expires IS NULL OR expires > ?) ORDER BY kv_key LIMIT ?",
+			startKey, endKey, now, limit)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var i backend.Item
+			var expires NullTime
+			if err := rows.Scan(&i.Key, &i.Value, &expires, &i.ID); err != nil {
+				return trace.Wrap(err)
+			}
+			i.Expires = expires.Time
+			result.Items = append(result.Items, i)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &result, nil
+}
+
+// KeepAlive updates TTL on the lease
+func (m *Backend) KeepAlive(ctx context.Context, lease backend.Lease, expires time.Time) error {
+	if len(lease.Key) == 0 {
+		return trace.BadParameter("lease key is not specified")
+	}
+	now := m.clock.Now().UTC()
+	return m.inTransaction(ctx, func(tx *sql.Tx) error {
+		var item backend.Item
+		err := m.getInTransaction(ctx, lease.Key, tx, &item)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		result, err := tx.ExecContext(ctx,
+			"UPDATE kv SET expires = ?, modified = ? WHERE kv_key = ?",
+			expires.UTC(), id(now), lease.Key)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if rows == 0 {
+			return trace.NotFound("key %v is not found", string(lease.Key))
+		}
+		if !m.EventsOff {
+			created := m.clock.Now().UTC()
+			if err := m.emitEvent(ctx, tx, backend.OpPut, created, backend.Item{
+				Key: item.Key, ID: id(created), Expires: expires.UTC(), Value: item.Value,
+			}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Backend) deleteInTransaction(ctx context.Context, key []byte, tx *sql.Tx) error {
+	result, err := tx.ExecContext(ctx, "DELETE FROM kv WHERE kv_key = ?", key)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if rows == 0 {
+		return trace.NotFound("key %v is not found", string(key))
+	}
+	if !m.EventsOff {
+		created := m.clock.Now().UTC()
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO events(type, created, kv_key, kv_modified) VALUES(?, ?, ?, ?)",
+			int(backend.OpDelete), created, key, created.UnixNano()); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// Delete deletes item by key, returns NotFound error
+// if item does not exist
+func (m *Backend) Delete(ctx context.Context, key []byte) error {
+	if len(key) == 0 {
+		return trace.BadParameter("missing parameter key")
+	}
+	return m.inTransaction(ctx, func(tx *sql.Tx) error {
+		return m.deleteInTransaction(ctx, key, tx)
+	})
+}
+
+// DeleteRange deletes range of items with keys between startKey and endKey
+// Note that elements deleted by range do not produce any events
+func (m *Backend) DeleteRange(ctx context.Context, startKey, endKey []byte) error {
+	if len(startKey) == 0 {
+		return trace.BadParameter("missing parameter startKey")
+	}
+	if len(endKey) == 0 {
+		return trace.BadParameter("missing parameter endKey")
+	}
+	return m.inTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			"SELECT kv_key FROM kv WHERE kv_key >= ? AND kv_key <= ? FOR UPDATE",
+			startKey, endKey)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer rows.Close()
+		var keys [][]byte
+		for rows.Next() {
+			var key []byte
+			if err := rows.Scan(&key); err != nil {
+				return trace.Wrap(err)
+			}
+			keys = append(keys, key)
+		}
+		if err := rows.Close(); err != nil {
+			return trace.Wrap(err)
+		}
+
+		for i := range keys {
+			if err := m.deleteInTransaction(ctx, keys[i], tx); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// NewWatcher returns a new event watcher
+func (m *Backend) NewWatcher(ctx context.Context, watch backend.Watch) (backend.Watcher, error) {
+	if m.EventsOff {
+		return nil, trace.BadParameter("events are turned off for this backend")
+	}
+	select {
+	case <-m.watchStarted.Done():
+	case <-ctx.Done():
+		return nil, trace.ConnectionProblem(ctx.Err(), "context is closing")
+	}
+	return m.buf.NewWatcher(ctx, watch)
+}
+
+// Close closes all associated resources
+func (m *Backend) Close() error {
+	m.cancel()
+	return m.closeDatabase()
+}
+
+// CloseWatchers closes all the watchers
+// without closing the backend
+func (m *Backend) CloseWatchers() {
+	m.buf.Reset()
+}
+
+func (m *Backend) isClosed() bool {
+	return atomic.LoadInt32(&m.closedFlag) == 1
+}
+
+func (m *Backend) setClosed() {
+	atomic.StoreInt32(&m.closedFlag, 1)
+}
+
+func (m *Backend) closeDatabase() error {
+	m.setClosed()
+	m.buf.Close()
+	return m.db.Close()
+}
+
+func (m *Backend) inTransaction(ctx context.Context, f func(tx *sql.Tx) error) (err error) {
+	start := time.Now()
+	defer func() {
+		diff := time.Since(start)
+		if diff > slowTransactionThreshold {
+			m.Warningf("SLOW TRANSACTION: %v, %v.", diff, string(debug.Stack()))
+		}
+	}()
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return trace.Wrap(convertError(err))
+	}
+	commit := func() error {
+		return tx.Commit()
+	}
+	rollback := func() error {
+		return tx.Rollback()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			m.Errorf("Unexpected panic in inTransaction: %v, trying to rollback.", r)
+			err = trace.BadParameter("panic: %v", r)
+			if e2 := rollback(); e2 != nil {
+				m.Errorf("Failed to rollback: %v.", e2)
+			}
+			return
+		}
+		if err != nil && !trace.IsNotFound(err) {
+			if isErrorCode(trace.Unwrap(err), errDupEntry) {
+				err = trace.AlreadyExists(err.Error())
+			}
+			if isErrorCode(trace.Unwrap(err), errLockDeadlock) || isErrorCode(trace.Unwrap(err), errLockWaitTimeout) {
+				err = trace.ConnectionProblem(err, "failed to acquire row locks")
+			}
+			if !m.isClosed() {
+				if !trace.IsCompareFailed(err) && !trace.IsAlreadyExists(err) && !trace.IsConnectionProblem(err) {
+					m.Warningf("Unexpected error in inTransaction: %v, rolling back.", trace.DebugReport(err))
+				}
+				if e2 := rollback(); e2 != nil {
+					m.Errorf("Failed to rollback too: %v.", e2)
+				}
+			}
+			return
+		}
+		if err2 := commit(); err2 != nil {
+			err = trace.Wrap(err2)
+		}
+	}()
+	err = f(tx)
+	return
+}
+
+func expires(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+func convertError(err error) error {
+	origError := trace.Unwrap(err)
+	if isClosedError(origError) {
+		return trace.ConnectionProblem(err, "database is closed")
+	}
+	return err
+}
+
+func isClosedError(err error) bool {
+	return err.Error() == "sql: database is closed"
+}
+
+// isErrorCode matches a server error number in the text produced by
+// the MySQL driver ("Error 1062: Duplicate entry ..."), matching on
+// the text keeps this package free of a hard driver dependency
+func isErrorCode(err error, code int) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), fmt.Sprintf("Error %v", code))
+}
+
+// NullTime represents a time.Time that may be null. NullTime implements the
+// sql.Scanner interface so it can be used as a scan destination, similar to
+// sql.NullString.
+type NullTime struct {
+	Time  time.Time
+	Valid bool // Valid is true if Time is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (nt *NullTime) Scan(value interface{}) error {
+	nt.Time, nt.Valid = value.(time.Time)
+	return nil
+}
+
+// Value implements the driver Valuer interface.
+func (nt NullTime) Value() (driver.Value, error) {
+	if !nt.Valid {
+		return nil, nil
+	}
+	return nt.Time, nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/backend/test"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"gopkg.in/check.v1"
+)
+
+func TestMySQL(t *testing.T) { check.TestingT(t) }
+
+// TestDriverRegistered makes sure the build links in a database/sql
+// driver under DriverName, without it every connection attempt fails
+// with "sql: unknown driver". It does not require a running server:
+// sql.Open validates the driver name without dialing.
+func TestDriverRegistered(t *testing.T) {
+	cfg := Config{Addr: "localhost:3306"}
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open(DriverName, cfg.connectorURL())
+	if err != nil {
+		t.Fatalf("driver %q is not registered: %v", DriverName, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type MySQLSuite struct {
+	bk    *Backend
+	suite test.BackendSuite
+}
+
+var _ = check.Suite(&MySQLSuite{})
+
+// SetUpSuite connects to the MySQL server pointed to by
+// TELEPORT_MYSQL_TEST_ADDR, the suite is skipped when
+// the variable is not set
+func (s *MySQLSuite) SetUpSuite(c *check.C) {
+	utils.InitLoggerForTests(testing.Verbose())
+
+	addr := os.Getenv("TELEPORT_MYSQL_TEST_ADDR")
+	if addr == "" {
+		c.Skip("TELEPORT_MYSQL_TEST_ADDR is not set, skipping MySQL backend tests")
+	}
+	config := backend.Params{
+		"addr":     addr,
+		"database": os.Getenv("TELEPORT_MYSQL_TEST_DATABASE"),
+		"username": os.Getenv("TELEPORT_MYSQL_TEST_USER"),
+		"password": os.Getenv("TELEPORT_MYSQL_TEST_PASS"),
+	}
+	newBackend := func() (backend.Backend, error) {
+		return New(context.Background(), config)
+	}
+	s.suite.NewBackend = newBackend
+}
+
+func (s *MySQLSuite) SetUpTest(c *check.C) {
+	b, err := s.suite.NewBackend()
+	c.Assert(err, check.IsNil)
+	s.bk = b.(*Backend)
+	s.suite.B = s.bk
+
+	// start each test from a clean table
+	_, err = s.bk.db.ExecContext(context.Background(), "DELETE FROM kv")
+	c.Assert(err, check.IsNil)
+	_, err = s.bk.db.ExecContext(context.Background(), "DELETE FROM events")
+	c.Assert(err, check.IsNil)
+}
+
+func (s *MySQLSuite) TearDownTest(c *check.C) {
+	if s.bk != nil {
+		c.Assert(s.bk.Close(), check.IsNil)
+	}
+}
+
+func (s *MySQLSuite) TestCRUD(c *check.C) {
+	s.suite.CRUD(c)
+}
+
+func (s *MySQLSuite) TestRange(c *check.C) {
+	s.suite.Range(c)
+}
+
+func (s *MySQLSuite) TestDeleteRange(c *check.C) {
+	s.suite.DeleteRange(c)
+}
+
+func (s *MySQLSuite) TestCompareAndSwap(c *check.C) {
+	s.suite.CompareAndSwap(c)
+}
+
+func (s *MySQLSuite) TestExpiration(c *check.C) {
+	s.suite.Expiration(c)
+}
+
+func (s *MySQLSuite) TestEvents(c *check.C) {
+	s.suite.Events(c)
+}
+
+func (s *MySQLSuite) TestWatchersClose(c *check.C) {
+	s.suite.WatchersClose(c)
+}
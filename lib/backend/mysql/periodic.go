@@ -0,0 +1,177 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+)
+
+const notSet = -2
+
+func (m *Backend) runPeriodicOperations() {
+	t := time.NewTicker(m.PollStreamPeriod)
+	defer t.Stop()
+
+	rowid := int64(notSet)
+	for {
+		select {
+		case <-m.ctx.Done():
+			if err := m.closeDatabase(); err != nil {
+				m.Warningf("Error closing database: %v", err)
+			}
+			return
+		case <-t.C:
+			err := m.removeExpiredKeys()
+			if err != nil {
+				// connection problem means that database is closed
+				// or is closing, downgrade the log to debug
+				// to avoid polluting logs in production
+				if trace.IsConnectionProblem(err) {
+					m.Debugf("Failed to run remove expired keys: %v", err)
+				} else {
+					m.Warningf("Failed to run remove expired keys: %v", err)
+				}
+			}
+			if !m.EventsOff {
+				err = m.removeOldEvents()
+				if err != nil {
+					m.Warningf("Failed to run remove old events: %v", err)
+				}
+				rowid, err = m.pollEvents(rowid)
+				if err != nil {
+					m.Warningf("Failed to run poll events: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func (m *Backend) removeExpiredKeys() error {
+	// In mirror mode, don't expire any elements. This allows the cache to setup
+	// a watch and expire elements as the events roll in.
+	if m.Mirror {
+		return nil
+	}
+
+	now := m.clock.Now().UTC()
+	return m.inTransaction(m.ctx, func(tx *sql.Tx) error {
+		// FOR UPDATE locks the expired rows so that concurrent sweeps
+		// running on other auth servers pointed at the same database
+		// don't race deleting the same keys
+		rows, err := tx.QueryContext(m.ctx,
+			"SELECT kv_key FROM kv WHERE expires <= ? ORDER BY kv_key LIMIT ? FOR UPDATE",
+			now, m.BufferSize)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer rows.Close()
+		var keys [][]byte
+		for rows.Next() {
+			var key []byte
+			if err := rows.Scan(&key); err != nil {
+				return trace.Wrap(err)
+			}
+			keys = append(keys, key)
+		}
+		if err := rows.Close(); err != nil {
+			return trace.Wrap(err)
+		}
+
+		for i := range keys {
+			if err := m.deleteInTransaction(m.ctx, keys[i], tx); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (m *Backend) removeOldEvents() error {
+	expiryTime := m.clock.Now().UTC().Add(-1 * backend.DefaultEventsTTL)
+	return m.inTransaction(m.ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(m.ctx, "DELETE FROM events WHERE created <= ?", expiryTime)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		return nil
+	})
+}
+
+func (m *Backend) pollEvents(rowid int64) (int64, error) {
+	if rowid == notSet {
+		err := m.inTransaction(m.ctx, func(tx *sql.Tx) error {
+			row := tx.QueryRowContext(m.ctx,
+				"SELECT id FROM events ORDER BY id DESC LIMIT 1")
+			if err := row.Scan(&rowid); err != nil {
+				if err != sql.ErrNoRows {
+					return trace.Wrap(err)
+				}
+				rowid = -1
+			} else {
+				rowid = rowid - 1
+			}
+			return nil
+		})
+		if err != nil {
+			return rowid, trace.Wrap(err)
+		}
+		m.Debugf("Initialized event ID iterator to %v", rowid)
+		m.signalWatchStart()
+	}
+
+	var events []backend.Event
+	var lastID int64
+	err := m.inTransaction(m.ctx, func(tx *sql.Tx) error {
+		limit := m.BufferSize / 2
+		if limit <= 0 {
+			limit = 1
+		}
+		rows, err := tx.QueryContext(m.ctx,
+			"SELECT id, type, kv_key, kv_value, kv_modified, kv_expires FROM events WHERE id > ? ORDER BY id LIMIT ?",
+			rowid, limit)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var event backend.Event
+			var expires NullTime
+			if err := rows.Scan(&lastID, &event.Type, &event.Item.Key, &event.Item.Value, &event.Item.ID, &expires); err != nil {
+				return trace.Wrap(err)
+			}
+			if expires.Valid {
+				event.Item.Expires = expires.Time
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return rowid, trace.Wrap(err)
+	}
+	m.buf.PushBatch(events)
+	if len(events) != 0 {
+		return lastID, nil
+	}
+	return rowid, nil
+}
@@ -0,0 +1,59 @@
+// +build bpf,!386
+
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bpf
+
+// #cgo LDFLAGS: -ldl
+// #include <dlfcn.h>
+// #include <stdlib.h>
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/gravitational/trace"
+)
+
+// IsHostCompatible checks that BPF programs can run on this host.
+func IsHostCompatible() error {
+	// To find the cgroup ID of a program, bpf_get_current_cgroup_id is needed
+	// which was introduced in 4.18.
+	// https://github.com/torvalds/linux/commit/bf6fa2c893c5237b48569a13fa3c673041430b6c
+	minKernel := semver.New(teleport.EnhancedRecordingMinKernel)
+	version, err := utils.KernelVersion()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if version.LessThan(*minKernel) {
+		return trace.BadParameter("incompatible kernel found, minimum supported kernel is %v", minKernel)
+	}
+
+	// Check that libbcc is on the system.
+	libraryName := C.CString("libbcc.so.0")
+	defer C.free(unsafe.Pointer(libraryName))
+	handle := C.dlopen(libraryName, C.RTLD_NOW)
+	if handle == nil {
+		return trace.BadParameter("libbcc.so not found")
+	}
+
+	return nil
+}
@@ -49,6 +49,7 @@ func ForAuth(cfg Config) Config {
 		{Kind: services.KindReverseTunnel},
 		{Kind: services.KindTunnelConnection},
 		{Kind: services.KindAccessRequest},
+		{Kind: services.KindLock},
 	}
 	cfg.QueueSize = defaults.AuthQueueSize
 	return cfg
@@ -68,6 +69,7 @@ func ForProxy(cfg Config) Config {
 		{Kind: services.KindAuthServer},
 		{Kind: services.KindReverseTunnel},
 		{Kind: services.KindTunnelConnection},
+		{Kind: services.KindLock},
 	}
 	cfg.QueueSize = defaults.ProxyQueueSize
 	return cfg
@@ -85,6 +87,7 @@ func ForNode(cfg Config) Config {
 		// namespace events to avoid matching too much
 		// data about other namespaces or node events
 		{Kind: services.KindNamespace, Name: defaults.Namespace},
+		{Kind: services.KindLock},
 	}
 	cfg.QueueSize = defaults.NodeQueueSize
 	return cfg
@@ -125,6 +128,17 @@ type Cache struct {
 
 	// closedFlag is set to indicate that the services are closed
 	closedFlag int32
+
+	// staleSince is the time (in unix nanoseconds) the connection to the
+	// backend was lost and the cache started serving stale data, zero if
+	// the cache is up to date
+	staleSince int64
+}
+
+// IsStale reports whether the cache has lost the connection to the backend
+// and is serving the last fetched data within the staleness budget.
+func (c *Cache) IsStale() bool {
+	return atomic.LoadInt64(&c.staleSince) != 0
 }
 
 // Config defines cache configuration parameters
@@ -180,9 +194,16 @@ type Config struct {
 // using recent data and failing otherwise.
 // Used by auth servers and other systems
 // having direct access to the backend.
+// If MaxStaleness is set, short backend outages
+// are tolerated by serving the last fetched data
+// marked as stale instead of failing reads right away.
 type OnlyRecent struct {
 	// Enabled enables cache behavior
 	Enabled bool
+	// MaxStaleness is the longest the last fetched data is still served
+	// after the connection to the backend is lost, zero means reads start
+	// failing as soon as the outage is detected
+	MaxStaleness time.Duration
 }
 
 // PreferRecent defined cache behavior
@@ -352,11 +373,26 @@ func (c *Cache) update(ctx context.Context) {
 
 // setCacheState for "only recent" cache behavior will erase
 // the cache and set error mode to refuse to serve stale data,
-// otherwise does nothing
+// unless the staleness budget set by MaxStaleness allows serving
+// the last fetched data for a little longer, otherwise does nothing
 func (c *Cache) setCacheState(err error) {
 	if !c.OnlyRecent.Enabled {
 		return
 	}
+	if c.OnlyRecent.MaxStaleness != 0 {
+		now := c.Clock.Now()
+		staleSince := atomic.LoadInt64(&c.staleSince)
+		if staleSince == 0 {
+			staleSince = now.UnixNano()
+			atomic.StoreInt64(&c.staleSince, staleSince)
+		}
+		stale := now.Sub(time.Unix(0, staleSince))
+		if stale < c.OnlyRecent.MaxStaleness {
+			c.Warningf("Backend is unavailable, serving stale data for %v out of the %v budget.", stale, c.OnlyRecent.MaxStaleness)
+			return
+		}
+		c.Warningf("Backend is unavailable and the %v staleness budget is exhausted, failing reads.", c.OnlyRecent.MaxStaleness)
+	}
 	if err := c.eraseAll(); err != nil {
 		if !c.isClosed() {
 			c.Warningf("Failed to erase the data: %v.", err)
@@ -471,6 +507,7 @@ func (c *Cache) fetchAndWatch(ctx context.Context, retry utils.Retry) error {
 	}
 	retry.Reset()
 	c.wrapper.SetReadError(nil)
+	atomic.StoreInt64(&c.staleSince, 0)
 	c.notify(Event{Type: WatcherStarted})
 	for {
 		select {
@@ -598,6 +635,20 @@ func (c *Cache) GetRole(name string) (services.Role, error) {
 	return role, err
 }
 
+// GetLocks is a part of auth.AccessPoint implementation
+func (c *Cache) GetLocks() ([]services.Lock, error) {
+	return c.accessCache.GetLocks()
+}
+
+// GetLock is a part of auth.AccessPoint implementation
+func (c *Cache) GetLock(name string) (services.Lock, error) {
+	lock, err := c.accessCache.GetLock(name)
+	if trace.IsNotFound(err) {
+		return c.Access.GetLock(name)
+	}
+	return lock, err
+}
+
 // GetNamespace returns namespace
 func (c *Cache) GetNamespace(name string) (*services.Namespace, error) {
 	return c.presenceCache.GetNamespace(name)
@@ -80,6 +80,11 @@ func setupCollections(c *Cache, watches []services.WatchKind) (map[string]collec
 				return nil, trace.BadParameter("missing parameter Access")
 			}
 			collections[watch.Kind] = &role{watch: watch, Cache: c}
+		case services.KindLock:
+			if c.Access == nil {
+				return nil, trace.BadParameter("missing parameter Access")
+			}
+			collections[watch.Kind] = &lock{watch: watch, Cache: c}
 		case services.KindNamespace:
 			if c.Presence == nil {
 				return nil, trace.BadParameter("missing parameter Presence")
@@ -1051,3 +1056,67 @@ func (c *role) processEvent(ctx context.Context, event services.Event) error {
 func (c *role) watchKind() services.WatchKind {
 	return c.watch
 }
+
+type lock struct {
+	*Cache
+	watch services.WatchKind
+}
+
+// erase erases all data in the collection
+func (c *lock) erase() error {
+	if err := c.accessCache.DeleteAllLocks(); err != nil {
+		if !trace.IsNotFound(err) {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (c *lock) fetch(ctx context.Context) error {
+	resources, err := c.Access.GetLocks()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := c.erase(); err != nil {
+		return trace.Wrap(err)
+	}
+	for _, resource := range resources {
+		c.setTTL(resource)
+		if err := c.accessCache.UpsertLock(ctx, resource); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+func (c *lock) processEvent(ctx context.Context, event services.Event) error {
+	switch event.Type {
+	case backend.OpDelete:
+		err := c.accessCache.DeleteLock(ctx, event.Resource.GetName())
+		if err != nil {
+			// resource could be missing in the cache
+			// expired or not created, if the first consumed
+			// event is delete
+			if !trace.IsNotFound(err) {
+				c.Warningf("Failed to delete lock %v.", err)
+				return trace.Wrap(err)
+			}
+		}
+	case backend.OpPut:
+		resource, ok := event.Resource.(services.Lock)
+		if !ok {
+			return trace.BadParameter("unexpected type %T", event.Resource)
+		}
+		c.setTTL(resource)
+		if err := c.accessCache.UpsertLock(ctx, resource); err != nil {
+			return trace.Wrap(err)
+		}
+	default:
+		c.Warningf("Skipping unsupported event type %v.", event.Type)
+	}
+	return nil
+}
+
+func (c *lock) watchKind() services.WatchKind {
+	return c.watch
+}
@@ -27,6 +27,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
@@ -54,6 +55,9 @@ var log = logrus.WithFields(logrus.Fields{
 type Config struct {
 	// MountPath is where the cgroupv2 hierarchy is mounted.
 	MountPath string
+
+	// Limits are optional resource limits applied to each session cgroup.
+	Limits *Limits
 }
 
 // CheckAndSetDefaults checks BPF configuration.
@@ -91,11 +95,47 @@ func New(config *Config) (*Service, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	// If resource limits were requested, enable the required controllers
+	// for session cgroups.
+	if config.Limits != nil {
+		if err := s.enableControllers(config.Limits.controllers()); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
 	log.Debugf("Teleport session hierarchy mounted at: %v.", s.teleportRoot)
 
 	return s, nil
 }
 
+// enableControllers enables the given cgroup controllers for all ancestors
+// of the session cgroups so limits can be applied to the sessions.
+func (s *Service) enableControllers(controllers []string) error {
+	if len(controllers) == 0 {
+		return nil
+	}
+	var control bytes.Buffer
+	for _, controller := range controllers {
+		fmt.Fprintf(&control, "+%v ", controller)
+	}
+	// controllers have to be enabled top down from the root of the
+	// hierarchy down to the parent of the session cgroups
+	var dirs []string
+	for dir := s.teleportRoot; strings.HasPrefix(dir, s.MountPath); dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == s.MountPath {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		err := ioutil.WriteFile(path.Join(dirs[i], subtreeControl), control.Bytes(), fileMode)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	return nil
+}
+
 // Close will unmount the cgroup filesystem.
 func (s *Service) Close() error {
 	err := s.cleanupHierarchy()
@@ -114,10 +154,18 @@ func (s *Service) Close() error {
 
 // Create will create a cgroup for a given session.
 func (s *Service) Create(sessionID string) error {
-	err := os.Mkdir(path.Join(s.teleportRoot, sessionID), fileMode)
+	dir := path.Join(s.teleportRoot, sessionID)
+	err := os.Mkdir(dir, fileMode)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	// apply resource limits, if configured, before any process is placed
+	// in the cgroup
+	if s.Limits != nil {
+		if err := s.Limits.apply(dir); err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	return nil
 }
 
@@ -372,10 +420,6 @@ var (
 )
 
 const (
-	// fileMode is the mode files and directories are created in within the
-	// cgroup filesystem.
-	fileMode = 0555
-
 	// teleportRoot is the prefix of the root cgroup that holds all other
 	// Teleport cgroups.
 	teleportRoot = "teleport"
@@ -383,4 +427,8 @@ const (
 	// cgroupProcs is the name of the file that contains all processes within
 	// a cgroup.
 	cgroupProcs = "cgroup.procs"
+
+	// subtreeControl is the name of the file used to enable controllers for
+	// child cgroups.
+	subtreeControl = "cgroup.subtree_control"
 )
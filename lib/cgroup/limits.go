@@ -0,0 +1,98 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cgroup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+
+	"github.com/gravitational/trace"
+)
+
+// Limits configures cgroupv2 resource limits applied to a session cgroup.
+type Limits struct {
+	// MaxMemoryBytes caps the memory available to a session ("memory.max").
+	MaxMemoryBytes int64
+	// CPUPercent caps CPU time available to a session as a percentage of a
+	// single CPU ("cpu.max").
+	CPUPercent int64
+	// MaxProcesses caps the number of processes in a session ("pids.max").
+	MaxProcesses int64
+}
+
+// apply writes the configured limits into the control files of a cgroup.
+func (l *Limits) apply(dir string) error {
+	if l.MaxMemoryBytes > 0 {
+		err := ioutil.WriteFile(path.Join(dir, memoryMax), []byte(strconv.FormatInt(l.MaxMemoryBytes, 10)), fileMode)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	if l.CPUPercent > 0 {
+		// "cpu.max" takes a quota and a period in microseconds, use the
+		// default period of 100ms and derive the quota from the percentage
+		quota := fmt.Sprintf("%v %v", l.CPUPercent*cpuPeriod/100, cpuPeriod)
+		err := ioutil.WriteFile(path.Join(dir, cpuMax), []byte(quota), fileMode)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	if l.MaxProcesses > 0 {
+		err := ioutil.WriteFile(path.Join(dir, pidsMax), []byte(strconv.FormatInt(l.MaxProcesses, 10)), fileMode)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	return nil
+}
+
+// controllers returns the cgroup controllers needed to enforce the limits.
+func (l *Limits) controllers() []string {
+	var out []string
+	if l.MaxMemoryBytes > 0 {
+		out = append(out, "memory")
+	}
+	if l.CPUPercent > 0 {
+		out = append(out, "cpu")
+	}
+	if l.MaxProcesses > 0 {
+		out = append(out, "pids")
+	}
+	return out
+}
+
+const (
+	// fileMode is the mode files and directories are created in within the
+	// cgroup filesystem.
+	fileMode = 0555
+
+	// memoryMax is the name of the file that caps memory usage of a cgroup.
+	memoryMax = "memory.max"
+
+	// cpuMax is the name of the file that caps CPU usage of a cgroup.
+	cpuMax = "cpu.max"
+
+	// pidsMax is the name of the file that caps the number of processes in
+	// a cgroup.
+	pidsMax = "pids.max"
+
+	// cpuPeriod is the scheduling period in microseconds used when capping
+	// CPU usage.
+	cpuPeriod = 100000
+)
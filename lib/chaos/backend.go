@@ -0,0 +1,154 @@
+// +build chaos
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// WrapBackend wraps the backend with a fault injecting layer when
+// any faults are configured, otherwise the backend is returned as is
+func WrapBackend(bk backend.Backend) backend.Backend {
+	if !Enabled() {
+		return bk
+	}
+	return &chaosBackend{backend: bk}
+}
+
+// chaosBackend delays every backend operation and fails a
+// configurable fraction of them with connection problems
+type chaosBackend struct {
+	backend backend.Backend
+}
+
+// inject applies the configured faults to a single operation
+func (c *chaosBackend) inject(op string) error {
+	f := config()
+	f.delay()
+	if f.fire() {
+		return trace.ConnectionProblem(nil, "chaos: backend %v failed", op)
+	}
+	return nil
+}
+
+// Create creates item if it does not exist
+func (c *chaosBackend) Create(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if err := c.inject("create"); err != nil {
+		return nil, err
+	}
+	return c.backend.Create(ctx, i)
+}
+
+// Put puts value into backend (creates if it does not exist, updates it otherwise)
+func (c *chaosBackend) Put(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if err := c.inject("put"); err != nil {
+		return nil, err
+	}
+	return c.backend.Put(ctx, i)
+}
+
+// CompareAndSwap compares item with existing item and replaces it with replaceWith item
+func (c *chaosBackend) CompareAndSwap(ctx context.Context, expected backend.Item, replaceWith backend.Item) (*backend.Lease, error) {
+	if err := c.inject("compare_and_swap"); err != nil {
+		return nil, err
+	}
+	return c.backend.CompareAndSwap(ctx, expected, replaceWith)
+}
+
+// Update updates value in the backend
+func (c *chaosBackend) Update(ctx context.Context, i backend.Item) (*backend.Lease, error) {
+	if err := c.inject("update"); err != nil {
+		return nil, err
+	}
+	return c.backend.Update(ctx, i)
+}
+
+// Get returns a single item or not found error
+func (c *chaosBackend) Get(ctx context.Context, key []byte) (*backend.Item, error) {
+	if err := c.inject("get"); err != nil {
+		return nil, err
+	}
+	return c.backend.Get(ctx, key)
+}
+
+// GetRange returns query range
+func (c *chaosBackend) GetRange(ctx context.Context, startKey []byte, endKey []byte, limit int) (*backend.GetResult, error) {
+	if err := c.inject("get_range"); err != nil {
+		return nil, err
+	}
+	return c.backend.GetRange(ctx, startKey, endKey, limit)
+}
+
+// Delete deletes item by key
+func (c *chaosBackend) Delete(ctx context.Context, key []byte) error {
+	if err := c.inject("delete"); err != nil {
+		return err
+	}
+	return c.backend.Delete(ctx, key)
+}
+
+// DeleteRange deletes range of items
+func (c *chaosBackend) DeleteRange(ctx context.Context, startKey []byte, endKey []byte) error {
+	if err := c.inject("delete_range"); err != nil {
+		return err
+	}
+	return c.backend.DeleteRange(ctx, startKey, endKey)
+}
+
+// KeepAlive keeps object from expiring, updates lease on the existing object
+func (c *chaosBackend) KeepAlive(ctx context.Context, lease backend.Lease, expires time.Time) error {
+	if err := c.inject("keep_alive"); err != nil {
+		return err
+	}
+	return c.backend.KeepAlive(ctx, lease, expires)
+}
+
+// NewWatcher returns a new event watcher
+func (c *chaosBackend) NewWatcher(ctx context.Context, watch backend.Watch) (backend.Watcher, error) {
+	if err := c.inject("new_watcher"); err != nil {
+		return nil, err
+	}
+	return c.backend.NewWatcher(ctx, watch)
+}
+
+// Close releases the resources taken up by this backend
+func (c *chaosBackend) Close() error {
+	return c.backend.Close()
+}
+
+// Clock returns clock used by this backend
+func (c *chaosBackend) Clock() clockwork.Clock {
+	return c.backend.Clock()
+}
+
+// CloseWatchers closes all the watchers without closing the backend
+func (c *chaosBackend) CloseWatchers() {
+	c.backend.CloseWatchers()
+}
+
+// Migrate runs the necessary data migrations for this backend
+func (c *chaosBackend) Migrate(ctx context.Context) error {
+	return c.backend.Migrate(ctx)
+}
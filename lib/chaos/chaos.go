@@ -0,0 +1,139 @@
+// +build chaos
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// faults holds the fault injection settings parsed from the
+// environment, a zero value disables all injection
+type faults struct {
+	// latency is added to every hooked operation
+	latency time.Duration
+	// errorRate is the probability in [0, 1] that a hooked
+	// operation fails with a connection problem
+	errorRate float64
+	// partitions is a set of addresses that are unreachable,
+	// every dial to them fails
+	partitions map[string]bool
+}
+
+var (
+	parseOnce sync.Once
+	active    faults
+)
+
+// config returns the fault configuration, parsing
+// the environment on first use
+func config() *faults {
+	parseOnce.Do(func() {
+		spec := os.Getenv(EnvVar)
+		if spec == "" {
+			return
+		}
+		parsed, err := parseSpec(spec)
+		if err != nil {
+			log.Warningf("Failed to parse %v=%q: %v. Fault injection is off.", EnvVar, spec, err)
+			return
+		}
+		active = *parsed
+		log.Warningf("CHAOS: fault injection enabled: latency=%v, error-rate=%v, partitions=%v.",
+			active.latency, active.errorRate, len(active.partitions))
+	})
+	return &active
+}
+
+// parseSpec parses a fault specification of the form
+// "latency=100ms;error-rate=0.05;partition=host1:3025,host2:3022"
+func parseSpec(spec string) (*faults, error) {
+	var f faults
+	for _, part := range strings.Split(spec, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, trace.BadParameter("expected key=value, got %q", part)
+		}
+		switch kv[0] {
+		case "latency":
+			latency, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return nil, trace.BadParameter("failed to parse latency %q: %v", kv[1], err)
+			}
+			f.latency = latency
+		case "error-rate":
+			rate, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil || rate < 0 || rate > 1 {
+				return nil, trace.BadParameter("error-rate %q is not a number in [0, 1]", kv[1])
+			}
+			f.errorRate = rate
+		case "partition":
+			f.partitions = make(map[string]bool)
+			for _, addr := range strings.Split(kv[1], ",") {
+				f.partitions[addr] = true
+			}
+		default:
+			return nil, trace.BadParameter("unknown fault %q, supported faults are latency, error-rate and partition", kv[0])
+		}
+	}
+	return &f, nil
+}
+
+// Enabled returns true when this build supports fault injection
+// and the environment configures any faults
+func Enabled() bool {
+	f := config()
+	return f.latency != 0 || f.errorRate != 0 || len(f.partitions) != 0
+}
+
+// delay sleeps for the configured latency with up to 50% jitter
+func (f *faults) delay() {
+	if f.latency == 0 {
+		return
+	}
+	jitter := time.Duration(rand.Int63n(int64(f.latency) + 1))
+	time.Sleep(f.latency + jitter/2)
+}
+
+// fire returns true with the configured error probability
+func (f *faults) fire() bool {
+	return f.errorRate > 0 && rand.Float64() < f.errorRate
+}
+
+// DialHook injects faults into a network dial to addr, it blocks
+// for the configured latency and fails the dial when addr is
+// partitioned or the error rate fires
+func DialHook(network, addr string) error {
+	f := config()
+	f.delay()
+	if f.partitions[addr] {
+		return trace.ConnectionProblem(nil, "chaos: %v is partitioned", addr)
+	}
+	if f.fire() {
+		return trace.ConnectionProblem(nil, "chaos: dial %v %v failed", network, addr)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// +build !chaos
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"github.com/gravitational/teleport/lib/backend"
+)
+
+// Enabled returns false, fault injection is compiled out
+// of builds without the "chaos" build tag
+func Enabled() bool {
+	return false
+}
+
+// WrapBackend returns the backend unchanged, fault injection
+// is compiled out of this build
+func WrapBackend(bk backend.Backend) backend.Backend {
+	return bk
+}
+
+// DialHook does nothing, fault injection is compiled out of this build
+func DialHook(network, addr string) error {
+	return nil
+}
@@ -0,0 +1,53 @@
+// +build chaos
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseSpec verifies parsing of fault specifications
+func TestParseSpec(t *testing.T) {
+	f, err := parseSpec("latency=100ms;error-rate=0.05;partition=auth:3025,node:3022")
+	assert.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, f.latency)
+	assert.Equal(t, 0.05, f.errorRate)
+	assert.True(t, f.partitions["auth:3025"])
+	assert.True(t, f.partitions["node:3022"])
+
+	_, err = parseSpec("latency=oops")
+	assert.Error(t, err)
+	_, err = parseSpec("error-rate=7")
+	assert.Error(t, err)
+	_, err = parseSpec("flip-bits=1")
+	assert.Error(t, err)
+}
+
+// TestDialHookPartition verifies that dials to partitioned
+// addresses fail
+func TestDialHookPartition(t *testing.T) {
+	active = faults{partitions: map[string]bool{"auth:3025": true}}
+	defer func() { active = faults{} }()
+
+	assert.Error(t, DialHook("tcp", "auth:3025"))
+	assert.NoError(t, DialHook("tcp", "node:3022"))
+}
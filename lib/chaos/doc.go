@@ -0,0 +1,29 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package chaos implements fault injection hooks around the storage
+// backend and network dials so integration tests can verify behavior
+// under degraded infrastructure. The hooks compile to no-ops unless
+// the binary is built with the "chaos" build tag, and do nothing at
+// runtime unless faults are configured in the TELEPORT_CHAOS
+// environment variable, for example:
+//
+//   TELEPORT_CHAOS="latency=100ms;error-rate=0.05;partition=auth.example.com:3025"
+//
+package chaos
+
+// EnvVar is the environment variable holding the fault specification
+const EnvVar = "TELEPORT_CHAOS"
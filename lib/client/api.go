@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net"
 	"net/url"
 	"os"
@@ -36,6 +37,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 	"unicode/utf8"
@@ -127,6 +129,16 @@ func (p *DynamicForwardedPort) ToString() string {
 // remote host key or certificate validity
 type HostKeyCallback func(host string, ip net.Addr, key ssh.PublicKey) error
 
+const (
+	// NodePickPrompt makes tsh show an interactive node picker when several
+	// nodes match the label query of an interactive session.
+	NodePickPrompt = "prompt"
+	// NodePickFirst makes tsh connect to the first matching node.
+	NodePickFirst = "first"
+	// NodePickRandom makes tsh connect to a random matching node.
+	NodePickRandom = "random"
+)
+
 // Config is a client config
 type Config struct {
 	// Username is the Teleport account username (for logging into Teleport proxies)
@@ -225,9 +237,41 @@ type Config struct {
 	// if empty, they'll go to ~/.tsh
 	KeysDir string
 
+	// CAPins is a list of SPKI hashes of the cluster certificate
+	// authorities, usually imported from a shared profile bundle.
+	// Certificates received on login are checked against them.
+	CAPins []string
+
+	// HostAliases maps short host names to the real target host names.
+	HostAliases map[string]string
+
+	// TrustedCAKeysFile is an optional path to a file with SSH CA public keys
+	// (in authorized_keys format) to pre-seed into the local trust store.
+	// Used by deployments that pin cluster CAs ahead of time so users are
+	// never prompted to trust a CA on first connect.
+	TrustedCAKeysFile string
+
+	// EncryptKeys makes tsh store session private keys encrypted with a
+	// passphrase instead of in plaintext under ~/.tsh. Previously stored
+	// encrypted keys are decrypted on load regardless of this setting.
+	EncryptKeys bool
+
 	// Env is a map of environmnent variables to send when opening session
 	Env map[string]string
 
+	// SendEnv is a list of local environment variable names to forward into
+	// the session, similar to the SendEnv directive of OpenSSH. The node
+	// checks the variables against its accept_env policy.
+	SendEnv []string
+
+	// NodePickPolicy controls what happens when an interactive shell is
+	// requested and several nodes match the label query: NodePickPrompt
+	// shows an interactive picker, NodePickFirst connects to the first
+	// match and NodePickRandom to a random one. When empty, the picker is
+	// shown when attached to a terminal and the first match is used
+	// otherwise.
+	NodePickPolicy string
+
 	// Interactive, when set to true, tells tsh to launch a remote command
 	// in interactive mode, i.e. attaching the temrinal to it
 	Interactive bool
@@ -270,6 +314,16 @@ type Config struct {
 	// command/shell execution. This also requires Stdin to be an interactive
 	// terminal.
 	EnableEscapeSequences bool
+
+	// EscapeChar overrides the default '~' escape character for interactive
+	// sessions. Zero means the default.
+	EscapeChar byte
+
+	// AutoReconnect enables automatic re-attach to the server-side session
+	// when the connection to the server is lost mid-session. The server
+	// keeps the session alive for a grace period after the last client
+	// disconnects.
+	AutoReconnect bool
 }
 
 // CachePolicy defines cache policy for local clients
@@ -602,6 +656,8 @@ func (c *Config) LoadProfile(profileDir string, proxyName string) error {
 	c.KubeProxyAddr = cp.KubeProxyAddr
 	c.WebProxyAddr = cp.WebProxyAddr
 	c.SSHProxyAddr = cp.SSHProxyAddr
+	c.CAPins = cp.CAPins
+	c.HostAliases = cp.HostAliases
 
 	c.LocalForwardPorts, err = ParsePortForwardSpec(cp.ForwardedPorts)
 	if err != nil {
@@ -632,6 +688,8 @@ func (c *Config) SaveProfile(dir string, makeCurrent bool) error {
 	cp.KubeProxyAddr = c.KubeProxyAddr
 	cp.ForwardedPorts = c.LocalForwardPorts.String()
 	cp.SiteName = c.SiteName
+	cp.CAPins = c.CAPins
+	cp.HostAliases = c.HostAliases
 
 	if err := cp.SaveToDir(dir, makeCurrent); err != nil {
 		return trace.Wrap(err)
@@ -836,9 +894,19 @@ func NewClient(c *Config) (tc *TeleportClient, err error) {
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
+		if c.EncryptKeys {
+			tc.localAgent.EncryptKeysAtRest()
+		}
 		if tc.HostKeyCallback == nil {
 			tc.HostKeyCallback = tc.localAgent.CheckHostSignature
 		}
+		// pre-seed pinned CA keys so the first connection does not have to
+		// rely on trust on first use
+		if tc.TrustedCAKeysFile != "" {
+			if err := tc.localAgent.LoadTrustedCAKeys(tc.TrustedCAKeysFile); err != nil {
+				return nil, trace.Wrap(err)
+			}
+		}
 	}
 
 	return tc, nil
@@ -959,6 +1027,16 @@ func (tc *TeleportClient) SSH(ctx context.Context, command []string, runLocally
 	if len(nodeAddrs) == 0 {
 		return trace.BadParameter("no target host specified")
 	}
+	// If an interactive shell was requested and several nodes match the
+	// label query, pick a single node up front instead of silently using
+	// the first match.
+	if len(nodeAddrs) > 1 && len(command) == 0 && !runLocally && !tc.NoRemoteExec {
+		addr, err := tc.pickNodeAddr(ctx, proxyClient, nodeAddrs)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		nodeAddrs = []string{addr}
+	}
 	nodeClient, err := proxyClient.ConnectToNode(
 		ctx,
 		NodeAddr{Addr: nodeAddrs[0], Namespace: tc.Namespace, Cluster: siteInfo.Name},
@@ -1006,10 +1084,120 @@ func (tc *TeleportClient) SSH(ctx context.Context, command []string, runLocally
 	}
 
 	// Issue "shell" request to run single node.
-	if len(nodeAddrs) > 1 {
+	sessionID, err := tc.runShell(nodeClient, nil)
+	// If the connection dropped mid-session, try to transparently re-attach
+	// to the same server-side session.
+	if tc.AutoReconnect && trace.IsConnectionProblem(err) {
+		return trace.Wrap(tc.reattachSession(ctx, sessionID))
+	}
+	return trace.Wrap(err)
+}
+
+// pickNodeAddr selects a single node out of several matching a label query,
+// based on the configured selection policy. When no policy is set, an
+// interactive picker is shown if tsh is attached to a terminal, otherwise
+// the first match is used so that scripts keep working.
+func (tc *TeleportClient) pickNodeAddr(ctx context.Context, proxy *ProxyClient, nodeAddrs []string) (string, error) {
+	policy := tc.NodePickPolicy
+	if policy == "" {
+		if tc.Stdin == os.Stdin && term.IsTerminal(0) {
+			policy = NodePickPrompt
+		} else {
+			policy = NodePickFirst
+		}
+	}
+	switch policy {
+	case NodePickFirst:
 		fmt.Printf("\x1b[1mWARNING\x1b[0m: Multiple nodes match the label selector, picking first: %v\n", nodeAddrs[0])
+		return nodeAddrs[0], nil
+	case NodePickRandom:
+		return nodeAddrs[rand.Intn(len(nodeAddrs))], nil
+	case NodePickPrompt:
+	default:
+		return "", trace.BadParameter("unsupported node selection policy %q, try one of: %v, %v, %v",
+			policy, NodePickPrompt, NodePickFirst, NodePickRandom)
+	}
+
+	// re-fetch the matching nodes: the picker needs their names and labels,
+	// not just the addresses
+	nodes, err := proxy.FindServersByLabels(ctx, tc.Namespace, tc.Labels)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	node, err := tc.promptForNode(nodes)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	addr := node.GetAddr()
+	if addr == "" {
+		// address is empty, try dialing by UUID instead.
+		addr = fmt.Sprintf("%s:0", node.GetName())
 	}
-	return tc.runShell(nodeClient, nil)
+	return addr, nil
+}
+
+// promptForNode shows the nodes matching a label query and asks the user to
+// pick one. Typing a number selects a node, any other input narrows the list
+// down to nodes whose name or labels fuzzy-match it, and an empty line
+// resets the filter.
+func (tc *TeleportClient) promptForNode(nodes []services.Server) (services.Server, error) {
+	if len(nodes) == 0 {
+		return nil, trace.NotFound("no nodes match the label query")
+	}
+	reader := bufio.NewReader(tc.Stdin)
+	filtered := nodes
+	for {
+		fmt.Fprintf(tc.Stderr, "\n%v nodes match:\n", len(filtered))
+		for i, node := range filtered {
+			fmt.Fprintf(tc.Stderr, "  %v: %v (%v) %v\n", i+1, node.GetHostname(), node.GetAddr(), node.LabelsString())
+		}
+		fmt.Fprintf(tc.Stderr, "Select a node by number or type a part of its name or labels to filter: ")
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		input := strings.TrimSpace(line)
+		if input == "" {
+			filtered = nodes
+			continue
+		}
+		if index, err := strconv.Atoi(input); err == nil {
+			if index < 1 || index > len(filtered) {
+				fmt.Fprintf(tc.Stderr, "Invalid selection: %v\n", index)
+				continue
+			}
+			return filtered[index-1], nil
+		}
+		var matched []services.Server
+		for _, node := range filtered {
+			if fuzzyMatch(input, node.GetHostname()+" "+node.LabelsString()) {
+				matched = append(matched, node)
+			}
+		}
+		switch len(matched) {
+		case 0:
+			fmt.Fprintf(tc.Stderr, "Nothing matches %q.\n", input)
+		case 1:
+			return matched[0], nil
+		default:
+			filtered = matched
+		}
+	}
+}
+
+// fuzzyMatch reports whether all characters of needle occur in haystack in
+// the same order, ignoring case. It is intentionally simple: good enough for
+// narrowing down a node list without pulling in a matching library.
+func fuzzyMatch(needle, haystack string) bool {
+	needle, haystack = strings.ToLower(needle), strings.ToLower(haystack)
+	for _, r := range needle {
+		i := strings.IndexRune(haystack, r)
+		if i < 0 {
+			return false
+		}
+		haystack = haystack[i+utf8.RuneLen(r):]
+	}
+	return true
 }
 
 func (tc *TeleportClient) startPortForwarding(ctx context.Context, nodeClient *NodeClient) {
@@ -1119,7 +1307,8 @@ func (tc *TeleportClient) Join(ctx context.Context, namespace string, sessionID
 	tc.startPortForwarding(ctx, nc)
 
 	// running shell with a given session means "join" it:
-	return tc.runShell(nc, session)
+	_, err = tc.runShell(nc, session)
+	return trace.Wrap(err)
 }
 
 // Play replays the recorded session
@@ -1426,6 +1615,57 @@ func (tc *TeleportClient) ListNodes(ctx context.Context) ([]services.Server, err
 	return proxyClient.FindServersByLabels(ctx, tc.Namespace, tc.Labels)
 }
 
+// ListNodesInAllClusters returns the nodes in the root cluster and all leaf
+// clusters connected to the proxy, keyed by cluster name. The clusters are
+// queried concurrently through the proxy; leaf clusters that cannot be
+// reached are skipped with a warning so that an offline leaf does not fail
+// the whole listing.
+func (tc *TeleportClient) ListNodesInAllClusters(ctx context.Context) (map[string][]services.Server, error) {
+	var err error
+	// userhost is specified? that must be labels
+	if tc.Host != "" {
+		tc.Labels, err = ParseLabelSpec(tc.Host)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
+	// connect to the proxy and fan the request out to every cluster it serves
+	proxyClient, err := tc.ConnectToProxy(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer proxyClient.Close()
+
+	sites, err := proxyClient.GetSites()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = make(map[string][]services.Server)
+	)
+	for _, site := range sites {
+		wg.Add(1)
+		go func(cluster string) {
+			defer wg.Done()
+			nodes, err := proxyClient.FindServersByLabelsInCluster(ctx, cluster, tc.Namespace, tc.Labels)
+			if err != nil {
+				log.Warnf("Unable to list nodes in cluster %q: %v.", cluster, err)
+				return
+			}
+			mu.Lock()
+			result[cluster] = nodes
+			mu.Unlock()
+		}(site.Name)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
 // ListAllNodes is the same as ListNodes except that it ignores labels.
 func (tc *TeleportClient) ListAllNodes(ctx context.Context) ([]services.Server, error) {
 	proxyClient, err := tc.ConnectToProxy(ctx)
@@ -1482,6 +1722,13 @@ func (tc *TeleportClient) runCommand(ctx context.Context, nodeClient *NodeClient
 	}
 	defer nodeSession.Close()
 	if err := nodeSession.runCommand(ctx, command, tc.OnShellCreated, tc.Config.Interactive); err != nil {
+		// If this side stopped consuming the output mid-command (e.g. the
+		// pipe `tsh ssh host cmd | head -1` was closed by head), exit the
+		// way a shell reports a death by SIGPIPE and swallow the error.
+		if nodeSession.BrokenPipe() {
+			tc.ExitStatus = 128 + int(syscall.SIGPIPE)
+			return nil
+		}
 		originErr := trace.Unwrap(err)
 		exitErr, ok := originErr.(*ssh.ExitError)
 		if ok {
@@ -1501,22 +1748,51 @@ func (tc *TeleportClient) runCommand(ctx context.Context, nodeClient *NodeClient
 	return nil
 }
 
-// runShell starts an interactive SSH session/shell.
+// runShell starts an interactive SSH session/shell and returns the ID of the
+// session that was run.
 // sessionID : when empty, creates a new shell. otherwise it tries to join the existing session.
-func (tc *TeleportClient) runShell(nodeClient *NodeClient, sessToJoin *session.Session) error {
+func (tc *TeleportClient) runShell(nodeClient *NodeClient, sessToJoin *session.Session) (session.ID, error) {
 	nodeSession, err := newSession(nodeClient, sessToJoin, tc.Env, tc.Stdin, tc.Stdout, tc.Stderr, tc.useLegacyID(nodeClient), tc.EnableEscapeSequences)
 	if err != nil {
-		return trace.Wrap(err)
+		return "", trace.Wrap(err)
 	}
 	if err = nodeSession.runShell(tc.OnShellCreated); err != nil {
-		return trace.Wrap(err)
+		return nodeSession.id, trace.Wrap(err)
+	}
+	if nodeSession.Disconnected() {
+		return nodeSession.id, trace.ConnectionProblem(nil, "the connection to the server was lost")
 	}
 	if nodeSession.ExitMsg == "" {
 		fmt.Fprintln(tc.Stderr, "the connection was closed on the remote side on ", time.Now().Format(time.RFC822))
 	} else {
 		fmt.Fprintln(tc.Stderr, nodeSession.ExitMsg)
 	}
-	return nil
+	return nodeSession.id, nil
+}
+
+// reattachSession keeps trying to re-join an interrupted interactive session
+// while the server-side session is still alive. The server keeps sessions
+// around for a while after the last client disconnects, so clients on flaky
+// networks can transparently re-attach instead of losing their shell.
+func (tc *TeleportClient) reattachSession(ctx context.Context, sessionID session.ID) error {
+	fmt.Fprintf(tc.Stderr, "Trying to re-attach to session %v...\n", sessionID)
+	start := time.Now()
+	for {
+		err := tc.Join(ctx, tc.Namespace, sessionID, tc.Stdin)
+		if err == nil {
+			return nil
+		}
+		log.Debugf("Failed to re-attach to session %v: %v.", sessionID, err)
+		if time.Since(start) > defaults.ReattachGracePeriod {
+			return trace.ConnectionProblem(nil, "failed to re-attach to session %v within %v",
+				sessionID, defaults.ReattachGracePeriod)
+		}
+		select {
+		case <-ctx.Done():
+			return trace.Wrap(ctx.Err())
+		case <-time.After(defaults.ReattachRetryInterval):
+		}
+	}
 }
 
 // getProxyLogin determines which SSH principal to use when connecting to proxy.
@@ -1739,6 +2015,14 @@ func (tc *TeleportClient) Login(ctx context.Context, activateKey bool) (*Key, er
 	// Add the cluster name into the key from the host certificate.
 	key.ClusterName = response.HostSigners[0].ClusterName
 
+	// If the profile pins cluster certificate authorities, refuse to trust
+	// authorities that do not match any pin.
+	if len(tc.CAPins) != 0 {
+		if err := CheckCAPins(response.HostSigners, tc.CAPins); err != nil {
+			return nil, trace.Wrap(err)
+		}
+	}
+
 	if activateKey && tc.localAgent != nil {
 		// save the list of CAs client trusts to ~/.tsh/known_hosts
 		err = tc.localAgent.AddHostSignersToCache(response.HostSigners)
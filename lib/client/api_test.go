@@ -125,6 +125,18 @@ func (s *APITestSuite) TestParseLabels(c *check.C) {
 	c.Assert(err, check.NotNil)
 }
 
+func (s *APITestSuite) TestFuzzyMatch(c *check.C) {
+	// characters have to occur in order, case does not matter
+	c.Assert(fuzzyMatch("db1", "db1.example.com"), check.Equals, true)
+	c.Assert(fuzzyMatch("dbex", "DB1.Example.com"), check.Equals, true)
+	c.Assert(fuzzyMatch("role=master", "db1 role=master,env=prod"), check.Equals, true)
+	// out of order or missing characters do not match
+	c.Assert(fuzzyMatch("xd", "db1.example.com"), check.Equals, false)
+	c.Assert(fuzzyMatch("db2", "db1.example.com"), check.Equals, false)
+	// empty needle matches everything
+	c.Assert(fuzzyMatch("", "db1.example.com"), check.Equals, true)
+}
+
 func (s *APITestSuite) TestPortsParsing(c *check.C) {
 	// empty:
 	ports, err := ParsePortForwardSpec(nil)
@@ -0,0 +1,171 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/tlsca"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileBundle is the shareable part of a client profile: connection
+// coordinates, trust anchors and default options, but no user name and
+// no key material. Teams export a bundle once and new members import it
+// instead of typing proxy addresses and trusting CAs on first connect.
+type ProfileBundle struct {
+	// WebProxyAddr is the host:port the web proxy can be accessed at.
+	WebProxyAddr string `yaml:"web_proxy_addr,omitempty"`
+
+	// SSHProxyAddr is the host:port the SSH proxy can be accessed at.
+	SSHProxyAddr string `yaml:"ssh_proxy_addr,omitempty"`
+
+	// KubeProxyAddr is the host:port the Kubernetes proxy can be accessed at.
+	KubeProxyAddr string `yaml:"kube_proxy_addr,omitempty"`
+
+	// Cluster is the default cluster to connect to.
+	Cluster string `yaml:"cluster,omitempty"`
+
+	// ForwardedPorts is the list of ports to forward to the target node.
+	ForwardedPorts []string `yaml:"forward_ports,omitempty"`
+
+	// DynamicForwardedPorts is a list of ports to use for dynamic port
+	// forwarding (SOCKS5).
+	DynamicForwardedPorts []string `yaml:"dynamic_forward_ports,omitempty"`
+
+	// CAPins is a list of SPKI hashes of the cluster certificate
+	// authorities, certificates received on login are checked against them.
+	CAPins []string `yaml:"ca_pins,omitempty"`
+
+	// HostAliases maps short host names to the real target host names.
+	HostAliases map[string]string `yaml:"host_aliases,omitempty"`
+}
+
+// NewProfileBundle builds a shareable bundle out of a client profile and
+// the PEM-encoded TLS certificates of the certificate authorities the
+// profile trusts. The certificates themselves are not included, only
+// their SPKI pins.
+func NewProfileBundle(cp *Profile, certsPEM [][]byte) (*ProfileBundle, error) {
+	bundle := &ProfileBundle{
+		WebProxyAddr:          cp.WebProxyAddr,
+		SSHProxyAddr:          cp.SSHProxyAddr,
+		KubeProxyAddr:         cp.KubeProxyAddr,
+		Cluster:               cp.SiteName,
+		ForwardedPorts:        cp.ForwardedPorts,
+		DynamicForwardedPorts: cp.DynamicForwardedPorts,
+		HostAliases:           cp.HostAliases,
+		CAPins:                cp.CAPins,
+	}
+	seen := make(map[string]bool)
+	for _, pin := range bundle.CAPins {
+		seen[pin] = true
+	}
+	for _, certPEM := range certsPEM {
+		rest := certPEM
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			cert, err := tlsca.ParseCertificatePEM(pem.EncodeToMemory(block))
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if pin := utils.CalculateSPKI(cert); !seen[pin] {
+				seen[pin] = true
+				bundle.CAPins = append(bundle.CAPins, pin)
+			}
+		}
+	}
+	return bundle, nil
+}
+
+// Encode serializes the bundle to YAML.
+func (b *ProfileBundle) Encode() ([]byte, error) {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return data, nil
+}
+
+// ProfileBundleFromFile loads a profile bundle from a YAML file.
+func ProfileBundleFromFile(filePath string) (*ProfileBundle, error) {
+	bytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var bundle ProfileBundle
+	if err := yaml.Unmarshal(bytes, &bundle); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if bundle.WebProxyAddr == "" {
+		return nil, trace.BadParameter("invalid profile bundle: missing web_proxy_addr")
+	}
+	return &bundle, nil
+}
+
+// Profile converts the bundle into a client profile. The user name is
+// deliberately left empty: every team member logs in as themselves.
+func (b *ProfileBundle) Profile() *Profile {
+	return &Profile{
+		WebProxyAddr:          b.WebProxyAddr,
+		SSHProxyAddr:          b.SSHProxyAddr,
+		KubeProxyAddr:         b.KubeProxyAddr,
+		SiteName:              b.Cluster,
+		ForwardedPorts:        b.ForwardedPorts,
+		DynamicForwardedPorts: b.DynamicForwardedPorts,
+		CAPins:                b.CAPins,
+		HostAliases:           b.HostAliases,
+	}
+}
+
+// CheckCAPins verifies that every TLS certificate authority in cas matches
+// one of the SPKI pins. Note that pinning is strict: certificate
+// authorities added to the cluster after the pins were exported (e.g. new
+// trusted clusters) require a re-export.
+func CheckCAPins(cas []auth.TrustedCerts, pins []string) error {
+	for _, ca := range cas {
+		for _, certPEM := range ca.TLSCertificates {
+			cert, err := tlsca.ParseCertificatePEM(certPEM)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if !matchesAnyPin(cert, pins) {
+				return trace.AccessDenied("certificate authority of cluster %q does not match any of the pinned CAs, refusing to proceed", ca.ClusterName)
+			}
+		}
+	}
+	return nil
+}
+
+func matchesAnyPin(cert *x509.Certificate, pins []string) bool {
+	for _, pin := range pins {
+		if utils.CheckSPKI(pin, cert) == nil {
+			return true
+		}
+	}
+	return false
+}
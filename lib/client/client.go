@@ -219,11 +219,24 @@ func (proxy *ProxyClient) NewWatcher(ctx context.Context, watch services.Watch)
 // A server is matched when ALL labels match.
 // If no labels are passed, ALL nodes are returned.
 func (proxy *ProxyClient) FindServersByLabels(ctx context.Context, namespace string, labels map[string]string) ([]services.Server, error) {
+	cluster, err := proxy.currentCluster()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return proxy.FindServersByLabelsInCluster(ctx, cluster.Name, namespace, labels)
+}
+
+// FindServersByLabelsInCluster returns list of the nodes in a specified cluster
+// which have labels exactly matching the given label set.
+//
+// A server is matched when ALL labels match.
+// If no labels are passed, ALL nodes are returned.
+func (proxy *ProxyClient) FindServersByLabelsInCluster(ctx context.Context, cluster, namespace string, labels map[string]string) ([]services.Server, error) {
 	if namespace == "" {
 		return nil, trace.BadParameter(auth.MissingNamespaceError)
 	}
 	nodes := make([]services.Server, 0)
-	site, err := proxy.CurrentClusterAccessPoint(ctx, false)
+	site, err := proxy.ClusterAccessPoint(ctx, cluster, false)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -19,14 +19,24 @@ limitations under the License.
 package escape
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"sort"
 	"sync"
 )
 
 const (
 	readerBufferLimit = 10 * 1024 * 1024 // 10MB
 
+	// defaultEscapeChar is the escape character used unless overridden with
+	// SetEscapeChar, the same as the OpenSSH default.
+	defaultEscapeChar = '~'
+
+	// helpText is the help printed with the default escape character and no
+	// extra sequences registered.
+	//
 	// Note: on a raw terminal, "\r\n" is needed to move a cursor to the start
 	// of next line.
 	helpText = "\r\ntsh escape characters:\r\n  ~? - display a list of escape characters\r\n  ~. - disconnect\r\n"
@@ -52,11 +62,20 @@ type Reader struct {
 	onDisconnect func(error)
 	bufferLimit  int
 
-	// cond protects buf and err and also announces to blocked readers that
-	// more data is available.
-	cond sync.Cond
-	buf  []byte
-	err  error
+	// cond protects buf, err, escapeChar and handlers and also announces to
+	// blocked readers that more data is available.
+	cond       sync.Cond
+	buf        []byte
+	err        error
+	escapeChar byte
+	handlers   map[byte]sequenceHandler
+}
+
+// sequenceHandler is an extra escape sequence registered with
+// SetSequenceHandler.
+type sequenceHandler struct {
+	help string
+	fn   func()
 }
 
 // NewReader creates a new Reader to catch escape sequences from 'in'.
@@ -95,11 +114,41 @@ func newUnstartedReader(in io.Reader, out io.Writer, onDisconnect func(error)) *
 		onDisconnect: onDisconnect,
 		bufferLimit:  readerBufferLimit,
 		cond:         sync.Cond{L: &sync.Mutex{}},
+		escapeChar:   defaultEscapeChar,
 		// note: no need to pre-allocate buf, it will allocate and grow as
 		// needed in runReads via append.
 	}
 }
 
+// SetEscapeChar overrides the default '~' escape character. It should be
+// called before any input is read.
+func (r *Reader) SetEscapeChar(c byte) {
+	r.cond.L.Lock()
+	r.escapeChar = c
+	r.cond.L.Unlock()
+}
+
+// SetSequenceHandler registers fn to run when the escape character is
+// followed by c, with help printed by the help sequence. The built-in help
+// ('?') and disconnect ('.') sequences cannot be overridden. It should be
+// called before any input is read.
+func (r *Reader) SetSequenceHandler(c byte, help string, fn func()) {
+	r.cond.L.Lock()
+	if r.handlers == nil {
+		r.handlers = make(map[byte]sequenceHandler)
+	}
+	r.handlers[c] = sequenceHandler{help: help, fn: fn}
+	r.cond.L.Unlock()
+}
+
+// sequence looks up an extra handler for the escape sequence ending in c.
+func (r *Reader) sequence(c byte) (sequenceHandler, bool) {
+	r.cond.L.Lock()
+	defer r.cond.L.Unlock()
+	handler, ok := r.handlers[c]
+	return handler, ok
+}
+
 func (r *Reader) runReads() {
 	readBuf := make([]byte, 1024)
 	// writeBuf is a copy of data in readBuf after filtering out any escape
@@ -118,29 +167,34 @@ func (r *Reader) runReads() {
 			return
 		}
 
+		r.cond.L.Lock()
+		escapeChar := r.escapeChar
+		r.cond.L.Unlock()
+
 		// Reset the output buffer from previous state.
 		writeBuf = writeBuf[:0]
 	inner:
 		for _, b := range readBuf[:n] {
 			// Note: this switch only filters and updates newLine and escape.
 			// b is written to writeBuf afterwards.
-			switch b {
-			case '\r', '\n':
+			switch {
+			case b == '\r' || b == '\n':
 				if escape {
-					// An incomplete escape sequence, send out a '~' that was
-					// previously suppressed.
-					writeBuf = append(writeBuf, '~')
+					// An incomplete escape sequence, send out the escape
+					// character that was previously suppressed.
+					writeBuf = append(writeBuf, escapeChar)
 				}
 				newLine, escape = true, false
-			case '~':
+			case b == escapeChar:
 				if newLine {
-					// Start escape sequence, don't write the '~' just yet.
+					// Start escape sequence, don't write the escape character
+					// just yet.
 					newLine, escape = false, true
 					continue inner
 				} else if escape {
 					newLine, escape = false, false
 				}
-			case '?':
+			case b == '?':
 				if escape {
 					// Complete help sequence.
 					r.printHelp()
@@ -148,7 +202,7 @@ func (r *Reader) runReads() {
 					continue inner
 				}
 				newLine = false
-			case '.':
+			case b == '.':
 				if escape {
 					// Complete disconnect sequence.
 					r.setErr(ErrDisconnect)
@@ -157,9 +211,15 @@ func (r *Reader) runReads() {
 				newLine = false
 			default:
 				if escape {
-					// An incomplete escape sequence, send out a '~' that was
-					// previously suppressed.
-					writeBuf = append(writeBuf, '~')
+					if handler, ok := r.sequence(b); ok {
+						// Complete a registered extra sequence.
+						handler.fn()
+						newLine, escape = false, false
+						continue inner
+					}
+					// An incomplete escape sequence, send out the escape
+					// character that was previously suppressed.
+					writeBuf = append(writeBuf, escapeChar)
 				}
 				newLine, escape = false, false
 			}
@@ -216,5 +276,31 @@ func (r *Reader) setErr(err error) {
 }
 
 func (r *Reader) printHelp() {
-	r.out.Write([]byte(helpText))
+	r.cond.L.Lock()
+	escapeChar := r.escapeChar
+	extra := make([]byte, 0, len(r.handlers))
+	for c := range r.handlers {
+		extra = append(extra, c)
+	}
+	handlers := r.handlers
+	r.cond.L.Unlock()
+	sort.Slice(extra, func(i, j int) bool { return extra[i] < extra[j] })
+
+	var help bytes.Buffer
+	fmt.Fprintf(&help, "\r\ntsh escape characters:\r\n")
+	fmt.Fprintf(&help, "  %s? - display a list of escape characters\r\n", printable(escapeChar))
+	fmt.Fprintf(&help, "  %s. - disconnect\r\n", printable(escapeChar))
+	for _, c := range extra {
+		fmt.Fprintf(&help, "  %s%s - %s\r\n", printable(escapeChar), printable(c), handlers[c].help)
+	}
+	r.out.Write(help.Bytes())
+}
+
+// printable renders a character for the help text, control characters are
+// shown in caret notation (e.g. Ctrl-Z as "^Z").
+func printable(c byte) string {
+	if c < 0x20 {
+		return fmt.Sprintf("^%c", c+'@')
+	}
+	return string(c)
 }
@@ -21,6 +21,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"os"
 	"strings"
@@ -123,6 +124,15 @@ func (a *LocalKeyAgent) UpdateProxyHost(proxyHost string) {
 	a.proxyHost = proxyHost
 }
 
+// EncryptKeysAtRest makes the key store write private keys encrypted with a
+// passphrase instead of in plaintext. The decrypted key is only held in
+// memory by the agent, which serves signatures to tsh invocations.
+func (a *LocalKeyAgent) EncryptKeysAtRest() {
+	if fs, ok := a.keyStore.(*FSLocalKeyStore); ok {
+		fs.EncryptKeys = true
+	}
+}
+
 // LoadKey adds a key into the Teleport ssh agent as well as the system ssh
 // agent.
 func (a *LocalKeyAgent) LoadKey(key Key) (*agent.AddedKey, error) {
@@ -239,8 +249,16 @@ func (a *LocalKeyAgent) AddHostSignersToCache(certAuthorities []auth.TrustedCert
 			a.log.Error(err)
 			return trace.Wrap(err)
 		}
+		a.checkHostSignerChange(ca.ClusterName, publicKeys)
 		a.log.Debugf("Adding CA key for %s", ca.ClusterName)
-		err = a.keyStore.AddKnownHostKeys(ca.ClusterName, publicKeys)
+		// key the entry by the proxy the CA was received from as well as by the
+		// cluster name, so the trust store records which proxy vouched for
+		// which cluster CA
+		hosts := ca.ClusterName
+		if a.proxyHost != "" {
+			hosts = fmt.Sprintf("%s,%s", ca.ClusterName, a.proxyHost)
+		}
+		err = a.keyStore.AddKnownHostKeys(hosts, publicKeys)
 		if err != nil {
 			return trace.Wrap(err)
 		}
@@ -248,6 +266,82 @@ func (a *LocalKeyAgent) AddHostSignersToCache(certAuthorities []auth.TrustedCert
 	return nil
 }
 
+// checkHostSignerChange compares the CA keys received for a cluster against
+// the ones already present in the trust store. New keys showing up alongside
+// the old ones is how CA rotation looks from the client side and is accepted
+// with a log message. An outright replacement never happens during a rotation
+// (the new CA is always served next to the old one first), so it gets a loud
+// warning on stderr before the new keys are trusted.
+func (a *LocalKeyAgent) checkHostSignerChange(clusterName string, publicKeys []ssh.PublicKey) {
+	existing, err := a.keyStore.GetKnownHostKeys(clusterName)
+	if err != nil {
+		a.log.Warnf("Unable to read trust store for %v: %v.", clusterName, err)
+		return
+	}
+	if len(existing) == 0 {
+		return
+	}
+	var added []ssh.PublicKey
+	var overlap bool
+	for _, key := range publicKeys {
+		seen := false
+		for i := range existing {
+			if sshutils.KeysEqual(key, existing[i]) {
+				seen = true
+				overlap = true
+				break
+			}
+		}
+		if !seen {
+			added = append(added, key)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	if overlap {
+		for _, key := range added {
+			a.log.Infof("CA for %v is being rotated, trusting new CA key %v.", clusterName, sshutils.Fingerprint(key))
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: The certificate authority for cluster %q has changed unexpectedly.\n", clusterName)
+	fmt.Fprintf(os.Stderr, "During a CA rotation the new CA is served alongside the old one, but none of the keys received match the trust store.\n")
+	for _, key := range added {
+		fmt.Fprintf(os.Stderr, "New CA key: %v\n", sshutils.Fingerprint(key))
+	}
+	fmt.Fprintf(os.Stderr, "Unless this cluster was rebuilt on purpose, contact your cluster administrator before proceeding.\n")
+	a.log.Warnf("CA for %v was replaced outside of a rotation.", clusterName)
+}
+
+// LoadTrustedCAKeys pre-seeds the trust store with CA public keys read from a
+// file in authorized_keys format. It lets deployments pin cluster CAs ahead
+// of time so first connections do not have to rely on trust on first use. The
+// pins are stored keyed by the proxy they were supplied for.
+func (a *LocalKeyAgent) LoadTrustedCAKeys(path string) error {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	var keys []ssh.PublicKey
+	for len(bytes) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(bytes)
+		if err != nil {
+			if strings.TrimSpace(string(bytes)) == "" {
+				break
+			}
+			return trace.BadParameter("failed to parse CA key in %v: %v", path, err)
+		}
+		keys = append(keys, key)
+		bytes = rest
+	}
+	if len(keys) == 0 {
+		return trace.BadParameter("no CA keys found in %v", path)
+	}
+	a.log.Debugf("Pre-seeding %v CA keys from %v.", len(keys), path)
+	return a.keyStore.AddKnownHostKeys(a.proxyHost, keys)
+}
+
 func (a *LocalKeyAgent) SaveCerts(certAuthorities []auth.TrustedCerts) error {
 	return a.keyStore.SaveCerts(a.proxyHost, certAuthorities)
 }
@@ -30,6 +30,7 @@ import (
 	"golang.org/x/crypto/ssh/agent"
 
 	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/auth/testauthority"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/fixtures"
@@ -296,6 +297,63 @@ func (s *KeyAgentTestSuite) TestHostCertVerification(c *check.C) {
 	}
 }
 
+// TestHostSignerRotation checks that CA keys received from the proxy end up
+// in the trust store keyed by both the cluster name and the proxy host, that
+// new CA keys served alongside the old ones (a rotation) are trusted, and
+// that CA pins can be pre-seeded from a file for zero-TOFU deployments.
+func (s *KeyAgentTestSuite) TestHostSignerRotation(c *check.C) {
+	lka, err := NewLocalAgent(s.keyDir, s.hostname, s.username, true)
+	c.Assert(err, check.IsNil)
+
+	keygen := testauthority.New()
+	newCAKey := func() (ssh.PublicKey, []byte) {
+		_, pub, err := keygen.GenerateKeyPair("")
+		c.Assert(err, check.IsNil)
+		key, _, _, _, err := ssh.ParseAuthorizedKey(pub)
+		c.Assert(err, check.IsNil)
+		return key, pub
+	}
+	oldKey, oldKeyBytes := newCAKey()
+	newKey, newKeyBytes := newCAKey()
+
+	// the first login stores the CA keyed by the cluster and by the proxy
+	err = lka.AddHostSignersToCache([]auth.TrustedCerts{{
+		ClusterName:      "zoo.example.com",
+		HostCertificates: [][]byte{oldKeyBytes},
+	}})
+	c.Assert(err, check.IsNil)
+	keys, err := lka.keyStore.GetKnownHostKeys("zoo.example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(keys, check.HasLen, 1)
+	c.Assert(sshutils.KeysEqual(keys[0], oldKey), check.Equals, true)
+	keys, err = lka.keyStore.GetKnownHostKeys(s.hostname)
+	c.Assert(err, check.IsNil)
+	c.Assert(keys, check.HasLen, 1)
+
+	// during a rotation the new CA is served alongside the old one and both
+	// end up trusted
+	err = lka.AddHostSignersToCache([]auth.TrustedCerts{{
+		ClusterName:      "zoo.example.com",
+		HostCertificates: [][]byte{oldKeyBytes, newKeyBytes},
+	}})
+	c.Assert(err, check.IsNil)
+	keys, err = lka.keyStore.GetKnownHostKeys("zoo.example.com")
+	c.Assert(err, check.IsNil)
+	c.Assert(keys, check.HasLen, 2)
+	c.Assert(sshutils.KeysEqual(keys[1], newKey), check.Equals, true)
+
+	// pins pre-seeded from a file are stored keyed by the proxy
+	pinnedKey, pinnedKeyBytes := newCAKey()
+	pinsFile := filepath.Join(s.keyDir, "ca-pins")
+	err = ioutil.WriteFile(pinsFile, pinnedKeyBytes, 0640)
+	c.Assert(err, check.IsNil)
+	err = lka.LoadTrustedCAKeys(pinsFile)
+	c.Assert(err, check.IsNil)
+	keys, err = lka.keyStore.GetKnownHostKeys(s.hostname)
+	c.Assert(err, check.IsNil)
+	c.Assert(sshutils.KeysEqual(keys[len(keys)-1], pinnedKey), check.Equals, true)
+}
+
 func (s *KeyAgentTestSuite) TestHostKeyVerification(c *check.C) {
 	// make a new local agent
 	lka, err := NewLocalAgent(s.keyDir, s.hostname, s.username, true)
@@ -18,6 +18,7 @@ package client
 
 import (
 	"bufio"
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -27,8 +28,10 @@ import (
 	"os/user"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
@@ -118,6 +121,16 @@ type FSLocalKeyStore struct {
 
 	// KeyDir is the directory where all keys are stored.
 	KeyDir string
+
+	// EncryptKeys, when set, makes AddKey store private keys encrypted with
+	// a passphrase instead of in plaintext. Keys written by older versions
+	// or with the option disabled are still read back transparently.
+	EncryptKeys bool
+
+	// PassphrasePrompt is used to ask the user for the key passphrase. When
+	// nil, the passphrase is read from the terminal. The confirm parameter
+	// is set when a new passphrase is chosen and has to be repeated.
+	PassphrasePrompt func(confirm bool) ([]byte, error)
 }
 
 // NewFSLocalKeyStore creates a new filesystem-based local keystore object
@@ -162,12 +175,99 @@ func (fs *FSLocalKeyStore) AddKey(host, username string, key *Key) error {
 	if err = writeBytes(username+fileExtPub, key.Pub); err != nil {
 		return trace.Wrap(err)
 	}
-	if err = writeBytes(username, key.Priv); err != nil {
+	priv := key.Priv
+	if fs.EncryptKeys {
+		priv, err = fs.encryptPrivateKey(priv)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	if err = writeBytes(username, priv); err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
 
+// encryptPrivateKey encrypts a PEM encoded private key with a passphrase
+// using AES-256-CBC PEM encryption. It protects the key at rest on platforms
+// without OS keychain integration: the plaintext key is only held in memory
+// by the tsh agent, which serves signatures to later tsh invocations.
+func (fs *FSLocalKeyStore) encryptPrivateKey(priv []byte) ([]byte, error) {
+	block, _ := pem.Decode(priv)
+	if block == nil {
+		return nil, trace.BadParameter("failed to parse private key")
+	}
+	passphrase, err := fs.passphrase(true)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	encrypted, err := x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, passphrase, x509.PEMCipherAES256)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return pem.EncodeToMemory(encrypted), nil
+}
+
+// decryptPrivateKey decrypts a passphrase protected private key. Keys stored
+// in plaintext are returned as is.
+func (fs *FSLocalKeyStore) decryptPrivateKey(priv []byte) ([]byte, error) {
+	block, _ := pem.Decode(priv)
+	if block == nil || !x509.IsEncryptedPEMBlock(block) {
+		return priv, nil
+	}
+	passphrase, err := fs.passphrase(false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	der, err := x509.DecryptPEMBlock(block, passphrase)
+	if err != nil {
+		return nil, trace.AccessDenied("unable to decrypt the session key, wrong passphrase?")
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
+
+// passphrase obtains the key passphrase from the configured prompt, falling
+// back to reading it from the terminal.
+func (fs *FSLocalKeyStore) passphrase(confirm bool) ([]byte, error) {
+	if fs.PassphrasePrompt != nil {
+		return fs.PassphrasePrompt(confirm)
+	}
+	return passphraseFromConsole(confirm)
+}
+
+// passphraseFromConsole reads a key passphrase from the terminal without
+// echoing the typed characters. When confirm is set the passphrase has to be
+// entered twice.
+func passphraseFromConsole(confirm bool) ([]byte, error) {
+	// syscall.Stdin is not an int on windows. The linter will complain only on
+	// linux where syscall.Stdin is an int.
+	//
+	// nolint:unconvert
+	fd := int(syscall.Stdin)
+	fmt.Fprintf(os.Stderr, "Enter passphrase for the session key: ")
+	first, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !confirm {
+		return first, nil
+	}
+	if len(first) == 0 {
+		return nil, trace.BadParameter("empty passphrase")
+	}
+	fmt.Fprintf(os.Stderr, "Repeat the passphrase: ")
+	second, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if string(first) != string(second) {
+		return nil, trace.BadParameter("passphrases do not match")
+	}
+	return first, nil
+}
+
 // DeleteKey deletes a key from the local store
 func (fs *FSLocalKeyStore) DeleteKey(host string, username string) error {
 	dirPath, err := fs.dirFor(host, false)
@@ -234,6 +334,10 @@ func (fs *FSLocalKeyStore) GetKey(proxyHost string, username string) (*Key, erro
 		fs.log.Error(err)
 		return nil, trace.Wrap(err)
 	}
+	priv, err = fs.decryptPrivateKey(priv)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
 	tlsCA, err := fs.GetCertsPEM(proxyHost)
 	if err != nil {
 		fs.log.Error(err)
@@ -17,10 +17,14 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"crypto/rsa"
 	"crypto/x509/pkix"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gravitational/teleport/lib/auth"
@@ -145,6 +149,44 @@ func (s *KeyStoreTestSuite) TestKeyCRUD(c *check.C) {
 	c.Assert(trace.IsNotFound(err), check.Equals, true)
 }
 
+func (s *KeyStoreTestSuite) TestEncryptedKeyRoundTrip(c *check.C) {
+	s.store.EncryptKeys = true
+	s.store.PassphrasePrompt = func(confirm bool) ([]byte, error) {
+		return []byte("banana"), nil
+	}
+	defer func() {
+		s.store.EncryptKeys = false
+		s.store.PassphrasePrompt = nil
+	}()
+
+	key := s.makeSignedKey(c, false)
+	err := s.addKey("host.a", "bob", key)
+	c.Assert(err, check.IsNil)
+
+	// the private key on disk must not be plaintext:
+	dirPath, err := s.store.dirFor("host.a", false)
+	c.Assert(err, check.IsNil)
+	onDisk, err := ioutil.ReadFile(filepath.Join(dirPath, "bob"))
+	c.Assert(err, check.IsNil)
+	c.Assert(strings.Contains(string(onDisk), "ENCRYPTED"), check.Equals, true)
+	c.Assert(bytes.Equal(onDisk, key.Priv), check.Equals, false)
+
+	// loading it back must transparently decrypt:
+	keyCopy, err := s.store.GetKey("host.a", "bob")
+	c.Assert(err, check.IsNil)
+	c.Assert(bytes.TrimSpace(keyCopy.Priv), check.DeepEquals, bytes.TrimSpace(key.Priv))
+	c.Assert(keyCopy.Cert, check.DeepEquals, key.Cert)
+	c.Assert(keyCopy.Pub, check.DeepEquals, key.Pub)
+
+	// a wrong passphrase must be rejected:
+	s.store.PassphrasePrompt = func(confirm bool) ([]byte, error) {
+		return []byte("grapefruit"), nil
+	}
+	_, err = s.store.GetKey("host.a", "bob")
+	c.Assert(err, check.NotNil)
+	c.Assert(trace.IsAccessDenied(err), check.Equals, true)
+}
+
 func (s *KeyStoreTestSuite) TestDeleteAll(c *check.C) {
 	key := s.makeSignedKey(c, false)
 
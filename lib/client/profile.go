@@ -55,6 +55,13 @@ type Profile struct {
 	// DynamicForwardedPorts is a list of ports to use for dynamic port
 	// forwarding (SOCKS5).
 	DynamicForwardedPorts []string `yaml:"dynamic_forward_ports,omitempty"`
+
+	// CAPins is a list of SPKI hashes of the cluster certificate
+	// authorities, certificates received on login are checked against them.
+	CAPins []string `yaml:"ca_pins,omitempty"`
+
+	// HostAliases maps short host names to the real target host names.
+	HostAliases map[string]string `yaml:"host_aliases,omitempty"`
 }
 
 // Name returns the name of the profile.
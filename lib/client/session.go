@@ -26,6 +26,8 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -43,6 +45,10 @@ import (
 	"github.com/gravitational/trace"
 )
 
+// signalForwardingGracePeriod is how long to wait for a remote command to
+// exit after forwarding an interrupt to it before dropping the connection.
+const signalForwardingGracePeriod = 3 * time.Second
+
 type NodeSession struct {
 	// namespace is a session this namespace belongs to
 	namespace string
@@ -70,6 +76,29 @@ type NodeSession struct {
 	ExitMsg string
 
 	enableEscapeSequences bool
+
+	// disconnected is set when the session ended because the connection to
+	// the server was lost rather than because the remote shell exited. It is
+	// written before the closer is closed and only read after, so no
+	// additional synchronization is needed.
+	disconnected bool
+
+	// brokenPipe is set when the session was torn down because the local
+	// consumer of the output went away, e.g. `tsh ssh host cmd | head -1`
+	// once head has exited. Accessed atomically, it is written from the
+	// output forwarding goroutine.
+	brokenPipe int32
+
+	// termState holds the state the local terminal was in before it was
+	// switched to raw mode, so the suspend escape sequence can restore it
+	// while tsh is stopped. Protected by termStateMu.
+	termState   *term.State
+	termStateMu sync.Mutex
+
+	// agentForwarding is set while the forwarded agent is enabled. Accessed
+	// atomically so the agent forwarding escape sequence can toggle it
+	// mid-session.
+	agentForwarding int32
 }
 
 // newSession creates a new Teleport session with the given remote node
@@ -151,12 +180,48 @@ func (ns *NodeSession) regularSession(callback func(s *ssh.Session) error) error
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	session.Stdout = ns.stdout
-	session.Stderr = ns.stderr
+	// Wrap the output streams so that a consumer that goes away mid-command
+	// tears the session down instead of blocking the remote command on a
+	// full channel window forever.
+	session.Stdout = ns.breakOnWriteError(session, ns.stdout)
+	session.Stderr = ns.breakOnWriteError(session, ns.stderr)
 	session.Stdin = ns.stdin
 	return trace.Wrap(callback(session))
 }
 
+// breakOnWriteError returns a writer that forwards the remote output to w
+// and closes the session on the first write error, so the remote command
+// receives a closed channel (and with it EOF/SIGPIPE) instead of filling
+// up the channel window and hanging.
+func (ns *NodeSession) breakOnWriteError(session *ssh.Session, w io.Writer) io.Writer {
+	return &breakingWriter{ns: ns, session: session, w: w}
+}
+
+type breakingWriter struct {
+	ns      *NodeSession
+	session *ssh.Session
+	w       io.Writer
+	once    sync.Once
+}
+
+func (b *breakingWriter) Write(data []byte) (int, error) {
+	n, err := b.w.Write(data)
+	if err != nil {
+		b.once.Do(func() {
+			log.Debugf("Failed to write remote output locally: %v. Closing the session.", err)
+			atomic.StoreInt32(&b.ns.brokenPipe, 1)
+			b.session.Close()
+		})
+	}
+	return n, err
+}
+
+// BrokenPipe reports whether the session was torn down because the local
+// consumer of the output went away.
+func (ns *NodeSession) BrokenPipe() bool {
+	return atomic.LoadInt32(&ns.brokenPipe) != 0
+}
+
 type interactiveCallback func(serverSession *ssh.Session, shell io.ReadWriteCloser) error
 
 func (ns *NodeSession) createServerSession() (*ssh.Session, error) {
@@ -164,8 +229,13 @@ func (ns *NodeSession) createServerSession() (*ssh.Session, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	tc := ns.nodeClient.Proxy.teleportClient
+
 	// pass language info into the remote session.
 	evarsToPass := []string{"LANG", "LANGUAGE"}
+	// pass local environment variables requested by the user (similar to
+	// OpenSSH SendEnv). The node checks them against its accept_env policy.
+	evarsToPass = append(evarsToPass, tc.SendEnv...)
 	for _, evar := range evarsToPass {
 		if value := os.Getenv(evar); value != "" {
 			err = sess.Setenv(evar, value)
@@ -184,9 +254,12 @@ func (ns *NodeSession) createServerSession() (*ssh.Session, error) {
 
 	// if agent forwarding was requested (and we have a agent to forward),
 	// forward the agent to endpoint.
-	tc := ns.nodeClient.Proxy.teleportClient
 	if tc.ForwardAgent && tc.localAgent.Agent != nil {
-		err = agent.ForwardToAgent(ns.nodeClient.Client, tc.localAgent.Agent)
+		atomic.StoreInt32(&ns.agentForwarding, 1)
+		err = agent.ForwardToAgent(ns.nodeClient.Client, &gatedAgent{
+			Agent:   tc.localAgent.Agent,
+			enabled: &ns.agentForwarding,
+		})
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
@@ -230,6 +303,10 @@ func (ns *NodeSession) interactiveSession(callback interactiveCallback) error {
 		ns.watchSignals(remoteTerm)
 	}
 
+	// send keep-alive requests to the server so dead connections (e.g. after
+	// a network drop) are detected instead of hanging the session forever
+	go ns.sendKeepAlives()
+
 	// start piping input into the remote shell and pipe the output from
 	// the remote shell into stdout:
 	ns.pipeInOut(remoteTerm)
@@ -240,6 +317,7 @@ func (ns *NodeSession) interactiveSession(callback interactiveCallback) error {
 		if err != nil {
 			log.Warn(err)
 		} else {
+			ns.setTermState(ts)
 			defer term.RestoreTerminal(0, ts)
 		}
 	}
@@ -402,6 +480,60 @@ func (ns *NodeSession) updateTerminalSize(s *ssh.Session) {
 	}
 }
 
+// sendKeepAlives periodically sends keep-alive requests to the server and
+// closes the session after too many of them go unanswered.
+func (ns *NodeSession) sendKeepAlives() {
+	ticker := time.NewTicker(defaults.ClientKeepAliveInterval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-ticker.C:
+			if ns.sendKeepAliveWithTimeout(defaults.ClientKeepAliveInterval) {
+				missed = 0
+				continue
+			}
+			missed++
+			if missed < defaults.KeepAliveCountMax {
+				continue
+			}
+			log.Debugf("Missed %v keep-alive responses, closing session.", missed)
+			ns.disconnected = true
+			fmt.Fprintf(ns.stderr, "\r\nThe connection to the server was lost.\r\n")
+			ns.closer.Close()
+			return
+		case <-ns.closer.C:
+			return
+		}
+	}
+}
+
+// sendKeepAliveWithTimeout sends a keepalive@openssh.com request to the
+// server and reports if a response arrived in time. A manual timeout is
+// needed because SendRequest waits for a response forever.
+func (ns *NodeSession) sendKeepAliveWithTimeout(timeout time.Duration) bool {
+	errorCh := make(chan error, 1)
+	go func() {
+		// SendRequest will unblock when the connection is closed.
+		_, _, err := ns.nodeClient.Client.SendRequest(teleport.KeepAliveReqType, true, nil)
+		errorCh <- err
+	}()
+	select {
+	case err := <-errorCh:
+		return err == nil
+	case <-time.After(timeout):
+		return false
+	case <-ns.closer.C:
+		return false
+	}
+}
+
+// Disconnected returns true if the session ended because the connection to
+// the server was lost rather than because the remote shell exited.
+func (ns *NodeSession) Disconnected() bool {
+	return ns.disconnected
+}
+
 // isTerminalAttached returns true when this session is be controlled by
 // a real terminal.
 // It will return False for sessions initiated by the Web client or
@@ -487,6 +619,17 @@ func (ns *NodeSession) runCommand(ctx context.Context, cmd []string, callback Sh
 		// The passed in context timed out. This is often due to the user hitting
 		// Ctrl-C.
 		case <-ctx.Done():
+			// Forward the interrupt to the remote command first so it can
+			// terminate and deliver its exit status, the way OpenSSH does,
+			// instead of tearing the connection from under it.
+			if err := s.Signal(ssh.SIGINT); err != nil {
+				log.Debugf("Unable to forward SIGINT to remote command: %v", err)
+			}
+			select {
+			case <-runContext.Done():
+				return trace.Wrap(err)
+			case <-time.After(signalForwardingGracePeriod):
+			}
 			err = s.Close()
 			if err != nil {
 				log.Debugf("Unable to close SSH channel: %v", err)
@@ -554,7 +697,7 @@ func (ns *NodeSession) pipeInOut(shell io.ReadWriteCloser) {
 
 		stdin := ns.stdin
 		if ns.isTerminalAttached() && ns.enableEscapeSequences {
-			stdin = escape.NewReader(stdin, ns.stderr, func(err error) {
+			r := escape.NewReader(stdin, ns.stderr, func(err error) {
 				switch err {
 				case escape.ErrDisconnect:
 					fmt.Fprintf(ns.stderr, "\r\n%v\r\n", err)
@@ -565,6 +708,15 @@ func (ns *NodeSession) pipeInOut(shell io.ReadWriteCloser) {
 				}
 				ns.closer.Close()
 			})
+			if escapeChar := ns.nodeClient.Proxy.teleportClient.EscapeChar; escapeChar != 0 {
+				r.SetEscapeChar(escapeChar)
+			}
+			r.SetSequenceHandler(0x1a, "suspend tsh", ns.suspend)
+			r.SetSequenceHandler('#', "display connection information", ns.printConnectionInfo)
+			if atomic.LoadInt32(&ns.agentForwarding) != 0 {
+				r.SetSequenceHandler('a', "toggle agent forwarding", ns.toggleAgentForwarding)
+			}
+			stdin = r
 		}
 		for {
 			n, err := stdin.Read(buf)
@@ -584,6 +736,93 @@ func (ns *NodeSession) pipeInOut(shell io.ReadWriteCloser) {
 	}()
 }
 
+func (ns *NodeSession) setTermState(ts *term.State) {
+	ns.termStateMu.Lock()
+	ns.termState = ts
+	ns.termStateMu.Unlock()
+}
+
+// suspend implements the suspend escape sequence: it restores the local
+// terminal, stops the whole process group and switches the terminal back
+// into raw mode once the user resumes tsh.
+func (ns *NodeSession) suspend() {
+	ns.termStateMu.Lock()
+	ts := ns.termState
+	ns.termStateMu.Unlock()
+	if ts != nil {
+		term.RestoreTerminal(0, ts)
+	}
+
+	// SIGSTOP cannot be caught, so the SIGTSTP handler installed by
+	// watchSignals does not get in the way. Wait for SIGCONT to learn when
+	// the user has resumed tsh.
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(cont)
+	if err := syscall.Kill(0, syscall.SIGSTOP); err != nil {
+		log.Warnf("Unable to suspend: %v.", err)
+		return
+	}
+	<-cont
+
+	if ts != nil {
+		if _, err := term.SetRawTerminal(0); err != nil {
+			log.Warn(err)
+		}
+	}
+}
+
+// printConnectionInfo implements the connection information escape sequence.
+func (ns *NodeSession) printConnectionInfo() {
+	fmt.Fprintf(ns.stderr, "\r\ntsh session %v\r\n", ns.id)
+	fmt.Fprintf(ns.stderr, "  connected to %v from %v\r\n",
+		ns.nodeClient.Client.RemoteAddr(), ns.nodeClient.Client.LocalAddr())
+	if atomic.LoadInt32(&ns.agentForwarding) != 0 {
+		fmt.Fprintf(ns.stderr, "  agent forwarding: enabled\r\n")
+	}
+}
+
+// toggleAgentForwarding implements the agent forwarding escape sequence,
+// temporarily cutting off (or re-enabling) the forwarded agent for this
+// connection.
+func (ns *NodeSession) toggleAgentForwarding() {
+	if atomic.CompareAndSwapInt32(&ns.agentForwarding, 1, 0) {
+		fmt.Fprintf(ns.stderr, "\r\nagent forwarding disabled\r\n")
+		return
+	}
+	atomic.StoreInt32(&ns.agentForwarding, 1)
+	fmt.Fprintf(ns.stderr, "\r\nagent forwarding enabled\r\n")
+}
+
+// gatedAgent wraps the forwarded SSH agent so agent forwarding can be cut
+// off mid-session: while disabled it presents an empty keyring and refuses
+// signing requests.
+type gatedAgent struct {
+	agent.Agent
+	enabled *int32
+}
+
+func (g *gatedAgent) List() ([]*agent.Key, error) {
+	if atomic.LoadInt32(g.enabled) == 0 {
+		return nil, nil
+	}
+	return g.Agent.List()
+}
+
+func (g *gatedAgent) Signers() ([]ssh.Signer, error) {
+	if atomic.LoadInt32(g.enabled) == 0 {
+		return nil, nil
+	}
+	return g.Agent.Signers()
+}
+
+func (g *gatedAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	if atomic.LoadInt32(g.enabled) == 0 {
+		return nil, trace.AccessDenied("agent forwarding is disabled")
+	}
+	return g.Agent.Sign(key, data)
+}
+
 func (ns *NodeSession) Close() error {
 	if ns.closer != nil {
 		ns.closer.Close()
@@ -33,6 +33,9 @@ import (
 // support "tsh ssh" this code will never be called.
 type NodeSession struct {
 	ExitMsg string
+
+	// id is the ID of the session on the server
+	id session.ID
 }
 
 func newSession(
@@ -61,6 +64,14 @@ func (ns *NodeSession) NodeClient() *NodeClient {
 	return nil
 }
 
+func (ns *NodeSession) BrokenPipe() bool {
+	return false
+}
+
+func (ns *NodeSession) Disconnected() bool {
+	return false
+}
+
 func (ns *NodeSession) Close() error {
 	return trace.BadParameter("sessions not supported on Windows")
 }
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/gravitational/trace"
+)
+
+// SessionStreamHandler receives the stream of a session attached
+// to with StreamSession. Handlers must not block: the stream is
+// polled on a single goroutine and a slow handler delays delivery.
+type SessionStreamHandler struct {
+	// Output receives the raw terminal output of the session
+	// in the order it was written
+	Output io.Writer
+	// OnResize, if set, is called when a party resizes the
+	// session terminal
+	OnResize func(params session.TerminalParams)
+}
+
+// StreamSession attaches to the session in read-only mode and delivers
+// its terminal stream and resize events to the handler. Unlike Join
+// the caller never becomes a session party and cannot inject input.
+// The call blocks until the session ends, so it can be used both to
+// mirror a live session and to read back a finished recording.
+func (tc *TeleportClient) StreamSession(ctx context.Context, namespace, sessionID string, handler SessionStreamHandler) error {
+	if namespace == "" {
+		return trace.BadParameter(auth.MissingNamespaceError)
+	}
+	if handler.Output == nil {
+		return trace.BadParameter("missing parameter Output")
+	}
+	sid, err := session.ParseID(sessionID)
+	if err != nil {
+		return trace.BadParameter("%q is not a valid session ID (must be GUID)", sessionID)
+	}
+	proxyClient, err := tc.ConnectToProxy(ctx)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer proxyClient.Close()
+	site, err := proxyClient.ConnectToCurrentCluster(ctx, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer site.Close()
+
+	ticker := time.NewTicker(defaults.SessionRefreshPeriod)
+	defer ticker.Stop()
+
+	var offset, eventCursor int
+	for {
+		// drain all output the node has uploaded since the last poll
+		for {
+			chunk, err := site.GetSessionChunk(namespace, *sid, offset, events.MaxChunkBytes)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			if len(chunk) == 0 {
+				break
+			}
+			offset += len(chunk)
+			if _, err := handler.Output.Write(chunk); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		sessionEvents, err := site.GetSessionEvents(namespace, *sid, eventCursor, false)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		for _, event := range sessionEvents {
+			eventCursor = event.GetInt(events.EventCursor) + 1
+			switch event.GetString(events.EventType) {
+			case events.ResizeEvent, events.SessionStartEvent:
+				if handler.OnResize == nil {
+					continue
+				}
+				params, err := session.UnmarshalTerminalParams(event.GetString(events.TerminalSize))
+				if err != nil {
+					continue
+				}
+				handler.OnResize(*params)
+			case events.SessionEndEvent:
+				return nil
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return trace.Wrap(ctx.Err())
+		}
+	}
+}
@@ -60,6 +60,9 @@ type SSOLoginConsoleReq struct {
 	// KubernetesCluster is an optional k8s cluster name to route the response
 	// credentials to.
 	KubernetesCluster string
+	// ClientVersion is the teleport version of the client requesting the
+	// certificate.
+	ClientVersion string `json:"client_version,omitempty"`
 }
 
 // CheckAndSetDefaults makes sure that the request is valid
@@ -113,6 +116,9 @@ type CreateSSHCertReq struct {
 	// KubernetesCluster is an optional k8s cluster name to route the response
 	// credentials to.
 	KubernetesCluster string
+	// ClientVersion is the teleport version of the client requesting the
+	// certificate.
+	ClientVersion string `json:"client_version,omitempty"`
 }
 
 // CreateSSHCertWithU2FReq are passed by web client
@@ -137,6 +143,9 @@ type CreateSSHCertWithU2FReq struct {
 	// KubernetesCluster is an optional k8s cluster name to route the response
 	// credentials to.
 	KubernetesCluster string
+	// ClientVersion is the teleport version of the client requesting the
+	// certificate.
+	ClientVersion string `json:"client_version,omitempty"`
 }
 
 // PingResponse contains data about the Teleport server like supported
@@ -474,6 +483,7 @@ func SSHAgentLogin(ctx context.Context, login SSHLoginDirect) (*auth.SSHLoginRes
 		Compatibility:     login.Compatibility,
 		RouteToCluster:    login.RouteToCluster,
 		KubernetesCluster: login.KubernetesCluster,
+		ClientVersion:     teleport.Version,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -579,6 +589,7 @@ func SSHAgentU2FLogin(ctx context.Context, login SSHLoginU2F) (*auth.SSHLoginRes
 		Compatibility:     login.Compatibility,
 		RouteToCluster:    login.RouteToCluster,
 		KubernetesCluster: login.KubernetesCluster,
+		ClientVersion:     teleport.Version,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err)
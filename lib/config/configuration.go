@@ -28,6 +28,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 	"unicode"
@@ -40,11 +42,15 @@ import (
 	"github.com/gravitational/teleport/lib/backend/lite"
 	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/labels"
 	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/pam"
 	"github.com/gravitational/teleport/lib/service"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/srv/regular"
 	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/webhooks"
 	"github.com/gravitational/trace"
 
 	log "github.com/sirupsen/logrus"
@@ -72,6 +78,10 @@ type CommandLineFlags struct {
 	// Bootstrap flag contains a YAML file that defines a set of resources to bootstrap
 	// a cluster.
 	BootstrapFile string
+	// ApplyOnStartupFile contains a YAML file (or a directory of YAML files)
+	// that defines a set of resources reconciled into the backend on every
+	// auth server start.
+	ApplyOnStartupFile string
 	// ConfigString is a base64 encoded configuration string
 	// set by --config-string or TELEPORT_CONFIG environment variable
 	ConfigString string
@@ -150,6 +160,32 @@ func ReadResources(filePath string) ([]services.Resource, error) {
 	return resources, nil
 }
 
+// ReadResourcesFromPath loads resources from a file, or from every
+// .yaml/.yml file of a directory in lexical order.
+func ReadResourcesFromPath(path string) ([]services.Resource, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	if !fi.IsDir() {
+		return ReadResources(path)
+	}
+	matches, err := filepath.Glob(filepath.Join(path, "*.y*ml"))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sort.Strings(matches)
+	var resources []services.Resource
+	for _, match := range matches {
+		loaded, err := ReadResources(match)
+		if err != nil {
+			return nil, trace.Wrap(err, "failed reading %q", match)
+		}
+		resources = append(resources, loaded...)
+	}
+	return resources, nil
+}
+
 // ApplyFileConfig applies configuration from a YAML file to Teleport
 // runtime config
 func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
@@ -230,40 +266,8 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 	}
 
 	// apply logger settings
-	switch fc.Logger.Output {
-	case "":
-		break // not set
-	case "stderr", "error", "2":
-		log.SetOutput(os.Stderr)
-	case "stdout", "out", "1":
-		log.SetOutput(os.Stdout)
-	case teleport.Syslog:
-		err := utils.SwitchLoggingtoSyslog()
-		if err != nil {
-			// this error will go to stderr
-			log.Errorf("Failed to switch logging to syslog: %v.", err)
-		}
-	default:
-		// assume it's a file path:
-		logFile, err := os.Create(fc.Logger.Output)
-		if err != nil {
-			return trace.Wrap(err, "failed to create the log file")
-		}
-		log.SetOutput(logFile)
-	}
-	switch strings.ToLower(fc.Logger.Severity) {
-	case "":
-		break // not set
-	case "info":
-		log.SetLevel(log.InfoLevel)
-	case "err", "error":
-		log.SetLevel(log.ErrorLevel)
-	case teleport.DebugLevel:
-		log.SetLevel(log.DebugLevel)
-	case "warn", "warning":
-		log.SetLevel(log.WarnLevel)
-	default:
-		return trace.BadParameter("unsupported logger severity: '%v'", fc.Logger.Severity)
+	if err := applyLogConfig(fc.Logger); err != nil {
+		return trace.Wrap(err)
 	}
 	// apply cache policy for node and proxy
 	cachePolicy, err := fc.CachePolicy.Parse()
@@ -294,6 +298,18 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 		cfg.CASignatureAlgorithm = fc.CASignatureAlgorithm
 	}
 
+	if fc.ShutdownDrainTimeout != 0 {
+		cfg.ShutdownTimeout = fc.ShutdownDrainTimeout.Value()
+	}
+
+	if fc.ClockSkewTolerance != 0 {
+		cfg.ClockSkewTolerance = fc.ClockSkewTolerance.Value()
+	}
+
+	if fc.AuthorizationGracePeriod != 0 {
+		cfg.AuthorizationGracePeriod = fc.AuthorizationGracePeriod.Value()
+	}
+
 	// Read in how nodes will validate the CA.
 	if fc.CAPin != "" {
 		cfg.CAPin = fc.CAPin
@@ -309,6 +325,9 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 		if fc.Limits.MaxConnections > 0 {
 			l.MaxConnections = fc.Limits.MaxConnections
 		}
+		if fc.Limits.MaxTotalConnections > 0 {
+			l.MaxTotalConnections = fc.Limits.MaxTotalConnections
+		}
 		if fc.Limits.MaxUsers > 0 {
 			l.MaxNumberOfUsers = fc.Limits.MaxUsers
 		}
@@ -350,6 +369,131 @@ func ApplyFileConfig(fc *FileConfig, cfg *service.Config) error {
 	return nil
 }
 
+// applyLogConfig applies logger settings, it is safe to call at runtime
+// to change logging of a running process
+func applyLogConfig(logger Log) error {
+	switch logger.Output {
+	case "":
+		break // not set
+	case "stderr", "error", "2":
+		log.SetOutput(os.Stderr)
+	case "stdout", "out", "1":
+		log.SetOutput(os.Stdout)
+	case teleport.Syslog:
+		err := utils.SwitchLoggingtoSyslog()
+		if err != nil {
+			// this error will go to stderr
+			log.Errorf("Failed to switch logging to syslog: %v.", err)
+		}
+	default:
+		// assume it's a file path:
+		logFile, err := os.Create(logger.Output)
+		if err != nil {
+			return trace.Wrap(err, "failed to create the log file")
+		}
+		log.SetOutput(logFile)
+	}
+	if logger.Severity != "" {
+		level, err := parseLogSeverity(logger.Severity)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		log.SetLevel(level)
+	}
+	switch strings.ToLower(logger.Format) {
+	case "", "text":
+		break // the default
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		return trace.BadParameter("unsupported logger format: '%v'", logger.Format)
+	}
+	if len(logger.ComponentLevels) != 0 {
+		levels := make(map[string]log.Level, len(logger.ComponentLevels))
+		for component, severity := range logger.ComponentLevels {
+			level, err := parseLogSeverity(severity)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			levels[component] = level
+		}
+		// raise the global level to let the most verbose component
+		// through, the formatter filters the other components back down
+		// to their configured levels
+		defaultLevel := log.GetLevel()
+		globalLevel := defaultLevel
+		for _, level := range levels {
+			if level > globalLevel {
+				globalLevel = level
+			}
+		}
+		log.SetLevel(globalLevel)
+		log.SetFormatter(&utils.ComponentLevelFormatter{
+			Formatter:    log.StandardLogger().Formatter,
+			Levels:       levels,
+			DefaultLevel: defaultLevel,
+		})
+	}
+	return nil
+}
+
+// parseLogSeverity parses a logger severity string used in configuration
+func parseLogSeverity(severity string) (log.Level, error) {
+	switch strings.ToLower(severity) {
+	case "info":
+		return log.InfoLevel, nil
+	case "err", "error":
+		return log.ErrorLevel, nil
+	case teleport.DebugLevel:
+		return log.DebugLevel, nil
+	case "warn", "warning":
+		return log.WarnLevel, nil
+	default:
+		return log.InfoLevel, trace.BadParameter("unsupported logger severity: '%v'", severity)
+	}
+}
+
+// ApplyReload re-reads the configuration file on SIGHUP, applies the
+// settings that are safe to change at runtime (currently the logger output
+// and severity) and returns the list of configuration sections whose
+// changes can only take effect after a restart. An empty list means the
+// reload has been fully applied in place.
+func ApplyReload(configFilePath string, prev *FileConfig) (*FileConfig, []string, error) {
+	fc, err := ReadFromFile(configFilePath)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	if err := applyLogConfig(fc.Logger); err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+	return fc, restartSections(prev, fc), nil
+}
+
+// restartSections compares two file configurations and returns the names
+// of the sections that differ in settings that require a process restart
+func restartSections(prev, next *FileConfig) []string {
+	var sections []string
+	// logging changes have been applied in place, ignore them in the diff
+	prevGlobal, nextGlobal := prev.Global, next.Global
+	prevGlobal.Logger, nextGlobal.Logger = Log{}, Log{}
+	if !reflect.DeepEqual(prevGlobal, nextGlobal) {
+		sections = append(sections, "teleport")
+	}
+	if !reflect.DeepEqual(prev.Auth, next.Auth) {
+		sections = append(sections, "auth_service")
+	}
+	if !reflect.DeepEqual(prev.SSH, next.SSH) {
+		sections = append(sections, "ssh_service")
+	}
+	if !reflect.DeepEqual(prev.Proxy, next.Proxy) {
+		sections = append(sections, "proxy_service")
+	}
+	if !reflect.DeepEqual(prev.Kube, next.Kube) {
+		sections = append(sections, "kubernetes_service")
+	}
+	return sections
+}
+
 // applyAuthConfig applies file configuration for the "auth_service" section.
 func applyAuthConfig(fc *FileConfig, cfg *service.Config) error {
 	var err error
@@ -363,6 +507,38 @@ func applyAuthConfig(fc *FileConfig, cfg *service.Config) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	cfg.Auth.SessionRecordingEncryption, err = utils.ParseOnOff("session_recording_encryption", fc.Auth.SessionRecordingEncryption, false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if fc.Auth.NotificationWebhook != nil {
+		cfg.Auth.NotificationWebhook = &events.WebhookEmitterConfig{
+			URL:    fc.Auth.NotificationWebhook.URL,
+			Format: fc.Auth.NotificationWebhook.Format,
+			Types:  fc.Auth.NotificationWebhook.EventTypes,
+		}
+	}
+	if fc.Auth.Reconcile != nil {
+		cfg.Auth.Reconcile = service.ReconcileConfig{
+			Enabled:     true,
+			Period:      fc.Auth.Reconcile.Period.Value(),
+			DefaultMode: fc.Auth.Reconcile.DefaultMode,
+			Modes:       fc.Auth.Reconcile.Modes,
+		}
+	}
+	for _, hook := range fc.Auth.NodeWebhooks {
+		cfg.Auth.NodeWebhooks = append(cfg.Auth.NodeWebhooks, webhooks.Hook{
+			URL:    hook.URL,
+			Events: hook.Events,
+		})
+	}
+	if fc.Auth.AuditRedaction != nil {
+		cfg.Auth.AuditRedaction = events.RedactionRules{
+			HashUserNames:        fc.Auth.AuditRedaction.HashUsernames,
+			DropCommandArguments: fc.Auth.AuditRedaction.DropCommandArguments,
+			MaskAddresses:        fc.Auth.AuditRedaction.MaskAddresses,
+		}
+	}
 	if fc.Auth.ListenAddress != "" {
 		addr, err := utils.ParseHostPortAddr(fc.Auth.ListenAddress, int(defaults.AuthListenPort))
 		if err != nil {
@@ -580,6 +756,27 @@ func applyProxyConfig(fc *FileConfig, cfg *service.Config) error {
 		}
 		cfg.Proxy.TunnelPublicAddrs = addrs
 	}
+	for _, t := range fc.Proxy.Templates {
+		template := regular.ProxyTemplate{
+			Pattern: t.Pattern,
+			Labels:  t.Labels,
+			Policy:  t.Policy,
+		}
+		if err := template.CheckAndSetDefaults(); err != nil {
+			return trace.Wrap(err)
+		}
+		cfg.Proxy.Templates = append(cfg.Proxy.Templates, template)
+	}
+	if fc.Proxy.HostResolver != nil {
+		resolver, err := regular.NewHTTPHostResolver(regular.HTTPHostResolverConfig{
+			URL:     fc.Proxy.HostResolver.URL,
+			Timeout: fc.Proxy.HostResolver.Timeout.Value(),
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		cfg.Proxy.HostResolver = resolver
+	}
 
 	return nil
 
@@ -610,12 +807,25 @@ func applySSHConfig(fc *FileConfig, cfg *service.Config) error {
 			}
 		}
 	}
+	if fc.SSH.LabelProviders != nil {
+		cfg.SSH.LabelProviders = make([]labels.ProviderConfig, 0, len(fc.SSH.LabelProviders))
+		for _, provider := range fc.SSH.LabelProviders {
+			cfg.SSH.LabelProviders = append(cfg.SSH.LabelProviders, labels.ProviderConfig{
+				Command: provider.Command,
+				Period:  provider.Period,
+				Timeout: provider.Timeout,
+			})
+		}
+	}
 	if fc.SSH.Namespace != "" {
 		cfg.SSH.Namespace = fc.SSH.Namespace
 	}
 	if fc.SSH.PermitUserEnvironment {
 		cfg.SSH.PermitUserEnvironment = true
 	}
+	if len(fc.SSH.AcceptEnv) != 0 {
+		cfg.SSH.AcceptEnv = fc.SSH.AcceptEnv
+	}
 	if fc.SSH.PAM != nil {
 		cfg.SSH.PAM = fc.SSH.PAM.Parse()
 
@@ -637,6 +847,9 @@ func applySSHConfig(fc *FileConfig, cfg *service.Config) error {
 			}
 		}
 	}
+	if fc.SSH.HostUsers != nil {
+		cfg.SSH.HostUsers = fc.SSH.HostUsers.Parse()
+	}
 	if len(fc.SSH.PublicAddr) != 0 {
 		addrs, err := fc.SSH.PublicAddr.Addrs(defaults.SSHServerListenPort)
 		if err != nil {
@@ -647,10 +860,40 @@ func applySSHConfig(fc *FileConfig, cfg *service.Config) error {
 	if fc.SSH.BPF != nil {
 		cfg.SSH.BPF = fc.SSH.BPF.Parse()
 	}
+	if fc.SSH.SessionLimits != nil {
+		cfg.SSH.SessionLimits = fc.SSH.SessionLimits.Parse()
+	}
+	banner, err := readMessage(fc.SSH.Banner, fc.SSH.BannerFile, "banner")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cfg.SSH.Banner = banner
+	motd, err := readMessage(fc.SSH.MOTD, fc.SSH.MOTDFile, "motd")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cfg.SSH.MOTD = motd
+	cfg.SSH.ReportUtilization = fc.SSH.ReportUtilization
 
 	return nil
 }
 
+// readMessage resolves a message configured either as inline text or as a
+// path to a file holding it, like the SSH banner and the message of the day.
+func readMessage(text string, file string, name string) (string, error) {
+	if text != "" && file != "" {
+		return "", trace.BadParameter("both %q and %q_file are set, only one can be used", name, name)
+	}
+	if file == "" {
+		return text, nil
+	}
+	out, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return string(out), nil
+}
+
 // applyKubeConfig applies file configuration for the "kubernetes_service" section.
 func applyKubeConfig(fc *FileConfig, cfg *service.Config) error {
 	if fc.Proxy.ListenAddress != "" {
@@ -903,6 +1146,17 @@ func Configure(clf *CommandLineFlags, cfg *service.Config) error {
 		cfg.Auth.Resources = resources
 	}
 
+	if clf.ApplyOnStartupFile != "" {
+		resources, err := ReadResourcesFromPath(clf.ApplyOnStartupFile)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if len(resources) < 1 {
+			return trace.BadParameter("no resources found: %q", clf.ApplyOnStartupFile)
+		}
+		cfg.Auth.ApplyOnStartupResources = resources
+	}
+
 	// Apply command line --debug flag to override logger severity.
 	if clf.Debug {
 		// If debug logging is requested and no file configuration exists, set the
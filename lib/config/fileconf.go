@@ -31,10 +31,12 @@ import (
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/bpf"
+	"github.com/gravitational/teleport/lib/cgroup"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/pam"
 	"github.com/gravitational/teleport/lib/service"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/srv"
 	"github.com/gravitational/teleport/lib/utils"
 
 	"github.com/gravitational/trace"
@@ -52,120 +54,155 @@ var (
 	// true  = has sub-keys
 	// false = does not have sub-keys (a leaf)
 	validKeys = map[string]bool{
-		"proxy_protocol":          false,
-		"namespace":               true,
-		"cluster_name":            true,
-		"trusted_clusters":        true,
-		"pid_file":                true,
-		"cert_file":               true,
-		"private_key_file":        true,
-		"cert":                    true,
-		"private_key":             true,
-		"checking_keys":           true,
-		"checking_key_files":      true,
-		"signing_keys":            true,
-		"signing_key_files":       true,
-		"allowed_logins":          true,
-		"teleport":                true,
-		"enabled":                 true,
-		"ssh_service":             true,
-		"proxy_service":           true,
-		"auth_service":            true,
-		"kubernetes":              true,
-		"kubeconfig_file":         true,
-		"auth_token":              true,
-		"auth_servers":            true,
-		"domain_name":             true,
-		"storage":                 false,
-		"nodename":                true,
-		"log":                     true,
-		"period":                  true,
-		"connection_limits":       true,
-		"max_connections":         true,
-		"max_users":               true,
-		"rates":                   true,
-		"commands":                true,
-		"labels":                  false,
-		"output":                  true,
-		"severity":                true,
-		"role":                    true,
-		"name":                    true,
-		"type":                    true,
-		"data_dir":                true,
-		"web_listen_addr":         true,
-		"tunnel_listen_addr":      true,
-		"ssh_listen_addr":         true,
-		"listen_addr":             true,
-		"ca_cert_file":            false,
-		"https_key_file":          true,
-		"https_cert_file":         true,
-		"advertise_ip":            true,
-		"authorities":             true,
-		"keys":                    true,
-		"reverse_tunnels":         true,
-		"addresses":               true,
-		"oidc_connectors":         true,
-		"id":                      true,
-		"issuer_url":              true,
-		"client_id":               true,
-		"client_secret":           true,
-		"redirect_url":            true,
-		"acr_values":              true,
-		"provider":                true,
-		"tokens":                  true,
-		"region":                  true,
-		"table_name":              true,
-		"access_key":              true,
-		"secret_key":              true,
-		"u2f":                     true,
-		"app_id":                  true,
-		"facets":                  true,
-		"authentication":          true,
-		"second_factor":           false,
-		"oidc":                    true,
-		"display":                 false,
-		"scope":                   false,
-		"claims_to_roles":         true,
-		"dynamic_config":          false,
-		"seed_config":             false,
-		"public_addr":             false,
-		"ssh_public_addr":         false,
-		"tunnel_public_addr":      false,
-		"cache":                   true,
-		"ttl":                     false,
-		"issuer":                  false,
-		"permit_user_env":         false,
-		"ciphers":                 false,
-		"kex_algos":               false,
-		"mac_algos":               false,
-		"ca_signature_algo":       false,
-		"connector_name":          false,
-		"session_recording":       false,
-		"read_capacity_units":     false,
-		"write_capacity_units":    false,
-		"license_file":            false,
-		"proxy_checks_host_keys":  false,
-		"audit_table_name":        false,
-		"audit_sessions_uri":      false,
-		"audit_events_uri":        false,
-		"pam":                     true,
-		"use_pam_auth":            false,
-		"service_name":            false,
-		"client_idle_timeout":     false,
-		"session_control_timeout": false,
-		"disconnect_expired_cert": false,
-		"ciphersuites":            false,
-		"ca_pin":                  false,
-		"keep_alive_interval":     false,
-		"keep_alive_count_max":    false,
-		"local_auth":              false,
-		"enhanced_recording":      false,
-		"command_buffer_size":     false,
-		"disk_buffer_size":        false,
-		"network_buffer_size":     false,
-		"cgroup_path":             false,
-		"kubernetes_service":      true,
-		"kube_cluster_name":       false,
+		"proxy_protocol":               false,
+		"namespace":                    true,
+		"cluster_name":                 true,
+		"trusted_clusters":             true,
+		"pid_file":                     true,
+		"cert_file":                    true,
+		"private_key_file":             true,
+		"cert":                         true,
+		"private_key":                  true,
+		"checking_keys":                true,
+		"checking_key_files":           true,
+		"signing_keys":                 true,
+		"signing_key_files":            true,
+		"allowed_logins":               true,
+		"teleport":                     true,
+		"enabled":                      true,
+		"ssh_service":                  true,
+		"proxy_service":                true,
+		"auth_service":                 true,
+		"kubernetes":                   true,
+		"kubeconfig_file":              true,
+		"auth_token":                   true,
+		"auth_servers":                 true,
+		"domain_name":                  true,
+		"storage":                      false,
+		"nodename":                     true,
+		"log":                          true,
+		"period":                       true,
+		"connection_limits":            true,
+		"max_connections":              true,
+		"max_total_connections":        false,
+		"max_users":                    true,
+		"rates":                        true,
+		"commands":                     true,
+		"label_providers":              true,
+		"component_levels":             false,
+		"shutdown_drain_timeout":       false,
+		"clock_skew_tolerance":         false,
+		"authorization_grace_period":   false,
+		"labels":                       false,
+		"output":                       true,
+		"severity":                     true,
+		"role":                         true,
+		"name":                         true,
+		"type":                         true,
+		"data_dir":                     true,
+		"web_listen_addr":              true,
+		"tunnel_listen_addr":           true,
+		"ssh_listen_addr":              true,
+		"listen_addr":                  true,
+		"ca_cert_file":                 false,
+		"https_key_file":               true,
+		"https_cert_file":              true,
+		"advertise_ip":                 true,
+		"authorities":                  true,
+		"keys":                         true,
+		"reverse_tunnels":              true,
+		"addresses":                    true,
+		"oidc_connectors":              true,
+		"id":                           true,
+		"issuer_url":                   true,
+		"client_id":                    true,
+		"client_secret":                true,
+		"redirect_url":                 true,
+		"acr_values":                   true,
+		"provider":                     true,
+		"tokens":                       true,
+		"region":                       true,
+		"table_name":                   true,
+		"access_key":                   true,
+		"secret_key":                   true,
+		"u2f":                          true,
+		"app_id":                       true,
+		"facets":                       true,
+		"authentication":               true,
+		"second_factor":                false,
+		"oidc":                         true,
+		"display":                      false,
+		"scope":                        false,
+		"claims_to_roles":              true,
+		"dynamic_config":               false,
+		"seed_config":                  false,
+		"public_addr":                  false,
+		"ssh_public_addr":              false,
+		"tunnel_public_addr":           false,
+		"cache":                        true,
+		"ttl":                          false,
+		"issuer":                       false,
+		"permit_user_env":              false,
+		"accept_env":                   false,
+		"ciphers":                      false,
+		"kex_algos":                    false,
+		"mac_algos":                    false,
+		"ca_signature_algo":            false,
+		"connector_name":               false,
+		"session_recording":            false,
+		"session_recording_encryption": false,
+		"read_capacity_units":          false,
+		"write_capacity_units":         false,
+		"license_file":                 false,
+		"proxy_checks_host_keys":       false,
+		"audit_table_name":             false,
+		"audit_sessions_uri":           false,
+		"audit_events_uri":             false,
+		"pam":                          true,
+		"use_pam_auth":                 false,
+		"environment":                  false,
+		"host_users":                   false,
+		"groups":                       false,
+		"shell":                        false,
+		"create_home":                  false,
+		"notification_webhook":         false,
+		"url":                          false,
+		"format":                       false,
+		"event_types":                  false,
+		"audit_redaction":              false,
+		"hash_usernames":               false,
+		"drop_command_arguments":       false,
+		"mask_addresses":               false,
+		"service_name":                 false,
+		"client_idle_timeout":          false,
+		"session_control_timeout":      false,
+		"disconnect_expired_cert":      false,
+		"ciphersuites":                 false,
+		"ca_pin":                       false,
+		"keep_alive_interval":          false,
+		"keep_alive_count_max":         false,
+		"local_auth":                   false,
+		"enhanced_recording":           false,
+		"command_buffer_size":          false,
+		"disk_buffer_size":             false,
+		"network_buffer_size":          false,
+		"cgroup_path":                  false,
+		"session_limits":               false,
+		"banner":                       false,
+		"banner_file":                  false,
+		"motd":                         false,
+		"motd_file":                    false,
+		"report_utilization":           false,
+		"templates":                    false,
+		"pattern":                      false,
+		"policy":                       false,
+		"host_resolver":                false,
+		"timeout":                      false,
+		"max_memory_bytes":             false,
+		"cpu_percent":                  false,
+		"max_processes":                false,
+		"kubernetes_service":           true,
+		"kube_cluster_name":            false,
 	}
 )
 
@@ -218,7 +255,9 @@ func ReadConfig(reader io.Reader) (*FileConfig, error) {
 		return nil, trace.Wrap(err, "failed reading Teleport configuration")
 	}
 	var fc FileConfig
-	if err = yaml.Unmarshal(bytes, &fc); err != nil {
+	// decode strictly so misspelled fields (e.g. "comands" in a label) are
+	// reported with their line numbers instead of being silently dropped
+	if err = yaml.UnmarshalStrict(bytes, &fc); err != nil {
 		return nil, trace.BadParameter("failed to parse Teleport configuration: %v", err)
 	}
 	// don't start Teleport with invalid ciphers, kex algorithms, or mac algorithms.
@@ -322,6 +361,66 @@ func MakeSampleFileConfig() (fc *FileConfig, err error) {
 	return fc, nil
 }
 
+// SampleFlags parameterizes the configuration generated by
+// MakeTopologyFileConfig
+type SampleFlags struct {
+	// Topology is one of the common setups to generate a configuration
+	// for: "demo", "auth", "node" or "leaf"
+	Topology string
+	// ClusterName overrides the name of the cluster
+	ClusterName string
+	// AuthServer is the address of the auth server nodes connect to
+	AuthServer string
+	// AuthToken is the join token used by the node topology
+	AuthToken string
+	// CAPin is the SPKI hash used by nodes to validate the auth server CA
+	CAPin string
+}
+
+// MakeTopologyFileConfig returns a ready-to-run config for one of the
+// common topologies: an all-in-one demo process, an auth+proxy pair, a
+// node joining via token or a leaf cluster connecting over a reverse
+// tunnel.
+func MakeTopologyFileConfig(flags SampleFlags) (*FileConfig, error) {
+	fc, err := MakeSampleFileConfig()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch flags.Topology {
+	case "", "demo":
+		// the all-in-one sample with every role enabled
+	case "auth":
+		// auth and proxy pair serving a cluster, no local node
+		fc.SSH.EnabledFlag = "no"
+	case "node":
+		// a node joining an existing cluster with a token
+		fc.Auth.EnabledFlag = "no"
+		fc.Proxy.EnabledFlag = "no"
+		fc.Auth.StaticTokens = nil
+		if flags.AuthToken != "" {
+			fc.AuthToken = flags.AuthToken
+		}
+	case "leaf":
+		// a leaf cluster, the trust relationship with the root cluster
+		// is established at runtime with a trusted_cluster resource
+		if flags.ClusterName == "" {
+			return nil, trace.BadParameter("leaf topology requires --cluster-name")
+		}
+	default:
+		return nil, trace.BadParameter("unsupported topology %q, must be one of: demo, auth, node or leaf", flags.Topology)
+	}
+	if flags.ClusterName != "" {
+		fc.Auth.ClusterName = ClusterName(flags.ClusterName)
+	}
+	if flags.AuthServer != "" {
+		fc.AuthServers = []string{flags.AuthServer}
+	}
+	if flags.CAPin != "" {
+		fc.CAPin = flags.CAPin
+	}
+	return fc, nil
+}
+
 // DebugDumpToYAML allows for quick YAML dumping of the config
 func (conf *FileConfig) DebugDumpToYAML() string {
 	bytes, err := yaml.Marshal(&conf)
@@ -369,9 +468,10 @@ type ConnectionRate struct {
 
 // ConnectionLimits sets up connection limiter
 type ConnectionLimits struct {
-	MaxConnections int64            `yaml:"max_connections"`
-	MaxUsers       int              `yaml:"max_users"`
-	Rates          []ConnectionRate `yaml:"rates,omitempty"`
+	MaxConnections      int64            `yaml:"max_connections"`
+	MaxTotalConnections int64            `yaml:"max_total_connections,omitempty"`
+	MaxUsers            int              `yaml:"max_users"`
+	Rates               []ConnectionRate `yaml:"rates,omitempty"`
 }
 
 // Log configures teleport logging
@@ -381,6 +481,12 @@ type Log struct {
 	Output string `yaml:"output,omitempty"`
 	// Severity defines how verbose the log will be. Possible valus are "error", "info", "warn"
 	Severity string `yaml:"severity,omitempty"`
+	// Format defines the log output format, "text" (default) or "json" for
+	// log aggregation pipelines
+	Format string `yaml:"format,omitempty"`
+	// ComponentLevels overrides Severity for individual components,
+	// e.g. "auth": "debug"
+	ComponentLevels map[string]string `yaml:"component_levels,omitempty"`
 }
 
 // Global is 'teleport' (global) section of the config file
@@ -420,6 +526,19 @@ type Global struct {
 
 	// CAPin is the SKPI hash of the CA used to verify the Auth Server.
 	CAPin string `yaml:"ca_pin"`
+
+	// ShutdownDrainTimeout is the maximum time active sessions are allowed
+	// to finish during a graceful shutdown before being disconnected.
+	ShutdownDrainTimeout services.Duration `yaml:"shutdown_drain_timeout,omitempty"`
+
+	// ClockSkewTolerance extends the validity window of certificates to
+	// tolerate wall clock drift between this host and the auth server.
+	ClockSkewTolerance services.Duration `yaml:"clock_skew_tolerance,omitempty"`
+
+	// AuthorizationGracePeriod sets for how long nodes keep authorizing
+	// already-seen users from cached role data when the auth server is
+	// unreachable.
+	AuthorizationGracePeriod services.Duration `yaml:"authorization_grace_period,omitempty"`
 }
 
 // CachePolicy is used to control  local cache
@@ -430,6 +549,9 @@ type CachePolicy struct {
 	EnabledFlag string `yaml:"enabled,omitempty"`
 	// TTL sets maximum TTL for the cached values
 	TTL string `yaml:"ttl,omitempty"`
+	// MaxStaleness is how long the auth server keeps serving
+	// last-known-good data after the backend becomes unavailable
+	MaxStaleness string `yaml:"max_staleness,omitempty"`
 }
 
 func isNever(v string) bool {
@@ -470,6 +592,13 @@ func (c *CachePolicy) Parse() (*service.CachePolicy, error) {
 			}
 		}
 	}
+	if c.MaxStaleness != "" {
+		var err error
+		out.MaxStaleness, err = time.ParseDuration(c.MaxStaleness)
+		if err != nil {
+			return nil, trace.BadParameter("cache.max_staleness invalid duration: %v, accepted format '30s'", c.MaxStaleness)
+		}
+	}
 	if err := out.CheckAndSetDefaults(); err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -531,6 +660,10 @@ type Auth struct {
 	// SessionRecording determines where the session is recorded: node, proxy, or off.
 	SessionRecording string `yaml:"session_recording,omitempty"`
 
+	// SessionRecordingEncryption turns on encryption of stored session
+	// recordings with per-session keys: yes or no (the default).
+	SessionRecordingEncryption string `yaml:"session_recording_encryption,omitempty"`
+
 	// ProxyChecksHostKeys is used when the proxy is in recording mode and
 	// determines if the proxy will check the host key of the client or not.
 	ProxyChecksHostKeys string `yaml:"proxy_checks_host_keys,omitempty"`
@@ -581,6 +714,23 @@ type Auth struct {
 	// of contact with the auth server before it starts terminating controlled sessions.
 	SessionControlTimeout services.Duration `yaml:"session_control_timeout,omitempty"`
 
+	// NotificationWebhook configures delivery of security events to an
+	// external webhook endpoint, for example a Slack incoming webhook.
+	NotificationWebhook *NotificationWebhook `yaml:"notification_webhook,omitempty"`
+
+	// NodeWebhooks configures delivery of node lifecycle notifications
+	// (joined, expired, deleted) to external webhook endpoints.
+	NodeWebhooks []NodeWebhook `yaml:"node_webhooks,omitempty"`
+
+	// Reconcile enables continuous re-assertion of the resources
+	// applied on startup.
+	Reconcile *Reconcile `yaml:"reconcile,omitempty"`
+
+	// AuditRedaction configures redaction of audit events exported to
+	// the external sinks listed in audit_events_uri. The local audit log
+	// keeps events with full fidelity.
+	AuditRedaction *AuditRedaction `yaml:"audit_redaction,omitempty"`
+
 	// KubeconfigFile is an optional path to kubeconfig file,
 	// if specified, teleport will use API server address and
 	// trusted certificate authority information from it
@@ -719,13 +869,64 @@ type SSH struct {
 	Namespace             string            `yaml:"namespace,omitempty"`
 	Labels                map[string]string `yaml:"labels,omitempty"`
 	Commands              []CommandLabel    `yaml:"commands,omitempty"`
+	LabelProviders        []LabelProvider   `yaml:"label_providers,omitempty"`
 	PermitUserEnvironment bool              `yaml:"permit_user_env,omitempty"`
 	PAM                   *PAM              `yaml:"pam,omitempty"`
+
+	// AcceptEnv is a list of environment variable names (with optional
+	// trailing '*' wildcard) clients are allowed to set in their sessions,
+	// similar to the AcceptEnv directive of OpenSSH. When empty, all
+	// variables sent by the client are accepted.
+	AcceptEnv []string `yaml:"accept_env,omitempty"`
+
+	// HostUsers is configuration for automatic creation of local Unix
+	// accounts.
+	HostUsers *HostUsers `yaml:"host_users,omitempty"`
 	// PublicAddr sets SSH host principals for SSH service
 	PublicAddr utils.Strings `yaml:"public_addr,omitempty"`
 
 	// BPF is used to configure BPF-based auditing for this node.
 	BPF *BPF `yaml:"enhanced_recording,omitempty"`
+
+	// SessionLimits caps the resources available to each session on this node.
+	SessionLimits *SessionLimits `yaml:"session_limits,omitempty"`
+
+	// Banner is a message presented to the client before authentication.
+	// Either inline text or the path to a file holding it.
+	Banner     string `yaml:"banner,omitempty"`
+	BannerFile string `yaml:"banner_file,omitempty"`
+
+	// MOTD is a message of the day shown to the user after login. Either
+	// inline text or the path to a file holding it.
+	MOTD     string `yaml:"motd,omitempty"`
+	MOTDFile string `yaml:"motd_file,omitempty"`
+
+	// ReportUtilization enables reporting of CPU, memory and disk
+	// utilization gauges in this node's heartbeats.
+	ReportUtilization bool `yaml:"report_utilization,omitempty"`
+}
+
+// SessionLimits is configuration for cgroupv2 resource limits applied to
+// each session spawned on a node.
+type SessionLimits struct {
+	// MaxMemoryBytes caps the memory available to a session.
+	MaxMemoryBytes int64 `yaml:"max_memory_bytes,omitempty"`
+
+	// CPUPercent caps CPU time available to a session as a percentage of a
+	// single CPU.
+	CPUPercent int64 `yaml:"cpu_percent,omitempty"`
+
+	// MaxProcesses caps the number of processes in a session.
+	MaxProcesses int64 `yaml:"max_processes,omitempty"`
+}
+
+// Parse will parse the session resource limits configuration.
+func (l *SessionLimits) Parse() *cgroup.Limits {
+	return &cgroup.Limits{
+		MaxMemoryBytes: l.MaxMemoryBytes,
+		CPUPercent:     l.CPUPercent,
+		MaxProcesses:   l.MaxProcesses,
+	}
 }
 
 // CommandLabel is `command` section of `ssh_service` in the config file
@@ -735,6 +936,14 @@ type CommandLabel struct {
 	Period  time.Duration `yaml:"period"`
 }
 
+// LabelProvider is `label_providers` section of `ssh_service` in the config
+// file: an external process queried on a period for additional node labels
+type LabelProvider struct {
+	Command []string      `yaml:"command,flow"`
+	Period  time.Duration `yaml:"period,omitempty"`
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
 // PAM is configuration for Pluggable Authentication Modules (PAM).
 type PAM struct {
 	// Enabled controls if PAM will be used or not.
@@ -746,6 +955,9 @@ type PAM struct {
 	// UsePAMAuth specifies whether to trigger the "auth" PAM modules from the
 	// policy.
 	UsePAMAuth bool `yaml:"use_pam_auth"`
+
+	// Environment represents environment variables to pass to PAM.
+	Environment map[string]string `yaml:"environment,omitempty"`
 }
 
 // Parse returns a parsed pam.Config.
@@ -759,6 +971,92 @@ func (p *PAM) Parse() *pam.Config {
 		Enabled:     enabled,
 		ServiceName: serviceName,
 		UsePAMAuth:  p.UsePAMAuth,
+		Env:         p.Environment,
+	}
+}
+
+// NotificationWebhook is configuration for delivery of security events to
+// an external webhook endpoint.
+type NotificationWebhook struct {
+	// URL is the webhook endpoint events are posted to.
+	URL string `yaml:"url"`
+
+	// Format is the payload format, either "json" (default) or "slack".
+	Format string `yaml:"format,omitempty"`
+
+	// EventTypes is an optional list of event types to deliver. If empty,
+	// only events with warning or error codes are delivered.
+	EventTypes []string `yaml:"event_types,omitempty"`
+}
+
+// Reconcile holds configuration under the "reconcile" key of the auth
+// service, controlling continuous re-assertion of the resources applied
+// on startup (see the --apply-on-startup flag).
+type Reconcile struct {
+	// Period is how often declared resources are compared with the
+	// backend state.
+	Period services.Duration `yaml:"period,omitempty"`
+
+	// DefaultMode is applied to resource kinds without an explicit
+	// mode: "revert" (default) or "alert".
+	DefaultMode string `yaml:"default_mode,omitempty"`
+
+	// Modes optionally overrides the reconcile mode per resource kind,
+	// for example: {role: revert, user: alert}.
+	Modes map[string]string `yaml:"modes,omitempty"`
+}
+
+// NodeWebhook is configuration for delivery of node lifecycle
+// notifications to an external webhook endpoint.
+type NodeWebhook struct {
+	// URL is the webhook endpoint notifications are posted to.
+	URL string `yaml:"url"`
+
+	// Events is an optional list of event names to deliver
+	// (node.joined, node.expired, node.deleted). All events are
+	// delivered when empty.
+	Events []string `yaml:"events,omitempty"`
+}
+
+// AuditRedaction holds configuration values under "audit_redaction" key,
+// controlling which fields are redacted from audit events exported to
+// external sinks.
+type AuditRedaction struct {
+	// HashUsernames replaces user names and OS logins with a sha256 hash.
+	HashUsernames bool `yaml:"hash_usernames,omitempty"`
+
+	// DropCommandArguments strips arguments from executed commands.
+	DropCommandArguments bool `yaml:"drop_command_arguments,omitempty"`
+
+	// MaskAddresses masks the host part of client and server addresses.
+	MaskAddresses bool `yaml:"mask_addresses,omitempty"`
+}
+
+// HostUsers is configuration for automatic creation of local Unix accounts.
+type HostUsers struct {
+	// Enabled controls if local Unix accounts will be created or not.
+	Enabled string `yaml:"enabled"`
+
+	// Groups is a list of additional groups the created account will be
+	// added to.
+	Groups []string `yaml:"groups,omitempty"`
+
+	// Shell is the login shell assigned to the created account.
+	Shell string `yaml:"shell,omitempty"`
+
+	// CreateHome controls if a home directory will be created for the
+	// account.
+	CreateHome bool `yaml:"create_home,omitempty"`
+}
+
+// Parse returns a parsed srv.HostUsersConfig.
+func (h *HostUsers) Parse() *srv.HostUsersConfig {
+	enabled, _ := utils.ParseBool(h.Enabled)
+	return &srv.HostUsersConfig{
+		Enabled:    enabled,
+		Groups:     h.Groups,
+		Shell:      h.Shell,
+		CreateHome: h.CreateHome,
 	}
 }
 
@@ -826,6 +1124,37 @@ type Proxy struct {
 	// endpoint. The hosts in PublicAddr are included in the list of host
 	// principals on the SSH certificate.
 	TunnelPublicAddr utils.Strings `yaml:"tunnel_public_addr,omitempty"`
+
+	// Templates map host name patterns requested by clients to label
+	// queries so nodes can be dialed by alias.
+	Templates []ProxyTemplate `yaml:"templates,omitempty"`
+
+	// HostResolver configures resolution of requested host names via an
+	// external inventory, e.g. a CMDB.
+	HostResolver *HostResolver `yaml:"host_resolver,omitempty"`
+}
+
+// HostResolver configures lookups of requested host names against an
+// external inventory endpoint.
+type HostResolver struct {
+	// URL is the HTTP endpoint queried with the requested host name
+	URL string `yaml:"url"`
+	// Timeout bounds a single lookup
+	Timeout services.Duration `yaml:"timeout,omitempty"`
+}
+
+// ProxyTemplate matches requested host names against a pattern and maps
+// them to a label query picking the target node.
+type ProxyTemplate struct {
+	// Pattern is a regular expression matched against the requested host
+	// name, e.g. "^db-(.*)$".
+	Pattern string `yaml:"pattern"`
+	// Labels is the label query a node has to match. Values may reference
+	// capture groups of the pattern, e.g. "$1".
+	Labels map[string]string `yaml:"labels,omitempty"`
+	// Policy picks a node when several match the label query: "random"
+	// (the default), "first", "round-robin", "least-sessions" or "newest".
+	Policy string `yaml:"policy,omitempty"`
 }
 
 // KubeProxy is a `kubernetes` section in `proxy_service`.
@@ -1032,6 +1361,10 @@ func (o *OIDCConnector) Parse() (services.OIDCConnector, error) {
 type U2F struct {
 	AppID  string   `yaml:"app_id,omitempty"`
 	Facets []string `yaml:"facets,omitempty"`
+
+	// EnabledFlag is deprecated and ignored, accepted so legacy
+	// configuration files keep parsing under strict decoding
+	EnabledFlag string `yaml:"enabled,omitempty"`
 }
 
 // Parse parses values in the U2F configuration section and validates its content.
@@ -79,6 +79,11 @@ const (
 	// RecentCacheTTL is a default cache TTL for recently accessed items
 	RecentCacheTTL = 2 * time.Second
 
+	// AuthorizationGracePeriod is a default period during which nodes keep
+	// authorizing already-seen users from cached role data when the auth
+	// server is unreachable
+	AuthorizationGracePeriod = 5 * time.Minute
+
 	// InviteTokenTTL sets the lifespan of tokens used for adding nodes and users
 	// to a cluster
 	InviteTokenTTL = 15 * time.Minute
@@ -120,6 +125,10 @@ const (
 	// ShutdownPollPeriod is a polling period for graceful shutdowns of SSH servers
 	ShutdownPollPeriod = 500 * time.Millisecond
 
+	// ClockDriftThreshold is the amount of wall clock drift between a node
+	// and the auth server that triggers a warning.
+	ClockDriftThreshold = time.Minute
+
 	// ReadHeadersTimeout is a default TCP timeout when we wait
 	// for the response headers to arrive
 	ReadHeadersTimeout = time.Second
@@ -284,6 +293,18 @@ var (
 	// their stored list of auth servers
 	AuthServersRefreshPeriod = 30 * time.Second
 
+	// CertExpiryCheckPeriod is how often the auth server inspects stored
+	// certificates for approaching expiry
+	CertExpiryCheckPeriod = time.Hour
+
+	// CertExpiryWarningThreshold is how far ahead of a certificate expiry
+	// warnings are raised
+	CertExpiryWarningThreshold = 30 * 24 * time.Hour
+
+	// LockPollPeriod is how often established connections check the
+	// local cache for locks that terminate matching sessions
+	LockPollPeriod = 2 * time.Second
+
 	// TerminalResizePeriod is how long tsh waits before updating the size of the
 	// terminal window.
 	TerminalResizePeriod = 2 * time.Second
@@ -320,6 +341,14 @@ var (
 	// HeartbeatCheckPeriod is a period between heartbeat status checks
 	HeartbeatCheckPeriod = 5 * time.Second
 
+	// HeartbeatFanInFlushPeriod is how often the heartbeat fan-in
+	// forwards buffered node heartbeats to the auth server
+	HeartbeatFanInFlushPeriod = 5 * time.Second
+
+	// HeartbeatFanInBatchSize is the number of buffered node heartbeats
+	// that triggers an early fan-in flush
+	HeartbeatFanInBatchSize = 1000
+
 	// LowResPollingPeriod is a default low resolution polling period
 	LowResPollingPeriod = 600 * time.Second
 
@@ -333,6 +362,19 @@ var (
 	// timeout of 350 seconds)
 	KeepAliveInterval = 5 * time.Minute
 
+	// ClientKeepAliveInterval is the interval at which tsh sends keep-alive
+	// requests to the node during an interactive session to detect dead
+	// connections
+	ClientKeepAliveInterval = 30 * time.Second
+
+	// ReattachGracePeriod is for how long tsh keeps trying to re-attach to
+	// an interactive session after the connection to the server was lost
+	ReattachGracePeriod = 1 * time.Minute
+
+	// ReattachRetryInterval is how often tsh retries to re-attach to an
+	// interrupted interactive session
+	ReattachRetryInterval = 2 * time.Second
+
 	// KeepAliveCountMax is the number of keep-alive messages that can be sent
 	// without receiving a response from the client before the client is
 	// disconnected. The max count mirrors ClientAliveCountMax of sshd.
@@ -390,6 +432,14 @@ const (
 	// LimiterMaxConcurrentSignatures limits maximum number of concurrently
 	// generated signatures by the auth server
 	LimiterMaxConcurrentSignatures = 10
+
+	// LimiterClusterLoginPeriod is the counting window of the cluster-wide
+	// login rate limiter
+	LimiterClusterLoginPeriod = time.Minute
+
+	// LimiterClusterLoginMax is the number of login attempts per client
+	// allowed cluster-wide within one counting window
+	LimiterClusterLoginMax = 60
 )
 
 const (
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventbus implements a typed in-process publish/subscribe bus, so
+// components like presence, sessions, audit and tunnels can react to each
+// other's events without calling each other directly.
+package eventbus
+
+import (
+	"errors"
+	"sync"
+)
+
+// Topics published by teleport components. Payload types are owned by the
+// publishing component, subscribers type-assert on the Payload field.
+const (
+	// TopicPresence carries server presence changes (heartbeats, expiry).
+	TopicPresence = "presence"
+	// TopicSessions carries session lifecycle events (created, ended).
+	TopicSessions = "sessions"
+	// TopicAudit carries emitted audit events.
+	TopicAudit = "audit"
+	// TopicTunnels carries reverse tunnel connectivity changes.
+	TopicTunnels = "tunnels"
+)
+
+// defaultQueueSize bounds a subscriber queue unless it requests otherwise.
+const defaultQueueSize = 64
+
+// ErrSlowConsumer is reported by subscribers that were dropped from the bus
+// because their queue filled up. Publishers never block: a subscriber that
+// can not keep up is closed instead.
+var ErrSlowConsumer = errors.New("subscriber is too slow, events were dropped")
+
+// ErrBusClosed is reported by subscribers when the bus itself was closed.
+var ErrBusClosed = errors.New("event bus is closed")
+
+// Event is a single message published on the bus.
+type Event struct {
+	// Topic is the topic the event was published on.
+	Topic string
+	// Payload is the typed event payload.
+	Payload interface{}
+}
+
+// Bus fans published events out to all subscribers of the event's topic.
+// A zero queue size on Subscribe means the default. Bus is safe for
+// concurrent use.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[string]map[uint64]*Subscriber
+	nextID      uint64
+	closed      bool
+}
+
+// New creates a new event bus.
+func New() *Bus {
+	return &Bus{
+		subscribers: make(map[string]map[uint64]*Subscriber),
+	}
+}
+
+// Subscribe registers a new subscriber for events on the given topics.
+// queueSize bounds the number of undelivered events buffered for the
+// subscriber, zero means the default.
+func (b *Bus) Subscribe(queueSize int, topics ...string) (*Subscriber, error) {
+	if len(topics) == 0 {
+		return nil, errors.New("missing topics to subscribe to")
+	}
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+	b.nextID++
+	s := &Subscriber{
+		bus:     b,
+		id:      b.nextID,
+		topics:  topics,
+		eventsC: make(chan Event, queueSize),
+		doneC:   make(chan struct{}),
+	}
+	for _, topic := range topics {
+		if b.subscribers[topic] == nil {
+			b.subscribers[topic] = make(map[uint64]*Subscriber)
+		}
+		b.subscribers[topic][s.id] = s
+	}
+	return s, nil
+}
+
+// Publish delivers the payload to every subscriber of the topic. Publish
+// never blocks: subscribers whose queues are full are dropped from the bus
+// and closed with ErrSlowConsumer.
+func (b *Bus) Publish(topic string, payload interface{}) {
+	event := Event{Topic: topic, Payload: payload}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subscribers[topic] {
+		select {
+		case s.eventsC <- event:
+		default:
+			b.removeSubscriber(s, ErrSlowConsumer)
+		}
+	}
+}
+
+// Close closes the bus and all attached subscribers.
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for _, subscribers := range b.subscribers {
+		for _, s := range subscribers {
+			b.removeSubscriber(s, ErrBusClosed)
+		}
+	}
+	return nil
+}
+
+// removeSubscriber detaches the subscriber from all its topics and closes
+// it with the given error. The bus lock must be held.
+func (b *Bus) removeSubscriber(s *Subscriber, err error) {
+	for _, topic := range s.topics {
+		delete(b.subscribers[topic], s.id)
+		if len(b.subscribers[topic]) == 0 {
+			delete(b.subscribers, topic)
+		}
+	}
+	s.closeWithError(err)
+}
+
+// Subscriber receives events published on the topics it subscribed to.
+type Subscriber struct {
+	bus     *Bus
+	id      uint64
+	topics  []string
+	eventsC chan Event
+	doneC   chan struct{}
+
+	errMu     sync.Mutex
+	err       error
+	closeOnce sync.Once
+}
+
+// Events returns the channel with events. Buffered events can still be
+// read after the subscriber has been closed.
+func (s *Subscriber) Events() <-chan Event {
+	return s.eventsC
+}
+
+// Done returns the channel signalling the closure.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.doneC
+}
+
+// Close detaches the subscriber from the bus and releases all associated
+// resources.
+func (s *Subscriber) Close() error {
+	s.bus.mu.Lock()
+	s.bus.removeSubscriber(s, nil)
+	s.bus.mu.Unlock()
+	return nil
+}
+
+// Error returns the error the subscriber was closed with, if any.
+// ErrSlowConsumer means the subscriber fell behind and events were lost.
+func (s *Subscriber) Error() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+func (s *Subscriber) closeWithError(err error) {
+	s.closeOnce.Do(func() {
+		s.errMu.Lock()
+		s.err = err
+		s.errMu.Unlock()
+		close(s.doneC)
+	})
+}
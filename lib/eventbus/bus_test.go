@@ -0,0 +1,121 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbus
+
+import (
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestEventBus(t *testing.T) { check.TestingT(t) }
+
+type BusSuite struct{}
+
+var _ = check.Suite(&BusSuite{})
+
+func (s *BusSuite) TestPublishSubscribe(c *check.C) {
+	bus := New()
+	defer bus.Close()
+
+	sessions, err := bus.Subscribe(0, TopicSessions)
+	c.Assert(err, check.IsNil)
+	both, err := bus.Subscribe(0, TopicSessions, TopicTunnels)
+	c.Assert(err, check.IsNil)
+
+	bus.Publish(TopicSessions, "session-started")
+	bus.Publish(TopicTunnels, "tunnel-up")
+	// nobody subscribes to audit, the event is dropped
+	bus.Publish(TopicAudit, "audit-event")
+
+	event := <-sessions.Events()
+	c.Assert(event.Topic, check.Equals, TopicSessions)
+	c.Assert(event.Payload, check.Equals, "session-started")
+
+	event = <-both.Events()
+	c.Assert(event.Topic, check.Equals, TopicSessions)
+	event = <-both.Events()
+	c.Assert(event.Topic, check.Equals, TopicTunnels)
+	c.Assert(event.Payload, check.Equals, "tunnel-up")
+
+	select {
+	case event = <-sessions.Events():
+		c.Fatalf("unexpected event: %v", event)
+	default:
+	}
+}
+
+func (s *BusSuite) TestSlowConsumer(c *check.C) {
+	bus := New()
+	defer bus.Close()
+
+	slow, err := bus.Subscribe(1, TopicPresence)
+	c.Assert(err, check.IsNil)
+
+	// The first event fills the queue, the second one overflows it and
+	// drops the subscriber without blocking the publisher.
+	bus.Publish(TopicPresence, 1)
+	bus.Publish(TopicPresence, 2)
+
+	<-slow.Done()
+	c.Assert(slow.Error(), check.Equals, ErrSlowConsumer)
+
+	// The buffered event is still readable after closure.
+	event := <-slow.Events()
+	c.Assert(event.Payload, check.Equals, 1)
+
+	// The dropped subscriber no longer receives events.
+	bus.Publish(TopicPresence, 3)
+	select {
+	case event = <-slow.Events():
+		c.Fatalf("unexpected event: %v", event)
+	default:
+	}
+}
+
+func (s *BusSuite) TestClose(c *check.C) {
+	bus := New()
+
+	sub, err := bus.Subscribe(0, TopicAudit)
+	c.Assert(err, check.IsNil)
+
+	c.Assert(bus.Close(), check.IsNil)
+	<-sub.Done()
+	c.Assert(sub.Error(), check.Equals, ErrBusClosed)
+
+	_, err = bus.Subscribe(0, TopicAudit)
+	c.Assert(err, check.Equals, ErrBusClosed)
+}
+
+func (s *BusSuite) TestSubscriberClose(c *check.C) {
+	bus := New()
+	defer bus.Close()
+
+	sub, err := bus.Subscribe(0, TopicSessions)
+	c.Assert(err, check.IsNil)
+	c.Assert(sub.Close(), check.IsNil)
+
+	<-sub.Done()
+	c.Assert(sub.Error(), check.IsNil)
+
+	bus.Publish(TopicSessions, "after-close")
+	select {
+	case event := <-sub.Events():
+		c.Fatalf("unexpected event: %v", event)
+	default:
+	}
+}
@@ -148,6 +148,10 @@ const (
 	LoginMethodLocal = "local"
 	// LoginMethodClientCert represents login with client certificate
 	LoginMethodClientCert = "client.cert"
+
+	// UserLoginNewDeviceEvent indicates that a user logged in with a client
+	// key fingerprint or from a source network not seen for them before
+	UserLoginNewDeviceEvent = "user.login.new_device"
 	// LoginMethodOIDC represents login with OIDC
 	LoginMethodOIDC = "oidc"
 	// LoginMethodSAML represents login with SAML
@@ -308,6 +312,29 @@ const (
 	// RoleDeletedEvent fires when role is deleted.
 	RoleDeletedEvent = "role.deleted"
 
+	// ClusterConfigUpdateEvent fires when the cluster configuration is updated.
+	ClusterConfigUpdateEvent = "cluster_config.update"
+	// AuthPreferenceUpdateEvent fires when the cluster authentication
+	// preference is updated.
+	AuthPreferenceUpdateEvent = "auth_preference.update"
+
+	// ClockDriftEvent fires when the wall clock of a node drifts too far
+	// from the clock of the auth server.
+	ClockDriftEvent = "node.clock_drift"
+
+	// CertificateReissueEvent fires when a node detects that its addresses
+	// or principals have changed and re-issues its host certificate.
+	CertificateReissueEvent = "cert.reissue"
+
+	// CertificateExpiringEvent fires when a stored certificate is
+	// approaching its expiry date.
+	CertificateExpiringEvent = "cert.expiring"
+
+	// LockCreatedEvent fires when a cluster-wide connection lock is created.
+	LockCreatedEvent = "lock.created"
+	// LockDeletedEvent fires when a cluster-wide connection lock is deleted.
+	LockDeletedEvent = "lock.deleted"
+
 	// TrustedClusterCreateEvent is the event for creating a trusted cluster.
 	TrustedClusterCreateEvent = "trusted_cluster.create"
 	// TrustedClusterDeleteEvent is the event for removing a trusted cluster.
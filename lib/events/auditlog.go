@@ -62,6 +62,9 @@ const (
 	// LogfileExt defines the ending of the daily event log file
 	LogfileExt = ".log"
 
+	// GzipExt defines the ending of gzipped rotated event log files
+	GzipExt = ".gz"
+
 	// SymlinkFilename is a name of the symlink pointing to the last
 	// current log file
 	SymlinkFilename = "events.log"
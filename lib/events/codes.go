@@ -211,6 +211,45 @@ var (
 		Name: RoleDeletedEvent,
 		Code: RoleDeletedCode,
 	}
+	// ClusterConfigUpdateE is emitted when the cluster configuration is updated.
+	ClusterConfigUpdateE = Event{
+		Name: ClusterConfigUpdateEvent,
+		Code: ClusterConfigUpdateCode,
+	}
+	// AuthPreferenceUpdateE is emitted when the cluster authentication
+	// preference is updated.
+	AuthPreferenceUpdateE = Event{
+		Name: AuthPreferenceUpdateEvent,
+		Code: AuthPreferenceUpdateCode,
+	}
+	// ClockDriftE is emitted when the wall clock of a node drifts too far
+	// from the clock of the auth server.
+	ClockDriftE = Event{
+		Name: ClockDriftEvent,
+		Code: ClockDriftCode,
+	}
+	// CertificateReissueE is emitted when a node re-issues its host
+	// certificate because its addresses or principals have changed.
+	CertificateReissueE = Event{
+		Name: CertificateReissueEvent,
+		Code: CertificateReissueCode,
+	}
+	// CertificateExpiringE is emitted when a stored certificate is
+	// approaching its expiry date.
+	CertificateExpiringE = Event{
+		Name: CertificateExpiringEvent,
+		Code: CertificateExpiringCode,
+	}
+	// LockCreatedE is emitted when a cluster-wide connection lock is created.
+	LockCreatedE = Event{
+		Name: LockCreatedEvent,
+		Code: LockCreatedCode,
+	}
+	// LockDeletedE is emitted when a cluster-wide connection lock is deleted.
+	LockDeletedE = Event{
+		Name: LockDeletedEvent,
+		Code: LockDeletedCode,
+	}
 	// TrustedClusterCreateE is emitted when a trusted cluster relationship is created.
 	TrustedClusterCreateE = Event{
 		Name: TrustedClusterCreateEvent,
@@ -290,6 +329,9 @@ const (
 	UserDeleteCode = "T1004I"
 	// UserPasswordChangeCode is an event code for when user changes their own password.
 	UserPasswordChangeCode = "T1005I"
+	// UserLoginNewDeviceCode is an event code for when a user logs in with a
+	// client key fingerprint or from a source network not seen before.
+	UserLoginNewDeviceCode = "T1007W"
 
 	// SessionRejectedCode is an event code for when a user's attempt to create an
 	// session/connection has been rejected.
@@ -343,6 +385,9 @@ const (
 	// Note: some requests (like exec into a pod) use other codes (like
 	// ExecCode).
 	KubeRequestCode = "T3009I"
+	// AuthAttemptCachedCode is emitted when a node authorizes a user from
+	// cached role data because the auth server is unreachable.
+	AuthAttemptCachedCode = "T3010W"
 
 	// SessionCommandCode is a session command code.
 	SessionCommandCode = "T4000I"
@@ -386,4 +431,20 @@ const (
 	RoleCreatedCode = "T9000I"
 	// RoleDeletedCode is the role deleted event code.
 	RoleDeletedCode = "T9001I"
+
+	// ClusterConfigUpdateCode is the cluster configuration update event code.
+	ClusterConfigUpdateCode = "T9100I"
+	// ClockDriftCode is the node clock drift warning event code.
+	ClockDriftCode = "T9102W"
+	// CertificateReissueCode is the host certificate re-issuance event code.
+	CertificateReissueCode = "T9103I"
+	// CertificateExpiringCode is the certificate expiry warning event code.
+	CertificateExpiringCode = "T9104W"
+	// LockCreatedCode is the lock created event code.
+	LockCreatedCode = "T9105I"
+	// LockDeletedCode is the lock deleted event code.
+	LockDeletedCode = "T9106I"
+	// AuthPreferenceUpdateCode is the cluster authentication preference
+	// update event code.
+	AuthPreferenceUpdateCode = "T9101I"
 )
@@ -0,0 +1,234 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/lib/secret"
+	"github.com/gravitational/teleport/lib/session"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
+)
+
+// RecordingKey is a cluster-level key used to wrap the per-session data
+// keys that encrypt session recordings at rest. The most recently created
+// key wraps new recordings, older keys are kept around to open recordings
+// sealed before a rotation.
+type RecordingKey struct {
+	// ID identifies the key in recording envelopes.
+	ID string `json:"id"`
+	// Key is the symmetric key material.
+	Key secret.Key `json:"key"`
+	// Created is the time the key was generated.
+	Created time.Time `json:"created"`
+}
+
+// NewRecordingKey generates a new cluster recording key.
+func NewRecordingKey() (*RecordingKey, error) {
+	key, err := secret.NewKey()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &RecordingKey{
+		ID:      uuid.New(),
+		Key:     key,
+		Created: time.Now().UTC(),
+	}, nil
+}
+
+// recordingEnvelope is the at-rest format of an encrypted session
+// recording: the per-session data key wrapped by a cluster recording key,
+// the metadata needed to find that key, and the sealed recording itself.
+type recordingEnvelope struct {
+	// Version is the envelope format version.
+	Version int `json:"version"`
+	// KeyID is the ID of the cluster recording key wrapping SessionKey.
+	KeyID string `json:"key_id"`
+	// SessionKey is the per-session data key sealed by the cluster
+	// recording key.
+	SessionKey []byte `json:"session_key"`
+	// Data is the recording sealed by the session key.
+	Data []byte `json:"data"`
+}
+
+// envelopeVersion is the current recording envelope format version.
+const envelopeVersion = 1
+
+// RecordingEncryptor seals and opens session recordings using per-session
+// data keys wrapped by a rotatable cluster recording key.
+type RecordingEncryptor struct {
+	mu sync.RWMutex
+	// keys holds all known cluster recording keys by ID.
+	keys map[string]*RecordingKey
+	// current is the most recently created key, used to wrap new
+	// recordings.
+	current *RecordingKey
+}
+
+// NewRecordingEncryptor creates an encryptor from the set of cluster
+// recording keys, at least one key is required.
+func NewRecordingEncryptor(keys ...*RecordingKey) (*RecordingEncryptor, error) {
+	if len(keys) == 0 {
+		return nil, trace.BadParameter("missing recording keys")
+	}
+	encryptor := &RecordingEncryptor{
+		keys: make(map[string]*RecordingKey),
+	}
+	for _, key := range keys {
+		encryptor.AddKey(key)
+	}
+	return encryptor, nil
+}
+
+// AddKey adds a cluster recording key to the ring. If the key is the most
+// recently created one it wraps new recordings from now on.
+func (e *RecordingEncryptor) AddKey(key *RecordingKey) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys[key.ID] = key
+	if e.current == nil || key.Created.After(e.current.Created) {
+		e.current = key
+	}
+}
+
+// CurrentKeyID returns the ID of the key wrapping new recordings.
+func (e *RecordingEncryptor) CurrentKeyID() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.current.ID
+}
+
+// Seal encrypts a session recording under a fresh per-session data key and
+// wraps that key with the current cluster recording key.
+func (e *RecordingEncryptor) Seal(data []byte) ([]byte, error) {
+	sessionKey, err := secret.NewKey()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sealed, err := sessionKey.Seal(data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	e.mu.RLock()
+	current := e.current
+	e.mu.RUnlock()
+	wrapped, err := current.Key.Seal([]byte(sessionKey))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return utils.FastMarshal(recordingEnvelope{
+		Version:    envelopeVersion,
+		KeyID:      current.ID,
+		SessionKey: wrapped,
+		Data:       sealed,
+	})
+}
+
+// Open decrypts a sealed session recording, unwrapping its data key with
+// the cluster recording key recorded in the envelope.
+func (e *RecordingEncryptor) Open(data []byte) ([]byte, error) {
+	var envelope recordingEnvelope
+	if err := utils.FastUnmarshal(data, &envelope); err != nil {
+		return nil, trace.BadParameter("failed to parse recording envelope: %v", err)
+	}
+	e.mu.RLock()
+	key, ok := e.keys[envelope.KeyID]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, trace.NotFound("cluster recording key %v not found", envelope.KeyID)
+	}
+	sessionKey, err := key.Key.Open(envelope.SessionKey)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	plaintext, err := secret.Key(sessionKey).Open(envelope.Data)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return plaintext, nil
+}
+
+// EncryptedHandler wraps an upload handler, sealing session recordings
+// before they are stored and opening them on download. Multipart
+// proto-stream operations are passed through unchanged, external stores
+// used for them are expected to provide their own at-rest encryption.
+type EncryptedHandler struct {
+	MultipartHandler
+	encryptor *RecordingEncryptor
+}
+
+// NewEncryptedHandler wraps the handler with recording encryption.
+func NewEncryptedHandler(handler MultipartHandler, encryptor *RecordingEncryptor) (*EncryptedHandler, error) {
+	if handler == nil {
+		return nil, trace.BadParameter("missing parameter handler")
+	}
+	if encryptor == nil {
+		return nil, trace.BadParameter("missing parameter encryptor")
+	}
+	return &EncryptedHandler{
+		MultipartHandler: handler,
+		encryptor:        encryptor,
+	}, nil
+}
+
+// Upload seals the session recording and uploads the envelope.
+func (h *EncryptedHandler) Upload(ctx context.Context, sessionID session.ID, reader io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	sealed, err := h.encryptor.Seal(data)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return h.MultipartHandler.Upload(ctx, sessionID, bytes.NewReader(sealed))
+}
+
+// Download fetches the sealed recording and writes the opened plaintext to
+// the writer.
+func (h *EncryptedHandler) Download(ctx context.Context, sessionID session.ID, writer io.WriterAt) error {
+	tmp, err := ioutil.TempFile("", string(sessionID))
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err := h.MultipartHandler.Download(ctx, sessionID, tmp); err != nil {
+		return trace.Wrap(err)
+	}
+	sealed, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	data, err := h.encryptor.Open(sealed)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if _, err := writer.WriteAt(data, 0); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecordingEncryptor tests the seal/open round trip of session
+// recording encryption.
+func TestRecordingEncryptor(t *testing.T) {
+	key, err := NewRecordingKey()
+	require.NoError(t, err)
+
+	encryptor, err := NewRecordingEncryptor(key)
+	require.NoError(t, err)
+
+	plaintext := []byte("session recording data")
+	sealed, err := encryptor.Seal(plaintext)
+	require.NoError(t, err)
+	require.NotEqual(t, plaintext, sealed)
+
+	out, err := encryptor.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, out)
+
+	// two seals of the same data use different session keys
+	sealed2, err := encryptor.Seal(plaintext)
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, sealed2)
+}
+
+// TestRecordingKeyRotation makes sure recordings sealed before a key
+// rotation stay playable and new recordings use the new key.
+func TestRecordingKeyRotation(t *testing.T) {
+	oldKey, err := NewRecordingKey()
+	require.NoError(t, err)
+	oldKey.Created = time.Now().UTC().Add(-time.Hour)
+
+	encryptor, err := NewRecordingEncryptor(oldKey)
+	require.NoError(t, err)
+
+	plaintext := []byte("recorded before rotation")
+	sealed, err := encryptor.Seal(plaintext)
+	require.NoError(t, err)
+
+	newKey, err := NewRecordingKey()
+	require.NoError(t, err)
+	encryptor.AddKey(newKey)
+	require.Equal(t, newKey.ID, encryptor.CurrentKeyID())
+
+	// the old recording opens with the retained old key
+	out, err := encryptor.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, out)
+
+	// a new recording does not open without the new key
+	sealed2, err := encryptor.Seal([]byte("recorded after rotation"))
+	require.NoError(t, err)
+	oldOnly, err := NewRecordingEncryptor(oldKey)
+	require.NoError(t, err)
+	_, err = oldOnly.Open(sealed2)
+	require.True(t, trace.IsNotFound(err))
+}
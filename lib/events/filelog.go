@@ -18,9 +18,11 @@ package events
 
 import (
 	"bufio"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -43,6 +45,16 @@ import (
 type FileLogConfig struct {
 	// RotationPeriod defines how frequently to rotate the log file
 	RotationPeriod time.Duration
+	// MaxFileSize is an optional size limit in bytes, when the current
+	// log file would grow past it, the file is rotated ahead of the
+	// rotation period, 0 disables size-based rotation
+	MaxFileSize int64
+	// CompressRotated enables gzip compression of rotated log files,
+	// searches read compressed and plain files transparently
+	CompressRotated bool
+	// SyncWrites forces an fsync of the log file after every event,
+	// trading throughput for durability
+	SyncWrites bool
 	// Dir is a directory where logger puts the files
 	Dir string
 	// SymlinkDir is a directory for symlink pointer to the current log
@@ -108,40 +120,29 @@ type FileLog struct {
 	// file is the current global event log file. As the time goes
 	// on, it will be replaced by a new file every day
 	file *os.File
+	// fileName is the path of the currently opened file
+	fileName string
 	// fileTime is a rounded (to a day, by default) timestamp of the
 	// currently opened file
 	fileTime time.Time
+	// fileSize is the current size of the opened file in bytes,
+	// used for size-based rotation
+	fileSize int64
 }
 
 // EmitAuditEvent adds a new event to the log.
 func (l *FileLog) EmitAuditEvent(ctx context.Context, event AuditEvent) error {
-	// see if the log needs to be rotated
-	err := l.rotateLog()
-	if err != nil {
-		log.Error(err)
-	}
 	// line is the text to be logged
 	line, err := utils.FastMarshal(event)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	if l.file == nil {
-		return trace.NotFound(
-			"file log is not found due to permission or disk issue")
-	}
-	// log it to the main log file:
-	_, err = fmt.Fprintln(l.file, string(line))
-	return trace.ConvertSystemError(err)
+	return trace.Wrap(l.write(line))
 }
 
 // EmitAuditEventLegacy adds a new event to the log. Part of auth.IFileLog interface.
 func (l *FileLog) EmitAuditEventLegacy(event Event, fields EventFields) error {
-	// see if the log needs to be rotated
-	err := l.rotateLog()
-	if err != nil {
-		log.Error(err)
-	}
-	err = UpdateEventFields(event, fields, l.Clock, l.UIDGenerator)
+	err := UpdateEventFields(event, fields, l.Clock, l.UIDGenerator)
 	if err != nil {
 		log.Error(err)
 	}
@@ -151,8 +152,33 @@ func (l *FileLog) EmitAuditEventLegacy(event Event, fields EventFields) error {
 		return trace.Wrap(err)
 	}
 	// log it to the main log file:
-	if l.file != nil {
-		fmt.Fprintln(l.file, string(line))
+	if err := l.write(line); err != nil {
+		log.Error(err)
+	}
+	return nil
+}
+
+// write appends a single line to the current log file, rotating it first
+// if needed. It is safe for concurrent use by multiple goroutines.
+func (l *FileLog) write(line []byte) error {
+	l.Lock()
+	defer l.Unlock()
+	// see if the log needs to be rotated, account for the pending line
+	// and the trailing newline when checking the size limit
+	if err := l.rotateLocked(int64(len(line)) + 1); err != nil {
+		log.Error(err)
+	}
+	if l.file == nil {
+		return trace.NotFound(
+			"file log is not found due to permission or disk issue")
+	}
+	n, err := fmt.Fprintln(l.file, string(line))
+	l.fileSize += int64(n)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	if l.SyncWrites {
+		return trace.ConvertSystemError(l.file.Sync())
 	}
 	return nil
 }
@@ -308,12 +334,10 @@ func (l *FileLog) GetSessionEvents(namespace string, sid session.ID, after int,
 	return nil, trace.NotImplemented("not implemented")
 }
 
-// rotateLog checks if the current log file is older than a given duration,
-// and if it is, closes it and opens a new one.
-func (l *FileLog) rotateLog() (err error) {
-	l.Lock()
-	defer l.Unlock()
-
+// rotateLocked checks if the current log file is older than the rotation
+// period or would grow past the size limit with the pending write, and if
+// so, closes it and opens a new one. The caller must hold the lock.
+func (l *FileLog) rotateLocked(pendingBytes int64) (err error) {
 	// determine the timestamp for the current log file
 	fileTime := l.Clock.Now().In(time.UTC)
 
@@ -323,13 +347,19 @@ func (l *FileLog) rotateLog() (err error) {
 	logFilename := filepath.Join(l.Dir,
 		fileTime.Format(defaults.AuditLogTimeFormat)+LogfileExt)
 
-	openLogFile := func() error {
-		l.file, err = os.OpenFile(logFilename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	openLogFile := func(filename string) error {
+		l.file, err = os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
 		if err != nil {
 			log.Error(err)
+			return trace.Wrap(err)
 		}
+		l.fileName = filename
 		l.fileTime = fileTime
-		return trace.Wrap(err)
+		l.fileSize = 0
+		if fi, err := l.file.Stat(); err == nil {
+			l.fileSize = fi.Size()
+		}
+		return nil
 	}
 
 	linkFilename := filepath.Join(l.SymlinkDir, SymlinkFilename)
@@ -340,12 +370,12 @@ func (l *FileLog) rotateLog() (err error) {
 				return trace.Wrap(err)
 			}
 		}
-		return trace.ConvertSystemError(os.Symlink(logFilename, linkFilename))
+		return trace.ConvertSystemError(os.Symlink(l.fileName, linkFilename))
 	}
 
 	// need to create a log file?
 	if l.file == nil {
-		if err := openLogFile(); err != nil {
+		if err := openLogFile(logFilename); err != nil {
 			return trace.Wrap(err)
 		}
 		return trace.Wrap(createSymlink())
@@ -354,7 +384,26 @@ func (l *FileLog) rotateLog() (err error) {
 	// time to advance the logfile?
 	if l.fileTime.Before(fileTime) {
 		l.file.Close()
-		if err := openLogFile(); err != nil {
+		l.compressRotated(l.fileName)
+		if err := openLogFile(logFilename); err != nil {
+			return trace.Wrap(err)
+		}
+		return trace.Wrap(createSymlink())
+	}
+
+	// would the current logfile grow past the size limit?
+	if l.MaxFileSize > 0 && l.fileSize > 0 && l.fileSize+pendingBytes > l.MaxFileSize {
+		// rotated files are named with the rotation timestamp, if the
+		// file rotated less than a second ago let it grow instead of
+		// overwriting it
+		rotatedFilename := filepath.Join(l.Dir,
+			l.Clock.Now().In(time.UTC).Format(defaults.AuditLogTimeFormat)+LogfileExt)
+		if rotatedFilename == l.fileName {
+			return nil
+		}
+		l.file.Close()
+		l.compressRotated(l.fileName)
+		if err := openLogFile(rotatedFilename); err != nil {
 			return trace.Wrap(err)
 		}
 		return trace.Wrap(createSymlink())
@@ -362,6 +411,45 @@ func (l *FileLog) rotateLog() (err error) {
 	return nil
 }
 
+// compressRotated gzips a rotated log file in the background and removes
+// the original, searches read both formats transparently.
+func (l *FileLog) compressRotated(filename string) {
+	if !l.CompressRotated {
+		return
+	}
+	go func() {
+		if err := gzipFile(filename); err != nil {
+			l.Warningf("Failed to compress rotated log file %q: %v.", filename, err)
+		}
+	}()
+}
+
+// gzipFile compresses a file to <filename>.gz and removes the original.
+func gzipFile(filename string) error {
+	source, err := os.Open(filename)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer source.Close()
+	target, err := os.OpenFile(filename+GzipExt, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	writer := gzip.NewWriter(target)
+	if _, err := io.Copy(writer, source); err != nil {
+		target.Close()
+		return trace.ConvertSystemError(err)
+	}
+	if err := writer.Close(); err != nil {
+		target.Close()
+		return trace.Wrap(err)
+	}
+	if err := target.Close(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(os.Remove(filename))
+}
+
 // matchingFiles returns files matching the time restrictions of the query
 // across multiple auth servers, returns a list of file names
 func (l *FileLog) matchingFiles(fromUTC, toUTC time.Time) ([]eventFile, error) {
@@ -390,10 +478,12 @@ func (l *FileLog) matchingFiles(fromUTC, toUTC time.Time) ([]eventFile, error) {
 		}
 		for i := range entries {
 			fi := entries[i]
-			if fi.IsDir() || filepath.Ext(fi.Name()) != LogfileExt {
+			// rotated files may have been compressed in place
+			name := strings.TrimSuffix(fi.Name(), GzipExt)
+			if fi.IsDir() || filepath.Ext(name) != LogfileExt {
 				continue
 			}
-			fd, err := parseFileTime(fi.Name())
+			fd, err := parseFileTime(name)
 			if err != nil {
 				l.Warningf("Failed to parse audit log file %q format: %v", fi.Name(), err)
 				continue
@@ -445,8 +535,19 @@ func (l *FileLog) findInFile(fn string, query url.Values, total *int, limit int)
 	}
 	defer lf.Close()
 
+	// rotated files may have been compressed in place
+	var reader io.Reader = lf
+	if filepath.Ext(fn) == GzipExt {
+		gzReader, err := gzip.NewReader(lf)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		defer gzReader.Close()
+		reader = gzReader
+	}
+
 	// for each line...
-	scanner := bufio.NewScanner(lf)
+	scanner := bufio.NewScanner(reader)
 	for lineNo := 0; scanner.Scan(); lineNo++ {
 		accepted := false
 		// optimization: to avoid parsing JSON unnecessarily, lets see if we
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// RedactionRules configures which fields are redacted from audit events
+// before they are exported to external sinks. The events stored by the
+// primary audit log keep full fidelity.
+type RedactionRules struct {
+	// HashUserNames replaces user names and OS logins with a sha256 hash
+	HashUserNames bool
+	// DropCommandArguments strips arguments from executed commands,
+	// keeping only the command name
+	DropCommandArguments bool
+	// MaskAddresses masks the host part of client and server addresses
+	MaskAddresses bool
+}
+
+// IsEmpty returns true if no redaction rules have been set.
+func (r RedactionRules) IsEmpty() bool {
+	return !r.HashUserNames && !r.DropCommandArguments && !r.MaskAddresses
+}
+
+// NewRedactingLog returns an audit log wrapper that applies the redaction
+// rules to every event before forwarding it to the wrapped log.
+func NewRedactingLog(inner IAuditLog, rules RedactionRules) *RedactingLog {
+	return &RedactingLog{IAuditLog: inner, rules: rules}
+}
+
+// RedactingLog applies redaction rules to audit events on their way
+// to an external sink.
+type RedactingLog struct {
+	IAuditLog
+	rules RedactionRules
+}
+
+// EmitAuditEventLegacy redacts the event fields and forwards them
+// to the wrapped log.
+func (r *RedactingLog) EmitAuditEventLegacy(event Event, fields EventFields) error {
+	return r.IAuditLog.EmitAuditEventLegacy(event, r.redactFields(fields))
+}
+
+// EmitAuditEvent redacts the event and forwards it to the wrapped log.
+func (r *RedactingLog) EmitAuditEvent(ctx context.Context, event AuditEvent) error {
+	emitter, ok := r.IAuditLog.(Emitter)
+	if !ok {
+		return trace.BadParameter("expected emitter, but %T does not emit", r.IAuditLog)
+	}
+	return emitter.EmitAuditEvent(ctx, r.redactEvent(event))
+}
+
+// userRedactor is implemented by events carrying user metadata.
+type userRedactor interface {
+	RedactUser(hash func(string) string)
+}
+
+// commandRedactor is implemented by events carrying executed commands.
+type commandRedactor interface {
+	RedactCommandArguments()
+}
+
+// addressRedactor is implemented by events carrying connection metadata.
+type addressRedactor interface {
+	RedactAddresses(mask func(string) string)
+}
+
+// redactEvent applies the configured rules to the event. Events are
+// redacted in place right before they are handed to the sink, matching
+// how other emitters in this package set missing fields.
+func (r *RedactingLog) redactEvent(event AuditEvent) AuditEvent {
+	if r.rules.HashUserNames {
+		if redactor, ok := event.(userRedactor); ok {
+			redactor.RedactUser(hashString)
+		}
+	}
+	if r.rules.DropCommandArguments {
+		if redactor, ok := event.(commandRedactor); ok {
+			redactor.RedactCommandArguments()
+		}
+	}
+	if r.rules.MaskAddresses {
+		if redactor, ok := event.(addressRedactor); ok {
+			redactor.RedactAddresses(maskAddr)
+		}
+	}
+	return event
+}
+
+// redactFields applies the configured rules to legacy event fields,
+// returning a redacted copy.
+func (r *RedactingLog) redactFields(fields EventFields) EventFields {
+	out := make(EventFields, len(fields))
+	for key, value := range fields {
+		out[key] = value
+	}
+	if r.rules.HashUserNames {
+		for _, key := range []string{EventUser, EventLogin} {
+			if value := out.GetString(key); value != "" {
+				out[key] = hashString(value)
+			}
+		}
+	}
+	if r.rules.DropCommandArguments {
+		if value := out.GetString(ExecEventCommand); value != "" {
+			out[ExecEventCommand] = dropArguments(value)
+		}
+		if _, ok := out[Argv]; ok {
+			out[Argv] = []string{}
+		}
+	}
+	if r.rules.MaskAddresses {
+		for _, key := range []string{LocalAddr, RemoteAddr} {
+			if value := out.GetString(key); value != "" {
+				out[key] = maskAddr(value)
+			}
+		}
+	}
+	return out
+}
+
+// RedactUser replaces the user name and OS login with hashed values.
+func (m *UserMetadata) RedactUser(hash func(string) string) {
+	if m.User != "" {
+		m.User = hash(m.User)
+	}
+	if m.Login != "" {
+		m.Login = hash(m.Login)
+	}
+}
+
+// RedactCommandArguments strips everything but the command name.
+func (m *CommandMetadata) RedactCommandArguments() {
+	m.Command = dropArguments(m.Command)
+}
+
+// RedactCommandArguments strips the argument list of the executed program.
+func (m *SessionCommand) RedactCommandArguments() {
+	m.Argv = nil
+}
+
+// RedactAddresses masks the local and remote addresses.
+func (m *ConnectionMetadata) RedactAddresses(mask func(string) string) {
+	m.LocalAddr = mask(m.LocalAddr)
+	m.RemoteAddr = mask(m.RemoteAddr)
+}
+
+// hashString returns a hex encoded sha256 hash of the input, used to
+// pseudonymize user names in exported events.
+func hashString(in string) string {
+	sum := sha256.Sum256([]byte(in))
+	return hex.EncodeToString(sum[:])
+}
+
+// dropArguments returns the command line with all arguments removed.
+func dropArguments(command string) string {
+	if i := strings.IndexByte(command, ' '); i >= 0 {
+		return command[:i]
+	}
+	return command
+}
+
+// maskAddr masks the host part of a "host:port" address: IPv4 addresses
+// keep the first two octets, IPv6 addresses keep the first 64 bits, other
+// hosts are replaced with a hash.
+func maskAddr(addr string) string {
+	if addr == "" {
+		return addr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, ""
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if v4 := ip.To4(); v4 != nil {
+			host = v4.Mask(net.CIDRMask(16, 32)).String()
+		} else {
+			host = ip.Mask(net.CIDRMask(64, 128)).String()
+		}
+	} else {
+		host = hashString(host)
+	}
+	if port == "" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedactEvent verifies that the redaction rules rewrite user names,
+// command arguments and addresses on proto events.
+func TestRedactEvent(t *testing.T) {
+	log := NewRedactingLog(NewDiscardAuditLog(), RedactionRules{
+		HashUserNames:        true,
+		DropCommandArguments: true,
+		MaskAddresses:        true,
+	})
+
+	event := &Exec{
+		UserMetadata: UserMetadata{
+			User:  "alice",
+			Login: "root",
+		},
+		ConnectionMetadata: ConnectionMetadata{
+			LocalAddr:  "10.1.2.3:3022",
+			RemoteAddr: "192.168.5.6:40000",
+		},
+		CommandMetadata: CommandMetadata{
+			Command: "curl -H 'Authorization: Bearer secret' https://example.com",
+		},
+	}
+	log.redactEvent(event)
+
+	require.NotEqual(t, "alice", event.User)
+	require.NotEqual(t, "root", event.Login)
+	require.Equal(t, hashString("alice"), event.User)
+	require.Equal(t, "curl", event.Command)
+	require.Equal(t, "10.1.0.0:3022", event.LocalAddr)
+	require.Equal(t, "192.168.0.0:40000", event.RemoteAddr)
+
+	command := &SessionCommand{
+		Path: "/usr/bin/curl",
+		Argv: []string{"-H", "Authorization: Bearer secret"},
+	}
+	log.redactEvent(command)
+	require.Empty(t, command.Argv)
+	require.Equal(t, "/usr/bin/curl", command.Path)
+}
+
+// TestRedactFields verifies that legacy event fields are redacted into a
+// copy, leaving the original fields untouched.
+func TestRedactFields(t *testing.T) {
+	log := NewRedactingLog(NewDiscardAuditLog(), RedactionRules{
+		HashUserNames: true,
+		MaskAddresses: true,
+	})
+
+	fields := EventFields{
+		EventUser:  "alice",
+		RemoteAddr: "192.168.5.6:40000",
+	}
+	redacted := log.redactFields(fields)
+	require.Equal(t, hashString("alice"), redacted.GetString(EventUser))
+	require.Equal(t, "192.168.0.0:40000", redacted.GetString(RemoteAddr))
+	// the original fields are left intact
+	require.Equal(t, "alice", fields.GetString(EventUser))
+}
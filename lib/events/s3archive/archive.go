@@ -0,0 +1,564 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package s3archive implements a cold storage tier for audit events.
+// Recent events stay in the local file log for fast search, daily log
+// files older than the retention cutoff are migrated to compressed S3
+// objects with an index, and search results spanning the cutoff are
+// transparently merged from both tiers.
+package s3archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	awssession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultRetentionPeriod is how long events stay in the local
+	// file log before they are migrated to S3
+	defaultRetentionPeriod = 90 * 24 * time.Hour
+	// defaultMigrationPeriod is how frequently the migration
+	// loop scans the local log for files past the cutoff
+	defaultMigrationPeriod = time.Hour
+	// archiveExt is the extension of archived daily log objects
+	archiveExt = ".log.gz"
+	// indexKey is the name of the archive index object
+	indexKey = "index.json"
+)
+
+// Config is the archive log configuration
+type Config struct {
+	// LocalLog is the file log holding the hot tier of events,
+	// daily files older than the retention period are migrated
+	// from it to S3
+	LocalLog *events.FileLog
+	// Bucket is S3 bucket name
+	Bucket string
+	// Region is S3 bucket region
+	Region string
+	// Path is an optional bucket path
+	Path string
+	// Endpoint is an optional third party S3 compatible endpoint
+	Endpoint string
+	// Insecure is an optional switch to opt out of https connections
+	Insecure bool
+	// RetentionPeriod is how long events are kept in the local
+	// file log before migration to S3
+	RetentionPeriod time.Duration
+	// MigrationPeriod is how frequently the archiver checks
+	// for local files past the retention cutoff
+	MigrationPeriod time.Duration
+	// Session is an optional existing AWS client session
+	Session *awssession.Session
+	// Credentials if supplied are used in tests
+	Credentials *credentials.Credentials
+	// Clock is a clock interface, used in tests
+	Clock clockwork.Clock
+}
+
+// SetFromURL sets values on the Config from the supplied URI
+func (cfg *Config) SetFromURL(in *url.URL, inRegion string) error {
+	region := inRegion
+	if uriRegion := in.Query().Get(teleport.Region); uriRegion != "" {
+		region = uriRegion
+	}
+	if endpoint := in.Query().Get(teleport.Endpoint); endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	if val := in.Query().Get(teleport.Insecure); val != "" {
+		insecure, err := strconv.ParseBool(val)
+		if err != nil {
+			return trace.BadParameter("failed to parse URI %q flag %q - %q, supported values are 'true' or 'false'", in.String(), teleport.Insecure, val)
+		}
+		cfg.Insecure = insecure
+	}
+	if val := in.Query().Get("retention_days"); val != "" {
+		days, err := strconv.Atoi(val)
+		if err != nil || days <= 0 {
+			return trace.BadParameter("failed to parse URI %q flag %q - %q, expected a positive number of days", in.String(), "retention_days", val)
+		}
+		cfg.RetentionPeriod = time.Duration(days) * 24 * time.Hour
+	}
+	cfg.Region = region
+	cfg.Bucket = in.Host
+	cfg.Path = in.Path
+	return nil
+}
+
+// CheckAndSetDefaults checks and sets defaults
+func (cfg *Config) CheckAndSetDefaults() error {
+	if cfg.LocalLog == nil {
+		return trace.BadParameter("missing parameter LocalLog")
+	}
+	if cfg.Bucket == "" {
+		return trace.BadParameter("missing parameter Bucket")
+	}
+	if cfg.RetentionPeriod == 0 {
+		cfg.RetentionPeriod = defaultRetentionPeriod
+	}
+	if cfg.MigrationPeriod == 0 {
+		cfg.MigrationPeriod = defaultMigrationPeriod
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	if cfg.Session == nil {
+		// create an AWS session using default SDK behavior, i.e. it will interpret
+		// the environment and ~/.aws directory just like an AWS CLI tool would:
+		sess, err := awssession.NewSessionWithOptions(awssession.Options{
+			SharedConfigState: awssession.SharedConfigEnable,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// override the default environment (region + Host + credentials) with the values
+		// from the YAML file:
+		if cfg.Region != "" {
+			sess.Config.Region = aws.String(cfg.Region)
+		}
+		if cfg.Endpoint != "" {
+			sess.Config.Endpoint = aws.String(cfg.Endpoint)
+			sess.Config.S3ForcePathStyle = aws.Bool(true)
+		}
+		if cfg.Insecure {
+			sess.Config.DisableSSL = aws.Bool(cfg.Insecure)
+		}
+		if cfg.Credentials != nil {
+			sess.Config.Credentials = cfg.Credentials
+		}
+		cfg.Session = sess
+	}
+	return nil
+}
+
+// New returns a new archive log that serves reads and writes from the
+// local file log and migrates daily files past the retention cutoff to S3
+func New(ctx context.Context, cfg Config) (*Log, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	closeCtx, cancel := context.WithCancel(ctx)
+	l := &Log{
+		IAuditLog: cfg.LocalLog,
+		Config:    cfg,
+		Entry: log.WithFields(log.Fields{
+			trace.Component: teleport.Component(teleport.ComponentAuditLog, teleport.SchemeS3),
+		}),
+		uploader:   s3manager.NewUploader(cfg.Session),
+		downloader: s3manager.NewDownloader(cfg.Session),
+		client:     s3.New(cfg.Session),
+		ctx:        closeCtx,
+		cancel:     cancel,
+	}
+	go l.runMigrations()
+	return l, nil
+}
+
+// Log implements events.IAuditLog by delegating writes and recent
+// searches to the local file log and merging in archived events from S3
+// when a search range crosses the retention cutoff
+type Log struct {
+	events.IAuditLog
+	Config
+	*log.Entry
+
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+	client     *s3.S3
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// indexMutex serializes read-modify-write updates of the
+	// archive index object
+	indexMutex sync.Mutex
+}
+
+// archiveIndex lists archived daily objects, it is stored
+// as a JSON object next to the archives
+type archiveIndex struct {
+	// Days is a list of archived days sorted by date
+	Days []archiveEntry `json:"days"`
+}
+
+// archiveEntry describes a single archived daily log file
+type archiveEntry struct {
+	// Date is the UTC timestamp of the day encoded
+	// in the audit log file name format
+	Date string `json:"date"`
+	// Key is the object key of the compressed archive
+	// relative to the configured path
+	Key string `json:"key"`
+	// Size is the compressed size in bytes
+	Size int64 `json:"size"`
+}
+
+// time parses the entry date in the audit log file name format
+func (e archiveEntry) time() (time.Time, error) {
+	return time.Parse(defaults.AuditLogTimeFormat, e.Date)
+}
+
+// cutoff returns the point in time separating the hot and cold tiers,
+// events before it are served from S3
+func (l *Log) cutoff() time.Time {
+	return l.Clock.Now().UTC().Add(-l.RetentionPeriod)
+}
+
+// SearchEvents finds events, transparently merging archived events from
+// S3 when the query range extends past the retention cutoff
+func (l *Log) SearchEvents(fromUTC, toUTC time.Time, query string, limit int) ([]events.EventFields, error) {
+	local, err := l.LocalLog.SearchEvents(fromUTC, toUTC, query, limit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if !fromUTC.Before(l.cutoff()) {
+		return local, nil
+	}
+	archived, err := l.searchArchive(fromUTC, toUTC, query, limit)
+	if err != nil {
+		// local results are better than no results when the
+		// archive is unreachable
+		l.Warningf("Failed to search archived events: %v.", err)
+		return local, nil
+	}
+	merged := append(archived, local...)
+	sort.Sort(events.ByTimeAndIndex(merged))
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// SearchSessionEvents searches for session related events,
+// see SearchEvents for the archive merge semantics
+func (l *Log) SearchSessionEvents(fromUTC, toUTC time.Time, limit int) ([]events.EventFields, error) {
+	query := url.Values{}
+	query[events.EventType] = []string{
+		events.SessionStartEvent,
+		events.SessionEndEvent,
+	}
+	return l.SearchEvents(fromUTC, toUTC, query.Encode(), limit)
+}
+
+// Close stops the migration loop and closes the local log
+func (l *Log) Close() error {
+	l.cancel()
+	return l.IAuditLog.Close()
+}
+
+// searchArchive downloads archived days overlapping the query range
+// and returns matching events
+func (l *Log) searchArchive(fromUTC, toUTC time.Time, query string, limit int) ([]events.EventFields, error) {
+	queryVals, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, trace.BadParameter("invalid query")
+	}
+	index, err := l.fetchIndex()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var total int
+	matched := make([]events.EventFields, 0)
+	for _, entry := range index.Days {
+		day, err := entry.time()
+		if err != nil {
+			l.Warningf("Failed to parse archive index entry %q: %v.", entry.Date, err)
+			continue
+		}
+		// the same window used by the file log when matching daily
+		// files, days are rounded so both boundary days are included
+		if !day.After(fromUTC.Add(-24*time.Hour)) || !day.Before(toUTC.Add(24*time.Hour)) {
+			continue
+		}
+		found, err := l.findInArchive(entry, queryVals, &total, limit)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		matched = append(matched, found...)
+		if limit > 0 && total >= limit {
+			break
+		}
+	}
+	return matched, nil
+}
+
+// findInArchive downloads and scans a single archived day object,
+// matching events the same way the file log matches events in daily files
+func (l *Log) findInArchive(entry archiveEntry, queryVals url.Values, total *int, limit int) ([]events.EventFields, error) {
+	eventFilter := queryVals[events.EventType]
+	doFilter := len(eventFilter) > 0
+
+	out, err := l.client.GetObjectWithContext(l.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(l.Bucket),
+		Key:    aws.String(l.objectKey(entry.Key)),
+	})
+	if err != nil {
+		return nil, trace.Wrap(convertS3Error(err))
+	}
+	defer out.Body.Close()
+	unzip, err := gzip.NewReader(out.Body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer unzip.Close()
+
+	var matched []events.EventFields
+	scanner := bufio.NewScanner(unzip)
+	for lineNo := 0; scanner.Scan(); lineNo++ {
+		accepted := false
+		for i := range eventFilter {
+			if strings.Contains(scanner.Text(), eventFilter[i]) {
+				accepted = true
+				break
+			}
+		}
+		if doFilter && !accepted {
+			continue
+		}
+		var fields events.EventFields
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			l.Warnf("Failed to parse JSON line %v in %v: %v.", lineNo, entry.Key, err)
+			continue
+		}
+		accepted = !doFilter || utils.SliceContainsStr(eventFilter, fields.GetString(events.EventType))
+		if accepted {
+			matched = append(matched, fields)
+			*total++
+			if limit > 0 && *total >= limit {
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// runMigrations periodically migrates local daily log files
+// past the retention cutoff to S3
+func (l *Log) runMigrations() {
+	t := time.NewTicker(l.MigrationPeriod)
+	defer t.Stop()
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-t.C:
+			if err := l.migrateExpiredFiles(); err != nil {
+				l.Warningf("Failed to migrate expired audit log files: %v.", err)
+			}
+		}
+	}
+}
+
+// migrateExpiredFiles uploads daily files past the cutoff to S3
+// and removes them from the local log directory
+func (l *Log) migrateExpiredFiles() error {
+	files, err := l.expiredFiles()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	for _, file := range files {
+		if err := l.archiveFile(file); err != nil {
+			return trace.Wrap(err)
+		}
+		if err := os.Remove(file); err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		l.Infof("Migrated audit log file %v to s3://%v.", filepath.Base(file), l.Bucket)
+	}
+	return nil
+}
+
+// expiredFiles returns local daily log files that are entirely
+// past the retention cutoff, the currently written day is never
+// considered expired
+func (l *Log) expiredFiles() ([]string, error) {
+	df, err := os.Open(l.LocalLog.Dir)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer df.Close()
+	entries, err := df.Readdir(-1)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	// a day is expired when the next rotation period after it
+	// is still before the cutoff
+	cutoff := l.cutoff().Add(-l.LocalLog.RotationPeriod)
+	var expired []string
+	for i := range entries {
+		fi := entries[i]
+		if fi.IsDir() || filepath.Ext(fi.Name()) != events.LogfileExt {
+			continue
+		}
+		base := strings.TrimSuffix(fi.Name(), filepath.Ext(fi.Name()))
+		fd, err := time.Parse(defaults.AuditLogTimeFormat, base)
+		if err != nil {
+			l.Warningf("Failed to parse audit log file %q format: %v.", fi.Name(), err)
+			continue
+		}
+		if fd.Before(cutoff) {
+			expired = append(expired, filepath.Join(l.LocalLog.Dir, fi.Name()))
+		}
+	}
+	sort.Strings(expired)
+	return expired, nil
+}
+
+// archiveFile compresses and uploads a single daily log file
+// and records it in the archive index
+func (l *Log) archiveFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	key := base + archiveExt
+
+	reader, writer := io.Pipe()
+	go func() {
+		zip := gzip.NewWriter(writer)
+		_, err := io.Copy(zip, f)
+		if err2 := zip.Close(); err == nil {
+			err = err2
+		}
+		writer.CloseWithError(err)
+	}()
+	_, err = l.uploader.UploadWithContext(l.ctx, &s3manager.UploadInput{
+		Bucket: aws.String(l.Bucket),
+		Key:    aws.String(l.objectKey(key)),
+		Body:   reader,
+	})
+	if err != nil {
+		return trace.Wrap(convertS3Error(err))
+	}
+
+	head, err := l.client.HeadObjectWithContext(l.ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(l.Bucket),
+		Key:    aws.String(l.objectKey(key)),
+	})
+	if err != nil {
+		return trace.Wrap(convertS3Error(err))
+	}
+	return l.updateIndex(archiveEntry{
+		Date: base,
+		Key:  key,
+		Size: aws.Int64Value(head.ContentLength),
+	})
+}
+
+// fetchIndex downloads the archive index, a missing index
+// means nothing has been archived yet
+func (l *Log) fetchIndex() (*archiveIndex, error) {
+	out, err := l.client.GetObjectWithContext(l.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(l.Bucket),
+		Key:    aws.String(l.objectKey(indexKey)),
+	})
+	if err != nil {
+		err = convertS3Error(err)
+		if trace.IsNotFound(err) {
+			return &archiveIndex{}, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	defer out.Body.Close()
+	var index archiveIndex
+	if err := json.NewDecoder(out.Body).Decode(&index); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &index, nil
+}
+
+// updateIndex adds an entry to the archive index, replacing
+// a stale entry for the same day if present
+func (l *Log) updateIndex(entry archiveEntry) error {
+	l.indexMutex.Lock()
+	defer l.indexMutex.Unlock()
+
+	index, err := l.fetchIndex()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	days := index.Days[:0]
+	for _, day := range index.Days {
+		if day.Date != entry.Date {
+			days = append(days, day)
+		}
+	}
+	index.Days = append(days, entry)
+	sort.Slice(index.Days, func(i, j int) bool {
+		return index.Days[i].Date < index.Days[j].Date
+	})
+	data, err := json.Marshal(index)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = l.uploader.UploadWithContext(l.ctx, &s3manager.UploadInput{
+		Bucket: aws.String(l.Bucket),
+		Key:    aws.String(l.objectKey(indexKey)),
+		Body:   bytes.NewReader(data),
+	})
+	return trace.Wrap(convertS3Error(err))
+}
+
+// objectKey returns the object key under the configured bucket path
+func (l *Log) objectKey(name string) string {
+	return strings.TrimPrefix(filepath.Join(l.Path, name), "/")
+}
+
+func convertS3Error(err error, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	switch aerr := trace.Unwrap(err).(type) {
+	case awserr.Error:
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, s3.ErrCodeNoSuchBucket, s3.ErrCodeNoSuchUpload, "NotFound":
+			return trace.NotFound(aerr.Error(), args...)
+		default:
+			return trace.BadParameter(aerr.Error(), args...)
+		}
+	}
+	return err
+}
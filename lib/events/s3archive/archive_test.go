@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3archive
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigSetFromURL verifies that the archive configuration is
+// parsed from audit_events_uri values
+func TestConfigSetFromURL(t *testing.T) {
+	in, err := url.Parse("s3://audit-archive/teleport/events?region=us-west-1&retention_days=30")
+	assert.NoError(t, err)
+
+	var cfg Config
+	err = cfg.SetFromURL(in, "us-east-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "audit-archive", cfg.Bucket)
+	assert.Equal(t, "/teleport/events", cfg.Path)
+	assert.Equal(t, "us-west-1", cfg.Region)
+	assert.Equal(t, 30*24*time.Hour, cfg.RetentionPeriod)
+
+	in, err = url.Parse("s3://audit-archive?retention_days=oops")
+	assert.NoError(t, err)
+	err = cfg.SetFromURL(in, "us-east-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// WebhookFormatJSON posts the full audit event as JSON.
+	WebhookFormatJSON = "json"
+	// WebhookFormatSlack posts a Slack-compatible message with a short
+	// event summary.
+	WebhookFormatSlack = "slack"
+
+	// webhookQueueSize is the number of events buffered for delivery before
+	// new events are dropped. Delivery must never block the emit path.
+	webhookQueueSize = 256
+
+	// webhookTimeout is the timeout of a single webhook delivery attempt.
+	webhookTimeout = 5 * time.Second
+
+	// webhookRetryAttempts is the default number of delivery attempts
+	// per event before it is dropped.
+	webhookRetryAttempts = 3
+
+	// webhookRetryBackoff is the default pause before the first retry,
+	// it doubles with every subsequent attempt.
+	webhookRetryBackoff = time.Second
+
+	// webhookSlackTemplate is the default template of the Slack message
+	// text, executed with the JSON encoding of the audit event.
+	webhookSlackTemplate = "{{.event}} ({{.code}})"
+)
+
+// WebhookEmitterConfig configures a webhook emitter.
+type WebhookEmitterConfig struct {
+	// URL is the webhook endpoint events are posted to.
+	URL string
+	// Format is the payload format, either "json" or "slack".
+	Format string
+	// Template is an optional Go text/template used to build the payload.
+	// It is executed with the JSON encoding of the audit event, so fields
+	// are referenced by their JSON names, e.g. {{.user}} logged in from
+	// {{.addr_remote}}. In "slack" format the rendered text becomes the
+	// message text, in "json" format it becomes the request body.
+	Template string
+	// Types is an optional list of event types to deliver. If empty, only
+	// security relevant events (events with warning or error codes) are
+	// delivered.
+	Types []string
+	// RetryAttempts is the number of delivery attempts per event before
+	// it is dropped, defaults to 3.
+	RetryAttempts int
+	// RetryBackoff is the pause before the first retry, doubling with
+	// every subsequent attempt, defaults to 1 second.
+	RetryBackoff time.Duration
+	// Client is an optional HTTP client used to deliver events.
+	Client *http.Client
+}
+
+// CheckAndSetDefaults checks and sets default values.
+func (c *WebhookEmitterConfig) CheckAndSetDefaults() error {
+	if c.URL == "" {
+		return trace.BadParameter("missing parameter URL")
+	}
+	if c.Format == "" {
+		c.Format = WebhookFormatJSON
+	}
+	switch c.Format {
+	case WebhookFormatJSON, WebhookFormatSlack:
+	default:
+		return trace.BadParameter("unsupported webhook format %q", c.Format)
+	}
+	if c.Template == "" && c.Format == WebhookFormatSlack {
+		c.Template = webhookSlackTemplate
+	}
+	if c.RetryAttempts == 0 {
+		c.RetryAttempts = webhookRetryAttempts
+	}
+	if c.RetryBackoff == 0 {
+		c.RetryBackoff = webhookRetryBackoff
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: webhookTimeout}
+	}
+	return nil
+}
+
+// NewWebhookEmitter returns an emitter that posts matching audit events to
+// an external webhook endpoint, for example a Slack incoming webhook.
+// Delivery is asynchronous and never blocks the caller; events are dropped
+// with a warning if the endpoint can not keep up.
+func NewWebhookEmitter(cfg WebhookEmitterConfig) (*WebhookEmitter, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var tpl *template.Template
+	if cfg.Template != "" {
+		var err error
+		tpl, err = template.New("webhook").Parse(cfg.Template)
+		if err != nil {
+			return nil, trace.BadParameter("failed to parse webhook template: %v", err)
+		}
+	}
+	w := &WebhookEmitter{
+		cfg:      cfg,
+		template: tpl,
+		eventC:   make(chan AuditEvent, webhookQueueSize),
+	}
+	go w.forward()
+	return w, nil
+}
+
+// WebhookEmitter posts audit events to an external webhook endpoint.
+type WebhookEmitter struct {
+	cfg      WebhookEmitterConfig
+	template *template.Template
+	eventC   chan AuditEvent
+}
+
+// EmitAuditEvent queues the event for delivery if it matches the
+// configured filter.
+func (w *WebhookEmitter) EmitAuditEvent(ctx context.Context, event AuditEvent) error {
+	if !w.matches(event) {
+		return nil
+	}
+	select {
+	case w.eventC <- event:
+	default:
+		log.WithFields(log.Fields{trace.Component: teleport.ComponentAuditLog}).
+			Warnf("Webhook queue is full, dropping event %v.", event.GetType())
+	}
+	return nil
+}
+
+// matches returns true if the event should be delivered to the webhook.
+func (w *WebhookEmitter) matches(event AuditEvent) bool {
+	if len(w.cfg.Types) != 0 {
+		return utils.SliceContainsStr(w.cfg.Types, event.GetType())
+	}
+	// By default only deliver security relevant events: warning and error
+	// codes end with "W" and "E" respectively.
+	return strings.HasSuffix(event.GetCode(), "W") || strings.HasSuffix(event.GetCode(), "E")
+}
+
+// forward delivers queued events to the webhook endpoint.
+func (w *WebhookEmitter) forward() {
+	for event := range w.eventC {
+		w.deliver(event)
+	}
+}
+
+// deliver posts a single event, retrying failed attempts with
+// exponential backoff before giving up. Only the delivery goroutine
+// blocks on the backoff, the emit path keeps queueing events.
+func (w *WebhookEmitter) deliver(event AuditEvent) {
+	backoff := w.cfg.RetryBackoff
+	var err error
+	for attempt := 0; attempt < w.cfg.RetryAttempts; attempt++ {
+		if attempt != 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		if err = w.post(event); err == nil {
+			return
+		}
+	}
+	log.WithFields(log.Fields{trace.Component: teleport.ComponentAuditLog}).
+		WithError(err).Warnf("Dropping event %v after %v failed webhook deliveries.",
+		event.GetType(), w.cfg.RetryAttempts)
+}
+
+// payload builds the request body for the event.
+func (w *WebhookEmitter) payload(event AuditEvent) ([]byte, error) {
+	if w.template == nil {
+		return utils.FastMarshal(event)
+	}
+	// execute the template with the JSON encoding of the event so that
+	// fields are addressed by their stable JSON names
+	data, err := utils.FastMarshal(event)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var rendered bytes.Buffer
+	if err := w.template.Execute(&rendered, fields); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if w.cfg.Format == WebhookFormatSlack {
+		return json.Marshal(map[string]string{"text": rendered.String()})
+	}
+	return rendered.Bytes(), nil
+}
+
+// post delivers a single event to the webhook endpoint.
+func (w *WebhookEmitter) post(event AuditEvent) error {
+	payload, err := w.payload(event)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	resp, err := w.cfg.Client.Post(w.cfg.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return trace.BadParameter("webhook returned status %v", resp.Status)
+	}
+	return nil
+}
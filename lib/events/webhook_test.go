@@ -0,0 +1,151 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newLoginFailure returns a failed login event used by the webhook tests,
+// its failure code matches the default webhook filter.
+func newLoginFailure(user string) AuditEvent {
+	return &UserLogin{
+		Metadata: Metadata{
+			Type: UserLoginEvent,
+			Code: UserLocalLoginFailureCode,
+		},
+		UserMetadata: UserMetadata{
+			User: user,
+		},
+		Method: LoginMethodLocal,
+	}
+}
+
+// TestWebhookDelivery verifies that matching events are posted to the
+// endpoint as JSON and non-matching events are filtered out.
+func TestWebhookDelivery(t *testing.T) {
+	bodyC := make(chan []byte, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodyC <- body
+	}))
+	defer srv.Close()
+
+	emitter, err := NewWebhookEmitter(WebhookEmitterConfig{
+		URL:          srv.URL,
+		RetryBackoff: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// a successful login does not match the default filter
+	success := newLoginFailure("alice")
+	success.SetCode(UserLocalLoginCode)
+	require.NoError(t, emitter.EmitAuditEvent(context.Background(), success))
+	require.NoError(t, emitter.EmitAuditEvent(context.Background(), newLoginFailure("bob")))
+
+	select {
+	case body := <-bodyC:
+		require.Contains(t, string(body), UserLoginEvent)
+		require.Contains(t, string(body), UserLocalLoginFailureCode)
+		require.Contains(t, string(body), "bob")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook delivery")
+	}
+	// the filtered event should never arrive
+	select {
+	case body := <-bodyC:
+		t.Fatalf("unexpected delivery: %v", string(body))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestWebhookRetry verifies that failed deliveries are retried with
+// backoff until the endpoint accepts the event.
+func TestWebhookRetry(t *testing.T) {
+	var attempts int32
+	bodyC := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodyC <- body
+	}))
+	defer srv.Close()
+
+	emitter, err := NewWebhookEmitter(WebhookEmitterConfig{
+		URL:          srv.URL,
+		RetryBackoff: time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.NoError(t, emitter.EmitAuditEvent(context.Background(), newLoginFailure("alice")))
+
+	select {
+	case body := <-bodyC:
+		require.Contains(t, string(body), "alice")
+		require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook delivery")
+	}
+}
+
+// TestWebhookTemplate verifies payload templating: templated fields are
+// resolved from the JSON encoding of the event and slack format wraps
+// the rendered text into a message object.
+func TestWebhookTemplate(t *testing.T) {
+	bodyC := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodyC <- body
+	}))
+	defer srv.Close()
+
+	emitter, err := NewWebhookEmitter(WebhookEmitterConfig{
+		URL:          srv.URL,
+		Format:       WebhookFormatSlack,
+		Template:     "{{.user}} failed to log in using {{.method}}",
+		RetryBackoff: time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.NoError(t, emitter.EmitAuditEvent(context.Background(), newLoginFailure("alice")))
+
+	select {
+	case body := <-bodyC:
+		require.Equal(t, `{"text":"alice failed to log in using local"}`, string(body))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook delivery")
+	}
+
+	// an invalid template is rejected up front
+	_, err = NewWebhookEmitter(WebhookEmitterConfig{
+		URL:      srv.URL,
+		Template: "{{.user",
+	})
+	require.Error(t, err)
+}
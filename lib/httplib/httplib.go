@@ -19,12 +19,15 @@ limitations under the License.
 package httplib
 
 import (
+	"compress/gzip"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/gravitational/teleport/lib/httplib/csrf"
 
@@ -75,6 +78,34 @@ func MakeStdHandler(fn StdHandlerFunc) http.HandlerFunc {
 	}
 }
 
+// MakeGzipHandler wraps a handler and compresses its response with gzip
+// when the client advertises support via the Accept-Encoding header.
+// Useful for list endpoints whose responses grow with cluster size.
+func MakeGzipHandler(handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			handle(w, r, p)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		handle(&gzipResponseWriter{Writer: gz, ResponseWriter: w}, r, p)
+	}
+}
+
+// gzipResponseWriter routes the response body through a gzip writer
+// while headers and status code pass through to the wrapped writer.
+type gzipResponseWriter struct {
+	io.Writer
+	http.ResponseWriter
+}
+
+// Write compresses the data with the underlying gzip writer
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	return w.Writer.Write(data)
+}
+
 // WithCSRFProtection ensures that request to unauthenticated API is checked against CSRF attacks
 func WithCSRFProtection(fn HandlerFunc) httprouter.Handle {
 	hanlderFn := MakeHandler(fn)
@@ -114,6 +145,22 @@ func ConvertResponse(re *roundtrip.Response, err error) (*roundtrip.Response, er
 	return re, trace.ReadError(re.Code(), re.Bytes())
 }
 
+// ParseInt64 will parse an integer variable from url query
+// returns value, ok, error
+func ParseInt64(q url.Values, name string) (int64, bool, error) {
+	stringVal := q.Get(name)
+	if stringVal == "" {
+		return 0, false, nil
+	}
+
+	val, err := strconv.ParseInt(stringVal, 10, 64)
+	if err != nil {
+		return 0, false, trace.BadParameter(
+			"'%v': expected an integer, got %v", name, stringVal)
+	}
+	return val, true, nil
+}
+
 // ParseBool will parse boolean variable from url query
 // returns value, ok, error
 func ParseBool(q url.Values, name string) (bool, bool, error) {
@@ -17,8 +17,10 @@ limitations under the License.
 package httplib
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
@@ -53,6 +55,39 @@ func (s *HTTPSuite) TestRewritePaths(c *C) {
 	c.Assert(handler.capturedID, Equals, "s2")
 }
 
+func (s *HTTPSuite) TestMakeGzipHandler(c *C) {
+	payload := strings.Repeat("node", 1024)
+	router := httprouter.New()
+	router.GET("/nodes", MakeGzipHandler(MakeHandler(
+		func(w http.ResponseWriter, r *http.Request, p httprouter.Params) (interface{}, error) {
+			return payload, nil
+		})))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// the default transport advertises gzip support and
+	// decompresses the response transparently
+	re, err := http.Get(server.URL + "/nodes")
+	c.Assert(err, IsNil)
+	defer re.Body.Close()
+	c.Assert(re.StatusCode, Equals, http.StatusOK)
+	c.Assert(re.Uncompressed, Equals, true)
+	var out string
+	c.Assert(json.NewDecoder(re.Body).Decode(&out), IsNil)
+	c.Assert(out, Equals, payload)
+
+	// clients not asking for compression get the response as is
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/nodes", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Accept-Encoding", "identity")
+	re, err = http.DefaultClient.Do(req)
+	c.Assert(err, IsNil)
+	defer re.Body.Close()
+	c.Assert(re.StatusCode, Equals, http.StatusOK)
+	c.Assert(re.Uncompressed, Equals, false)
+	c.Assert(re.Header.Get("Content-Encoding"), Equals, "")
+}
+
 type testHandler struct {
 	httprouter.Router
 	capturedNamespace string
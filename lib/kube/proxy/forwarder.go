@@ -94,6 +94,10 @@ type ForwarderConfig struct {
 	// StrictImpersonationCheck specifies whether to fail when impersonation
 	// permissions of this forwarder can't be tested.
 	StrictImpersonationCheck bool
+	// CipherSuites is a list of TLS cipher suites to use for client
+	// connections to the kubernetes cluster. In FIPS mode this restricts the
+	// connection to approved suites only.
+	CipherSuites []uint16
 }
 
 // CheckAndSetDefaults checks and sets default values
@@ -1289,10 +1293,9 @@ func (f *Forwarder) requestCertificate(ctx authContext) (*tls.Config, error) {
 			return nil, trace.BadParameter("failed to append certificates, check that kubeconfig has correctly encoded certificate authority data")
 		}
 	}
-	tlsConfig := &tls.Config{
-		RootCAs:      pool,
-		Certificates: []tls.Certificate{cert},
-	}
+	tlsConfig := utils.TLSConfig(f.CipherSuites)
+	tlsConfig.RootCAs = pool
+	tlsConfig.Certificates = []tls.Certificate{cert}
 	tlsConfig.BuildNameToCertificate()
 
 	return tlsConfig, nil
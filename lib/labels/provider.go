@@ -0,0 +1,178 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package labels implements external label providers: helper processes
+// queried on a period over a simple stdin/stdout JSON protocol, so custom
+// inventory sources can feed node labels without forking teleport.
+//
+// On every query the provider process is started, receives a single
+// ProviderRequest JSON object on stdin, and is expected to print a single
+// ProviderResponse JSON object on stdout and exit.
+package labels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProtocolVersion is the version of the provider protocol sent in
+// every request, so providers can detect incompatible agents.
+const ProtocolVersion = 1
+
+const (
+	// defaultPeriod is how often a provider is queried unless
+	// configured otherwise
+	defaultPeriod = time.Minute
+	// defaultTimeout bounds a single query of the provider process
+	defaultTimeout = 30 * time.Second
+)
+
+// ProviderRequest is written to the provider's stdin as a single
+// JSON object.
+type ProviderRequest struct {
+	// Version is the protocol version spoken by the agent
+	Version int `json:"version"`
+}
+
+// ProviderResponse is read from the provider's stdout.
+type ProviderResponse struct {
+	// Labels are the labels to apply to the node
+	Labels map[string]string `json:"labels"`
+}
+
+// ProviderConfig configures an external label provider.
+type ProviderConfig struct {
+	// Command is the provider command and its arguments
+	Command []string
+	// Period is how often the provider is queried
+	Period time.Duration
+	// Timeout bounds a single query of the provider process
+	Timeout time.Duration
+}
+
+// CheckAndSetDefaults checks the config and sets default values
+func (c *ProviderConfig) CheckAndSetDefaults() error {
+	if len(c.Command) == 0 {
+		return trace.BadParameter("missing parameter Command")
+	}
+	if c.Period == 0 {
+		c.Period = defaultPeriod
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+// Provider queries an external process for labels on a period and caches
+// the last successful response. On query failures the previously reported
+// labels are kept.
+type Provider struct {
+	cfg ProviderConfig
+
+	mu     sync.Mutex
+	labels map[string]string
+
+	startOnce sync.Once
+}
+
+// NewProvider creates a new external label provider. The provider does
+// not query the process until Start is called.
+func NewProvider(cfg ProviderConfig) (*Provider, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Provider{cfg: cfg}, nil
+}
+
+// Start queries the provider once and keeps querying it on the configured
+// period until the context is cancelled. Start is idempotent.
+func (p *Provider) Start(ctx context.Context) {
+	p.startOnce.Do(func() {
+		go p.periodicQuery(ctx)
+	})
+}
+
+// Labels returns the labels from the last successful query of the
+// provider, nil if the provider has not answered yet.
+func (p *Provider) Labels() map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]string, len(p.labels))
+	for key, value := range p.labels {
+		out[key] = value
+	}
+	return out
+}
+
+func (p *Provider) periodicQuery(ctx context.Context) {
+	t := time.NewTicker(p.cfg.Period)
+	defer t.Stop()
+	for {
+		if err := p.Query(ctx); err != nil {
+			log.Warningf("Label provider %v failed: %v.", p.cfg.Command[0], err)
+		}
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Query runs the provider process once and replaces the cached labels
+// with its response.
+func (p *Provider) Query(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	request, err := json.Marshal(ProviderRequest{Version: ProtocolVersion})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	cmd := exec.CommandContext(ctx, p.cfg.Command[0], p.cfg.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(request)
+	out, err := cmd.Output()
+	if err != nil {
+		return trace.Wrap(err, "output: %v", string(out))
+	}
+	var response ProviderResponse
+	if err := utils.FastUnmarshal(out, &response); err != nil {
+		return trace.Wrap(err)
+	}
+	labels := make(map[string]string, len(response.Labels))
+	for key, value := range response.Labels {
+		if !services.IsValidLabelKey(key) {
+			log.Warningf("Label provider %v returned invalid label key %q, skipping it.", p.cfg.Command[0], key)
+			continue
+		}
+		labels[key] = value
+	}
+	p.mu.Lock()
+	p.labels = labels
+	p.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+func TestLabels(t *testing.T) { check.TestingT(t) }
+
+type ProviderSuite struct{}
+
+var _ = check.Suite(&ProviderSuite{})
+
+func (s *ProviderSuite) TestQuery(c *check.C) {
+	provider, err := NewProvider(ProviderConfig{
+		Command: []string{"sh", "-c", `echo '{"labels": {"env": "prod", "rack": "r12"}}'`},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(provider.Query(context.Background()), check.IsNil)
+	c.Assert(provider.Labels(), check.DeepEquals, map[string]string{
+		"env":  "prod",
+		"rack": "r12",
+	})
+}
+
+func (s *ProviderSuite) TestQueryFailureKeepsLabels(c *check.C) {
+	provider, err := NewProvider(ProviderConfig{
+		Command: []string{"sh", "-c", `echo '{"labels": {"env": "prod"}}'`},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(provider.Query(context.Background()), check.IsNil)
+
+	// replace the command with a failing one, the cached labels survive
+	provider.cfg.Command = []string{"false"}
+	c.Assert(provider.Query(context.Background()), check.NotNil)
+	c.Assert(provider.Labels(), check.DeepEquals, map[string]string{"env": "prod"})
+}
+
+func (s *ProviderSuite) TestInvalidLabelKeys(c *check.C) {
+	provider, err := NewProvider(ProviderConfig{
+		Command: []string{"sh", "-c", `echo '{"labels": {"env": "prod", "bad key!": "x"}}'`},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(provider.Query(context.Background()), check.IsNil)
+	c.Assert(provider.Labels(), check.DeepEquals, map[string]string{"env": "prod"})
+}
+
+func (s *ProviderSuite) TestConfig(c *check.C) {
+	_, err := NewProvider(ProviderConfig{})
+	c.Assert(err, check.NotNil)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limiter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// clusterLimiterPrefix is the backend key prefix shared by all
+	// cluster limiter counters
+	clusterLimiterPrefix = "ratelimit"
+
+	// clusterLimiterCASAttempts is how many times a contended counter
+	// increment is retried before the request is rejected
+	clusterLimiterCASAttempts = 5
+)
+
+// ClusterLimiterConfig configures a cluster-wide rate limiter.
+type ClusterLimiterConfig struct {
+	// Backend is the storage backend shared by the cluster
+	Backend backend.Backend
+	// Prefix names the protected endpoint, counters of different
+	// endpoints are kept separate
+	Prefix string
+	// Period is the length of the counting window
+	Period time.Duration
+	// Max is the number of requests allowed per token within one window
+	Max int64
+	// Clock is a clock override used in tests
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults checks and sets default values.
+func (c *ClusterLimiterConfig) CheckAndSetDefaults() error {
+	if c.Backend == nil {
+		return trace.BadParameter("missing parameter Backend")
+	}
+	if c.Prefix == "" {
+		return trace.BadParameter("missing parameter Prefix")
+	}
+	if c.Period == 0 {
+		c.Period = time.Minute
+	}
+	if c.Max == 0 {
+		return trace.BadParameter("missing parameter Max")
+	}
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// NewClusterLimiter returns a rate limiter shared by all processes in a
+// cluster: counters are stored in the backend and incremented atomically
+// with compare-and-swap, so the limit holds regardless of which proxy or
+// auth server serves the request.
+func NewClusterLimiter(cfg ClusterLimiterConfig) (*ClusterLimiter, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &ClusterLimiter{ClusterLimiterConfig: cfg}, nil
+}
+
+// ClusterLimiter counts requests per token in fixed windows backed by
+// the cluster state backend.
+type ClusterLimiter struct {
+	ClusterLimiterConfig
+}
+
+// RegisterRequest counts one request for the token (typically a client
+// address or user name) and returns trace.LimitExceeded when the
+// cluster-wide budget for the current window is spent.
+func (l *ClusterLimiter) RegisterRequest(ctx context.Context, token string) error {
+	window := l.Clock.Now().UTC().Truncate(l.Period)
+	key := backend.Key(clusterLimiterPrefix, l.Prefix, token, strconv.FormatInt(window.Unix(), 10))
+	// keep the counter around for one extra window so late requests
+	// of the previous window still see it
+	expires := window.Add(2 * l.Period)
+
+	for i := 0; i < clusterLimiterCASAttempts; i++ {
+		item, err := l.Backend.Get(ctx, key)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return trace.Wrap(err)
+			}
+			_, err = l.Backend.Create(ctx, backend.Item{
+				Key:     key,
+				Value:   []byte(strconv.FormatInt(1, 10)),
+				Expires: expires,
+			})
+			if err != nil {
+				if trace.IsAlreadyExists(err) {
+					// another process created the counter first
+					continue
+				}
+				return trace.Wrap(err)
+			}
+			return nil
+		}
+		count, err := strconv.ParseInt(string(item.Value), 10, 64)
+		if err != nil {
+			return trace.BadParameter("corrupted rate limiter counter %q: %v", string(item.Value), err)
+		}
+		if count >= l.Max {
+			return trace.LimitExceeded("rate limit for %q exceeded, try again later", token)
+		}
+		newItem := *item
+		newItem.Value = []byte(strconv.FormatInt(count+1, 10))
+		newItem.Expires = expires
+		if _, err := l.Backend.CompareAndSwap(ctx, *item, newItem); err != nil {
+			if trace.IsCompareFailed(err) {
+				// another process incremented the counter first
+				continue
+			}
+			return trace.Wrap(err)
+		}
+		return nil
+	}
+	return trace.LimitExceeded("rate limit counter for %q is contended, try again later", token)
+}
@@ -30,17 +30,20 @@ import (
 type ConnectionsLimiter struct {
 	*connlimit.ConnLimiter
 	*sync.Mutex
-	connections    map[string]int64
-	maxConnections int64
+	connections         map[string]int64
+	maxConnections      int64
+	totalConnections    int64
+	maxTotalConnections int64
 }
 
 // NewConnectionsLimiter returns new connection limiter, in case if connection
 // limits are not set, they won't be tracked
 func NewConnectionsLimiter(config Config) (*ConnectionsLimiter, error) {
 	limiter := ConnectionsLimiter{
-		Mutex:          &sync.Mutex{},
-		maxConnections: config.MaxConnections,
-		connections:    make(map[string]int64),
+		Mutex:               &sync.Mutex{},
+		maxConnections:      config.MaxConnections,
+		maxTotalConnections: config.MaxTotalConnections,
+		connections:         make(map[string]int64),
 	}
 
 	ipExtractor, err := utils.NewExtractor("client.ip")
@@ -67,13 +70,21 @@ func (l *ConnectionsLimiter) AcquireConnection(token string) error {
 	l.Lock()
 	defer l.Unlock()
 
+	if l.maxTotalConnections != 0 && l.totalConnections >= l.maxTotalConnections {
+		return trace.LimitExceeded(
+			"too many connections: %v, max is %v",
+			l.totalConnections, l.maxTotalConnections)
+	}
+
 	if l.maxConnections == 0 {
+		l.totalConnections++
 		return nil
 	}
 
 	numberOfConnections, exists := l.connections[token]
 	if !exists {
 		l.connections[token] = 1
+		l.totalConnections++
 		return nil
 	}
 	if numberOfConnections >= l.maxConnections {
@@ -82,6 +93,7 @@ func (l *ConnectionsLimiter) AcquireConnection(token string) error {
 			token, numberOfConnections, l.maxConnections)
 	}
 	l.connections[token] = numberOfConnections + 1
+	l.totalConnections++
 	return nil
 }
 
@@ -91,6 +103,10 @@ func (l *ConnectionsLimiter) ReleaseConnection(token string) {
 	l.Lock()
 	defer l.Unlock()
 
+	if l.totalConnections > 0 {
+		l.totalConnections--
+	}
+
 	if l.maxConnections == 0 {
 		return
 	}
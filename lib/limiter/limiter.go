@@ -38,7 +38,11 @@ type Config struct {
 	// Rates set ups rate limits
 	Rates []Rate
 	// MaxConnections configures maximum number of connections
+	// from a single client address
 	MaxConnections int64
+	// MaxTotalConnections configures maximum number of concurrent
+	// connections for this process regardless of the client address
+	MaxTotalConnections int64
 	// MaxNumberOfUsers controls maximum number of simultaneously active users
 	MaxNumberOfUsers int
 	// Clock is an optional parameter, if not set, will use system time
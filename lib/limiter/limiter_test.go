@@ -16,10 +16,15 @@ limitations under the License.
 package limiter
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/gravitational/teleport/lib/backend/memory"
 	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
 	"github.com/mailgun/timetools"
 
 	. "gopkg.in/check.v1"
@@ -163,3 +168,34 @@ func (s *LimiterSuite) TestRateLimiter(c *C) {
 	}
 	c.Assert(err, NotNil)
 }
+
+func (s *LimiterSuite) TestClusterLimiter(c *C) {
+	clock := clockwork.NewFakeClock()
+	bk, err := memory.New(memory.Config{Clock: clock})
+	c.Assert(err, IsNil)
+	defer bk.Close()
+
+	limiter, err := NewClusterLimiter(ClusterLimiterConfig{
+		Backend: bk,
+		Prefix:  "login",
+		Period:  time.Minute,
+		Max:     3,
+		Clock:   clock,
+	})
+	c.Assert(err, IsNil)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		c.Assert(limiter.RegisterRequest(ctx, "token1"), IsNil)
+	}
+	err = limiter.RegisterRequest(ctx, "token1")
+	c.Assert(err, NotNil)
+	c.Assert(trace.IsLimitExceeded(err), Equals, true)
+
+	// other tokens are counted separately
+	c.Assert(limiter.RegisterRequest(ctx, "token2"), IsNil)
+
+	// the next window starts with a fresh budget
+	clock.Advance(time.Minute)
+	c.Assert(limiter.RegisterRequest(ctx, "token1"), IsNil)
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package loadtest registers synthetic nodes and simulates session
+// churn against a cluster for capacity planning, it measures the
+// latency of presence and session operations and reports percentiles.
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/services/local"
+	"github.com/gravitational/teleport/lib/session"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config specifies the load test to run
+type Config struct {
+	// Backend is the storage backend of the target cluster
+	Backend backend.Backend
+	// Nodes is the number of synthetic nodes to register
+	Nodes int
+	// HeartbeatInterval is how frequently every synthetic
+	// node announces itself
+	HeartbeatInterval time.Duration
+	// Labels is the number of labels set on every synthetic node
+	Labels int
+	// LabelCardinality is the number of distinct values
+	// generated per label
+	LabelCardinality int
+	// SessionRate is the number of sessions created
+	// and torn down per second
+	SessionRate int
+	// Duration is the test duration
+	Duration time.Duration
+	// Namespace is the namespace synthetic resources are placed in
+	Namespace string
+}
+
+// CheckAndSetDefaults checks and sets default values
+func (cfg *Config) CheckAndSetDefaults() error {
+	if cfg.Backend == nil {
+		return trace.BadParameter("missing parameter Backend")
+	}
+	if cfg.Nodes <= 0 {
+		return trace.BadParameter("the number of synthetic nodes must be positive")
+	}
+	if cfg.HeartbeatInterval == 0 {
+		cfg.HeartbeatInterval = defaults.ServerAnnounceTTL / 2
+	}
+	if cfg.Labels < 0 || cfg.LabelCardinality < 0 {
+		return trace.BadParameter("label count and cardinality can not be negative")
+	}
+	if cfg.LabelCardinality == 0 {
+		cfg.LabelCardinality = 1
+	}
+	if cfg.Duration == 0 {
+		cfg.Duration = time.Minute
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = defaults.Namespace
+	}
+	return nil
+}
+
+// Result is a result of a load test run
+type Result struct {
+	// Heartbeats is the amount of node heartbeats originated
+	Heartbeats int
+	// HeartbeatFailures is the amount of heartbeats failed
+	HeartbeatFailures int
+	// Sessions is the amount of session create/delete cycles originated
+	Sessions int
+	// SessionFailures is the amount of session cycles failed
+	SessionFailures int
+	// HeartbeatHistogram is a heartbeat duration histogram
+	HeartbeatHistogram *hdrhistogram.Histogram
+	// SessionHistogram is a session cycle duration histogram
+	SessionHistogram *hdrhistogram.Histogram
+	// LastError contains the last recorded error
+	LastError error
+}
+
+// measure is a single recorded operation
+type measure struct {
+	session  bool
+	duration time.Duration
+	err      error
+}
+
+// Run registers synthetic nodes, heartbeats them at the configured
+// rate and churns sessions until the test duration elapses. It is a
+// blocking function that can be cancelled via the context argument.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	presence := local.NewPresenceService(cfg.Backend)
+	sessions, err := session.New(cfg.Backend)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	clock := clockwork.NewRealClock()
+
+	measures := make(chan measure, cfg.Nodes)
+	var wg sync.WaitGroup
+
+	// every synthetic node heartbeats on its own timer, stagger the
+	// initial announce so thousands of nodes don't fire at once
+	for i := 0; i < cfg.Nodes; i++ {
+		node := syntheticNode(i, cfg)
+		wg.Add(1)
+		go func(i int, node services.Server) {
+			defer wg.Done()
+			stagger := time.Duration(i) * cfg.HeartbeatInterval / time.Duration(cfg.Nodes)
+			select {
+			case <-time.After(stagger):
+			case <-ctx.Done():
+				return
+			}
+			t := time.NewTicker(cfg.HeartbeatInterval)
+			defer t.Stop()
+			for {
+				node.SetTTL(clock, defaults.ServerAnnounceTTL)
+				start := time.Now()
+				_, err := presence.UpsertNode(node)
+				report(ctx, measures, measure{duration: time.Since(start), err: err})
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, node)
+	}
+
+	// session churn creates a session and immediately deletes it,
+	// approximating short-lived interactive sessions
+	if cfg.SessionRate > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			interval := time.Duration(float64(time.Second) / float64(cfg.SessionRate))
+			t := time.NewTicker(interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-t.C:
+					start := time.Now()
+					err := sessionCycle(sessions, cfg.Namespace)
+					report(ctx, measures, measure{session: true, duration: time.Since(start), err: err})
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(measures)
+	}()
+
+	result := &Result{
+		// from one millisecond to 60000 milliseconds (minute) with 3 digits precision
+		HeartbeatHistogram: hdrhistogram.New(1, 60000, 3),
+		SessionHistogram:   hdrhistogram.New(1, 60000, 3),
+	}
+	for m := range measures {
+		histogram := result.HeartbeatHistogram
+		if m.session {
+			histogram = result.SessionHistogram
+			result.Sessions++
+		} else {
+			result.Heartbeats++
+		}
+		if err := histogram.RecordValue(int64(m.duration / time.Millisecond)); err != nil {
+			log.Debugf("Failed to record value: %v.", err)
+		}
+		if m.err != nil {
+			if m.session {
+				result.SessionFailures++
+			} else {
+				result.HeartbeatFailures++
+			}
+			result.LastError = m.err
+		}
+	}
+	return result, nil
+}
+
+// report delivers a measure unless the test is shutting down
+func report(ctx context.Context, measures chan<- measure, m measure) {
+	select {
+	case measures <- m:
+	case <-ctx.Done():
+	}
+}
+
+// syntheticNode generates a synthetic node with the configured
+// amount of labels, label values cycle through the configured
+// cardinality so searches hit realistic distributions
+func syntheticNode(i int, cfg Config) services.Server {
+	labels := make(map[string]string, cfg.Labels)
+	for l := 0; l < cfg.Labels; l++ {
+		labels[fmt.Sprintf("label-%v", l)] = fmt.Sprintf("value-%v", (i+l)%cfg.LabelCardinality)
+	}
+	name := fmt.Sprintf("loadtest-%v", i)
+	return &services.ServerV2{
+		Kind:    services.KindNode,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      name,
+			Namespace: cfg.Namespace,
+			Labels:    labels,
+		},
+		Spec: services.ServerSpecV2{
+			Addr:     fmt.Sprintf("%v.loadtest.local:3022", name),
+			Hostname: name,
+		},
+	}
+}
+
+// sessionCycle creates and deletes a single session
+func sessionCycle(sessions session.Service, namespace string) error {
+	sess := session.Session{
+		ID:             session.NewID(),
+		Namespace:      namespace,
+		Login:          "loadtest",
+		Created:        time.Now().UTC(),
+		LastActive:     time.Now().UTC(),
+		ServerID:       uuid.New(),
+		ServerHostname: "loadtest",
+	}
+	if err := sessions.CreateSession(sess); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(sessions.DeleteSession(namespace, sess.ID))
+}
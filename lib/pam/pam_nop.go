@@ -1,4 +1,4 @@
-// +build !pam,cgo
+// +build !pam !cgo
 
 /*
 Copyright 2018 Gravitational, Inc.
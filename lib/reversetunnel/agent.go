@@ -90,6 +90,15 @@ type AgentConfig struct {
 	// Lease manages gossip and exclusive claims.  Lease may be nil
 	// when used in the context of tests.
 	Lease track.Lease
+	// Ciphers is a list of SSH ciphers the agent will offer to the proxy. If
+	// omitted, the defaults will be used.
+	Ciphers []string
+	// KEXAlgorithms is a list of SSH kex algorithms the agent will offer to
+	// the proxy. If omitted, the defaults will be used.
+	KEXAlgorithms []string
+	// MACAlgorithms is a list of SSH mac algorithms the agent will offer to
+	// the proxy. If omitted, the defaults will be used.
+	MACAlgorithms []string
 }
 
 // CheckAndSetDefaults checks parameters and sets default values
@@ -239,39 +248,53 @@ func (a *Agent) checkHostSignature(hostport string, remote net.Addr, key ssh.Pub
 }
 
 func (a *Agent) connect() (conn *ssh.Client, err error) {
+	// Resolve the proxy address to dial candidates, results are cached
+	// so frequent reconnects do not hit DNS on every dial.
+	addrs, err := utils.DefaultResolver.Resolve(a.ctx, a.Addr)
+	if err != nil {
+		a.Debugf("Failed to resolve %v: %v.", a.Addr.Addr, err)
+		addrs = []utils.NetAddr{a.Addr}
+	}
 	for _, authMethod := range a.authMethods {
-		// Create a dialer (that respects HTTP proxies) and connect to remote host.
-		dialer := proxy.DialerFromEnvironment(a.Addr.Addr)
-		pconn, err := dialer.DialTimeout(a.Addr.AddrNetwork, a.Addr.Addr, defaults.DefaultDialTimeout)
-		if err != nil {
-			a.Debugf("Dial to %v failed: %v.", a.Addr.Addr, err)
-			continue
-		}
+		for _, addr := range addrs {
+			// Create a dialer (that respects HTTP proxies) and connect to remote host.
+			dialer := proxy.DialerFromEnvironment(addr.Addr)
+			pconn, err := dialer.DialTimeout(addr.AddrNetwork, addr.Addr, defaults.DefaultDialTimeout)
+			if err != nil {
+				a.Debugf("Dial to %v failed: %v.", addr.Addr, err)
+				continue
+			}
 
-		// Build a new client connection. This is done to get access to incoming
-		// global requests which dialer.Dial would not provide.
-		conn, chans, reqs, err := ssh.NewClientConn(pconn, a.Addr.Addr, &ssh.ClientConfig{
-			User:            a.Username,
-			Auth:            []ssh.AuthMethod{authMethod},
-			HostKeyCallback: a.checkHostSignature,
-			Timeout:         defaults.DefaultDialTimeout,
-		})
-		if err != nil {
-			a.Debugf("Failed to create client to %v: %v.", a.Addr.Addr, err)
-			continue
-		}
+			// Build a new client connection. This is done to get access to incoming
+			// global requests which dialer.Dial would not provide.
+			conn, chans, reqs, err := ssh.NewClientConn(pconn, addr.Addr, &ssh.ClientConfig{
+				Config: ssh.Config{
+					Ciphers:      a.Ciphers,
+					KeyExchanges: a.KEXAlgorithms,
+					MACs:         a.MACAlgorithms,
+				},
+				User:            a.Username,
+				Auth:            []ssh.AuthMethod{authMethod},
+				HostKeyCallback: a.checkHostSignature,
+				Timeout:         defaults.DefaultDialTimeout,
+			})
+			if err != nil {
+				a.Debugf("Failed to create client to %v: %v.", addr.Addr, err)
+				continue
+			}
 
-		// Create an empty channel and close it right away. This will prevent
-		// ssh.NewClient from attempting to process any incoming requests.
-		emptyCh := make(chan *ssh.Request)
-		close(emptyCh)
+			// Create an empty channel and close it right away. This will prevent
+			// ssh.NewClient from attempting to process any incoming requests.
+			emptyCh := make(chan *ssh.Request)
+			close(emptyCh)
 
-		client := ssh.NewClient(conn, chans, emptyCh)
+			client := ssh.NewClient(conn, chans, emptyCh)
 
-		// Start a goroutine to process global requests from the server.
-		go a.handleGlobalRequests(a.ctx, reqs)
+			// Start a goroutine to process global requests from the server.
+			go a.handleGlobalRequests(a.ctx, reqs)
 
-		return client, nil
+			return client, nil
+		}
 	}
 	return nil, trace.BadParameter("failed to dial: all auth methods failed")
 }
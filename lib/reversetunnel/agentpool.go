@@ -89,6 +89,14 @@ type AgentPoolConfig struct {
 	ProxyAddr string
 	// Cluster is a cluster name of the proxy.
 	Cluster string
+	// Ciphers is a list of SSH ciphers agents will offer when dialing out.
+	Ciphers []string
+	// KEXAlgorithms is a list of SSH kex algorithms agents will offer when
+	// dialing out.
+	KEXAlgorithms []string
+	// MACAlgorithms is a list of SSH mac algorithms agents will offer when
+	// dialing out.
+	MACAlgorithms []string
 }
 
 // CheckAndSetDefaults checks and sets defaults
@@ -264,6 +272,9 @@ func (m *AgentPool) addAgent(lease track.Lease) error {
 		LocalClusterName:    m.cfg.LocalCluster,
 		Tracker:             m.proxyTracker,
 		Lease:               lease,
+		Ciphers:             m.cfg.Ciphers,
+		KEXAlgorithms:       m.cfg.KEXAlgorithms,
+		MACAlgorithms:       m.cfg.MACAlgorithms,
 	})
 	if err != nil {
 		// ensure that lease has been released; OK to call multiple times.
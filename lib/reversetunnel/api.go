@@ -52,6 +52,11 @@ type DialParams struct {
 	// ServerID the hostUUID.clusterName of a Teleport node. Used with nodes
 	// that are connected over a reverse tunnel.
 	ServerID string
+
+	// RecordAtProxy requests the connection to be terminated and recorded at
+	// the proxy even when the cluster-wide session recording mode records at
+	// the node. Set when the target node is labeled for proxy recording.
+	RecordAtProxy bool
 }
 
 func (params DialParams) String() string {
@@ -96,11 +101,31 @@ type RemoteSite interface {
 	IsClosed() bool
 }
 
+// DialStats reports accumulated dial and throughput counters for a single
+// remote cluster connected over the reverse tunnel.
+type DialStats struct {
+	// ClusterName is the name of the remote cluster.
+	ClusterName string `json:"cluster_name"`
+	// Dials is the number of successful dials through the tunnel.
+	Dials int64 `json:"dials"`
+	// DialFailures is the number of failed dials through the tunnel.
+	DialFailures int64 `json:"dial_failures"`
+	// ActiveStreams is the number of currently open streams multiplexed
+	// over the tunnel.
+	ActiveStreams int64 `json:"active_streams"`
+	// TransmittedBytes is the number of bytes copied over the tunnel
+	// streams in either direction.
+	TransmittedBytes int64 `json:"transmitted_bytes"`
+}
+
 // Server is a TCP/IP SSH server which listens on an SSH endpoint and remote/local
 // sites connect and register with it.
 type Server interface {
 	// GetSites returns a list of connected remote sites
 	GetSites() []RemoteSite
+	// GetDialStats returns dial and throughput counters for every
+	// connected remote cluster.
+	GetDialStats() []DialStats
 	// GetSite returns remote site this node belongs to
 	GetSite(domainName string) (RemoteSite, error)
 	// RemoveSite removes the site with the specified name from the list of connected sites
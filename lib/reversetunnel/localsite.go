@@ -26,6 +26,7 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/chaos"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/srv/forward"
@@ -152,6 +153,9 @@ func (s *localSite) DialAuthServer() (conn net.Conn, err error) {
 
 	// try and dial to one of them, as soon as we are successful, return the net.Conn
 	for _, authServer := range authServers {
+		if err = chaos.DialHook("tcp", authServer.GetAddr()); err != nil {
+			continue
+		}
 		conn, err = net.DialTimeout("tcp", authServer.GetAddr(), defaults.DefaultDialTimeout)
 		if err == nil {
 			return conn, nil
@@ -169,7 +173,7 @@ func (s *localSite) Dial(params DialParams) (net.Conn, error) {
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
-	if services.IsRecordAtProxy(clusterConfig.GetSessionRecording()) {
+	if services.IsRecordAtProxy(clusterConfig.GetSessionRecording()) || params.RecordAtProxy {
 		return s.dialWithAgent(params)
 	}
 
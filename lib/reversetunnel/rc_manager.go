@@ -73,6 +73,14 @@ type RemoteClusterTunnelManagerConfig struct {
 	Clock clockwork.Clock
 	// KubeDialAddr is an optional address of a local kubernetes proxy.
 	KubeDialAddr utils.NetAddr
+	// Ciphers is a list of SSH ciphers agents will offer when dialing out.
+	Ciphers []string
+	// KEXAlgorithms is a list of SSH kex algorithms agents will offer when
+	// dialing out.
+	KEXAlgorithms []string
+	// MACAlgorithms is a list of SSH mac algorithms agents will offer when
+	// dialing out.
+	MACAlgorithms []string
 }
 
 func (c *RemoteClusterTunnelManagerConfig) CheckAndSetDefaults() error {
@@ -215,8 +223,11 @@ func (w *RemoteClusterTunnelManager) realNewAgentPool(ctx context.Context, clust
 		Component: teleport.ComponentProxy,
 
 		// Configs for remote cluster.
-		Cluster:   cluster,
-		ProxyAddr: addr,
+		Cluster:       cluster,
+		ProxyAddr:     addr,
+		Ciphers:       w.cfg.Ciphers,
+		KEXAlgorithms: w.cfg.KEXAlgorithms,
+		MACAlgorithms: w.cfg.MACAlgorithms,
 	})
 	if err != nil {
 		return nil, trace.Wrap(err, "failed creating reverse tunnel pool for remote cluster %q at address %q: %v", cluster, addr, err)
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -83,6 +84,28 @@ type remoteSite struct {
 	// offlineThreshold is how long to wait for a keep alive message before
 	// marking a reverse tunnel connection as invalid.
 	offlineThreshold time.Duration
+
+	// dialCounters accumulates dial and throughput counters for this
+	// cluster, exposed via Server.GetDialStats, the fields are updated
+	// atomically.
+	dialCounters struct {
+		dials         int64
+		dialFailures  int64
+		activeStreams int64
+		bytes         int64
+	}
+}
+
+// DialStats returns a snapshot of the dial and throughput counters
+// accumulated for this cluster.
+func (s *remoteSite) DialStats() DialStats {
+	return DialStats{
+		ClusterName:      s.domainName,
+		Dials:            atomic.LoadInt64(&s.dialCounters.dials),
+		DialFailures:     atomic.LoadInt64(&s.dialCounters.dialFailures),
+		ActiveStreams:    atomic.LoadInt64(&s.dialCounters.activeStreams),
+		TransmittedBytes: atomic.LoadInt64(&s.dialCounters.bytes),
+	}
 }
 
 func (s *remoteSite) getRemoteClient() (auth.ClientI, bool, error) {
@@ -286,6 +309,7 @@ func (s *remoteSite) getLastConnInfo() (services.TunnelConnection, error) {
 func (s *remoteSite) registerHeartbeat(t time.Time) {
 	connInfo := s.copyConnInfo()
 	connInfo.SetLastHeartbeat(t)
+	connInfo.SetConnectionCount(int32(s.connectionCount()))
 	connInfo.SetExpiry(s.clock.Now().Add(s.offlineThreshold))
 	s.setLastConnInfo(connInfo)
 	err := s.localAccessPoint.UpsertTunnelConnection(connInfo)
@@ -492,9 +516,13 @@ func (s *remoteSite) periodicUpdateCertAuthorities() {
 }
 
 func (s *remoteSite) DialAuthServer() (net.Conn, error) {
-	return s.connThroughTunnel(&dialReq{
+	conn, err := s.connThroughTunnel(&dialReq{
 		Address: RemoteAuthServer,
 	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return s.newStreamConn(conn), nil
 }
 
 // Dial is used to connect a requesting client (say, tsh) to an SSH server
@@ -508,7 +536,7 @@ func (s *remoteSite) Dial(params DialParams) (net.Conn, error) {
 
 	// If the proxy is in recording mode use the agent to dial and build a
 	// in-memory forwarding server.
-	if services.IsRecordAtProxy(clusterConfig.GetSessionRecording()) {
+	if services.IsRecordAtProxy(clusterConfig.GetSessionRecording()) || params.RecordAtProxy {
 		return s.dialWithAgent(params)
 	}
 	return s.DialTCP(params)
@@ -525,7 +553,7 @@ func (s *remoteSite) DialTCP(params DialParams) (net.Conn, error) {
 		return nil, trace.Wrap(err)
 	}
 
-	return conn, nil
+	return s.newStreamConn(conn), nil
 }
 
 func (s *remoteSite) dialWithAgent(params DialParams) (net.Conn, error) {
@@ -592,7 +620,7 @@ func (s *remoteSite) dialWithAgent(params DialParams) (net.Conn, error) {
 		return nil, trace.Wrap(err)
 	}
 
-	return conn, nil
+	return s.newStreamConn(conn), nil
 }
 
 func (s *remoteSite) connThroughTunnel(req *dialReq) (*utils.ChConn, error) {
@@ -600,6 +628,8 @@ func (s *remoteSite) connThroughTunnel(req *dialReq) (*utils.ChConn, error) {
 	s.Debugf("Requesting connection to %v [%v] in remote cluster.",
 		req.Address, req.ServerID)
 
+	start := s.clock.Now()
+
 	// Loop through existing remote connections and try and establish a
 	// connection over the "reverse tunnel".
 	var conn *utils.ChConn
@@ -607,6 +637,8 @@ func (s *remoteSite) connThroughTunnel(req *dialReq) (*utils.ChConn, error) {
 	for i := 0; i < s.connectionCount(); i++ {
 		conn, err = s.chanTransportConn(req)
 		if err == nil {
+			atomic.AddInt64(&s.dialCounters.dials, 1)
+			tunnelDialLatencies.WithLabelValues(s.domainName).Observe(s.clock.Now().Sub(start).Seconds())
 			return conn, nil
 		}
 		s.Warnf("Request for connection to remote site failed: %v.", err)
@@ -623,6 +655,8 @@ func (s *remoteSite) connThroughTunnel(req *dialReq) (*utils.ChConn, error) {
 		}
 		err = trace.ConnectionProblem(nil, message)
 	}
+	atomic.AddInt64(&s.dialCounters.dialFailures, 1)
+	tunnelDialFailures.WithLabelValues(s.domainName).Inc()
 	return nil, err
 }
 
@@ -642,3 +676,41 @@ func (s *remoteSite) chanTransportConn(req *dialReq) (*utils.ChConn, error) {
 
 	return conn, nil
 }
+
+// newStreamConn wraps a dialed connection to count the bytes copied over
+// it and to track the number of concurrent streams through the tunnel.
+func (s *remoteSite) newStreamConn(conn net.Conn) net.Conn {
+	atomic.AddInt64(&s.dialCounters.activeStreams, 1)
+	tunnelStreams.WithLabelValues(s.domainName).Inc()
+	return &streamConn{Conn: conn, site: s}
+}
+
+// streamConn is a net.Conn tracking throughput of a single stream dialed
+// through the reverse tunnel.
+type streamConn struct {
+	net.Conn
+	site *remoteSite
+	once sync.Once
+}
+
+func (c *streamConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.site.dialCounters.bytes, int64(n))
+	tunnelBytes.WithLabelValues(c.site.domainName).Add(float64(n))
+	return n, err
+}
+
+func (c *streamConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.site.dialCounters.bytes, int64(n))
+	tunnelBytes.WithLabelValues(c.site.domainName).Add(float64(n))
+	return n, err
+}
+
+func (c *streamConn) Close() error {
+	c.once.Do(func() {
+		atomic.AddInt64(&c.site.dialCounters.activeStreams, -1)
+		tunnelStreams.WithLabelValues(c.site.domainName).Dec()
+	})
+	return c.Conn.Close()
+}
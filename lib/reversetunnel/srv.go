@@ -57,12 +57,44 @@ var (
 		},
 		[]string{"cluster", "state"},
 	)
+	tunnelDialLatencies = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: teleport.MetricReverseTunnelDialSeconds,
+			Help: "Latency of dials through the reverse tunnel per remote cluster",
+		},
+		[]string{teleport.TagCluster},
+	)
+	tunnelDialFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: teleport.MetricReverseTunnelDialFailures,
+			Help: "Number of failed dials through the reverse tunnel per remote cluster",
+		},
+		[]string{teleport.TagCluster},
+	)
+	tunnelStreams = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: teleport.MetricReverseTunnelStreams,
+			Help: "Number of concurrent streams multiplexed over the reverse tunnel per remote cluster",
+		},
+		[]string{teleport.TagCluster},
+	)
+	tunnelBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: teleport.MetricReverseTunnelBytes,
+			Help: "Number of bytes copied over reverse tunnel streams per remote cluster",
+		},
+		[]string{teleport.TagCluster},
+	)
 )
 
 func init() {
 	// Metrics have to be registered to be exposed:
 	prometheus.MustRegister(remoteClustersStats)
 	prometheus.MustRegister(trustedClustersStats)
+	prometheus.MustRegister(tunnelDialLatencies)
+	prometheus.MustRegister(tunnelDialFailures)
+	prometheus.MustRegister(tunnelStreams)
+	prometheus.MustRegister(tunnelBytes)
 }
 
 // server is a "reverse tunnel server". it exposes the cluster capabilities
@@ -859,6 +891,18 @@ func (s *server) GetSites() []RemoteSite {
 	return out
 }
 
+// GetDialStats returns dial and throughput counters accumulated for every
+// connected remote cluster.
+func (s *server) GetDialStats() []DialStats {
+	s.RLock()
+	defer s.RUnlock()
+	out := make([]DialStats, 0, len(s.remoteSites))
+	for i := range s.remoteSites {
+		out = append(out, s.remoteSites[i].DialStats())
+	}
+	return out
+}
+
 func (s *server) getRemoteClusters() []*remoteSite {
 	s.RLock()
 	defer s.RUnlock()
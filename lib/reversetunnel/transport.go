@@ -30,6 +30,7 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/chaos"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/teleport/lib/utils/proxy"
@@ -399,6 +400,10 @@ func directDial(servers []string) (net.Conn, error) {
 	var errors []error
 
 	for _, addr := range servers {
+		if err := chaos.DialHook("tcp", addr); err != nil {
+			errors = append(errors, err)
+			continue
+		}
 		conn, err := net.Dial("tcp", addr)
 		if err == nil {
 			return conn, nil
@@ -33,13 +33,18 @@ import (
 	"github.com/gravitational/teleport/lib/backend/lite"
 	"github.com/gravitational/teleport/lib/backend/memory"
 	"github.com/gravitational/teleport/lib/bpf"
+	"github.com/gravitational/teleport/lib/cgroup"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/labels"
 	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/pam"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/srv"
+	"github.com/gravitational/teleport/lib/srv/regular"
 	"github.com/gravitational/teleport/lib/sshca"
 	"github.com/gravitational/teleport/lib/utils"
+	"github.com/gravitational/teleport/lib/webhooks"
 
 	"github.com/ghodss/yaml"
 	"github.com/gravitational/trace"
@@ -164,6 +169,14 @@ type Config struct {
 	// to inherit and use for listeners, used for in-process updates.
 	FileDescriptors []FileDescriptor
 
+	// OnReload is called when the process receives a reload signal (SIGHUP).
+	// The callback re-reads the configuration file, applies the changes that
+	// are safe to apply at runtime and returns the names of configuration
+	// sections whose changes require a restart. When the callback is not set,
+	// or restart-only changes are reported, the process falls back to a
+	// graceful restart.
+	OnReload func() ([]string, error) `json:"-"`
+
 	// PollingPeriod is set to override default internal polling periods
 	// of sync agents, used to speed up integration tests.
 	PollingPeriod time.Duration
@@ -175,6 +188,15 @@ type Config struct {
 	// ShutdownTimeout is set to override default shutdown timeout.
 	ShutdownTimeout time.Duration
 
+	// ClockSkewTolerance extends the validity window of certificates to
+	// tolerate wall clock drift between this host and the auth server.
+	ClockSkewTolerance time.Duration
+
+	// AuthorizationGracePeriod sets for how long nodes keep authorizing
+	// already-seen users from cached role data when the auth server is
+	// unreachable.
+	AuthorizationGracePeriod time.Duration
+
 	// CAPin is the SKPI hash of the CA used to verify the Auth Server.
 	CAPin string
 
@@ -250,6 +272,10 @@ type CachePolicy struct {
 	NeverExpires bool
 	// RecentTTL is the recently accessed items cache TTL
 	RecentTTL *time.Duration
+	// MaxStaleness is how long the auth server keeps serving
+	// last-known-good data for read-only queries after the
+	// backend becomes unavailable
+	MaxStaleness time.Duration
 }
 
 // GetRecentTTL either returns TTL that was set,
@@ -346,6 +372,14 @@ type ProxyConfig struct {
 	// list of host principals on the TLS and SSH certificate.
 	TunnelPublicAddrs []utils.NetAddr
 
+	// Templates map host name patterns requested by clients to label
+	// queries so nodes can be dialed by alias.
+	Templates []regular.ProxyTemplate
+
+	// HostResolver resolves requested host names via an external
+	// inventory when no node matches by name or address.
+	HostResolver regular.HostResolver
+
 	// Kube specifies kubernetes proxy configuration
 	Kube KubeProxyConfig
 }
@@ -414,6 +448,10 @@ type AuthConfig struct {
 	// used to bootstrap backend state on the first start.
 	Resources []services.Resource
 
+	// ApplyOnStartupResources is a set of resources reconciled
+	// into the backend on every start.
+	ApplyOnStartupResources []services.Resource
+
 	// Roles is a set of roles to pre-provision for this cluster
 	Roles []services.Role
 
@@ -435,6 +473,10 @@ type AuthConfig struct {
 	// NoAudit, when set to true, disables session recording and event audit
 	NoAudit bool
 
+	// SessionRecordingEncryption turns on encryption of stored session
+	// recordings with per-session keys.
+	SessionRecordingEncryption bool
+
 	// Preference defines the authentication preference (type and second factor) for
 	// the auth server.
 	Preference services.AuthPreference
@@ -445,10 +487,44 @@ type AuthConfig struct {
 	// LicenseFile is a full path to the license file
 	LicenseFile string
 
+	// NotificationWebhook optionally configures delivery of security events
+	// to an external webhook endpoint.
+	NotificationWebhook *events.WebhookEmitterConfig
+
+	// AuditRedaction configures redaction of audit events exported to
+	// external sinks, the local audit log keeps full fidelity events.
+	AuditRedaction events.RedactionRules
+
+	// NodeWebhooks optionally configures delivery of node lifecycle
+	// notifications (joined, expired, deleted) to external endpoints.
+	NodeWebhooks []webhooks.Hook
+
+	// Reconcile configures continuous re-assertion of the resources
+	// applied on startup.
+	Reconcile ReconcileConfig
+
 	// PublicAddrs affects the SSH host principals and DNS names added to the SSH and TLS certs.
 	PublicAddrs []utils.NetAddr
 }
 
+// ReconcileConfig configures continuous reconciliation of the
+// resources applied on startup
+type ReconcileConfig struct {
+	// Enabled turns the reconcile loop on
+	Enabled bool
+
+	// Period is how often declared resources are compared
+	// with the backend state
+	Period time.Duration
+
+	// DefaultMode is applied to resource kinds without an explicit
+	// mode, either "revert" or "alert"
+	DefaultMode string
+
+	// Modes optionally overrides the reconcile mode per resource kind
+	Modes map[string]string
+}
+
 // SSHConfig configures SSH server node role
 type SSHConfig struct {
 	Enabled               bool
@@ -460,14 +536,41 @@ type SSHConfig struct {
 	CmdLabels             services.CommandLabels
 	PermitUserEnvironment bool
 
+	// LabelProviders configures external processes queried on a period
+	// for additional node labels.
+	LabelProviders []labels.ProviderConfig
+
+	// AcceptEnv is a list of environment variable names (with optional
+	// trailing '*' wildcard) clients are allowed to set in their sessions.
+	// When empty, all variables sent by the client are accepted.
+	AcceptEnv []string
+
 	// PAM holds PAM configuration for Teleport.
 	PAM *pam.Config
 
+	// HostUsers holds configuration for automatic creation of local Unix
+	// accounts.
+	HostUsers *srv.HostUsersConfig
+
 	// PublicAddrs affects the SSH host principals and DNS names added to the SSH and TLS certs.
 	PublicAddrs []utils.NetAddr
 
 	// BPF holds BPF configuration for Teleport.
 	BPF *bpf.Config
+
+	// SessionLimits holds cgroupv2 resource limits applied to each session
+	// spawned on this node. Nil means no limits.
+	SessionLimits *cgroup.Limits
+
+	// Banner is a message presented to the client before authentication.
+	Banner string
+
+	// MOTD is a message of the day shown to the user after login.
+	MOTD string
+
+	// ReportUtilization enables reporting of CPU, memory and disk
+	// utilization gauges in this node's heartbeats.
+	ReportUtilization bool
 }
 
 // KubeConfig specifies configuration for kubernetes service
@@ -534,6 +637,7 @@ func ApplyDefaults(cfg *Config) {
 	cfg.Ciphers = sc.Ciphers
 	cfg.KEXAlgorithms = kex
 	cfg.MACAlgorithms = macs
+	cfg.AuthorizationGracePeriod = defaults.AuthorizationGracePeriod
 
 	// Auth service defaults.
 	cfg.Auth.Enabled = true
@@ -565,6 +669,7 @@ func ApplyDefaults(cfg *Config) {
 	cfg.SSH.Shell = defaults.DefaultShell
 	defaults.ConfigureLimiter(&cfg.SSH.Limiter)
 	cfg.SSH.PAM = &pam.Config{Enabled: false}
+	cfg.SSH.HostUsers = &srv.HostUsersConfig{Enabled: false}
 	cfg.SSH.BPF = &bpf.Config{Enabled: false}
 
 	// Kubernetes service defaults.
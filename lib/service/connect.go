@@ -30,6 +30,7 @@ import (
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/events"
 	"github.com/gravitational/teleport/lib/reversetunnel"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/tlsca"
@@ -683,6 +684,16 @@ func (process *TeleportProcess) rotate(conn *Connector, localState auth.StateV2,
 				if err != nil {
 					return nil, trace.Wrap(err)
 				}
+				// the event is advisory, a failure to deliver it should
+				// not block the certificate update.
+				if err := conn.Client.EmitAuditEventLegacy(events.CertificateReissueE, events.EventFields{
+					"role":       id.Role.String(),
+					"host_id":    id.HostUUID,
+					"principals": additionalPrincipals,
+					"dns_names":  dnsNames,
+				}); err != nil {
+					process.Debugf("Failed to emit certificate reissue event: %v.", err)
+				}
 				return &rotationStatus{needsReload: true}, nil
 			}
 			return &rotationStatus{}, nil
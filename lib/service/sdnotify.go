@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// notifySystemd implements the systemd Type=notify protocol: it signals
+// READY=1 once all enabled roles are actually serving, feeds the watchdog
+// at half the interval requested via WATCHDOG_USEC and signals STOPPING=1
+// on shutdown. It is a no-op when the process is not running under a
+// systemd notify unit.
+func (process *TeleportProcess) notifySystemd() {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return
+	}
+
+	// wait until all enabled roles have reported readiness
+	eventC := make(chan Event, 1)
+	process.WaitForEvent(process.ExitContext(), TeleportReadyEvent, eventC)
+	select {
+	case <-eventC:
+	case <-process.ExitContext().Done():
+		return
+	}
+	if err := sdNotify("READY=1"); err != nil {
+		process.Warningf("Failed to notify systemd of readiness: %v.", err)
+		return
+	}
+	process.Infof("Notified systemd that the process is ready.")
+
+	interval := watchdogInterval()
+	if interval <= 0 {
+		return
+	}
+
+	// feed the watchdog until the process exits or a critical service
+	// fails; in the latter case stop feeding so systemd restarts the unit
+	serviceErrorsC := make(chan Event, 10)
+	process.WaitForEvent(process.ExitContext(), ServiceExitedWithErrorEvent, serviceErrorsC)
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := sdNotify("WATCHDOG=1"); err != nil {
+				process.Warningf("Failed to feed systemd watchdog: %v.", err)
+			}
+		case event := <-serviceErrorsC:
+			se, ok := event.Payload.(ExitEventPayload)
+			if !ok || !se.Service.IsCritical() {
+				continue
+			}
+			process.Warningf("Critical service %v has failed, no longer feeding systemd watchdog.", se.Service)
+			return
+		case <-process.ExitContext().Done():
+			warnOnErr(sdNotify("STOPPING=1"))
+			return
+		}
+	}
+}
+
+// watchdogInterval returns the watchdog interval requested by systemd via
+// WATCHDOG_USEC, or zero when the watchdog is not enabled for this process
+func watchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// sdNotify sends a single state message to the systemd notify socket
+func sdNotify(state string) error {
+	name := os.Getenv("NOTIFY_SOCKET")
+	if name == "" {
+		return trace.NotFound("NOTIFY_SOCKET is not set")
+	}
+	// an address starting with "@" denotes an abstract socket
+	if name[0] == '@' {
+		name = "\x00" + name[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
@@ -30,6 +30,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -46,8 +47,10 @@ import (
 	"github.com/gravitational/teleport/lib/backend/firestore"
 	"github.com/gravitational/teleport/lib/backend/lite"
 	"github.com/gravitational/teleport/lib/backend/memory"
+	"github.com/gravitational/teleport/lib/backend/mysql"
 	"github.com/gravitational/teleport/lib/bpf"
 	"github.com/gravitational/teleport/lib/cache"
+	"github.com/gravitational/teleport/lib/chaos"
 	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
@@ -55,19 +58,23 @@ import (
 	"github.com/gravitational/teleport/lib/events/filesessions"
 	"github.com/gravitational/teleport/lib/events/firestoreevents"
 	"github.com/gravitational/teleport/lib/events/gcssessions"
+	"github.com/gravitational/teleport/lib/events/s3archive"
 	"github.com/gravitational/teleport/lib/events/s3sessions"
 	kubeproxy "github.com/gravitational/teleport/lib/kube/proxy"
+	"github.com/gravitational/teleport/lib/labels"
 	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/modules"
 	"github.com/gravitational/teleport/lib/multiplexer"
 	"github.com/gravitational/teleport/lib/reversetunnel"
 	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/services/local"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/srv"
 	"github.com/gravitational/teleport/lib/srv/regular"
 	"github.com/gravitational/teleport/lib/system"
 	"github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/teleport/lib/web"
+	"github.com/gravitational/teleport/lib/webhooks"
 	"github.com/gravitational/trace"
 
 	"github.com/gravitational/roundtrip"
@@ -694,6 +701,9 @@ func NewTeleport(cfg *Config) (*TeleportProcess, error) {
 	// notify parent process that this process has started
 	go process.notifyParent()
 
+	// notify systemd when running under a Type=notify unit
+	go process.notifySystemd()
+
 	return process, nil
 }
 
@@ -894,12 +904,59 @@ func initExternalLog(auditConfig services.AuditConfig) (events.IAuditLog, error)
 			if err := os.MkdirAll(uri.Path, teleport.SharedDirMode); err != nil {
 				return nil, trace.ConvertSystemError(err)
 			}
-			logger, err := events.NewFileLog(events.FileLogConfig{
+			fileLogConfig := events.FileLogConfig{
 				Dir: uri.Path,
+			}
+			if maxSize := uri.Query().Get("max_size"); maxSize != "" {
+				fileLogConfig.MaxFileSize, err = strconv.ParseInt(maxSize, 10, 64)
+				if err != nil {
+					return nil, trace.BadParameter("unsupported audit uri: %q (invalid 'max_size' parameter: %v)", uri, err)
+				}
+			}
+			if compress := uri.Query().Get("compress"); compress != "" {
+				fileLogConfig.CompressRotated, err = strconv.ParseBool(compress)
+				if err != nil {
+					return nil, trace.BadParameter("unsupported audit uri: %q (invalid 'compress' parameter: %v)", uri, err)
+				}
+			}
+			if sync := uri.Query().Get("sync"); sync != "" {
+				fileLogConfig.SyncWrites, err = strconv.ParseBool(sync)
+				if err != nil {
+					return nil, trace.BadParameter("unsupported audit uri: %q (invalid 'sync' parameter: %v)", uri, err)
+				}
+			}
+			logger, err := events.NewFileLog(fileLogConfig)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			loggers = append(loggers, logger)
+		case teleport.SchemeS3:
+			// S3 cold storage tier wrapping a local file log,
+			// the local directory holding the hot tier is passed
+			// in the 'dir' query parameter
+			dir := uri.Query().Get("dir")
+			if dir == "" {
+				return nil, trace.BadParameter("unsupported audit uri: %q (missing 'dir' parameter pointing to the local log directory)", uri)
+			}
+			if err := os.MkdirAll(dir, teleport.SharedDirMode); err != nil {
+				return nil, trace.ConvertSystemError(err)
+			}
+			localLog, err := events.NewFileLog(events.FileLogConfig{
+				Dir: dir,
 			})
 			if err != nil {
 				return nil, trace.Wrap(err)
 			}
+			cfg := s3archive.Config{
+				LocalLog: localLog,
+			}
+			if err := cfg.SetFromURL(uri, auditConfig.Region); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			logger, err := s3archive.New(context.TODO(), cfg)
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
 			loggers = append(loggers, logger)
 		case teleport.SchemeStdout:
 			logger := events.NewWriterEmitter(utils.NopWriteCloser(os.Stdout))
@@ -944,6 +1001,13 @@ func (process *TeleportProcess) initAuthService() error {
 	var emitter events.Emitter
 	var streamer events.Streamer
 	var uploadHandler events.MultipartHandler
+	var recordingEncryptor *events.RecordingEncryptor
+	if cfg.Auth.SessionRecordingEncryption {
+		recordingEncryptor, err = auth.LoadRecordingEncryptor(b)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+	}
 	// create the audit log, which will be consuming (and recording) all events
 	// and recording all sessions.
 	if cfg.Auth.NoAudit {
@@ -976,6 +1040,13 @@ func (process *TeleportProcess) initAuthService() error {
 				return trace.Wrap(err)
 			}
 		}
+		if recordingEncryptor != nil && uploadHandler != nil {
+			encryptedHandler, err := events.NewEncryptedHandler(uploadHandler, recordingEncryptor)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			uploadHandler = encryptedHandler
+		}
 
 		streamer, err = events.NewProtoStreamer(events.ProtoStreamerConfig{
 			Uploader: uploadHandler,
@@ -991,6 +1062,9 @@ func (process *TeleportProcess) initAuthService() error {
 				return trace.Wrap(err)
 			}
 		}
+		if externalLog != nil && !cfg.Auth.AuditRedaction.IsEmpty() {
+			externalLog = events.NewRedactingLog(externalLog, cfg.Auth.AuditRedaction)
+		}
 
 		auditServiceConfig := events.AuditLogConfig{
 			Context:        process.ExitContext(),
@@ -1033,8 +1107,17 @@ func (process *TeleportProcess) initAuthService() error {
 		}
 	}
 
+	emitters := []events.Emitter{events.NewLoggingEmitter(), emitter}
+	if cfg.Auth.NotificationWebhook != nil {
+		webhookEmitter, err := events.NewWebhookEmitter(*cfg.Auth.NotificationWebhook)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		emitters = append(emitters, webhookEmitter)
+	}
+
 	checkingEmitter, err := events.NewCheckingEmitter(events.CheckingEmitterConfig{
-		Inner: events.NewMultiEmitter(events.NewLoggingEmitter(), emitter),
+		Inner: events.NewMultiEmitter(emitters...),
 		Clock: process.Clock,
 	})
 	if err != nil {
@@ -1051,33 +1134,35 @@ func (process *TeleportProcess) initAuthService() error {
 
 	// first, create the AuthServer
 	authServer, err := auth.Init(auth.InitConfig{
-		Backend:              b,
-		Authority:            cfg.Keygen,
-		ClusterConfiguration: cfg.ClusterConfiguration,
-		ClusterConfig:        cfg.Auth.ClusterConfig,
-		ClusterName:          cfg.Auth.ClusterName,
-		AuthServiceName:      cfg.Hostname,
-		DataDir:              cfg.DataDir,
-		HostUUID:             cfg.HostUUID,
-		NodeName:             cfg.Hostname,
-		Authorities:          cfg.Auth.Authorities,
-		Resources:            cfg.Auth.Resources,
-		ReverseTunnels:       cfg.ReverseTunnels,
-		Trust:                cfg.Trust,
-		Presence:             cfg.Presence,
-		Events:               cfg.Events,
-		Provisioner:          cfg.Provisioner,
-		Identity:             cfg.Identity,
-		Access:               cfg.Access,
-		StaticTokens:         cfg.Auth.StaticTokens,
-		Roles:                cfg.Auth.Roles,
-		AuthPreference:       cfg.Auth.Preference,
-		OIDCConnectors:       cfg.OIDCConnectors,
-		AuditLog:             process.auditLog,
-		CipherSuites:         cfg.CipherSuites,
-		CASigningAlg:         cfg.CASignatureAlgorithm,
-		Emitter:              checkingEmitter,
-		Streamer:             events.NewReportingStreamer(checkingStreamer, process.Config.UploadEventsC),
+		Backend:                 b,
+		Authority:               cfg.Keygen,
+		ClusterConfiguration:    cfg.ClusterConfiguration,
+		ClusterConfig:           cfg.Auth.ClusterConfig,
+		ClusterName:             cfg.Auth.ClusterName,
+		AuthServiceName:         cfg.Hostname,
+		DataDir:                 cfg.DataDir,
+		HostUUID:                cfg.HostUUID,
+		NodeName:                cfg.Hostname,
+		Authorities:             cfg.Auth.Authorities,
+		Resources:               cfg.Auth.Resources,
+		ApplyOnStartupResources: cfg.Auth.ApplyOnStartupResources,
+		ReverseTunnels:          cfg.ReverseTunnels,
+		Trust:                   cfg.Trust,
+		Presence:                cfg.Presence,
+		Events:                  cfg.Events,
+		Provisioner:             cfg.Provisioner,
+		Identity:                cfg.Identity,
+		Access:                  cfg.Access,
+		StaticTokens:            cfg.Auth.StaticTokens,
+		Roles:                   cfg.Auth.Roles,
+		AuthPreference:          cfg.Auth.Preference,
+		OIDCConnectors:          cfg.OIDCConnectors,
+		AuditLog:                process.auditLog,
+		CipherSuites:            cfg.CipherSuites,
+		CASigningAlg:            cfg.CASignatureAlgorithm,
+		Emitter:                 checkingEmitter,
+		Streamer:                events.NewReportingStreamer(checkingStreamer, process.Config.UploadEventsC),
+		RecordingEncryptor:      recordingEncryptor,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -1112,11 +1197,12 @@ func (process *TeleportProcess) initAuthService() error {
 	var authCache auth.Cache
 	if process.Config.CachePolicy.Enabled {
 		cache, err := process.newAccessCache(accessCacheConfig{
-			services:  authServer.Services,
-			setup:     cache.ForAuth,
-			cacheName: []string{teleport.ComponentAuth},
-			inMemory:  true,
-			events:    true,
+			services:     authServer.Services,
+			setup:        cache.ForAuth,
+			cacheName:    []string{teleport.ComponentAuth},
+			inMemory:     true,
+			events:       true,
+			maxStaleness: cfg.CachePolicy.MaxStaleness,
 		})
 		if err != nil {
 			return trace.Wrap(err)
@@ -1274,6 +1360,45 @@ func (process *TeleportProcess) initAuthService() error {
 		return trace.Wrap(err)
 	}
 	process.RegisterFunc("auth.heartbeat", heartbeat.Run)
+
+	// continuously re-assert the declared resources if reconciliation
+	// is enabled
+	if cfg.Auth.Reconcile.Enabled {
+		if len(cfg.Auth.ApplyOnStartupResources) == 0 {
+			return trace.BadParameter("reconcile is enabled but no resources were supplied, use --apply-on-startup")
+		}
+		reconciler, err := local.NewReconciler(local.ReconcilerConfig{
+			Backend:     b,
+			Resources:   cfg.Auth.ApplyOnStartupResources,
+			Period:      cfg.Auth.Reconcile.Period,
+			DefaultMode: cfg.Auth.Reconcile.DefaultMode,
+			Modes:       cfg.Auth.Reconcile.Modes,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		process.RegisterFunc("auth.reconcile", func() error {
+			return trace.Wrap(reconciler.Run(process.ExitContext()))
+		})
+	}
+
+	// fire node lifecycle webhooks if any endpoints are configured
+	var nodeNotifier *webhooks.Notifier
+	if len(cfg.Auth.NodeWebhooks) != 0 {
+		nodeNotifier, err = webhooks.NewNotifier(webhooks.NotifierConfig{
+			Context: process.ExitContext(),
+			Client:  authServer,
+			Hooks:   cfg.Auth.NodeWebhooks,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		// fan certificate expiry warnings out to the same hooks
+		authServer.SetCertExpiryNotify(func(warning auth.CertExpiryWarning) {
+			nodeNotifier.NotifyCertExpiry(warning.Type, warning.Cluster, warning.NotAfter)
+		})
+	}
+
 	// execute this when process is asked to exit:
 	process.onExit("auth.shutdown", func(payload interface{}) {
 		// The listeners have to be closed here, because if shutdown
@@ -1297,6 +1422,9 @@ func (process *TeleportProcess) initAuthService() error {
 		if uploadCompleter != nil {
 			warnOnErr(uploadCompleter.Close())
 		}
+		if nodeNotifier != nil {
+			warnOnErr(nodeNotifier.Close())
+		}
 		log.Info("Exited.")
 	})
 	return nil
@@ -1343,6 +1471,10 @@ type accessCacheConfig struct {
 	events bool
 	// pollPeriod contains period for polling
 	pollPeriod time.Duration
+	// maxStaleness is how long the cache keeps serving last-known-good
+	// data after the backend becomes unavailable, only used with the
+	// "only recent" cache behavior
+	maxStaleness time.Duration
 }
 
 func (c *accessCacheConfig) CheckAndSetDefaults() error {
@@ -1405,7 +1537,7 @@ func (process *TeleportProcess) newAccessCache(cfg accessCacheConfig) (*cache.Ca
 		return nil, trace.Wrap(err)
 	}
 
-	return cache.New(cfg.setup(cache.Config{
+	cacheConfig := cache.Config{
 		Context:         process.ExitContext(),
 		Backend:         reporter,
 		Events:          cfg.services,
@@ -1418,7 +1550,14 @@ func (process *TeleportProcess) newAccessCache(cfg accessCacheConfig) (*cache.Ca
 		Presence:        cfg.services,
 		Component:       teleport.Component(append(cfg.cacheName, process.id, teleport.ComponentCache)...),
 		MetricComponent: teleport.Component(append(cfg.cacheName, teleport.ComponentCache)...),
-	}))
+	}
+	if cfg.maxStaleness != 0 {
+		cacheConfig.OnlyRecent = cache.OnlyRecent{
+			Enabled:      true,
+			MaxStaleness: cfg.maxStaleness,
+		}
+	}
+	return cache.New(cfg.setup(cacheConfig))
 }
 
 // setupCachePolicy sets up cache policy based on teleport configuration,
@@ -1487,6 +1626,7 @@ func (process *TeleportProcess) initSSH() error {
 	var agentPool *reversetunnel.AgentPool
 	var conn *Connector
 	var ebpf bpf.BPF
+	var sessionLimiter *srv.SessionLimiter
 	var s *regular.Server
 
 	process.RegisterCriticalFunc("ssh.node", func() error {
@@ -1518,6 +1658,10 @@ func (process *TeleportProcess) initSSH() error {
 			return trace.Wrap(err)
 		}
 
+		// Compare the local wall clock against the auth server to warn about
+		// drifted clocks early, before certificates start being rejected.
+		process.checkClockDrift(conn.Client, log)
+
 		// If session recording is disabled at the cluster level and the node is
 		// attempting to enabled enhanced session recording, show an error.
 		clusterConfig, err := authClient.GetClusterConfig()
@@ -1547,6 +1691,15 @@ func (process *TeleportProcess) initSSH() error {
 			return trace.Wrap(err)
 		}
 
+		// If resource limits were configured for sessions, set up the cgroup
+		// hierarchy they are enforced through. This only works on Linux.
+		if cfg.SSH.SessionLimits != nil {
+			sessionLimiter, err = srv.NewSessionLimiter(cfg.SSH.SessionLimits)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+		}
+
 		// make sure the namespace exists
 		namespace := services.ProcessNamespace(cfg.SSH.Namespace)
 		_, err = authClient.GetNamespace(namespace)
@@ -1592,6 +1745,15 @@ func (process *TeleportProcess) initSSH() error {
 			return trace.Wrap(err)
 		}
 
+		labelProviders := make([]*labels.Provider, 0, len(cfg.SSH.LabelProviders))
+		for _, providerConfig := range cfg.SSH.LabelProviders {
+			provider, err := labels.NewProvider(providerConfig)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			labelProviders = append(labelProviders, provider)
+		}
+
 		s, err = regular.New(cfg.SSH.Addr,
 			cfg.Hostname,
 			[]ssh.Signer{conn.ServerIdentity.KeySigner},
@@ -1604,16 +1766,25 @@ func (process *TeleportProcess) initSSH() error {
 			regular.SetEmitter(&events.StreamerAndEmitter{Emitter: emitter, Streamer: streamer}),
 			regular.SetSessionServer(conn.Client),
 			regular.SetLabels(cfg.SSH.Labels, cfg.SSH.CmdLabels),
+			regular.SetLabelProviders(labelProviders...),
 			regular.SetNamespace(namespace),
 			regular.SetPermitUserEnvironment(cfg.SSH.PermitUserEnvironment),
+			regular.SetAcceptEnv(cfg.SSH.AcceptEnv),
 			regular.SetCiphers(cfg.Ciphers),
 			regular.SetKEXAlgorithms(cfg.KEXAlgorithms),
 			regular.SetMACAlgorithms(cfg.MACAlgorithms),
 			regular.SetPAMConfig(cfg.SSH.PAM),
+			regular.SetHostUsersConfig(cfg.SSH.HostUsers),
 			regular.SetRotationGetter(process.getRotation),
 			regular.SetUseTunnel(conn.UseTunnel()),
 			regular.SetFIPS(cfg.FIPS),
 			regular.SetBPF(ebpf),
+			regular.SetSessionLimiter(sessionLimiter),
+			regular.SetBanner(cfg.SSH.Banner),
+			regular.SetMOTD(cfg.SSH.MOTD),
+			regular.SetUtilizationReporting(cfg.SSH.ReportUtilization),
+			regular.SetClockSkewTolerance(cfg.ClockSkewTolerance),
+			regular.SetAuthorizationGracePeriod(cfg.AuthorizationGracePeriod),
 			regular.SetOnHeartbeat(func(err error) {
 				if err != nil {
 					process.BroadcastEvent(Event{Name: TeleportDegradedEvent, Payload: teleport.ComponentNode})
@@ -1662,14 +1833,17 @@ func (process *TeleportProcess) initSSH() error {
 			agentPool, err = reversetunnel.NewAgentPool(
 				process.ExitContext(),
 				reversetunnel.AgentPoolConfig{
-					Component:   teleport.ComponentNode,
-					HostUUID:    conn.ServerIdentity.ID.HostUUID,
-					ProxyAddr:   conn.TunnelProxy(),
-					Client:      conn.Client,
-					AccessPoint: conn.Client,
-					HostSigner:  conn.ServerIdentity.KeySigner,
-					Cluster:     conn.ServerIdentity.Cert.Extensions[utils.CertExtensionAuthority],
-					Server:      s,
+					Component:     teleport.ComponentNode,
+					HostUUID:      conn.ServerIdentity.ID.HostUUID,
+					ProxyAddr:     conn.TunnelProxy(),
+					Client:        conn.Client,
+					AccessPoint:   conn.Client,
+					HostSigner:    conn.ServerIdentity.KeySigner,
+					Cluster:       conn.ServerIdentity.Cert.Extensions[utils.CertExtensionAuthority],
+					Server:        s,
+					Ciphers:       cfg.Ciphers,
+					KEXAlgorithms: cfg.KEXAlgorithms,
+					MACAlgorithms: cfg.MACAlgorithms,
 				})
 			if err != nil {
 				return trace.Wrap(err)
@@ -1717,12 +1891,48 @@ func (process *TeleportProcess) initSSH() error {
 			warnOnErr(ebpf.Close())
 		}
 
+		if sessionLimiter != nil {
+			// Release the cgroup hierarchy used for resource limits.
+			warnOnErr(sessionLimiter.Close())
+		}
+
 		log.Infof("Exited.")
 	})
 
 	return nil
 }
 
+// checkClockDrift compares the local wall clock against the auth server and
+// warns when the drift exceeds a threshold, as drifted clocks eventually
+// make hosts reject freshly issued certificates. The check is advisory, a
+// failure to read the time of the auth server is not fatal.
+func (process *TeleportProcess) checkClockDrift(client *auth.Client, log *logrus.Entry) {
+	serverTime, err := client.GetServerTime()
+	if err != nil {
+		log.Debugf("Failed to read time of the auth server: %v.", err)
+		return
+	}
+	drift := time.Now().UTC().Sub(serverTime)
+	if drift < 0 {
+		drift = -drift
+	}
+	threshold := process.Config.ClockSkewTolerance
+	if threshold == 0 {
+		threshold = defaults.ClockDriftThreshold
+	}
+	if drift < threshold {
+		return
+	}
+	log.Warningf("Wall clock of this host differs from the auth server by %v, "+
+		"certificate validation will eventually start failing, check NTP synchronization.", drift)
+	if err := client.EmitAuditEventLegacy(events.ClockDriftE, events.EventFields{
+		"drift":     drift.String(),
+		"threshold": threshold.String(),
+	}); err != nil {
+		log.Debugf("Failed to emit clock drift event: %v.", err)
+	}
+}
+
 // registerWithAuthServer uses one time provisioning token obtained earlier
 // from the server to get a pair of SSH keys signed by Auth server host
 // certificate authority
@@ -2005,9 +2215,9 @@ func (process *TeleportProcess) getAdditionalPrincipals(role teleport.Role) ([]s
 
 // initProxy gets called if teleport runs with 'proxy' role enabled.
 // this means it will do two things:
-//    1. serve a web UI
-//    2. proxy SSH connections to nodes running with 'node' role
-//    3. take care of reverse tunnels
+//  1. serve a web UI
+//  2. proxy SSH connections to nodes running with 'node' role
+//  3. take care of reverse tunnels
 func (process *TeleportProcess) initProxy() error {
 	// if no TLS key was provided for the web UI, generate a self signed cert
 	if process.Config.Proxy.TLSKey == "" && !process.Config.Proxy.DisableTLS && !process.Config.Proxy.DisableWebService {
@@ -2348,6 +2558,8 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 		process.proxyPublicAddr(),
 		regular.SetLimiter(proxyLimiter),
 		regular.SetProxyMode(tsrv),
+		regular.SetProxyTemplates(cfg.Proxy.Templates),
+		regular.SetHostResolver(cfg.Proxy.HostResolver),
 		regular.SetSessionServer(conn.Client),
 		regular.SetCiphers(cfg.Ciphers),
 		regular.SetKEXAlgorithms(cfg.KEXAlgorithms),
@@ -2386,6 +2598,9 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 		LocalCluster:        conn.ServerIdentity.Cert.Extensions[utils.CertExtensionAuthority],
 		KubeDialAddr:        utils.DialAddrFromListenAddr(cfg.Proxy.Kube.ListenAddr),
 		ReverseTunnelServer: tsrv,
+		Ciphers:             cfg.Ciphers,
+		KEXAlgorithms:       cfg.KEXAlgorithms,
+		MACAlgorithms:       cfg.MACAlgorithms,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -2430,6 +2645,7 @@ func (process *TeleportProcess) initProxyEndpoint(conn *Connector) error {
 				ServerID:        cfg.HostUUID,
 				ClusterOverride: cfg.Proxy.Kube.ClusterOverride,
 				KubeconfigPath:  cfg.Proxy.Kube.KubeconfigPath,
+				CipherSuites:    cfg.CipherSuites,
 			},
 			TLS:           tlsConfig,
 			LimiterConfig: cfg.Proxy.Limiter,
@@ -2538,12 +2754,18 @@ func (process *TeleportProcess) initAuthStorage() (bk backend.Backend, err error
 	// etcd backend.
 	case etcdbk.GetName():
 		bk, err = etcdbk.New(ctx, bc.Params)
+	// MySQL/MariaDB backend.
+	case mysql.GetName():
+		bk, err = mysql.New(ctx, bc.Params)
 	default:
 		err = trace.BadParameter("unsupported secrets storage type: %q", bc.Type)
 	}
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	// in chaos builds the backend is wrapped with a fault
+	// injection layer, a no-op in regular builds
+	bk = chaos.WrapBackend(bk)
 	if err := bk.Migrate(ctx); err != nil {
 		return nil, trace.Wrap(err)
 	}
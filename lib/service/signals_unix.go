@@ -0,0 +1,272 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2018-2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+
+	"github.com/gravitational/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// WaitForSignals waits for system signals and processes them.
+// Should not be called twice by the process.
+func (process *TeleportProcess) WaitForSignals(ctx context.Context) error {
+
+	sigC := make(chan os.Signal, 1024)
+	// Note: SIGKILL can't be trapped.
+	signal.Notify(sigC,
+		syscall.SIGQUIT, // graceful shutdown
+		syscall.SIGTERM, // graceful shutdown with session drain timeout
+		syscall.SIGINT,  // fast shutdown
+		syscall.SIGUSR1, // log process diagnostic info
+		syscall.SIGUSR2, // initiate process restart procedure
+		syscall.SIGHUP,  // graceful restart procedure
+		syscall.SIGCHLD, // collect child status
+	)
+
+	doneContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serviceErrorsC := make(chan Event, 10)
+	process.WaitForEvent(ctx, ServiceExitedWithErrorEvent, serviceErrorsC)
+
+	// Block until a signal is received or handler got an error.
+	// Notice how this handler is serialized - it will only receive
+	// signals in sequence and will not run in parallel.
+	for {
+		select {
+		case signal := <-sigC:
+			switch signal {
+			case syscall.SIGQUIT:
+				go process.printShutdownStatus(doneContext)
+				process.Shutdown(ctx)
+				process.Infof("All services stopped, exiting.")
+				return nil
+			case syscall.SIGTERM:
+				drainTimeout := process.Config.ShutdownTimeout
+				if drainTimeout == 0 {
+					drainTimeout = defaults.DefaultGracefulShutdownTimeout
+				}
+				process.Infof("Got signal %q, shutting down gracefully, draining active sessions for up to %v.", signal, drainTimeout)
+				go process.printShutdownStatus(doneContext)
+				timeoutCtx, timeoutCancel := context.WithTimeout(ctx, drainTimeout)
+				process.Shutdown(timeoutCtx)
+				timeoutCancel()
+				process.Infof("All services stopped or drain timeout passed, exiting.")
+				return nil
+			case syscall.SIGKILL, syscall.SIGINT:
+				process.Infof("Got signal %q, exiting immediately.", signal)
+				process.Close()
+				return nil
+			case syscall.SIGUSR1:
+				// All programs placed diagnostics on the standard output.
+				// This had always caused trouble when the output was redirected into a file, but became intolerable
+				// when the output was sent to an unsuspecting process.
+				// Nevertheless, unwilling to violate the simplicity of the standard-input-standard-output model,
+				// people tolerated this state of affairs through v6. Shortly thereafter Dennis Ritchie cut the Gordian
+				// knot by introducing the standard error file.
+				// That was not quite enough. With pipelines diagnostics could come from any of several programs running simultaneously.
+				// Diagnostics needed to identify themselves.
+				// - Doug McIllroy, "A Research UNIX Reader: Annotated Excerpts from the Programmer’s Manual, 1971-1986"
+				process.Infof("Got signal %q, logging diagostic info to stderr.", signal)
+				writeDebugInfo(os.Stderr)
+			case syscall.SIGUSR2:
+				log.Infof("Got signal %q, forking a new process.", signal)
+				if err := process.forkChild(); err != nil {
+					process.Warningf("Failed to fork: %v", err)
+				} else {
+					process.Infof("Successfully started new process.")
+				}
+			case syscall.SIGHUP:
+				if process.Config.OnReload != nil {
+					restartSections, err := process.Config.OnReload()
+					if err != nil {
+						process.Warningf("Failed to reload configuration: %v.", err)
+					} else if len(restartSections) == 0 {
+						process.Infof("Got signal %q, configuration reloaded in place.", signal)
+						continue
+					} else {
+						process.Infof("Changes to %v can not be applied at runtime and require a restart.", restartSections)
+					}
+				}
+				process.Infof("Got signal %q, performing graceful restart.", signal)
+				if err := process.forkChild(); err != nil {
+					process.Warningf("Failed to fork: %v", err)
+					continue
+				}
+				process.Infof("Successfully started new process, shutting down gracefully.")
+				go process.printShutdownStatus(doneContext)
+				process.Shutdown(ctx)
+				log.Infof("All services stopped, exiting.")
+				return nil
+			case syscall.SIGCHLD:
+				process.collectStatuses()
+			default:
+				process.Infof("Ignoring %q.", signal)
+			}
+		case <-process.ReloadContext().Done():
+			process.Infof("Exiting signal handler: process has started internal reload.")
+			return ErrTeleportReloading
+		case <-process.ExitContext().Done():
+			process.Infof("Someone else has closed context, exiting.")
+			return nil
+		case <-ctx.Done():
+			process.Close()
+			if err := process.Wait(); err != nil {
+				process.Warnf("Error waiting for all services to exit: %v", err)
+			}
+			process.Info("Got request to shutdown, context is closing")
+			return nil
+		case event := <-serviceErrorsC:
+			se, ok := event.Payload.(ExitEventPayload)
+			if !ok {
+				process.Warningf("Failed to decode service exit event, %T", event.Payload)
+				continue
+			}
+			if se.Service.IsCritical() {
+				process.Errorf("Critical service %v has exited with error %v, aborting.", se.Service, se.Error)
+				if err := process.Close(); err != nil {
+					process.Errorf("Error when shutting down teleport %v.", err)
+				}
+				return trace.Wrap(se.Error)
+			}
+			process.Warningf("Non-critical service %v has exited with error %v, continuing to operate.", se.Service, se.Error)
+		}
+	}
+}
+
+func (process *TeleportProcess) forkChild() error {
+	readPipe, writePipe, err := os.Pipe()
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer readPipe.Close()
+	defer writePipe.Close()
+
+	path, err := execPath()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	workingDir, err := os.Getwd()
+	if nil != err {
+		return err
+	}
+
+	log := log.WithFields(logrus.Fields{"path": path, "workingDir": workingDir})
+
+	log.Info("Forking child.")
+
+	listenerFiles, err := process.ExportFileDescriptors()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	listenerFiles = append(listenerFiles, FileDescriptor{
+		File:    writePipe,
+		Type:    signalPipeName,
+		Address: "127.0.0.1:0",
+	})
+
+	// These files will be passed to the child process
+	files := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	for _, f := range listenerFiles {
+		files = append(files, f.File)
+	}
+
+	// Serialize files to JSON string representation
+	vals, err := filesToString(listenerFiles)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Infof("Passing %s to child", vals)
+	os.Setenv(teleportFilesEnvVar, vals)
+
+	p, err := os.StartProcess(path, os.Args, &os.ProcAttr{
+		Dir:   workingDir,
+		Env:   os.Environ(),
+		Files: files,
+		Sys:   &syscall.SysProcAttr{},
+	})
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	process.pushForkedPID(p.Pid)
+	log.WithFields(logrus.Fields{"pid": p.Pid}).Infof("Forked new child process.")
+
+	messageReceived, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go func() {
+		data := make([]byte, 1024)
+		len, err := readPipe.Read(data)
+		if err != nil {
+			log.Debugf("Failed to read from pipe")
+			return
+		}
+		log.Infof("Received message from pid %v: %v", p.Pid, string(data[:len]))
+		cancel()
+	}()
+
+	select {
+	case <-time.After(signalPipeTimeout):
+		return trace.BadParameter("Failed waiting from process")
+	case <-messageReceived.Done():
+		log.WithFields(logrus.Fields{"pid": p.Pid}).Infof("Child process signals success.")
+	}
+
+	return nil
+}
+
+// collectStatuses attempts to collect exit statuses from
+// forked teleport child processes.
+// If forked teleport process exited with an error during graceful
+// restart, parent process has to collect the child process status
+// otherwise the child process will become a zombie process.
+// Call Wait4(-1) is trying to collect status of any child
+// leads to warnings in logs, because other parts of the program could
+// have tried to collect the status of this process.
+// Instead this logic tries to collect statuses of the processes
+// forked during restart procedure.
+func (process *TeleportProcess) collectStatuses() {
+	pids := process.getForkedPIDs()
+	if len(pids) == 0 {
+		return
+	}
+	for _, pid := range pids {
+		var wait syscall.WaitStatus
+		rpid, err := syscall.Wait4(pid, &wait, syscall.WNOHANG, nil)
+		if err != nil {
+			process.Errorf("Wait call failed: %v.", err)
+			continue
+		}
+		if rpid == pid {
+			process.popForkedPID(pid)
+			process.Warningf("Forked teleport process %v has exited with status: %v.", pid, wait.ExitStatus())
+		}
+	}
+}
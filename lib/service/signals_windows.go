@@ -0,0 +1,107 @@
+// +build windows
+
+/*
+Copyright 2018-2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gravitational/teleport/lib/defaults"
+
+	"github.com/gravitational/trace"
+)
+
+// WaitForSignals waits for system signals and processes them. The
+// fork-based graceful restart procedure (SIGUSR2/SIGHUP) and child status
+// collection (SIGCHLD) are POSIX mechanisms and are not available on
+// Windows, only shutdown is supported.
+func (process *TeleportProcess) WaitForSignals(ctx context.Context) error {
+	sigC := make(chan os.Signal, 1024)
+	signal.Notify(sigC,
+		syscall.SIGTERM, // graceful shutdown with session drain timeout
+		syscall.SIGINT,  // fast shutdown
+	)
+
+	doneContext, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	serviceErrorsC := make(chan Event, 10)
+	process.WaitForEvent(ctx, ServiceExitedWithErrorEvent, serviceErrorsC)
+
+	for {
+		select {
+		case signal := <-sigC:
+			switch signal {
+			case syscall.SIGTERM:
+				drainTimeout := process.Config.ShutdownTimeout
+				if drainTimeout == 0 {
+					drainTimeout = defaults.DefaultGracefulShutdownTimeout
+				}
+				process.Infof("Got signal %q, shutting down gracefully, draining active sessions for up to %v.", signal, drainTimeout)
+				go process.printShutdownStatus(doneContext)
+				timeoutCtx, timeoutCancel := context.WithTimeout(ctx, drainTimeout)
+				process.Shutdown(timeoutCtx)
+				timeoutCancel()
+				process.Infof("All services stopped or drain timeout passed, exiting.")
+				return nil
+			case syscall.SIGINT:
+				process.Infof("Got signal %q, exiting immediately.", signal)
+				process.Close()
+				return nil
+			default:
+				process.Infof("Ignoring %q.", signal)
+			}
+		case <-process.ReloadContext().Done():
+			process.Infof("Exiting signal handler: process has started internal reload.")
+			return ErrTeleportReloading
+		case <-process.ExitContext().Done():
+			process.Infof("Someone else has closed context, exiting.")
+			return nil
+		case <-ctx.Done():
+			process.Close()
+			if err := process.Wait(); err != nil {
+				process.Warnf("Error waiting for all services to exit: %v", err)
+			}
+			process.Info("Got request to shutdown, context is closing")
+			return nil
+		case event := <-serviceErrorsC:
+			se, ok := event.Payload.(ExitEventPayload)
+			if !ok {
+				process.Warningf("Failed to decode service exit event, %T", event.Payload)
+				continue
+			}
+			if se.Service.IsCritical() {
+				process.Errorf("Critical service %v has exited with error %v, aborting.", se.Service, se.Error)
+				if err := process.Close(); err != nil {
+					process.Errorf("Error when shutting down teleport %v.", err)
+				}
+				return trace.Wrap(se.Error)
+			}
+			process.Warningf("Non-critical service %v has exited with error %v, continuing to operate.", se.Service, se.Error)
+		}
+	}
+}
+
+// forkChild implements the fork-based graceful restart procedure, it is
+// not supported on Windows.
+func (process *TeleportProcess) forkChild() error {
+	return trace.NotImplemented("graceful restarts are not supported on Windows")
+}
@@ -20,6 +20,7 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gravitational/teleport"
@@ -69,6 +70,13 @@ func (c HostCertParams) Check() error {
 	if c.ClusterName == "" {
 		return trace.BadParameter("ClusterName [%q] is required", c.ClusterName)
 	}
+	for _, principal := range c.Principals {
+		if strings.Contains(principal, "*") {
+			if err := CheckWildcardPrincipal(principal); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+	}
 
 	if err := c.Roles.Check(); err != nil {
 		return trace.Wrap(err)
@@ -77,6 +85,30 @@ func (c HostCertParams) Check() error {
 	return nil
 }
 
+// CheckWildcardPrincipal checks that a wildcard principal is constrained
+// enough to be issued in a host certificate: the wildcard must be the entire
+// leftmost label (e.g. "*.db.internal") and must be followed by at least two
+// non-empty labels, so a single certificate can not match arbitrary hosts.
+func CheckWildcardPrincipal(principal string) error {
+	if !strings.HasPrefix(principal, "*.") {
+		return trace.BadParameter("wildcard principal %q must start with %q", principal, "*.")
+	}
+	suffix := strings.TrimPrefix(principal, "*.")
+	if strings.Contains(suffix, "*") {
+		return trace.BadParameter("wildcard principal %q may only contain a single leading wildcard", principal)
+	}
+	labels := strings.Split(suffix, ".")
+	if len(labels) < 2 {
+		return trace.BadParameter("wildcard principal %q is too broad, at least two labels must follow the wildcard", principal)
+	}
+	for _, label := range labels {
+		if label == "" {
+			return trace.BadParameter("wildcard principal %q contains an empty label", principal)
+		}
+	}
+	return nil
+}
+
 // ChangePasswordReq defines a request to change user password
 type ChangePasswordReq struct {
 	// User is user ID
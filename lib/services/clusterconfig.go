@@ -95,6 +95,22 @@ type ClusterConfig interface {
 	// the server disconnects the client.
 	SetKeepAliveCountMax(c int64)
 
+	// GetMaxConnections gets the cluster default maximum number of
+	// concurrent connections per user.
+	GetMaxConnections() int64
+
+	// SetMaxConnections sets the cluster default maximum number of
+	// concurrent connections per user.
+	SetMaxConnections(m int64)
+
+	// GetMaxSessions gets the cluster default maximum number of
+	// concurrent sessions per connection.
+	GetMaxSessions() int64
+
+	// SetMaxSessions sets the cluster default maximum number of
+	// concurrent sessions per connection.
+	SetMaxSessions(m int64)
+
 	// GetLocalAuth gets if local authentication is allowed.
 	GetLocalAuth() bool
 
@@ -353,6 +369,30 @@ func (c *ClusterConfigV3) SetKeepAliveCountMax(m int64) {
 	c.Spec.KeepAliveCountMax = m
 }
 
+// GetMaxConnections gets the cluster default maximum number of
+// concurrent connections per user.
+func (c *ClusterConfigV3) GetMaxConnections() int64 {
+	return c.Spec.MaxConnections
+}
+
+// SetMaxConnections sets the cluster default maximum number of
+// concurrent connections per user.
+func (c *ClusterConfigV3) SetMaxConnections(m int64) {
+	c.Spec.MaxConnections = m
+}
+
+// GetMaxSessions gets the cluster default maximum number of
+// concurrent sessions per connection.
+func (c *ClusterConfigV3) GetMaxSessions() int64 {
+	return c.Spec.MaxSessions
+}
+
+// SetMaxSessions sets the cluster default maximum number of
+// concurrent sessions per connection.
+func (c *ClusterConfigV3) SetMaxSessions(m int64) {
+	c.Spec.MaxSessions = m
+}
+
 // GetLocalAuth gets if local authentication is allowed.
 func (c *ClusterConfigV3) GetLocalAuth() bool {
 	return c.Spec.LocalAuth.Value()
@@ -445,6 +485,12 @@ const ClusterConfigSpecSchemaTemplate = `{
     "keep_alive_count_max": {
       "type": "number"
     },
+    "max_connections": {
+      "type": "number"
+    },
+    "max_sessions": {
+      "type": "number"
+    },
     "local_auth": {
       "anyOf": [{"type": "string"}, { "type": "boolean"}]
     },
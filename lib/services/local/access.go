@@ -101,6 +101,41 @@ func (s *AccessService) UpsertRole(ctx context.Context, role services.Role) erro
 	return nil
 }
 
+// CompareAndSwapRole updates the role to the new value if the revision
+// stored in the backend matches the revision of the existing parameter,
+// returns trace.CompareFailed otherwise.
+func (s *AccessService) CompareAndSwapRole(ctx context.Context, new, existing services.Role) error {
+	value, err := services.GetRoleMarshaler().MarshalRole(new)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	newItem := backend.Item{
+		Key:     backend.Key(rolesPrefix, new.GetName(), paramsPrefix),
+		Value:   value,
+		Expires: new.Expiry(),
+	}
+
+	item, err := s.Get(ctx, backend.Key(rolesPrefix, existing.GetName(), paramsPrefix))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.CompareFailed("role %v has been deleted by another party", existing.GetName())
+		}
+		return trace.Wrap(err)
+	}
+	if item.ID != existing.GetResourceID() {
+		return trace.CompareFailed("role %v has been updated by another party, fetch the latest revision and try again", existing.GetName())
+	}
+
+	_, err = s.CompareAndSwap(ctx, *item, newItem)
+	if err != nil {
+		if trace.IsCompareFailed(err) {
+			return trace.CompareFailed("role %v has been updated by another party, fetch the latest revision and try again", existing.GetName())
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 // GetRole returns a role by name
 func (s *AccessService) GetRole(name string) (services.Role, error) {
 	if name == "" {
@@ -131,7 +166,82 @@ func (s *AccessService) DeleteRole(ctx context.Context, name string) error {
 	return trace.Wrap(err)
 }
 
+// DeleteAllLocks deletes all locks
+func (s *AccessService) DeleteAllLocks() error {
+	return s.DeleteRange(context.TODO(), backend.Key(locksPrefix), backend.RangeEnd(backend.Key(locksPrefix)))
+}
+
+// GetLocks returns a list of locks registered with the local auth server
+func (s *AccessService) GetLocks() ([]services.Lock, error) {
+	result, err := s.GetRange(context.TODO(), backend.Key(locksPrefix), backend.RangeEnd(backend.Key(locksPrefix)), backend.NoLimit)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	out := make([]services.Lock, 0, len(result.Items))
+	for _, item := range result.Items {
+		lock, err := services.GetLockMarshaler().Unmarshal(item.Value,
+			services.WithResourceID(item.ID), services.WithExpires(item.Expires))
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		out = append(out, lock)
+	}
+	return out, nil
+}
+
+// GetLock returns a lock by name
+func (s *AccessService) GetLock(name string) (services.Lock, error) {
+	if name == "" {
+		return nil, trace.BadParameter("missing lock name")
+	}
+	item, err := s.Get(context.TODO(), backend.Key(locksPrefix, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return nil, trace.NotFound("lock %v is not found", name)
+		}
+		return nil, trace.Wrap(err)
+	}
+	return services.GetLockMarshaler().Unmarshal(item.Value,
+		services.WithResourceID(item.ID), services.WithExpires(item.Expires))
+}
+
+// UpsertLock creates or updates a lock
+func (s *AccessService) UpsertLock(ctx context.Context, lock services.Lock) error {
+	value, err := services.GetLockMarshaler().Marshal(lock)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	item := backend.Item{
+		Key:     backend.Key(locksPrefix, lock.GetName()),
+		Value:   value,
+		Expires: lock.Expiry(),
+		ID:      lock.GetResourceID(),
+	}
+
+	_, err = s.Put(ctx, item)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// DeleteLock deletes a lock from the backend
+func (s *AccessService) DeleteLock(ctx context.Context, name string) error {
+	if name == "" {
+		return trace.BadParameter("missing lock name")
+	}
+	err := s.Delete(ctx, backend.Key(locksPrefix, name))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			return trace.NotFound("lock %q is not found", name)
+		}
+	}
+	return trace.Wrap(err)
+}
+
 const (
 	rolesPrefix  = "roles"
 	paramsPrefix = "params"
+	locksPrefix  = "locks"
 )
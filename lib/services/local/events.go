@@ -70,6 +70,8 @@ func (e *EventsService) NewWatcher(ctx context.Context, watch services.Watch) (s
 			parser = newNamespaceParser(kind.Name)
 		case services.KindRole:
 			parser = newRoleParser()
+		case services.KindLock:
+			parser = newLockParser()
 		case services.KindUser:
 			parser = newUserParser()
 		case services.KindNode:
@@ -499,6 +501,42 @@ func (p *roleParser) parse(event backend.Event) (services.Resource, error) {
 	}
 }
 
+func newLockParser() *lockParser {
+	return &lockParser{
+		matchPrefix: backend.Key(locksPrefix),
+	}
+}
+
+type lockParser struct {
+	matchPrefix []byte
+}
+
+func (p *lockParser) prefix() []byte {
+	return p.matchPrefix
+}
+
+func (p *lockParser) match(key []byte) bool {
+	return bytes.HasPrefix(key, p.matchPrefix)
+}
+
+func (p *lockParser) parse(event backend.Event) (services.Resource, error) {
+	switch event.Type {
+	case backend.OpDelete:
+		return resourceHeader(event, services.KindLock, services.V2, 0)
+	case backend.OpPut:
+		resource, err := services.GetLockMarshaler().Unmarshal(event.Item.Value,
+			services.WithResourceID(event.Item.ID),
+			services.WithExpires(event.Item.Expires),
+		)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return resource, nil
+	default:
+		return nil, trace.BadParameter("event %v is not supported", event.Type)
+	}
+}
+
 func newAccessRequestParser(m map[string]string) (*accessRequestParser, error) {
 	var filter services.AccessRequestFilter
 	if err := filter.FromMap(m); err != nil {
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/utils"
 
@@ -175,6 +176,7 @@ func (s *PresenceService) getServers(kind, prefix string) ([]services.Server, er
 }
 
 func (s *PresenceService) upsertServer(prefix string, server services.Server) error {
+	server.SetLastHeartbeat(s.Clock().Now().UTC())
 	value, err := services.GetServerMarshaler().MarshalServer(server)
 	if err != nil {
 		return trace.Wrap(err)
@@ -188,22 +190,48 @@ func (s *PresenceService) upsertServer(prefix string, server services.Server) er
 	return trace.Wrap(err)
 }
 
+// validateNamespace checks that node presence operations target a
+// namespace that actually exists, so typos do not silently create or
+// query isolated inventories. The default namespace always exists.
+func (s *PresenceService) validateNamespace(namespace string) error {
+	if namespace == "" {
+		return trace.BadParameter("missing namespace value")
+	}
+	if namespace == defaults.Namespace {
+		return nil
+	}
+	if _, err := s.GetNamespace(namespace); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 // DeleteAllNodes deletes all nodes in a namespace
 func (s *PresenceService) DeleteAllNodes(namespace string) error {
+	if err := s.validateNamespace(namespace); err != nil {
+		return trace.Wrap(err)
+	}
 	startKey := backend.Key(nodesPrefix, namespace)
 	return s.DeleteRange(context.TODO(), startKey, backend.RangeEnd(startKey))
 }
 
 // DeleteNode deletes node
 func (s *PresenceService) DeleteNode(namespace string, name string) error {
+	if err := s.validateNamespace(namespace); err != nil {
+		return trace.Wrap(err)
+	}
 	key := backend.Key(nodesPrefix, namespace, name)
 	return s.Delete(context.TODO(), key)
 }
 
 // GetNodes returns a list of registered servers
 func (s *PresenceService) GetNodes(namespace string, opts ...services.MarshalOption) ([]services.Server, error) {
-	if namespace == "" {
-		return nil, trace.BadParameter("missing namespace value")
+	if err := s.validateNamespace(namespace); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	cfg, err := services.CollectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
 	}
 
 	// Get all items in the bucket.
@@ -213,8 +241,8 @@ func (s *PresenceService) GetNodes(namespace string, opts ...services.MarshalOpt
 		return nil, trace.Wrap(err)
 	}
 	// Marshal values into a []services.Server slice.
-	servers := make([]services.Server, len(result.Items))
-	for i, item := range result.Items {
+	servers := make([]services.Server, 0, len(result.Items))
+	for _, item := range result.Items {
 		server, err := services.GetServerMarshaler().UnmarshalServer(
 			item.Value,
 			services.KindNode,
@@ -224,18 +252,83 @@ func (s *PresenceService) GetNodes(namespace string, opts ...services.MarshalOpt
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		servers[i] = server
+		if cfg.SkipExpired && server.IsStale(s.Clock()) {
+			continue
+		}
+		servers = append(servers, server)
 	}
 
 	return servers, nil
 }
 
+// ListNodes returns a single page of nodes and the key to pass as StartKey
+// to fetch the next page. An empty key means the last page has been reached.
+// Label filtering happens before pagination, so full pages are returned even
+// when only a fraction of the nodes match.
+func (s *PresenceService) ListNodes(req services.ListNodesRequest) ([]services.Server, string, error) {
+	if err := req.CheckAndSetDefaults(); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	if err := s.validateNamespace(req.Namespace); err != nil {
+		return nil, "", trace.Wrap(err)
+	}
+	var selector *services.Selector
+	if req.Selector != "" {
+		var err error
+		selector, err = services.ParseSelector(req.Selector)
+		if err != nil {
+			return nil, "", trace.Wrap(err)
+		}
+	}
+
+	rangeStart := backend.Key(nodesPrefix, req.Namespace, req.StartKey)
+	rangeEnd := backend.RangeEnd(backend.Key(nodesPrefix, req.Namespace))
+
+	page := make([]services.Server, 0, req.Limit)
+	for {
+		// Fetch one item past the limit to tell a full last page apart from
+		// one that has more pages after it.
+		chunk := req.Limit - len(page) + 1
+		result, err := s.GetRange(context.TODO(), rangeStart, rangeEnd, chunk)
+		if err != nil {
+			return nil, "", trace.Wrap(err)
+		}
+		for _, item := range result.Items {
+			server, err := services.GetServerMarshaler().UnmarshalServer(
+				item.Value,
+				services.KindNode,
+				services.WithResourceID(item.ID),
+				services.WithExpires(item.Expires))
+			if err != nil {
+				return nil, "", trace.Wrap(err)
+			}
+			if len(req.Labels) != 0 && !server.MatchAgainst(req.Labels) {
+				continue
+			}
+			if selector != nil && !selector.Match(server.GetAllLabels()) {
+				continue
+			}
+			if len(page) == req.Limit {
+				// The page is full, the matched node starts the next one.
+				return page, server.GetName(), nil
+			}
+			page = append(page, server)
+		}
+		if len(result.Items) < chunk {
+			// The backend ran out of nodes, this is the last page.
+			return page, "", nil
+		}
+		rangeStart = backend.RangeEnd(result.Items[len(result.Items)-1].Key)
+	}
+}
+
 // UpsertNode registers node presence, permanently if TTL is 0 or for the
 // specified duration with second resolution if it's >= 1 second.
 func (s *PresenceService) UpsertNode(server services.Server) (*services.KeepAlive, error) {
-	if server.GetNamespace() == "" {
-		return nil, trace.BadParameter("missing node namespace")
+	if err := s.validateNamespace(server.GetNamespace()); err != nil {
+		return nil, trace.Wrap(err)
 	}
+	server.SetLastHeartbeat(s.Clock().Now().UTC())
 	value, err := services.GetServerMarshaler().MarshalServer(server)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -267,6 +360,12 @@ func (s *PresenceService) KeepAliveNode(ctx context.Context, h services.KeepAliv
 	return trace.Wrap(err)
 }
 
+// WatchPresence returns a watcher streaming add/update/delete events for
+// servers of the given kind as they heartbeat or expire.
+func (s *PresenceService) WatchPresence(kind string) (services.PresenceWatcher, error) {
+	return services.NewPresenceWatcher(context.TODO(), NewEventsService(s.Backend), kind)
+}
+
 // UpsertNodes is used for bulk insertion of nodes. Schema validation is
 // always skipped during bulk insertion.
 func (s *PresenceService) UpsertNodes(namespace string, servers []services.Server) error {
@@ -274,14 +373,15 @@ func (s *PresenceService) UpsertNodes(namespace string, servers []services.Serve
 	if !ok {
 		return trace.BadParameter("backend does not support batch interface")
 	}
-	if namespace == "" {
-		return trace.BadParameter("missing node namespace")
+	if err := s.validateNamespace(namespace); err != nil {
+		return trace.Wrap(err)
 	}
 
 	start := time.Now()
 
 	items := make([]backend.Item, len(servers))
 	for i, server := range servers {
+		server.SetLastHeartbeat(s.Clock().Now().UTC())
 		value, err := services.GetServerMarshaler().MarshalServer(server)
 		if err != nil {
 			return trace.Wrap(err)
@@ -23,6 +23,7 @@ import (
 
 	"github.com/gravitational/teleport/lib/backend"
 	"github.com/gravitational/teleport/lib/backend/lite"
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/utils"
 
@@ -110,3 +111,94 @@ func (s *PresenceSuite) TestTrustedClusterCRUD(c *check.C) {
 	c.Assert(err, check.NotNil)
 	c.Assert(trace.IsNotFound(err), check.Equals, true)
 }
+
+func (s *PresenceSuite) TestListNodes(c *check.C) {
+	presence := NewPresenceService(s.bk)
+
+	// Insert nodes, half of them carrying a label to filter on.
+	for i := 0; i < 10; i++ {
+		node := &services.ServerV2{
+			Kind:    services.KindNode,
+			Version: services.V2,
+			Metadata: services.Metadata{
+				Name:      fmt.Sprintf("node%v", i),
+				Namespace: defaults.Namespace,
+				Labels:    map[string]string{"parity": fmt.Sprintf("%v", i%2)},
+			},
+		}
+		_, err := presence.UpsertNode(node)
+		c.Assert(err, check.IsNil)
+	}
+
+	// Page through the full list.
+	page, nextKey, err := presence.ListNodes(services.ListNodesRequest{
+		Namespace: defaults.Namespace,
+		Limit:     4,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(page, check.HasLen, 4)
+	c.Assert(nextKey, check.Equals, "node4")
+
+	page, nextKey, err = presence.ListNodes(services.ListNodesRequest{
+		Namespace: defaults.Namespace,
+		Limit:     100,
+		StartKey:  nextKey,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(page, check.HasLen, 6)
+	c.Assert(nextKey, check.Equals, "")
+
+	// Filter by label: full pages come back even though only half of the
+	// nodes match, and the continuation key skips non-matching nodes.
+	page, nextKey, err = presence.ListNodes(services.ListNodesRequest{
+		Namespace: defaults.Namespace,
+		Limit:     3,
+		Labels:    map[string]string{"parity": "0"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(page, check.HasLen, 3)
+	c.Assert(nextKey, check.Equals, "node6")
+
+	page, nextKey, err = presence.ListNodes(services.ListNodesRequest{
+		Namespace: defaults.Namespace,
+		Limit:     3,
+		StartKey:  nextKey,
+		Labels:    map[string]string{"parity": "0"},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(page, check.HasLen, 2)
+	c.Assert(nextKey, check.Equals, "")
+}
+
+func (s *PresenceSuite) TestNamespaceValidation(c *check.C) {
+	presenceBackend := NewPresenceService(s.bk)
+
+	// node operations in a namespace that was never created are rejected
+	_, err := presenceBackend.GetNodes("missing")
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+	err = presenceBackend.DeleteAllNodes("missing")
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+
+	node := &services.ServerV2{
+		Kind:     services.KindNode,
+		Version:  services.V2,
+		Metadata: services.Metadata{Name: "node1", Namespace: "missing"},
+		Spec:     services.ServerSpecV2{Addr: "_", Hostname: "node1"},
+	}
+	_, err = presenceBackend.UpsertNode(node)
+	c.Assert(trace.IsNotFound(err), check.Equals, true, check.Commentf("%v", err))
+
+	// the default namespace always exists
+	_, err = presenceBackend.GetNodes(defaults.Namespace)
+	c.Assert(err, check.IsNil)
+
+	// creating the namespace makes it usable
+	c.Assert(presenceBackend.UpsertNamespace(services.Namespace{
+		Kind:     services.KindNamespace,
+		Version:  services.V2,
+		Metadata: services.Metadata{Name: "prod", Namespace: defaults.Namespace},
+	}), check.IsNil)
+	_, err = presenceBackend.GetNodes("prod")
+	c.Assert(err, check.IsNil)
+	c.Assert(presenceBackend.DeleteAllNodes("prod"), check.IsNil)
+}
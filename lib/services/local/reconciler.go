@@ -0,0 +1,207 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ReconcileModeRevert reverts out-of-band edits of declared
+	// resources back to their declared state
+	ReconcileModeRevert = "revert"
+	// ReconcileModeAlert only reports out-of-band edits of declared
+	// resources without changing the backend
+	ReconcileModeAlert = "alert"
+)
+
+// Drift describes a detected divergence between a declared resource
+// and its stored state
+type Drift struct {
+	// Kind is the resource kind
+	Kind string
+	// Name is the resource name
+	Name string
+	// Deleted is true when the stored resource is gone entirely
+	Deleted bool
+	// Reverted is true when the declared state was re-asserted
+	Reverted bool
+}
+
+// ReconcilerConfig configures a resource reconciler
+type ReconcilerConfig struct {
+	// Backend is the backend declared resources are asserted against
+	Backend backend.Backend
+	// Resources are the declared resources
+	Resources []services.Resource
+	// Period is how often declared state is compared with the
+	// backend, defaults to the low resolution polling period
+	Period time.Duration
+	// DefaultMode is applied to resource kinds without an explicit
+	// mode, defaults to ReconcileModeRevert
+	DefaultMode string
+	// Modes optionally overrides the reconcile mode per resource kind
+	Modes map[string]string
+	// OnDrift is an optional callback invoked for every detected drift
+	OnDrift func(Drift)
+	// Entry is a logging entry
+	Entry log.FieldLogger
+}
+
+// CheckAndSetDefaults checks and sets default values
+func (cfg *ReconcilerConfig) CheckAndSetDefaults() error {
+	if cfg.Backend == nil {
+		return trace.BadParameter("missing parameter Backend")
+	}
+	if len(cfg.Resources) == 0 {
+		return trace.BadParameter("missing parameter Resources")
+	}
+	if cfg.Period == 0 {
+		cfg.Period = defaults.LowResPollingPeriod
+	}
+	if cfg.DefaultMode == "" {
+		cfg.DefaultMode = ReconcileModeRevert
+	}
+	modes := []string{cfg.DefaultMode}
+	for _, mode := range cfg.Modes {
+		modes = append(modes, mode)
+	}
+	for _, mode := range modes {
+		switch mode {
+		case ReconcileModeRevert, ReconcileModeAlert:
+		default:
+			return trace.BadParameter("unsupported reconcile mode %q, expected %q or %q",
+				mode, ReconcileModeRevert, ReconcileModeAlert)
+		}
+	}
+	if cfg.Entry == nil {
+		cfg.Entry = log.StandardLogger()
+	}
+	return nil
+}
+
+// Reconciler periodically re-asserts a set of declared resources
+// against the backend, reverting or flagging out-of-band edits
+// depending on the per-kind mode
+type Reconciler struct {
+	ReconcilerConfig
+	log.FieldLogger
+}
+
+// NewReconciler returns a new resource reconciler
+func NewReconciler(cfg ReconcilerConfig) (*Reconciler, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Reconciler{
+		ReconcilerConfig: cfg,
+		FieldLogger:      cfg.Entry,
+	}, nil
+}
+
+// Run reconciles in a loop until the context is canceled
+func (r *Reconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.Period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.Reconcile(ctx); err != nil {
+				r.Warningf("Failed to reconcile declared resources: %v.", err)
+			}
+		}
+	}
+}
+
+// Reconcile runs a single reconciliation pass
+func (r *Reconciler) Reconcile(ctx context.Context) error {
+	for _, rsc := range r.Resources {
+		drift, err := r.reconcileResource(ctx, rsc)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if drift == nil {
+			continue
+		}
+		if drift.Reverted {
+			r.Warningf("Reverted out-of-band change of %v %q.", drift.Kind, drift.Name)
+		} else {
+			r.Warningf("Detected out-of-band change of %v %q.", drift.Kind, drift.Name)
+		}
+		if r.OnDrift != nil {
+			r.OnDrift(*drift)
+		}
+	}
+	return nil
+}
+
+// reconcileResource compares a single declared resource with the
+// backend, returns nil when the stored state matches
+func (r *Reconciler) reconcileResource(ctx context.Context, rsc services.Resource) (*Drift, error) {
+	items, err := itemsFromResource(rsc)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	drift := &Drift{
+		Kind: rsc.GetKind(),
+		Name: rsc.GetName(),
+	}
+	changed := false
+	for i, item := range items {
+		existing, err := r.Backend.Get(ctx, item.Key)
+		if err != nil {
+			if !trace.IsNotFound(err) {
+				return nil, trace.Wrap(err)
+			}
+			changed = true
+			if i == 0 {
+				drift.Deleted = true
+			}
+		} else if !bytes.Equal(existing.Value, item.Value) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	if r.mode(rsc.GetKind()) == ReconcileModeRevert {
+		if _, err := ApplyResources(ctx, r.Backend, rsc); err != nil {
+			return nil, trace.Wrap(err)
+		}
+		drift.Reverted = true
+	}
+	return drift, nil
+}
+
+// mode returns the reconcile mode of a resource kind
+func (r *Reconciler) mode(kind string) string {
+	if mode, ok := r.Modes[kind]; ok {
+		return mode
+	}
+	return r.DefaultMode
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package local
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"strings"
@@ -58,6 +59,76 @@ func CreateResources(ctx context.Context, b backend.Backend, resources ...servic
 	return nil
 }
 
+const (
+	// ApplyCreated means the resource did not exist and was created
+	ApplyCreated = "created"
+	// ApplyUpdated means the resource existed and was overwritten
+	ApplyUpdated = "updated"
+	// ApplyUnchanged means the stored resource already matched
+	ApplyUnchanged = "unchanged"
+)
+
+// ApplyResult reports the outcome of applying a single resource.
+type ApplyResult struct {
+	// Kind is the resource kind
+	Kind string
+	// Name is the resource name
+	Name string
+	// Status is one of ApplyCreated, ApplyUpdated or ApplyUnchanged
+	Status string
+}
+
+// ApplyResources idempotently reconciles the supplied resources into the
+// backend: missing resources are created, changed ones are overwritten and
+// identical ones are left untouched. It returns a per-resource report.
+//
+// NOTE: This function is non-atomic and performs no internal synchronization;
+// backend must be locked by caller when operating in parallel environment.
+func ApplyResources(ctx context.Context, b backend.Backend, resources ...services.Resource) ([]ApplyResult, error) {
+	results := make([]ApplyResult, 0, len(resources))
+	for _, rsc := range resources {
+		items, err := itemsFromResource(rsc)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		status := ApplyUnchanged
+		for i, item := range items {
+			existing, err := b.Get(ctx, item.Key)
+			if err != nil {
+				if !trace.IsNotFound(err) {
+					return nil, trace.Wrap(err)
+				}
+				if _, err := b.Create(ctx, item); err != nil {
+					return nil, trace.Wrap(err)
+				}
+				// the first item carries the resource itself, any
+				// extra items are derived secrets
+				if i == 0 {
+					status = ApplyCreated
+				} else if status == ApplyUnchanged {
+					status = ApplyUpdated
+				}
+				continue
+			}
+			if bytes.Equal(existing.Value, item.Value) {
+				continue
+			}
+			if _, err := b.Put(ctx, item); err != nil {
+				return nil, trace.Wrap(err)
+			}
+			if status == ApplyUnchanged {
+				status = ApplyUpdated
+			}
+		}
+		results = append(results, ApplyResult{
+			Kind:   rsc.GetKind(),
+			Name:   rsc.GetName(),
+			Status: status,
+		})
+	}
+	return results, nil
+}
+
 // ItemsFromResources attempts to convert resources into instances of backend.Item.
 // NOTE: this is not necessarily a 1-to-1 conversion.
 func ItemsFromResources(resources ...services.Resource) ([]backend.Item, error) {
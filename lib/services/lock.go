@@ -0,0 +1,354 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// Lock is a cluster-wide connection lock: while a lock is in force, all
+// new connections matching its target are rejected by proxies and nodes.
+// Locks propagate through the same event channel as other resources, so
+// they take effect cluster-wide within seconds of creation.
+type Lock interface {
+	Resource
+
+	// Target returns the connections the lock applies to.
+	Target() LockTarget
+	// SetTarget sets the connections the lock applies to.
+	SetTarget(target LockTarget)
+	// GetMessage returns the message shown to rejected users.
+	GetMessage() string
+	// SetMessage sets the message shown to rejected users.
+	SetMessage(message string)
+	// TerminateSessions returns true if sessions established before
+	// the lock was created should be disconnected as well.
+	TerminateSessions() bool
+	// CheckAndSetDefaults checks and sets default values.
+	CheckAndSetDefaults() error
+}
+
+// NewLock is a convenience method to create a Lock resource.
+func NewLock(name string, spec LockSpecV2) (Lock, error) {
+	lock := LockV2{
+		Kind:    KindLock,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := lock.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &lock, nil
+}
+
+// IsEmpty returns true if none of the target fields is set.
+func (t LockTarget) IsEmpty() bool {
+	return t.User == "" && t.Role == "" && t.Node == "" && t.IP == ""
+}
+
+// Match returns true if the lock target applies to the supplied
+// connection target. Lock targets are single-field, so the match is an
+// equality check on whichever field the lock pins down.
+func (t LockTarget) Match(target LockTarget) bool {
+	switch {
+	case t.User != "":
+		return t.User == target.User
+	case t.Role != "":
+		return t.Role == target.Role
+	case t.Node != "":
+		return t.Node == target.Node
+	case t.IP != "":
+		return t.IP == target.IP
+	}
+	return false
+}
+
+// LockInForce returns the first lock that applies to any of the supplied
+// connection targets, or nil if no lock matches. Expired locks are
+// skipped, the backend removes them shortly after expiry.
+func LockInForce(clock clockwork.Clock, locks []Lock, targets ...LockTarget) Lock {
+	for _, lock := range locks {
+		if !lock.Expiry().IsZero() && lock.Expiry().Before(clock.Now().UTC()) {
+			continue
+		}
+		for _, target := range targets {
+			if lock.Target().Match(target) {
+				return lock
+			}
+		}
+	}
+	return nil
+}
+
+// AccessDeniedByLock returns the access denied error reported to
+// connections rejected because of the supplied lock.
+func AccessDeniedByLock(lock Lock) error {
+	msg := "lock targeting " + lock.Target().String() + " is in force"
+	if lock.GetMessage() != "" {
+		msg += ": " + lock.GetMessage()
+	}
+	return trace.AccessDenied(msg)
+}
+
+// String returns a human readable description of the lock target.
+func (t LockTarget) String() string {
+	switch {
+	case t.User != "":
+		return fmt.Sprintf("user %q", t.User)
+	case t.Role != "":
+		return fmt.Sprintf("role %q", t.Role)
+	case t.Node != "":
+		return fmt.Sprintf("node %q", t.Node)
+	case t.IP != "":
+		return fmt.Sprintf("ip %q", t.IP)
+	}
+	return "<empty>"
+}
+
+// Target returns the connections the lock applies to.
+func (c *LockV2) Target() LockTarget {
+	return c.Spec.Target
+}
+
+// SetTarget sets the connections the lock applies to.
+func (c *LockV2) SetTarget(target LockTarget) {
+	c.Spec.Target = target
+}
+
+// GetMessage returns the message shown to rejected users.
+func (c *LockV2) GetMessage() string {
+	return c.Spec.Message
+}
+
+// SetMessage sets the message shown to rejected users.
+func (c *LockV2) SetMessage(message string) {
+	c.Spec.Message = message
+}
+
+// TerminateSessions returns true if sessions established before
+// the lock was created should be disconnected as well.
+func (c *LockV2) TerminateSessions() bool {
+	return c.Spec.TerminateSessions
+}
+
+// GetVersion returns resource version
+func (c *LockV2) GetVersion() string {
+	return c.Version
+}
+
+// GetSubKind returns resource sub kind
+func (c *LockV2) GetSubKind() string {
+	return c.SubKind
+}
+
+// SetSubKind sets resource subkind
+func (c *LockV2) SetSubKind(sk string) {
+	c.SubKind = sk
+}
+
+// GetKind returns resource kind
+func (c *LockV2) GetKind() string {
+	return c.Kind
+}
+
+// GetResourceID returns resource ID
+func (c *LockV2) GetResourceID() int64 {
+	return c.Metadata.ID
+}
+
+// SetResourceID sets resource ID
+func (c *LockV2) SetResourceID(id int64) {
+	c.Metadata.ID = id
+}
+
+// GetName returns the name of the lock.
+func (c *LockV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the lock.
+func (c *LockV2) SetName(e string) {
+	c.Metadata.Name = e
+}
+
+// Expiry returns object expiry setting
+func (c *LockV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets expiry time for the object
+func (c *LockV2) SetExpiry(expires time.Time) {
+	c.Metadata.SetExpiry(expires)
+}
+
+// SetTTL sets Expires header using the provided clock.
+// Use SetExpiry instead.
+// DELETE IN 7.0.0
+func (c *LockV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata
+func (c *LockV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// String represents a human readable version of the lock.
+func (c *LockV2) String() string {
+	return fmt.Sprintf("Lock(name=%v, target=%v)",
+		c.Metadata.Name, c.Spec.Target)
+}
+
+// CheckAndSetDefaults checks validity of all parameters and sets defaults.
+func (c *LockV2) CheckAndSetDefaults() error {
+	// make sure we have defaults for all metadata fields
+	err := c.Metadata.CheckAndSetDefaults()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	set := 0
+	for _, field := range []string{c.Spec.Target.User, c.Spec.Target.Role, c.Spec.Target.Node, c.Spec.Target.IP} {
+		if field != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return trace.BadParameter("lock target must set exactly one of user, role, node or ip")
+	}
+	return nil
+}
+
+// LockSpecSchemaTemplate is a template for Lock schema.
+const LockSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+    "target": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "user": { "type": "string" },
+        "role": { "type": "string" },
+        "node": { "type": "string" },
+        "ip": { "type": "string" }
+      }
+    },
+    "message": { "type": "string" },
+    "terminate_sessions": { "type": "boolean" }
+  }
+}`
+
+// GetLockSchema returns the validation schema for this object
+func GetLockSchema() string {
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, LockSpecSchemaTemplate, DefaultDefinitions)
+}
+
+// LockMarshaler implements marshal/unmarshal of Lock implementations
+// mostly adds support for extended versions.
+type LockMarshaler interface {
+	Marshal(l Lock, opts ...MarshalOption) ([]byte, error)
+	Unmarshal(bytes []byte, opts ...MarshalOption) (Lock, error)
+}
+
+var lockMarshaler LockMarshaler = &TeleportLockMarshaler{}
+
+// SetLockMarshaler sets the marshaler.
+func SetLockMarshaler(m LockMarshaler) {
+	marshalerMutex.Lock()
+	defer marshalerMutex.Unlock()
+	lockMarshaler = m
+}
+
+// GetLockMarshaler gets the marshaler.
+func GetLockMarshaler() LockMarshaler {
+	marshalerMutex.RLock()
+	defer marshalerMutex.RUnlock()
+	return lockMarshaler
+}
+
+// TeleportLockMarshaler is used to marshal and unmarshal Lock.
+type TeleportLockMarshaler struct{}
+
+// Unmarshal unmarshals Lock from JSON.
+func (t *TeleportLockMarshaler) Unmarshal(bytes []byte, opts ...MarshalOption) (Lock, error) {
+	var lock LockV2
+
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	cfg, err := collectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if cfg.SkipValidation {
+		if err := utils.FastUnmarshal(bytes, &lock); err != nil {
+			return nil, trace.BadParameter(err.Error())
+		}
+	} else {
+		err = utils.UnmarshalWithSchema(GetLockSchema(), &lock, bytes)
+		if err != nil {
+			return nil, trace.BadParameter(err.Error())
+		}
+	}
+
+	err = lock.CheckAndSetDefaults()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if cfg.ID != 0 {
+		lock.SetResourceID(cfg.ID)
+	}
+	if !cfg.Expires.IsZero() {
+		lock.SetExpiry(cfg.Expires)
+	}
+	return &lock, nil
+}
+
+// Marshal marshals Lock to JSON.
+func (t *TeleportLockMarshaler) Marshal(l Lock, opts ...MarshalOption) ([]byte, error) {
+	cfg, err := collectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch resource := l.(type) {
+	case *LockV2:
+		if !cfg.PreserveResourceID {
+			// avoid modifying the original object
+			// to prevent unexpected data races
+			copy := *resource
+			copy.SetResourceID(0)
+			resource = &copy
+		}
+		return utils.FastMarshal(resource)
+	default:
+		return nil, trace.BadParameter("unrecognized lock version %T", l)
+	}
+}
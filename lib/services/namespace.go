@@ -36,6 +36,12 @@ func (n *Namespace) CheckAndSetDefaults() error {
 	if !isValid {
 		return trace.BadParameter("namespace %q is invalid", n.Metadata.Name)
 	}
+	if n.Spec.MaxNodes < 0 {
+		return trace.BadParameter("max_nodes can not be negative")
+	}
+	if n.Spec.MaxSessions < 0 {
+		return trace.BadParameter("max_sessions can not be negative")
+	}
 
 	return nil
 }
@@ -103,7 +109,11 @@ func (n *Namespace) GetMetadata() Metadata {
 const NamespaceSpecSchema = `{
   "type": "object",
   "additionalProperties": false,
-  "default": {}
+  "default": {},
+  "properties": {
+    "max_nodes": {"type": "integer"},
+    "max_sessions": {"type": "integer"}
+  }
 }`
 
 const NamespaceSchemaTemplate = `{
@@ -0,0 +1,392 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// ClusterNetworkingConfig is a singleton resource that groups the
+// cluster-wide networking tunables stored in the cluster configuration:
+// keep-alives, idle timeouts and per-user connection limits. It is a view
+// over ClusterConfig, so changes made through it propagate to nodes the
+// same way other cluster configuration changes do.
+type ClusterNetworkingConfig interface {
+	Resource
+
+	// GetKeepAliveInterval gets the keep-alive interval for server to
+	// client connections.
+	GetKeepAliveInterval() time.Duration
+
+	// SetKeepAliveInterval sets the keep-alive interval for server to
+	// client connections.
+	SetKeepAliveInterval(t time.Duration)
+
+	// GetKeepAliveCountMax gets the number of missed keep-alive messages
+	// before the server disconnects the client.
+	GetKeepAliveCountMax() int64
+
+	// SetKeepAliveCountMax sets the number of missed keep-alive messages
+	// before the server disconnects the client.
+	SetKeepAliveCountMax(c int64)
+
+	// GetClientIdleTimeout returns client idle timeout setting
+	GetClientIdleTimeout() time.Duration
+
+	// SetClientIdleTimeout sets client idle timeout setting
+	SetClientIdleTimeout(t time.Duration)
+
+	// GetSessionControlTimeout gets the session control timeout.
+	GetSessionControlTimeout() time.Duration
+
+	// SetSessionControlTimeout sets the session control timeout.
+	SetSessionControlTimeout(t time.Duration)
+
+	// GetMaxConnections gets the cluster default maximum number of
+	// concurrent connections a user may hold.
+	GetMaxConnections() int64
+
+	// SetMaxConnections sets the cluster default maximum number of
+	// concurrent connections a user may hold.
+	SetMaxConnections(m int64)
+
+	// GetMaxSessions gets the cluster default maximum number of
+	// concurrent sessions a user may hold per connection.
+	GetMaxSessions() int64
+
+	// SetMaxSessions sets the cluster default maximum number of
+	// concurrent sessions a user may hold per connection.
+	SetMaxSessions(m int64)
+
+	// Apply copies the networking settings onto the supplied
+	// cluster configuration.
+	Apply(cc ClusterConfig)
+
+	// CheckAndSetDefaults checks and set default values for missing fields.
+	CheckAndSetDefaults() error
+}
+
+// NewClusterNetworkingConfig is a convenience method to create
+// ClusterNetworkingConfigV2.
+func NewClusterNetworkingConfig(spec ClusterNetworkingConfigSpecV2) (ClusterNetworkingConfig, error) {
+	netConfig := ClusterNetworkingConfigV2{
+		Kind:    KindClusterNetworkingConfig,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      MetaNameClusterNetworkingConfig,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := netConfig.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &netConfig, nil
+}
+
+// ClusterNetworkingConfigFromClusterConfig extracts the networking view
+// from a cluster configuration.
+func ClusterNetworkingConfigFromClusterConfig(cc ClusterConfig) (ClusterNetworkingConfig, error) {
+	return NewClusterNetworkingConfig(ClusterNetworkingConfigSpecV2{
+		KeepAliveInterval:     Duration(cc.GetKeepAliveInterval()),
+		KeepAliveCountMax:     cc.GetKeepAliveCountMax(),
+		ClientIdleTimeout:     Duration(cc.GetClientIdleTimeout()),
+		SessionControlTimeout: Duration(cc.GetSessionControlTimeout()),
+		MaxConnections:        cc.GetMaxConnections(),
+		MaxSessions:           cc.GetMaxSessions(),
+	})
+}
+
+// ClusterNetworkingConfigV2 implements the ClusterNetworkingConfig interface.
+type ClusterNetworkingConfigV2 struct {
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+
+	// SubKind is a resource sub kind
+	SubKind string `json:"sub_kind,omitempty"`
+
+	// Version is a resource version
+	Version string `json:"version"`
+
+	// Metadata is metadata about the resource
+	Metadata Metadata `json:"metadata"`
+
+	// Spec is the specification of the resource
+	Spec ClusterNetworkingConfigSpecV2 `json:"spec"`
+}
+
+// ClusterNetworkingConfigSpecV2 is the actual data we care about
+// for ClusterNetworkingConfigV2.
+type ClusterNetworkingConfigSpecV2 struct {
+	// KeepAliveInterval is the interval the server sends keep-alive messages
+	// to the client at.
+	KeepAliveInterval Duration `json:"keep_alive_interval,omitempty"`
+
+	// KeepAliveCountMax is the number of keep-alive messages that can be
+	// missed before the server disconnects the client.
+	KeepAliveCountMax int64 `json:"keep_alive_count_max,omitempty"`
+
+	// ClientIdleTimeout sets global cluster default setting for client idle
+	// timeouts.
+	ClientIdleTimeout Duration `json:"client_idle_timeout,omitempty"`
+
+	// SessionControlTimeout is the session control lease expiry and defines
+	// the upper limit of how long a node may be out of contact with the auth
+	// server before it begins terminating controlled sessions.
+	SessionControlTimeout Duration `json:"session_control_timeout,omitempty"`
+
+	// MaxConnections is the cluster default maximum number of concurrent
+	// connections a user may hold. Role options take precedence if set.
+	MaxConnections int64 `json:"max_connections,omitempty"`
+
+	// MaxSessions is the cluster default maximum number of concurrent
+	// sessions a user may hold per connection. Role options take precedence
+	// if set.
+	MaxSessions int64 `json:"max_sessions,omitempty"`
+}
+
+// GetVersion returns resource version
+func (c *ClusterNetworkingConfigV2) GetVersion() string {
+	return c.Version
+}
+
+// GetSubKind returns resource sub kind
+func (c *ClusterNetworkingConfigV2) GetSubKind() string {
+	return c.SubKind
+}
+
+// SetSubKind sets resource subkind
+func (c *ClusterNetworkingConfigV2) SetSubKind(s string) {
+	c.SubKind = s
+}
+
+// GetKind returns resource kind
+func (c *ClusterNetworkingConfigV2) GetKind() string {
+	return c.Kind
+}
+
+// GetResourceID returns resource ID
+func (c *ClusterNetworkingConfigV2) GetResourceID() int64 {
+	return c.Metadata.ID
+}
+
+// SetResourceID sets resource ID
+func (c *ClusterNetworkingConfigV2) SetResourceID(id int64) {
+	c.Metadata.ID = id
+}
+
+// GetName returns the name of the resource
+func (c *ClusterNetworkingConfigV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the resource
+func (c *ClusterNetworkingConfigV2) SetName(name string) {
+	c.Metadata.Name = name
+}
+
+// Expiry returns object expiry setting
+func (c *ClusterNetworkingConfigV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets object expiry
+func (c *ClusterNetworkingConfigV2) SetExpiry(t time.Time) {
+	c.Metadata.SetExpiry(t)
+}
+
+// SetTTL sets Expires header using current clock
+func (c *ClusterNetworkingConfigV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata
+func (c *ClusterNetworkingConfigV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetKeepAliveInterval gets the keep-alive interval.
+func (c *ClusterNetworkingConfigV2) GetKeepAliveInterval() time.Duration {
+	return c.Spec.KeepAliveInterval.Duration()
+}
+
+// SetKeepAliveInterval sets the keep-alive interval.
+func (c *ClusterNetworkingConfigV2) SetKeepAliveInterval(t time.Duration) {
+	c.Spec.KeepAliveInterval = Duration(t)
+}
+
+// GetKeepAliveCountMax gets the number of missed keep-alive messages before
+// the server disconnects the client.
+func (c *ClusterNetworkingConfigV2) GetKeepAliveCountMax() int64 {
+	return c.Spec.KeepAliveCountMax
+}
+
+// SetKeepAliveCountMax sets the number of missed keep-alive messages before
+// the server disconnects the client.
+func (c *ClusterNetworkingConfigV2) SetKeepAliveCountMax(m int64) {
+	c.Spec.KeepAliveCountMax = m
+}
+
+// GetClientIdleTimeout returns client idle timeout setting
+func (c *ClusterNetworkingConfigV2) GetClientIdleTimeout() time.Duration {
+	return c.Spec.ClientIdleTimeout.Duration()
+}
+
+// SetClientIdleTimeout sets client idle timeout setting
+func (c *ClusterNetworkingConfigV2) SetClientIdleTimeout(d time.Duration) {
+	c.Spec.ClientIdleTimeout = Duration(d)
+}
+
+// GetSessionControlTimeout gets the session control timeout.
+func (c *ClusterNetworkingConfigV2) GetSessionControlTimeout() time.Duration {
+	return c.Spec.SessionControlTimeout.Duration()
+}
+
+// SetSessionControlTimeout sets the session control timeout.
+func (c *ClusterNetworkingConfigV2) SetSessionControlTimeout(d time.Duration) {
+	c.Spec.SessionControlTimeout = Duration(d)
+}
+
+// GetMaxConnections gets the cluster default maximum number of
+// concurrent connections a user may hold.
+func (c *ClusterNetworkingConfigV2) GetMaxConnections() int64 {
+	return c.Spec.MaxConnections
+}
+
+// SetMaxConnections sets the cluster default maximum number of
+// concurrent connections a user may hold.
+func (c *ClusterNetworkingConfigV2) SetMaxConnections(m int64) {
+	c.Spec.MaxConnections = m
+}
+
+// GetMaxSessions gets the cluster default maximum number of
+// concurrent sessions a user may hold per connection.
+func (c *ClusterNetworkingConfigV2) GetMaxSessions() int64 {
+	return c.Spec.MaxSessions
+}
+
+// SetMaxSessions sets the cluster default maximum number of
+// concurrent sessions a user may hold per connection.
+func (c *ClusterNetworkingConfigV2) SetMaxSessions(m int64) {
+	c.Spec.MaxSessions = m
+}
+
+// Apply copies the networking settings onto the supplied
+// cluster configuration.
+func (c *ClusterNetworkingConfigV2) Apply(cc ClusterConfig) {
+	cc.SetKeepAliveInterval(c.GetKeepAliveInterval())
+	cc.SetKeepAliveCountMax(c.GetKeepAliveCountMax())
+	cc.SetClientIdleTimeout(c.GetClientIdleTimeout())
+	cc.SetSessionControlTimeout(c.GetSessionControlTimeout())
+	cc.SetMaxConnections(c.GetMaxConnections())
+	cc.SetMaxSessions(c.GetMaxSessions())
+}
+
+// CheckAndSetDefaults checks and set default values for missing fields.
+func (c *ClusterNetworkingConfigV2) CheckAndSetDefaults() error {
+	if err := c.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Spec.KeepAliveInterval.Duration() == 0 {
+		c.Spec.KeepAliveInterval = NewDuration(defaults.KeepAliveInterval)
+	}
+	if c.Spec.KeepAliveCountMax == 0 {
+		c.Spec.KeepAliveCountMax = int64(defaults.KeepAliveCountMax)
+	}
+	if c.Spec.MaxConnections < 0 {
+		return trace.BadParameter("max_connections must not be negative")
+	}
+	if c.Spec.MaxSessions < 0 {
+		return trace.BadParameter("max_sessions must not be negative")
+	}
+	return nil
+}
+
+// ClusterNetworkingConfigSpecSchemaTemplate is a template for
+// ClusterNetworkingConfig schema.
+const ClusterNetworkingConfigSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+	"keep_alive_interval": {"type": "string"},
+	"keep_alive_count_max": {"type": "number"},
+	"client_idle_timeout": {"type": "string"},
+	"session_control_timeout": {"type": "string"},
+	"max_connections": {"type": "number"},
+	"max_sessions": {"type": "number"}%v
+  }
+}`
+
+// GetClusterNetworkingConfigSchema returns the schema with optionally
+// injected schema for extensions.
+func GetClusterNetworkingConfigSchema(extensionSchema string) string {
+	var netConfigSchema string
+	if extensionSchema == "" {
+		netConfigSchema = fmt.Sprintf(ClusterNetworkingConfigSpecSchemaTemplate, "")
+	} else {
+		netConfigSchema = fmt.Sprintf(ClusterNetworkingConfigSpecSchemaTemplate, ","+extensionSchema)
+	}
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, netConfigSchema, DefaultDefinitions)
+}
+
+// UnmarshalClusterNetworkingConfig unmarshals ClusterNetworkingConfig from
+// JSON or YAML and validates schema.
+func UnmarshalClusterNetworkingConfig(bytes []byte) (ClusterNetworkingConfig, error) {
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	var netConfig ClusterNetworkingConfigV2
+	err := utils.UnmarshalWithSchema(GetClusterNetworkingConfigSchema(""), &netConfig, bytes)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+
+	if err := netConfig.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &netConfig, nil
+}
+
+// MarshalClusterNetworkingConfig marshals ClusterNetworkingConfig to JSON.
+func MarshalClusterNetworkingConfig(netConfig ClusterNetworkingConfig, opts ...MarshalOption) ([]byte, error) {
+	cfg, err := collectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch resource := netConfig.(type) {
+	case *ClusterNetworkingConfigV2:
+		if !cfg.PreserveResourceID {
+			// avoid modifying the original object
+			// to prevent unexpected data races
+			copy := *resource
+			copy.SetResourceID(0)
+			resource = &copy
+		}
+		return utils.FastMarshal(resource)
+	default:
+		return nil, trace.BadParameter("unrecognized resource version %T", netConfig)
+	}
+}
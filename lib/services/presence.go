@@ -46,6 +46,11 @@ type Presence interface {
 	// skipped to improve performance.
 	GetNodes(namespace string, opts ...MarshalOption) ([]Server, error)
 
+	// ListNodes returns a single page of registered servers and the key to
+	// pass as StartKey to fetch the next page. An empty key means the last
+	// page has been reached.
+	ListNodes(req ListNodesRequest) ([]Server, string, error)
+
 	// DeleteAllNodes deletes all nodes in a namespace.
 	DeleteAllNodes(namespace string) error
 
@@ -62,6 +67,11 @@ type Presence interface {
 	// KeepAliveNode updates node TTL in the storage
 	KeepAliveNode(ctx context.Context, h KeepAlive) error
 
+	// WatchPresence returns a watcher streaming add/update/delete events
+	// for servers of the given kind (one of KindNode, KindProxy or
+	// KindAuthServer) as they heartbeat or expire.
+	WatchPresence(kind string) (PresenceWatcher, error)
+
 	// GetAuthServers returns a list of registered servers
 	GetAuthServers() ([]Server, error)
 
@@ -167,6 +177,41 @@ type Presence interface {
 	DeleteAllRemoteClusters() error
 }
 
+// ListNodesRequest is a request to list a single page of nodes.
+type ListNodesRequest struct {
+	// Namespace is the namespace to list nodes in.
+	Namespace string
+	// Limit is the maximum number of nodes to return in one page.
+	Limit int
+	// StartKey is the continuation key returned with the previous page,
+	// empty for the first page.
+	StartKey string
+	// Labels, if set, filters the page to nodes matching all of the labels
+	// on the server side, so only matching nodes are transferred.
+	Labels map[string]string
+	// Selector, if set, filters the page to nodes matching the selector
+	// expression (see ParseSelector), which supports set membership,
+	// negation and regular expressions in addition to exact matching.
+	Selector string
+}
+
+// CheckAndSetDefaults checks the request for errors and sets defaults.
+func (r *ListNodesRequest) CheckAndSetDefaults() error {
+	if r.Namespace == "" {
+		return trace.BadParameter("missing namespace value")
+	}
+	if r.Limit < 0 {
+		return trace.BadParameter("negative limit value")
+	}
+	if r.Limit == 0 {
+		r.Limit = defaults.IterationLimit
+	}
+	if r.Limit > defaults.MaxIterationLimit {
+		return trace.BadParameter("limit %v exceeds maximum of %v", r.Limit, defaults.MaxIterationLimit)
+	}
+	return nil
+}
+
 // NewNamespace returns new namespace
 func NewNamespace(name string) Namespace {
 	return Namespace{
@@ -0,0 +1,185 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gravitational/teleport/lib/backend"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// PresenceEventAdded indicates that a server has joined the cluster.
+	PresenceEventAdded = "added"
+	// PresenceEventUpdated indicates a heartbeat from an already known server.
+	PresenceEventUpdated = "updated"
+	// PresenceEventDeleted indicates that a server has been deleted or its
+	// presence entry has expired.
+	PresenceEventDeleted = "deleted"
+)
+
+// PresenceEvent is a single change to the set of registered servers.
+type PresenceEvent struct {
+	// Type is one of PresenceEventAdded, PresenceEventUpdated or
+	// PresenceEventDeleted.
+	Type string
+	// Name is the name of the affected server.
+	Name string
+	// Server is the affected server, set for added and updated events.
+	Server Server
+}
+
+// PresenceWatcher streams changes to the set of registered servers of a
+// single kind, so callers can maintain live server lists without polling
+// the backend.
+type PresenceWatcher interface {
+	// Events returns channel with presence events.
+	Events() <-chan PresenceEvent
+
+	// Done returns the channel signalling the closure.
+	Done() <-chan struct{}
+
+	// Close closes the watcher and releases all associated resources.
+	Close() error
+
+	// Error returns error associated with the watcher.
+	Error() error
+}
+
+// NewPresenceWatcher converts raw events for the requested server kind (one
+// of KindNode, KindProxy or KindAuthServer) into add/update/delete presence
+// events. The watcher does not replay servers registered before it was
+// created, list them separately to seed the initial state.
+func NewPresenceWatcher(ctx context.Context, events Events, kind string) (PresenceWatcher, error) {
+	switch kind {
+	case KindNode, KindProxy, KindAuthServer:
+	default:
+		return nil, trace.BadParameter("unsupported presence kind %q", kind)
+	}
+	watcher, err := events.NewWatcher(ctx, Watch{
+		Name:  "presence",
+		Kinds: []WatchKind{{Kind: kind}},
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p := &presenceWatcher{
+		watcher: watcher,
+		eventsC: make(chan PresenceEvent),
+		ctx:     ctx,
+		cancel:  cancel,
+		known:   make(map[string]bool),
+	}
+	go p.forwardEvents()
+	return p, nil
+}
+
+type presenceWatcher struct {
+	watcher Watcher
+	eventsC chan PresenceEvent
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	// known tracks server names seen by this watcher to tell added events
+	// apart from updated ones. Only accessed from forwardEvents.
+	known map[string]bool
+
+	mu  sync.Mutex
+	err error
+}
+
+func (p *presenceWatcher) forwardEvents() {
+	defer p.cancel()
+	defer p.watcher.Close()
+	for {
+		select {
+		case event := <-p.watcher.Events():
+			converted, ok := p.convert(event)
+			if !ok {
+				continue
+			}
+			select {
+			case p.eventsC <- converted:
+			case <-p.ctx.Done():
+				return
+			}
+		case <-p.watcher.Done():
+			p.setError(p.watcher.Error())
+			return
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// convert translates a raw backend event into a presence event and reports
+// whether the event should be forwarded.
+func (p *presenceWatcher) convert(event Event) (PresenceEvent, bool) {
+	switch event.Type {
+	case backend.OpPut:
+		server, ok := event.Resource.(Server)
+		if !ok {
+			return PresenceEvent{}, false
+		}
+		name := server.GetName()
+		eventType := PresenceEventAdded
+		if p.known[name] {
+			eventType = PresenceEventUpdated
+		}
+		p.known[name] = true
+		return PresenceEvent{Type: eventType, Name: name, Server: server}, true
+	case backend.OpDelete:
+		name := event.Resource.GetName()
+		delete(p.known, name)
+		return PresenceEvent{Type: PresenceEventDeleted, Name: name}, true
+	}
+	// OpInit and other event types are not interesting to presence watchers.
+	return PresenceEvent{}, false
+}
+
+// Events returns channel with presence events.
+func (p *presenceWatcher) Events() <-chan PresenceEvent {
+	return p.eventsC
+}
+
+// Done returns the channel signalling the closure.
+func (p *presenceWatcher) Done() <-chan struct{} {
+	return p.ctx.Done()
+}
+
+// Close closes the watcher and releases all associated resources.
+func (p *presenceWatcher) Close() error {
+	p.cancel()
+	return p.watcher.Close()
+}
+
+// Error returns error associated with the watcher.
+func (p *presenceWatcher) Error() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *presenceWatcher) setError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
@@ -58,6 +58,29 @@ func NewProvisionToken(token string, roles teleport.Roles, expires time.Time) (P
 	return t, nil
 }
 
+// SingleUseTokenLabel is a metadata label marking a provisioning token that
+// is revoked right after its first successful use.
+const SingleUseTokenLabel = "single-use"
+
+// MarkTokenSingleUse marks the provisioning token to be revoked right after
+// its first successful use.
+func MarkTokenSingleUse(token ProvisionToken) {
+	v2, ok := token.(*ProvisionTokenV2)
+	if !ok {
+		return
+	}
+	if v2.Metadata.Labels == nil {
+		v2.Metadata.Labels = make(map[string]string)
+	}
+	v2.Metadata.Labels[SingleUseTokenLabel] = "true"
+}
+
+// IsSingleUseToken returns true if the provisioning token was marked to be
+// revoked right after its first successful use.
+func IsSingleUseToken(token ProvisionToken) bool {
+	return token.GetMetadata().Labels[SingleUseTokenLabel] == "true"
+}
+
 // Provisioner governs adding new nodes to the cluster
 type Provisioner interface {
 	// UpsertToken adds provisioning tokens for the auth server
@@ -0,0 +1,296 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// SessionRecordingConfig is a singleton resource that controls the session
+// recording policy of the cluster: where sessions are recorded (node, proxy
+// or not at all) and related knobs. It is a view over ClusterConfig, so
+// changes made through it take effect cluster-wide without restarting
+// proxies or nodes.
+type SessionRecordingConfig interface {
+	Resource
+
+	// GetMode gets where sessions are recorded.
+	GetMode() string
+
+	// SetMode sets where sessions are recorded.
+	SetMode(string)
+
+	// GetProxyChecksHostKeys gets if the proxy will check host keys
+	// when in recording mode.
+	GetProxyChecksHostKeys() string
+
+	// SetProxyChecksHostKeys sets if the proxy will check host keys
+	// when in recording mode.
+	SetProxyChecksHostKeys(string)
+
+	// Apply copies the recording settings onto the supplied
+	// cluster configuration.
+	Apply(cc ClusterConfig)
+
+	// CheckAndSetDefaults checks and set default values for missing fields.
+	CheckAndSetDefaults() error
+}
+
+// NewSessionRecordingConfig is a convenience method to create
+// SessionRecordingConfigV2.
+func NewSessionRecordingConfig(spec SessionRecordingConfigSpecV2) (SessionRecordingConfig, error) {
+	recConfig := SessionRecordingConfigV2{
+		Kind:    KindSessionRecordingConfig,
+		Version: V2,
+		Metadata: Metadata{
+			Name:      MetaNameSessionRecordingConfig,
+			Namespace: defaults.Namespace,
+		},
+		Spec: spec,
+	}
+	if err := recConfig.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &recConfig, nil
+}
+
+// SessionRecordingConfigFromClusterConfig extracts the recording view
+// from a cluster configuration.
+func SessionRecordingConfigFromClusterConfig(cc ClusterConfig) (SessionRecordingConfig, error) {
+	return NewSessionRecordingConfig(SessionRecordingConfigSpecV2{
+		Mode:                cc.GetSessionRecording(),
+		ProxyChecksHostKeys: cc.GetProxyChecksHostKeys(),
+	})
+}
+
+// SessionRecordingConfigV2 implements the SessionRecordingConfig interface.
+type SessionRecordingConfigV2 struct {
+	// Kind is a resource kind
+	Kind string `json:"kind"`
+
+	// SubKind is a resource sub kind
+	SubKind string `json:"sub_kind,omitempty"`
+
+	// Version is a resource version
+	Version string `json:"version"`
+
+	// Metadata is metadata about the resource
+	Metadata Metadata `json:"metadata"`
+
+	// Spec is the specification of the resource
+	Spec SessionRecordingConfigSpecV2 `json:"spec"`
+}
+
+// SessionRecordingConfigSpecV2 is the actual data we care about
+// for SessionRecordingConfigV2.
+type SessionRecordingConfigSpecV2 struct {
+	// Mode controls where (or if) sessions are recorded, one of:
+	// "node", "proxy", "node-sync", "proxy-sync" or "off".
+	Mode string `json:"mode"`
+
+	// ProxyChecksHostKeys is used to control if the proxy will check host
+	// keys when in recording mode.
+	ProxyChecksHostKeys string `json:"proxy_checks_host_keys,omitempty"`
+}
+
+// GetVersion returns resource version
+func (c *SessionRecordingConfigV2) GetVersion() string {
+	return c.Version
+}
+
+// GetSubKind returns resource sub kind
+func (c *SessionRecordingConfigV2) GetSubKind() string {
+	return c.SubKind
+}
+
+// SetSubKind sets resource subkind
+func (c *SessionRecordingConfigV2) SetSubKind(s string) {
+	c.SubKind = s
+}
+
+// GetKind returns resource kind
+func (c *SessionRecordingConfigV2) GetKind() string {
+	return c.Kind
+}
+
+// GetResourceID returns resource ID
+func (c *SessionRecordingConfigV2) GetResourceID() int64 {
+	return c.Metadata.ID
+}
+
+// SetResourceID sets resource ID
+func (c *SessionRecordingConfigV2) SetResourceID(id int64) {
+	c.Metadata.ID = id
+}
+
+// GetName returns the name of the resource
+func (c *SessionRecordingConfigV2) GetName() string {
+	return c.Metadata.Name
+}
+
+// SetName sets the name of the resource
+func (c *SessionRecordingConfigV2) SetName(name string) {
+	c.Metadata.Name = name
+}
+
+// Expiry returns object expiry setting
+func (c *SessionRecordingConfigV2) Expiry() time.Time {
+	return c.Metadata.Expiry()
+}
+
+// SetExpiry sets object expiry
+func (c *SessionRecordingConfigV2) SetExpiry(t time.Time) {
+	c.Metadata.SetExpiry(t)
+}
+
+// SetTTL sets Expires header using current clock
+func (c *SessionRecordingConfigV2) SetTTL(clock clockwork.Clock, ttl time.Duration) {
+	c.Metadata.SetTTL(clock, ttl)
+}
+
+// GetMetadata returns object metadata
+func (c *SessionRecordingConfigV2) GetMetadata() Metadata {
+	return c.Metadata
+}
+
+// GetMode gets where sessions are recorded.
+func (c *SessionRecordingConfigV2) GetMode() string {
+	return c.Spec.Mode
+}
+
+// SetMode sets where sessions are recorded.
+func (c *SessionRecordingConfigV2) SetMode(m string) {
+	c.Spec.Mode = m
+}
+
+// GetProxyChecksHostKeys gets if the proxy will check host keys
+// when in recording mode.
+func (c *SessionRecordingConfigV2) GetProxyChecksHostKeys() string {
+	return c.Spec.ProxyChecksHostKeys
+}
+
+// SetProxyChecksHostKeys sets if the proxy will check host keys
+// when in recording mode.
+func (c *SessionRecordingConfigV2) SetProxyChecksHostKeys(t string) {
+	c.Spec.ProxyChecksHostKeys = t
+}
+
+// Apply copies the recording settings onto the supplied
+// cluster configuration.
+func (c *SessionRecordingConfigV2) Apply(cc ClusterConfig) {
+	cc.SetSessionRecording(c.GetMode())
+	cc.SetProxyChecksHostKeys(c.GetProxyChecksHostKeys())
+}
+
+// CheckAndSetDefaults checks and set default values for missing fields.
+func (c *SessionRecordingConfigV2) CheckAndSetDefaults() error {
+	if err := c.Metadata.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+	if c.Spec.Mode == "" {
+		c.Spec.Mode = RecordAtNode
+	}
+	if c.Spec.ProxyChecksHostKeys == "" {
+		c.Spec.ProxyChecksHostKeys = HostKeyCheckYes
+	}
+
+	// check if the recording mode is valid
+	all := []string{RecordAtNode, RecordAtProxy, RecordAtNodeSync, RecordAtProxySync, RecordOff}
+	if !utils.SliceContainsStr(all, c.Spec.Mode) {
+		return trace.BadParameter("mode must either be: %v", strings.Join(all, ","))
+	}
+
+	// check if host key checking mode is valid
+	all = []string{HostKeyCheckYes, HostKeyCheckNo}
+	if !utils.SliceContainsStr(all, c.Spec.ProxyChecksHostKeys) {
+		return trace.BadParameter("proxy_checks_host_keys must be one of: %v", strings.Join(all, ","))
+	}
+
+	return nil
+}
+
+// SessionRecordingConfigSpecSchemaTemplate is a template for
+// SessionRecordingConfig schema.
+const SessionRecordingConfigSpecSchemaTemplate = `{
+  "type": "object",
+  "additionalProperties": false,
+  "properties": {
+	"mode": {"type": "string"},
+	"proxy_checks_host_keys": {"type": "string"}%v
+  }
+}`
+
+// GetSessionRecordingConfigSchema returns the schema with optionally
+// injected schema for extensions.
+func GetSessionRecordingConfigSchema(extensionSchema string) string {
+	var recConfigSchema string
+	if extensionSchema == "" {
+		recConfigSchema = fmt.Sprintf(SessionRecordingConfigSpecSchemaTemplate, "")
+	} else {
+		recConfigSchema = fmt.Sprintf(SessionRecordingConfigSpecSchemaTemplate, ","+extensionSchema)
+	}
+	return fmt.Sprintf(V2SchemaTemplate, MetadataSchema, recConfigSchema, DefaultDefinitions)
+}
+
+// UnmarshalSessionRecordingConfig unmarshals SessionRecordingConfig from
+// JSON or YAML and validates schema.
+func UnmarshalSessionRecordingConfig(bytes []byte) (SessionRecordingConfig, error) {
+	if len(bytes) == 0 {
+		return nil, trace.BadParameter("missing resource data")
+	}
+
+	var recConfig SessionRecordingConfigV2
+	err := utils.UnmarshalWithSchema(GetSessionRecordingConfigSchema(""), &recConfig, bytes)
+	if err != nil {
+		return nil, trace.BadParameter(err.Error())
+	}
+
+	if err := recConfig.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	return &recConfig, nil
+}
+
+// MarshalSessionRecordingConfig marshals SessionRecordingConfig to JSON.
+func MarshalSessionRecordingConfig(recConfig SessionRecordingConfig, opts ...MarshalOption) ([]byte, error) {
+	cfg, err := collectOptions(opts)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch resource := recConfig.(type) {
+	case *SessionRecordingConfigV2:
+		if !cfg.PreserveResourceID {
+			// avoid modifying the original object
+			// to prevent unexpected data races
+			copy := *resource
+			copy.SetResourceID(0)
+			resource = &copy
+		}
+		return utils.FastMarshal(resource)
+	default:
+		return nil, trace.BadParameter("unrecognized resource version %T", recConfig)
+	}
+}
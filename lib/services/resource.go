@@ -138,12 +138,40 @@ const (
 	// KindClusterConfig is the resource that holds cluster level configuration.
 	KindClusterConfig = "cluster_config"
 
+	// KindClusterNetworkingConfig is the resource that holds cluster-wide
+	// networking tunables, stored as part of the cluster config.
+	KindClusterNetworkingConfig = "cluster_networking_config"
+
+	// KindSessionRecordingConfig is the resource that holds the session
+	// recording policy, stored as part of the cluster config.
+	KindSessionRecordingConfig = "session_recording_config"
+
+	// KindClusterNotice is the resource that holds cluster notices shown to
+	// users by tools like tctl.
+	KindClusterNotice = "cluster_notice"
+
+	// KindJoinFailure is the resource that holds failed cluster join
+	// attempts recorded by the auth server.
+	KindJoinFailure = "join_failure"
+
 	// KindSemaphore is the resource that provides distributed semaphore functionality
 	KindSemaphore = "semaphore"
 
+	// KindLock is the resource that rejects connections
+	// matching its target cluster-wide
+	KindLock = "lock"
+
 	// MetaNameClusterConfig is the exact name of the cluster config singleton resource.
 	MetaNameClusterConfig = "cluster-config"
 
+	// MetaNameClusterNetworkingConfig is the exact name of the cluster
+	// networking config singleton resource.
+	MetaNameClusterNetworkingConfig = "cluster-networking-config"
+
+	// MetaNameSessionRecordingConfig is the exact name of the session
+	// recording config singleton resource.
+	MetaNameSessionRecordingConfig = "session-recording-config"
+
 	// KindClusterName is a type of configuration resource that contains the cluster name.
 	KindClusterName = "cluster_name"
 
@@ -214,6 +242,9 @@ const (
 	// VerbRotate is used to rotate certificate authorities
 	// used only internally
 	VerbRotate = "rotate"
+
+	// VerbPlay is used to play back encrypted session recordings.
+	VerbPlay = "play"
 )
 
 // CollectOptions collects all options from functional arg and returns config
@@ -254,6 +285,10 @@ type MarshalConfig struct {
 
 	// Expires is an optional expiry time
 	Expires time.Time
+
+	// SkipExpired skips resources that have expired but have not been
+	// pruned from the backend yet
+	SkipExpired bool
 }
 
 // GetVersion returns explicitly provided version or sets latest as default
@@ -320,6 +355,15 @@ func SkipValidation() MarshalOption {
 	}
 }
 
+// SkipExpired skips resources that have expired but have not been
+// pruned from the backend yet.
+func SkipExpired() MarshalOption {
+	return func(c *MarshalConfig) error {
+		c.SkipExpired = true
+		return nil
+	}
+}
+
 // marshalerMutex is a mutex for resource marshalers/unmarshalers
 var marshalerMutex sync.RWMutex
 
@@ -48,11 +48,20 @@ import (
 var AdminUserRules = []Rule{
 	NewRule(KindRole, RW()),
 	NewRule(KindAuthConnector, RW()),
-	NewRule(KindSession, RO()),
+	NewRule(KindSession, append(RO(), VerbPlay)),
 	NewRule(KindTrustedCluster, RW()),
 	NewRule(KindEvent, RO()),
 }
 
+// AuditorUserRules provides the read-only set of rules assigned to the
+// built-in auditor role: audit events, session recordings and listings of
+// nodes and users, with no mutations.
+var AuditorUserRules = []Rule{
+	NewRule(KindSession, append(RO(), VerbPlay)),
+	NewRule(KindEvent, RO()),
+	NewRule(KindUser, RO()),
+}
+
 // DefaultImplicitRules provides access to the default set of implicit rules
 // assigned to all roles.
 var DefaultImplicitRules = []Rule{
@@ -86,6 +95,11 @@ func RoleNameForCertAuthority(name string) string {
 	return "ca:" + name
 }
 
+// RoleNameForCertificate returns role name associated with a certificate.
+func RoleNameForCertificate(name string) string {
+	return "cert:" + name
+}
+
 // NewAdminRole is the default admin role for all local users if another role
 // is not explicitly assigned (this role applies to all users in OSS version).
 func NewAdminRole() Role {
@@ -117,6 +131,34 @@ func NewAdminRole() Role {
 	return role
 }
 
+// NewAuditorRole is the built-in read-only auditor role: it can view audit
+// events, play back session recordings and list nodes and users, but has
+// no logins so it cannot connect to nodes and no rules allowing mutations.
+func NewAuditorRole() Role {
+	role := &RoleV3{
+		Kind:    KindRole,
+		Version: V3,
+		Metadata: Metadata{
+			Name:        teleport.AuditorRoleName,
+			Namespace:   defaults.Namespace,
+			Description: "Read-only access to audit events and session recordings",
+		},
+		Spec: RoleSpecV3{
+			Options: RoleOptions{
+				CertificateFormat: teleport.CertificateFormatStandard,
+				MaxSessionTTL:     NewDuration(defaults.MaxCertDuration),
+				PortForwarding:    NewBoolOption(false),
+				ForwardAgent:      NewBool(false),
+			},
+			Allow: RoleConditions{
+				Namespaces: []string{defaults.Namespace},
+				Rules:      CopyRulesSlice(AuditorUserRules),
+			},
+		},
+	}
+	return role
+}
+
 // NewImplicitRole is the default implicit role that gets added to all
 // RoleSets.
 func NewImplicitRole() Role {
@@ -169,6 +211,38 @@ func RoleForUser(u User) Role {
 	}
 }
 
+// RoleForCertificate creates a role from the authorization metadata embedded
+// in an SSH certificate: the allowed logins come from the certificate
+// principals and the forwarding permissions from the permit-* extensions. The
+// metadata was computed from the user role set when the certificate was
+// issued, so nodes can fall back to it to make authorization decisions when
+// the role definitions are not available.
+func RoleForCertificate(cert *ssh.Certificate) Role {
+	_, portForwarding := cert.Extensions[teleport.CertExtensionPermitPortForwarding]
+	_, agentForwarding := cert.Extensions[teleport.CertExtensionPermitAgentForwarding]
+	return &RoleV3{
+		Kind:    KindRole,
+		Version: V3,
+		Metadata: Metadata{
+			Name:      RoleNameForCertificate(cert.KeyId),
+			Namespace: defaults.Namespace,
+		},
+		Spec: RoleSpecV3{
+			Options: RoleOptions{
+				CertificateFormat: teleport.CertificateFormatStandard,
+				MaxSessionTTL:     NewDuration(defaults.MaxCertDuration),
+				PortForwarding:    NewBoolOption(portForwarding),
+				ForwardAgent:      NewBool(agentForwarding),
+			},
+			Allow: RoleConditions{
+				Namespaces: []string{defaults.Namespace},
+				NodeLabels: Labels{Wildcard: []string{Wildcard}},
+				Logins:     cert.ValidPrincipals,
+			},
+		},
+	}
+}
+
 // RoleForCertauthority creates role using services.CertAuthority.
 func RoleForCertAuthority(ca CertAuthority) Role {
 	return &RoleV3{
@@ -211,6 +285,11 @@ type Access interface {
 	// UpsertRole creates or updates role
 	UpsertRole(ctx context.Context, role Role) error
 
+	// CompareAndSwapRole updates the role to the new value if the stored
+	// revision matches the existing parameter, returns trace.CompareFailed
+	// otherwise
+	CompareAndSwapRole(ctx context.Context, new Role, existing Role) error
+
 	// DeleteAllRoles deletes all roles
 	DeleteAllRoles() error
 
@@ -219,6 +298,21 @@ type Access interface {
 
 	// DeleteRole deletes role by name
 	DeleteRole(ctx context.Context, name string) error
+
+	// GetLocks returns a list of locks
+	GetLocks() ([]Lock, error)
+
+	// GetLock returns lock by name
+	GetLock(name string) (Lock, error)
+
+	// UpsertLock creates or updates a lock
+	UpsertLock(ctx context.Context, lock Lock) error
+
+	// DeleteLock deletes lock by name
+	DeleteLock(ctx context.Context, name string) error
+
+	// DeleteAllLocks deletes all locks
+	DeleteAllLocks() error
 }
 
 const (
@@ -939,7 +1033,6 @@ type RuleSet map[string][]Rule
 // Specifying order solves the problem on having multiple rules, e.g. one wildcard
 // rule can override more specific rules with 'where' sections that can have
 // 'actions' lists with side effects that will not be triggered otherwise.
-//
 func (set RuleSet) Match(whereParser predicate.Parser, actionsParser predicate.Parser, resource string, verb string) (bool, error) {
 	// empty set matches nothing
 	if len(set) == 0 {
@@ -1658,6 +1751,37 @@ func (set RoleSet) MaxSessions() int64 {
 	return ms
 }
 
+// CheckCommandAccess checks if the role set permits executing the given
+// command on a node. If no role in the set restricts commands, any command
+// is permitted. Otherwise the command has to match an entry (exact string
+// or glob pattern) in the allow-list of at least one role. An empty command
+// denotes a request for an interactive shell, which is rejected when a
+// restriction is in place.
+func (set RoleSet) CheckCommandAccess(command string) error {
+	var restricted bool
+	for _, role := range set {
+		permitted := role.GetOptions().PermittedCommands
+		if len(permitted) == 0 {
+			continue
+		}
+		restricted = true
+		matched, err := utils.SliceMatchesRegex(command, permitted)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if matched {
+			return nil
+		}
+	}
+	if !restricted {
+		return nil
+	}
+	if command == "" {
+		return trace.AccessDenied("this user has been restricted to a list of permitted commands, interactive sessions are not allowed")
+	}
+	return trace.AccessDenied("command %q is not in the list of commands permitted for this user", command)
+}
+
 // AdjustClientIdleTimeout adjusts requested idle timeout
 // to the lowest max allowed timeout, the most restrictive
 // option will be picked, negative values will be assumed as 0
@@ -1845,6 +1969,42 @@ func (set RoleSet) PermitX11Forwarding() bool {
 	return false
 }
 
+// RequireSessionRecording returns true if any role in the RoleSet only
+// permits sessions on nodes that establish a session recorder, the most
+// restrictive role wins.
+func (set RoleSet) RequireSessionRecording() bool {
+	for _, role := range set {
+		if role.GetOptions().RequireSessionRecording.Value() {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultShell returns the login shell enforced by the roles in the set,
+// or an empty string to keep the shell configured for the local account.
+// When several roles set a shell, the first role in the set wins.
+func (set RoleSet) DefaultShell() string {
+	for _, role := range set {
+		if shell := role.GetOptions().Shell; shell != "" {
+			return shell
+		}
+	}
+	return ""
+}
+
+// ForcedCommand returns the command every exec and shell request of this
+// RoleSet is forced to run, similar to command= in authorized_keys. When
+// several roles force a command, the first role in the set wins.
+func (set RoleSet) ForcedCommand() string {
+	for _, role := range set {
+		if command := role.GetOptions().ForcedCommand; command != "" {
+			return command
+		}
+	}
+	return ""
+}
+
 // CertificateFormat returns the most permissive certificate format in a
 // RoleSet.
 func (set RoleSet) CertificateFormat() string {
@@ -2326,7 +2486,14 @@ const RoleSpecV3SchemaTemplate = `{
           "items": { "type": "string" }
         },
         "max_connections": { "type": "number" },
-        "max_sessions": {"type": "number"}
+        "max_sessions": {"type": "number"},
+        "permitted_commands": {
+          "type": "array",
+          "items": { "type": "string" }
+        },
+        "require_session_recording": { "type": ["boolean", "string"] },
+        "shell": { "type": "string" },
+        "forced_command": { "type": "string" }
       }
     },
     "allow": { "$ref": "#/definitions/role_condition" },
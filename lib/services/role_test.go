@@ -1553,6 +1553,30 @@ func (s *RoleSuite) TestExtractFrom(c *C) {
 	c.Assert(traits, DeepEquals, origTraits)
 }
 
+// TestRoleForCertificate verifies that a role built from certificate metadata
+// reflects the allowed logins and forwarding permissions encoded in the
+// certificate at issuance.
+func (s *RoleSuite) TestRoleForCertificate(c *C) {
+	cert := &ssh.Certificate{
+		KeyId:           "alice",
+		ValidPrincipals: []string{"root", "alice"},
+		Permissions: ssh.Permissions{
+			Extensions: map[string]string{
+				teleport.CertExtensionPermitPortForwarding: "",
+			},
+		},
+	}
+
+	role := RoleForCertificate(cert)
+	c.Assert(role.GetName(), Equals, RoleNameForCertificate("alice"))
+	c.Assert(role.GetLogins(Allow), DeepEquals, []string{"root", "alice"})
+
+	set := NewRoleSet(role)
+	c.Assert(set.CanPortForward(), Equals, true)
+	// agent forwarding was not permitted by the certificate
+	c.Assert(set.CheckAgentForward("root"), NotNil)
+}
+
 // TestExtractFromLegacy verifies that roles and traits are fetched
 // from services.User for SSH certificates is the legacy format and TLS
 // certificates that don't contain traits.
@@ -0,0 +1,213 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/trace"
+)
+
+// Selector matches label sets against a parsed selector expression. Unlike
+// plain exact-match label maps, selectors support set membership, negation
+// and regular expression matching, e.g.:
+//
+//	env in (prod, staging), role!=db, hostname=~"web-.*"
+//
+// All requirements in a selector must match for the selector to match.
+type Selector struct {
+	requirements []requirement
+}
+
+// selector operators
+const (
+	selectorOpEquals    = "="
+	selectorOpNotEquals = "!="
+	selectorOpIn        = "in"
+	selectorOpRegexp    = "=~"
+)
+
+// requirement is a single parsed requirement of a selector.
+type requirement struct {
+	key    string
+	op     string
+	values []string
+	re     *regexp.Regexp
+}
+
+// ParseSelector parses a selector expression. An empty expression parses
+// into a selector that matches any label set.
+func ParseSelector(expr string) (*Selector, error) {
+	var s Selector
+	for _, raw := range splitRequirements(expr) {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		r, err := parseRequirement(raw)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		s.requirements = append(s.requirements, r)
+	}
+	return &s, nil
+}
+
+// splitRequirements splits the expression on commas, ignoring commas inside
+// parentheses and double quotes.
+func splitRequirements(expr string) []string {
+	var out []string
+	depth, quoted := 0, false
+	last := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '"':
+			quoted = !quoted
+		case '(':
+			if !quoted {
+				depth++
+			}
+		case ')':
+			if !quoted && depth > 0 {
+				depth--
+			}
+		case ',':
+			if !quoted && depth == 0 {
+				out = append(out, expr[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(out, expr[last:])
+}
+
+// parseRequirement parses a single requirement. The multi-character
+// operators are tried before "=" so "!=" and "=~" are not misparsed as
+// equality.
+func parseRequirement(raw string) (requirement, error) {
+	if i := strings.Index(raw, selectorOpRegexp); i >= 0 {
+		key := strings.TrimSpace(raw[:i])
+		value := strings.TrimSpace(raw[i+len(selectorOpRegexp):])
+		value = strings.TrimPrefix(strings.TrimSuffix(value, `"`), `"`)
+		if key == "" || value == "" {
+			return requirement{}, trace.BadParameter("invalid selector requirement %q", raw)
+		}
+		// Anchor the expression so partial matches do not count.
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return requirement{}, trace.BadParameter("invalid regexp in selector requirement %q: %v", raw, err)
+		}
+		return requirement{key: key, op: selectorOpRegexp, values: []string{value}, re: re}, nil
+	}
+	if i := strings.Index(raw, selectorOpNotEquals); i >= 0 {
+		key := strings.TrimSpace(raw[:i])
+		value := strings.TrimSpace(raw[i+len(selectorOpNotEquals):])
+		if key == "" || value == "" {
+			return requirement{}, trace.BadParameter("invalid selector requirement %q", raw)
+		}
+		return requirement{key: key, op: selectorOpNotEquals, values: []string{value}}, nil
+	}
+	if i := strings.Index(raw, "("); i >= 0 && strings.HasSuffix(raw, ")") {
+		head := strings.TrimSpace(raw[:i])
+		if !strings.HasSuffix(head, " "+selectorOpIn) {
+			return requirement{}, trace.BadParameter("invalid selector requirement %q", raw)
+		}
+		key := strings.TrimSpace(strings.TrimSuffix(head, selectorOpIn))
+		var values []string
+		for _, value := range strings.Split(raw[i+1:len(raw)-1], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		if key == "" || len(values) == 0 {
+			return requirement{}, trace.BadParameter("invalid selector requirement %q", raw)
+		}
+		return requirement{key: key, op: selectorOpIn, values: values}, nil
+	}
+	if i := strings.Index(raw, selectorOpEquals); i >= 0 {
+		key := strings.TrimSpace(raw[:i])
+		value := strings.TrimSpace(raw[i+len(selectorOpEquals):])
+		if key == "" || value == "" {
+			return requirement{}, trace.BadParameter("invalid selector requirement %q", raw)
+		}
+		return requirement{key: key, op: selectorOpEquals, values: []string{value}}, nil
+	}
+	return requirement{}, trace.BadParameter("invalid selector requirement %q", raw)
+}
+
+// Match reports whether the given labels satisfy every requirement of the
+// selector. Absent labels satisfy negation requirements and fail all other
+// requirement types.
+func (s *Selector) Match(labels map[string]string) bool {
+	for _, r := range s.requirements {
+		value, ok := labels[r.key]
+		switch r.op {
+		case selectorOpEquals:
+			if !ok || value != r.values[0] {
+				return false
+			}
+		case selectorOpNotEquals:
+			if ok && value == r.values[0] {
+				return false
+			}
+		case selectorOpIn:
+			if !ok {
+				return false
+			}
+			found := false
+			for _, v := range r.values {
+				if v == value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		case selectorOpRegexp:
+			if !ok || !r.re.MatchString(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Empty reports whether the selector has no requirements and matches any
+// label set.
+func (s *Selector) Empty() bool {
+	return len(s.requirements) == 0
+}
+
+// String returns the selector in canonical expression form, which parses
+// back into an equivalent selector.
+func (s *Selector) String() string {
+	out := make([]string, 0, len(s.requirements))
+	for _, r := range s.requirements {
+		switch r.op {
+		case selectorOpIn:
+			out = append(out, fmt.Sprintf("%v in (%v)", r.key, strings.Join(r.values, ", ")))
+		case selectorOpRegexp:
+			out = append(out, fmt.Sprintf("%v=~%q", r.key, r.values[0]))
+		default:
+			out = append(out, fmt.Sprintf("%v%v%v", r.key, r.op, r.values[0]))
+		}
+	}
+	return strings.Join(out, ", ")
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"gopkg.in/check.v1"
+)
+
+type SelectorSuite struct{}
+
+var _ = check.Suite(&SelectorSuite{})
+
+func (s *SelectorSuite) TestParseAndMatch(c *check.C) {
+	tests := []struct {
+		expr    string
+		labels  map[string]string
+		matches bool
+	}{
+		// exact match
+		{expr: "env=prod", labels: map[string]string{"env": "prod"}, matches: true},
+		{expr: "env=prod", labels: map[string]string{"env": "staging"}, matches: false},
+		{expr: "env=prod", labels: map[string]string{}, matches: false},
+		// negation, absent labels satisfy it
+		{expr: "role!=db", labels: map[string]string{"role": "web"}, matches: true},
+		{expr: "role!=db", labels: map[string]string{"role": "db"}, matches: false},
+		{expr: "role!=db", labels: map[string]string{}, matches: true},
+		// set membership
+		{expr: "env in (prod, staging)", labels: map[string]string{"env": "staging"}, matches: true},
+		{expr: "env in (prod, staging)", labels: map[string]string{"env": "dev"}, matches: false},
+		{expr: "env in (prod, staging)", labels: map[string]string{}, matches: false},
+		// regular expressions are anchored
+		{expr: `hostname=~"web-.*"`, labels: map[string]string{"hostname": "web-1"}, matches: true},
+		{expr: `hostname=~"web-.*"`, labels: map[string]string{"hostname": "xweb-1"}, matches: false},
+		{expr: `hostname=~"web-.*"`, labels: map[string]string{}, matches: false},
+		// multiple requirements must all match
+		{
+			expr:    `env in (prod, staging), role!=db, hostname=~"web-.*"`,
+			labels:  map[string]string{"env": "prod", "role": "web", "hostname": "web-3"},
+			matches: true,
+		},
+		{
+			expr:    `env in (prod, staging), role!=db, hostname=~"web-.*"`,
+			labels:  map[string]string{"env": "prod", "role": "db", "hostname": "web-3"},
+			matches: false,
+		},
+		// empty expression matches everything
+		{expr: "", labels: map[string]string{}, matches: true},
+	}
+	for _, tt := range tests {
+		selector, err := ParseSelector(tt.expr)
+		c.Assert(err, check.IsNil, check.Commentf("expr: %q", tt.expr))
+		c.Assert(selector.Match(tt.labels), check.Equals, tt.matches,
+			check.Commentf("expr: %q, labels: %v", tt.expr, tt.labels))
+	}
+}
+
+func (s *SelectorSuite) TestParseErrors(c *check.C) {
+	for _, expr := range []string{
+		"env",
+		"=prod",
+		"env=",
+		"env in ()",
+		"env (prod)",
+		`hostname=~"web-(*"`,
+	} {
+		_, err := ParseSelector(expr)
+		c.Assert(err, check.NotNil, check.Commentf("expr: %q", expr))
+	}
+}
+
+func (s *SelectorSuite) TestString(c *check.C) {
+	expr := `env in (prod, staging), role!=db, hostname=~"web-.*"`
+	selector, err := ParseSelector(expr)
+	c.Assert(err, check.IsNil)
+	c.Assert(selector.String(), check.Equals, expr)
+
+	// the canonical form parses back into an equivalent selector
+	reparsed, err := ParseSelector(selector.String())
+	c.Assert(err, check.IsNil)
+	c.Assert(reparsed.String(), check.Equals, selector.String())
+}
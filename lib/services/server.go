@@ -31,6 +31,26 @@ import (
 	"github.com/jonboulle/clockwork"
 )
 
+// RecordAtProxyLabel is a node label that requests sessions to the node to
+// be terminated and recorded at the proxy even when the cluster-wide session
+// recording mode records at the node. It is mostly useful for
+// agentless/OpenSSH nodes that can not record sessions themselves.
+const RecordAtProxyLabel = "record-at-proxy"
+
+// NodeRecordsAtProxy returns true if the node is labeled to have its
+// sessions terminated and recorded at the proxy.
+func NodeRecordsAtProxy(server Server) bool {
+	value, ok := server.GetAllLabels()[RecordAtProxyLabel]
+	if !ok {
+		return false
+	}
+	record, err := utils.ParseBool(value)
+	if err != nil {
+		return false
+	}
+	return record
+}
+
 // Server represents a Node, Proxy or Auth server in a Teleport cluster
 type Server interface {
 	// Resource provides common resource headers
@@ -59,6 +79,24 @@ type Server interface {
 	GetUseTunnel() bool
 	// SetUseTunnel sets if a reverse tunnel should be used to connect to this node.
 	SetUseTunnel(bool)
+	// GetCPUPercent returns the CPU utilization gauge reported by the
+	// server, 0 when reporting is disabled.
+	GetCPUPercent() int32
+	// GetMemoryPercent returns the memory utilization gauge reported by the
+	// server, 0 when reporting is disabled.
+	GetMemoryPercent() int32
+	// GetDiskPercent returns the root filesystem utilization gauge reported
+	// by the server, 0 when reporting is disabled.
+	GetDiskPercent() int32
+	// GetLastHeartbeat returns the time the last heartbeat was received
+	// from the server, zero if the server never heartbeated
+	GetLastHeartbeat() time.Time
+	// SetLastHeartbeat sets the time the last heartbeat was received
+	// from the server
+	SetLastHeartbeat(time.Time)
+	// IsStale reports whether the server record has expired but has not
+	// been pruned from the backend yet
+	IsStale(clock clockwork.Clock) bool
 	// String returns string representation of the server
 	String() string
 	// SetAddr sets server address
@@ -80,6 +118,35 @@ type Server interface {
 	CheckAndSetDefaults() error
 }
 
+// ShortNodeID returns the first eight characters of the node ID, enough
+// to tell apart nodes sharing a hostname.
+func ShortNodeID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// DisambiguateHostnames returns a display name for every server keyed by
+// node ID: servers with a unique hostname map to the plain hostname, while
+// servers sharing one get the short node ID appended so that listings and
+// error messages always name an unambiguous target.
+func DisambiguateHostnames(servers []Server) map[string]string {
+	count := make(map[string]int)
+	for _, server := range servers {
+		count[server.GetHostname()]++
+	}
+	out := make(map[string]string, len(servers))
+	for _, server := range servers {
+		hostname := server.GetHostname()
+		if count[hostname] > 1 {
+			hostname = fmt.Sprintf("%v-%v", hostname, ShortNodeID(server.GetName()))
+		}
+		out[server.GetName()] = hostname
+	}
+	return out
+}
+
 // ServersToV1 converts list of servers to slice of V1 style ones
 func ServersToV1(in []Server) []ServerV1 {
 	out := make([]ServerV1, len(in))
@@ -226,6 +293,43 @@ func (s *ServerV2) SetUseTunnel(useTunnel bool) {
 	s.Spec.UseTunnel = useTunnel
 }
 
+// GetCPUPercent returns the CPU utilization gauge reported by the server,
+// 0 when reporting is disabled.
+func (s *ServerV2) GetCPUPercent() int32 {
+	return s.Spec.CPUPercent
+}
+
+// GetMemoryPercent returns the memory utilization gauge reported by the
+// server, 0 when reporting is disabled.
+func (s *ServerV2) GetMemoryPercent() int32 {
+	return s.Spec.MemoryPercent
+}
+
+// GetDiskPercent returns the root filesystem utilization gauge reported by
+// the server, 0 when reporting is disabled.
+func (s *ServerV2) GetDiskPercent() int32 {
+	return s.Spec.DiskPercent
+}
+
+// GetLastHeartbeat returns the time the last heartbeat was received from
+// the server, zero if the server never heartbeated
+func (s *ServerV2) GetLastHeartbeat() time.Time {
+	return s.Spec.LastHeartbeat
+}
+
+// SetLastHeartbeat sets the time the last heartbeat was received from
+// the server
+func (s *ServerV2) SetLastHeartbeat(t time.Time) {
+	s.Spec.LastHeartbeat = t
+}
+
+// IsStale reports whether the server record has expired but has not been
+// pruned from the backend yet
+func (s *ServerV2) IsStale(clock clockwork.Clock) bool {
+	expiry := s.Expiry()
+	return !expiry.IsZero() && expiry.Before(clock.Now())
+}
+
 // GetHostname returns server hostname
 func (s *ServerV2) GetHostname() string {
 	return s.Spec.Hostname
@@ -393,6 +497,10 @@ const ServerSpecV2Schema = `{
     "public_addr": {"type": "string"},
     "hostname": {"type": "string"},
     "use_tunnel": {"type": "boolean"},
+    "cpu_percent": {"type": "integer"},
+    "memory_percent": {"type": "integer"},
+    "disk_percent": {"type": "integer"},
+    "last_heartbeat": {"type": "string"},
     "labels": {
       "type": "object",
       "additionalProperties": false,
@@ -47,6 +47,12 @@ type TunnelConnection interface {
 	GetType() TunnelType
 	// SetType sets the type of ReverseTunnel.
 	SetType(TunnelType)
+	// GetConnectionCount returns the number of active connections backing
+	// this tunnel
+	GetConnectionCount() int32
+	// SetConnectionCount sets the number of active connections backing
+	// this tunnel
+	SetConnectionCount(int32)
 	// Check checks tunnel for errors
 	Check() error
 	// CheckAndSetDefaults checks and set default values for any missing fields.
@@ -225,6 +231,18 @@ func (r *TunnelConnectionV2) SetType(tt TunnelType) {
 	r.Spec.Type = tt
 }
 
+// GetConnectionCount returns the number of active connections backing
+// this tunnel
+func (r *TunnelConnectionV2) GetConnectionCount() int32 {
+	return r.Spec.ConnectionCount
+}
+
+// SetConnectionCount sets the number of active connections backing
+// this tunnel
+func (r *TunnelConnectionV2) SetConnectionCount(count int32) {
+	r.Spec.ConnectionCount = count
+}
+
 // Check returns nil if all parameters are good, error otherwise
 func (r *TunnelConnectionV2) Check() error {
 	if r.Version == "" {
@@ -250,7 +268,8 @@ const TunnelConnectionSpecV2Schema = `{
     "cluster_name": {"type": "string"},
     "proxy_name": {"type": "string"},
     "last_heartbeat": {"type": "string"},
-    "type": {"type": "string"}
+    "type": {"type": "string"},
+    "connection_count": {"type": "integer"}
   }
 }`
 
@@ -54,6 +54,18 @@ type AuthHandlers struct {
 	// FIPS mode means Teleport started in a FedRAMP/FIPS 140-2 compliant
 	// configuration.
 	FIPS bool
+
+	// ClockSkewTolerance extends the validity window of certificates to
+	// tolerate wall clock drift between this host and the auth server.
+	ClockSkewTolerance time.Duration
+
+	// AuthorizationGracePeriod sets for how long the node keeps authorizing
+	// already-seen users from cached role data when the auth server is
+	// unreachable. Zero disables the cache.
+	AuthorizationGracePeriod time.Duration
+
+	// authzCache holds the role sets of recently authorized users.
+	authzCache authorizationCache
 }
 
 // CreateIdentityContext returns an IdentityContext populated with information
@@ -202,7 +214,8 @@ func (h *AuthHandlers) UserKeyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*s
 		CertChecker: ssh.CertChecker{
 			IsUserAuthority: h.IsUserAuthority,
 		},
-		FIPS: h.FIPS,
+		FIPS:               h.FIPS,
+		ClockSkewTolerance: h.ClockSkewTolerance,
 	}
 	permissions, err := certChecker.Authenticate(conn, key)
 	if err != nil {
@@ -222,6 +235,27 @@ func (h *AuthHandlers) UserKeyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*s
 	permissions.Extensions[utils.CertTeleportClusterName] = clusterName.GetClusterName()
 	permissions.Extensions[utils.CertTeleportUserCertificate] = string(ssh.MarshalAuthorizedKey(cert))
 
+	// check cluster-wide locks before anything else, the check runs on
+	// proxies as well as nodes so locked out users are rejected at the
+	// cluster edge.
+	certRoles, err := extractRolesFromCert(cert)
+	if err != nil {
+		log.Warnf("Failed to extract roles from certificate: %v.", err)
+	}
+	locks, err := h.AccessPoint.GetLocks()
+	if err != nil {
+		// the lock list is served from the local cache, a failure here
+		// means the cache has not initialized yet; do not lock out the
+		// whole cluster because of it.
+		log.Warnf("Failed to fetch locks: %v.", err)
+	} else if lock := services.LockInForce(h.Server.GetClock(), locks,
+		LockTargets(teleportUser, certRoles, h.Server.HostUUID(), conn.RemoteAddr())...); lock != nil {
+		err := services.AccessDeniedByLock(lock)
+		log.Errorf("Permission denied: %v", err)
+		recordFailedLogin(err)
+		return nil, trace.Wrap(err)
+	}
+
 	if h.isProxy() {
 		return permissions, nil
 	}
@@ -256,7 +290,8 @@ func (h *AuthHandlers) HostKeyAuth(addr string, remote net.Addr, key ssh.PublicK
 			IsHostAuthority: h.IsHostAuthority,
 			HostKeyFallback: h.hostKeyCallback,
 		},
-		FIPS: h.FIPS,
+		FIPS:               h.FIPS,
+		ClockSkewTolerance: h.ClockSkewTolerance,
 	}
 	err := certChecker.CheckHostKey(addr, remote, key)
 	if err != nil {
@@ -359,7 +394,26 @@ func (h *AuthHandlers) fetchRoleSet(cert *ssh.Certificate, ca services.CertAutho
 		}
 		roleset, err = services.FetchRoles(roles, h.AccessPoint, traits)
 		if err != nil {
-			return nil, trace.Wrap(err)
+			if !trace.IsNotFound(err) && !trace.IsConnectionProblem(err) {
+				return nil, trace.Wrap(err)
+			}
+			// Within the grace period, keep authorizing already-seen users
+			// from the cached role data and annotate the decision in the
+			// audit log.
+			if cached, ok := h.authzCache.get(teleportUser, h.AuthorizationGracePeriod); ok {
+				h.Warnf("Failed to fetch roles %v for user %v: %v. Authorizing from cached role data.",
+					roles, teleportUser, err)
+				h.emitCachedAuthorization(teleportUser)
+				return cached, nil
+			}
+			// The role definitions are not available, fall back to the
+			// authorization metadata (allowed logins and forwarding
+			// permissions) embedded in the certificate at issuance.
+			h.Warnf("Failed to fetch roles %v for user %v: %v. Falling back to certificate metadata.",
+				roles, teleportUser, err)
+			roleset = services.NewRoleSet(services.RoleForCertificate(cert))
+		} else {
+			h.authzCache.put(teleportUser, roleset)
 		}
 	} else {
 		roles, err := extractRolesFromCert(cert)
@@ -384,6 +438,27 @@ func (h *AuthHandlers) fetchRoleSet(cert *ssh.Certificate, ca services.CertAutho
 	return roleset, nil
 }
 
+// emitCachedAuthorization records in the audit log that an authorization
+// decision was made from cached role data because the auth server was
+// unreachable.
+func (h *AuthHandlers) emitCachedAuthorization(teleportUser string) {
+	if err := h.Emitter.EmitAuditEvent(h.Server.Context(), &events.AuthAttempt{
+		Metadata: events.Metadata{
+			Type: events.AuthAttemptEvent,
+			Code: events.AuthAttemptCachedCode,
+		},
+		UserMetadata: events.UserMetadata{
+			User: teleportUser,
+		},
+		Status: events.Status{
+			Success:     true,
+			UserMessage: "authorized from cached role data, the auth server was unreachable",
+		},
+	}); err != nil {
+		h.WithError(err).Warn("Failed to emit cached authorization audit event.")
+	}
+}
+
 // authorityForCert checks if the certificate was signed by a Teleport
 // Certificate Authority and returns it.
 func (h *AuthHandlers) authorityForCert(caType services.CertAuthType, key ssh.PublicKey) (services.CertAuthority, error) {
@@ -435,6 +510,23 @@ func (h *AuthHandlers) isProxy() bool {
 	return h.Component == teleport.ComponentProxy
 }
 
+// LockTargets returns the lock targets a connection should be checked
+// against: the teleport user, each of the user's roles, the UUID of the
+// host serving the connection and the client source IP.
+func LockTargets(teleportUser string, roles []string, hostUUID string, remoteAddr net.Addr) []services.LockTarget {
+	targets := []services.LockTarget{
+		{User: teleportUser},
+		{Node: hostUUID},
+	}
+	for _, role := range roles {
+		targets = append(targets, services.LockTarget{Role: role})
+	}
+	if ip, err := utils.Host(remoteAddr.String()); err == nil {
+		targets = append(targets, services.LockTarget{IP: ip})
+	}
+	return targets
+}
+
 // extractRolesFromCert extracts roles from certificate metadata extensions.
 func extractRolesFromCert(cert *ssh.Certificate) ([]string, error) {
 	data, ok := cert.Extensions[teleport.CertExtensionTeleportRoles]
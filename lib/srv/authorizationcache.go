@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport/lib/services"
+)
+
+// authorizationCache remembers the role sets of recently authorized users so
+// the node can keep letting them in for a grace period when the auth server
+// is unreachable. Decisions made from cached data are annotated in the audit
+// log by the caller.
+type authorizationCache struct {
+	sync.Mutex
+	entries map[string]authorizationCacheEntry
+}
+
+// authorizationCacheEntry is a role set together with the time it was
+// fetched from the auth server.
+type authorizationCacheEntry struct {
+	roleSet services.RoleSet
+	time    time.Time
+}
+
+// put remembers the role set that was just fetched for a user.
+func (c *authorizationCache) put(user string, roleSet services.RoleSet) {
+	c.Lock()
+	defer c.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]authorizationCacheEntry)
+	}
+	c.entries[user] = authorizationCacheEntry{
+		roleSet: roleSet,
+		time:    time.Now(),
+	}
+}
+
+// get returns the cached role set for a user if it was fetched within the
+// grace period, expired entries are evicted. A zero grace period disables
+// the cache.
+func (c *authorizationCache) get(user string, gracePeriod time.Duration) (services.RoleSet, bool) {
+	if gracePeriod == 0 {
+		return nil, false
+	}
+	c.Lock()
+	defer c.Unlock()
+	entry, ok := c.entries[user]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.time) > gracePeriod {
+		delete(c.entries, user)
+		return nil, false
+	}
+	return entry.roleSet, true
+}
@@ -118,6 +118,10 @@ type Server interface {
 	// GetBPF returns the BPF service used for enhanced session recording.
 	GetBPF() bpf.BPF
 
+	// GetSessionLimiter returns the limiter that applies resource limits to
+	// session processes. May be nil if no limits are configured.
+	GetSessionLimiter() *SessionLimiter
+
 	// Context returns server shutdown context
 	Context() context.Context
 }
@@ -319,6 +323,7 @@ func NewServerContext(ctx context.Context, parent *sshutils.ConnectionContext, s
 		"login":        child.Identity.Login,
 		"teleportUser": child.Identity.TeleportUser,
 		"id":           child.id,
+		"trace":        parent.GetTraceID(),
 	}
 	if !child.disconnectExpiredCert.IsZero() {
 		fields["cert"] = child.disconnectExpiredCert
@@ -331,7 +336,10 @@ func NewServerContext(ctx context.Context, parent *sshutils.ConnectionContext, s
 		trace.ComponentFields: fields,
 	})
 
-	if !child.disconnectExpiredCert.IsZero() || child.clientIdleTimeout != 0 {
+	lockTargets := LockTargets(identityContext.TeleportUser, identityContext.RoleSet.RoleNames(),
+		srv.HostUUID(), child.ServerConn.RemoteAddr())
+
+	if !child.disconnectExpiredCert.IsZero() || child.clientIdleTimeout != 0 || len(lockTargets) != 0 {
 		mon, err := NewMonitor(MonitorConfig{
 			DisconnectExpiredCert: child.disconnectExpiredCert,
 			ClientIdleTimeout:     child.clientIdleTimeout,
@@ -344,6 +352,8 @@ func NewServerContext(ctx context.Context, parent *sshutils.ConnectionContext, s
 			ServerID:              child.srv.ID(),
 			Entry:                 child.Entry,
 			Emitter:               child.srv,
+			AccessPoint:           srv.GetAccessPoint(),
+			LockTargets:           lockTargets,
 		})
 		if err != nil {
 			child.Close()
@@ -484,6 +494,11 @@ func (c *ServerContext) VisitEnv(visit func(key, val string)) {
 
 // SetEnv sets a environment variable within this context.
 func (c *ServerContext) SetEnv(key, val string) {
+	// adopt the correlation ID generated upstream by a client or proxy so
+	// all hops of the connection share a single trace ID
+	if key == teleport.SSHTeleportTraceID && val != "" {
+		c.Parent().SetTraceID(val)
+	}
 	c.env[key] = val
 }
 
@@ -657,6 +672,7 @@ func (c *ServerContext) ExecCommand() (*ExecCommand, error) {
 	var pamEnabled bool
 	var pamServiceName string
 	var pamUseAuth bool
+	var pamEnvironment map[string]string
 
 	// If this code is running on a node, check if PAM is enabled or not.
 	if c.srv.Component() == teleport.ComponentNode {
@@ -667,6 +683,7 @@ func (c *ServerContext) ExecCommand() (*ExecCommand, error) {
 		pamEnabled = conf.Enabled
 		pamServiceName = conf.ServiceName
 		pamUseAuth = conf.UsePAMAuth
+		pamEnvironment = conf.Env
 	}
 
 	// If the identity has roles, extract the role names.
@@ -690,20 +707,37 @@ func (c *ServerContext) ExecCommand() (*ExecCommand, error) {
 		requestType = c.request.Type
 	}
 
+	// Whether a terminal is attached depends on what the client originally
+	// requested, not on the command a role may force below.
+	terminal := c.termAllocated || command == ""
+
+	// If a role forces a command, it replaces whatever was requested. Like
+	// OpenSSH does for command= in authorized_keys, the originally requested
+	// command is passed to it in SSH_ORIGINAL_COMMAND.
+	environment := buildEnvironment(c)
+	if forced := c.Identity.RoleSet.ForcedCommand(); forced != "" {
+		if command != "" {
+			environment = append(environment, "SSH_ORIGINAL_COMMAND="+command)
+		}
+		command = forced
+	}
+
 	// Create the execCommand that will be sent to the child process.
 	return &ExecCommand{
 		Command:               command,
+		Shell:                 c.Identity.RoleSet.DefaultShell(),
 		DestinationAddress:    c.DstAddr,
 		Username:              c.Identity.TeleportUser,
 		Login:                 c.Identity.Login,
 		Roles:                 roleNames,
-		Terminal:              c.termAllocated || command == "",
+		Terminal:              terminal,
 		RequestType:           requestType,
 		PermitUserEnvironment: c.srv.PermitUserEnvironment(),
-		Environment:           buildEnvironment(c),
+		Environment:           environment,
 		PAM:                   pamEnabled,
 		ServiceName:           pamServiceName,
 		UsePAMAuth:            pamUseAuth,
+		PAMEnvironment:        pamEnvironment,
 		IsTestStub:            c.IsTestStub,
 	}, nil
 }
@@ -752,6 +786,7 @@ func buildEnvironment(ctx *ServerContext) []string {
 	env = append(env, teleport.SSHTeleportHostUUID+"="+ctx.srv.ID())
 	env = append(env, teleport.SSHTeleportClusterName+"="+ctx.ClusterName)
 	env = append(env, teleport.SSHTeleportUser+"="+ctx.Identity.TeleportUser)
+	env = append(env, teleport.SSHTeleportTraceID+"="+ctx.Parent().GetTraceID())
 
 	return env
 }
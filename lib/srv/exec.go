@@ -217,8 +217,12 @@ func (e *localExec) Continue() {
 	e.Ctx.contw = nil
 }
 
-// PID returns the PID of the Teleport process that was re-execed.
+// PID returns the PID of the Teleport process that was re-execed, or 0 if
+// the process has not started yet.
 func (e *localExec) PID() int {
+	if e.Cmd == nil || e.Cmd.Process == nil {
+		return 0
+	}
 	return e.Cmd.Process.Pid
 }
 
@@ -557,9 +561,20 @@ func exitCode(err error) int {
 		if !ok {
 			return teleport.RemoteCommandFailure
 		}
+		// A command killed by a signal has no exit status, report it the
+		// way shells do: 128 plus the signal number. Without this the raw
+		// -1 wait status wraps around when sent as the uint32 exit-status.
+		if waitStatus.Signaled() {
+			return 128 + int(waitStatus.Signal())
+		}
 		return waitStatus.ExitStatus()
 	// Remote execution.
 	case *ssh.ExitError:
+		// A remote command killed by a signal reports the signal name
+		// instead of an exit status, translate it the same way shells do.
+		if signal, ok := execSignals[v.Signal()]; ok {
+			return 128 + int(signal)
+		}
 		return v.ExitStatus()
 	// An error occurred, but the type is unknown, return a generic 255 code.
 	default:
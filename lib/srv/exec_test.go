@@ -476,6 +476,10 @@ func (f *fakeServer) GetBPF() bpf.BPF {
 	return &bpf.NOP{}
 }
 
+func (f *fakeServer) GetSessionLimiter() *SessionLimiter {
+	return nil
+}
+
 // fakeLog is used in tests to obtain the last event emit to the Audit Log.
 type fakeLog struct {
 }
@@ -0,0 +1,50 @@
+// +build !windows
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gravitational/trace"
+)
+
+// killProcess delivers the signal to the process with the given PID.
+func killProcess(pid int, signal syscall.Signal) error {
+	return trace.ConvertSystemError(syscall.Kill(pid, signal))
+}
+
+// execSignals maps the SSH wire names of signals that terminate a remote
+// command to their local numbers.
+var execSignals = map[string]syscall.Signal{
+	string(ssh.SIGABRT): syscall.SIGABRT,
+	string(ssh.SIGALRM): syscall.SIGALRM,
+	string(ssh.SIGFPE):  syscall.SIGFPE,
+	string(ssh.SIGHUP):  syscall.SIGHUP,
+	string(ssh.SIGILL):  syscall.SIGILL,
+	string(ssh.SIGINT):  syscall.SIGINT,
+	string(ssh.SIGKILL): syscall.SIGKILL,
+	string(ssh.SIGPIPE): syscall.SIGPIPE,
+	string(ssh.SIGQUIT): syscall.SIGQUIT,
+	string(ssh.SIGSEGV): syscall.SIGSEGV,
+	string(ssh.SIGTERM): syscall.SIGTERM,
+	string(ssh.SIGUSR1): syscall.SIGUSR1,
+	string(ssh.SIGUSR2): syscall.SIGUSR2,
+}
@@ -0,0 +1,58 @@
+// +build windows
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/gravitational/trace"
+)
+
+// killProcess delivers the signal to the process with the given PID.
+// Windows has no equivalent of kill(2), only termination is supported.
+func killProcess(pid int, signal syscall.Signal) error {
+	if signal != syscall.SIGKILL && signal != syscall.SIGTERM {
+		return trace.NotImplemented("signal %v is not supported on Windows", signal)
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(process.Kill())
+}
+
+// execSignals maps the SSH wire names of signals that terminate a remote
+// command to their local numbers. SIGUSR1 and SIGUSR2 do not exist on
+// Windows and are left out.
+var execSignals = map[string]syscall.Signal{
+	string(ssh.SIGABRT): syscall.SIGABRT,
+	string(ssh.SIGALRM): syscall.SIGALRM,
+	string(ssh.SIGFPE):  syscall.SIGFPE,
+	string(ssh.SIGHUP):  syscall.SIGHUP,
+	string(ssh.SIGILL):  syscall.SIGILL,
+	string(ssh.SIGINT):  syscall.SIGINT,
+	string(ssh.SIGKILL): syscall.SIGKILL,
+	string(ssh.SIGPIPE): syscall.SIGPIPE,
+	string(ssh.SIGQUIT): syscall.SIGQUIT,
+	string(ssh.SIGSEGV): syscall.SIGSEGV,
+	string(ssh.SIGTERM): syscall.SIGTERM,
+}
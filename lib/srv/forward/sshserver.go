@@ -386,6 +386,12 @@ func (s Server) GetBPF() bpf.BPF {
 	return &bpf.NOP{}
 }
 
+// GetSessionLimiter returns nil, resource limits only apply to processes
+// spawned on the node itself.
+func (s Server) GetSessionLimiter() *srv.SessionLimiter {
+	return nil
+}
+
 // GetInfo returns a services.Server that represents this server.
 func (s *Server) GetInfo() services.Server {
 	return &services.ServerV2{
@@ -870,6 +876,8 @@ func (s *Server) dispatch(ctx context.Context, ch ssh.Channel, req *ssh.Request,
 		return s.termHandlers.HandleShell(ch, req, scx)
 	case sshutils.WindowChangeRequest:
 		return s.termHandlers.HandleWinChange(ch, req, scx)
+	case sshutils.SignalRequest:
+		return s.termHandlers.HandleSignal(ch, req, scx)
 	case sshutils.EnvRequest:
 		return s.handleEnv(ch, req, scx)
 	case sshutils.SubsystemRequest:
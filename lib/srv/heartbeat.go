@@ -154,6 +154,11 @@ type HeartbeatConfig struct {
 	// bandwidh, also is used to derive time between
 	// failed attempts as well for auth and proxy modes
 	KeepAlivePeriod time.Duration
+	// GetKeepAlivePeriod optionally overrides KeepAlivePeriod with a
+	// dynamic value, e.g. the keep-alive interval set in cluster
+	// configuration, so that cluster-level changes take effect without
+	// restarting the agent. It is consulted on every heartbeat cycle.
+	GetKeepAlivePeriod func() time.Duration
 	// AnnouncePeriod is a period between announce calls,
 	// when client sends full server specification
 	// to the presence service
@@ -273,6 +278,17 @@ func (h *Heartbeat) Close() error {
 	return nil
 }
 
+// keepAlivePeriod returns the period between keep alive attempts,
+// preferring the dynamic cluster-level value when one is available
+func (h *Heartbeat) keepAlivePeriod() time.Duration {
+	if h.GetKeepAlivePeriod != nil {
+		if period := h.GetKeepAlivePeriod(); period > 0 {
+			return period
+		}
+	}
+	return h.KeepAlivePeriod
+}
+
 // setState is used to debug state transitions
 // as it logs in addition to setting state
 func (h *Heartbeat) setState(state KeepAliveState) {
@@ -365,7 +381,7 @@ func (h *Heartbeat) announce() error {
 			if err != nil {
 				// try next announce using keep alive period,
 				// that happens more frequently
-				h.nextAnnounce = h.Clock.Now().UTC().Add(h.KeepAlivePeriod)
+				h.nextAnnounce = h.Clock.Now().UTC().Add(h.keepAlivePeriod())
 				h.setState(HeartbeatStateAnnounceWait)
 				return trace.Wrap(err)
 			}
@@ -376,7 +392,7 @@ func (h *Heartbeat) announce() error {
 		case HeartbeatModeAuth:
 			err := h.Announcer.UpsertAuthServer(h.current)
 			if err != nil {
-				h.nextAnnounce = h.Clock.Now().UTC().Add(h.KeepAlivePeriod)
+				h.nextAnnounce = h.Clock.Now().UTC().Add(h.keepAlivePeriod())
 				h.setState(HeartbeatStateAnnounceWait)
 				return trace.Wrap(err)
 			}
@@ -396,7 +412,7 @@ func (h *Heartbeat) announce() error {
 			return trace.Wrap(err)
 		}
 		h.nextAnnounce = h.Clock.Now().UTC().Add(h.AnnouncePeriod)
-		h.nextKeepAlive = h.Clock.Now().UTC().Add(h.KeepAlivePeriod)
+		h.nextKeepAlive = h.Clock.Now().UTC().Add(h.keepAlivePeriod())
 		h.keepAlive = keepAlive
 		h.keepAliver = keepAliver
 		h.setState(HeartbeatStateKeepAliveWait)
@@ -404,7 +420,7 @@ func (h *Heartbeat) announce() error {
 	case HeartbeatStateKeepAlive:
 		keepAlive := *h.keepAlive
 		keepAlive.Expires = h.Clock.Now().UTC().Add(h.ServerTTL)
-		timeout := time.NewTimer(h.KeepAlivePeriod)
+		timeout := time.NewTimer(h.keepAlivePeriod())
 		defer timeout.Stop()
 		select {
 		case <-h.cancelCtx.Done():
@@ -415,7 +431,7 @@ func (h *Heartbeat) announce() error {
 			return trace.ConnectionProblem(nil, "timeout sending keep alive")
 		case h.keepAliver.KeepAlives() <- keepAlive:
 			h.notifySend()
-			h.nextKeepAlive = h.Clock.Now().UTC().Add(h.KeepAlivePeriod)
+			h.nextKeepAlive = h.Clock.Now().UTC().Add(h.keepAlivePeriod())
 			h.setState(HeartbeatStateKeepAliveWait)
 			return nil
 		case <-h.keepAliver.Done():
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gravitational/teleport/lib/shell"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// HostUsersConfig holds configuration for automatic creation of local Unix
+// accounts. When enabled, a node will create the local account for a
+// certificate principal that has no matching local user before launching
+// the shell or command.
+type HostUsersConfig struct {
+	// Enabled controls if local Unix accounts will be created or not.
+	Enabled bool
+
+	// Groups is a list of additional groups the created account will be
+	// added to. Groups have to exist on the host, they will not be created.
+	Groups []string
+
+	// Shell is the login shell assigned to the created account. If empty,
+	// the system default shell is used.
+	Shell string
+
+	// CreateHome controls if a home directory will be created for the
+	// account.
+	CreateHome bool
+}
+
+// EnsureHostUser creates the local Unix account for login if one does not
+// exist already. It returns true if the account was created by this call.
+func EnsureHostUser(login string, cfg *HostUsersConfig) (bool, error) {
+	if cfg == nil || !cfg.Enabled {
+		return false, nil
+	}
+
+	// If the account already exists, nothing to do.
+	_, err := user.Lookup(login)
+	if err == nil {
+		return false, nil
+	}
+	if _, ok := err.(user.UnknownUserError); !ok {
+		return false, trace.ConvertSystemError(err)
+	}
+
+	loginShell := cfg.Shell
+	if loginShell == "" {
+		loginShell = shell.DefaultShell
+	}
+
+	args := []string{"--shell", loginShell}
+	if cfg.CreateHome {
+		args = append(args, "--create-home")
+	} else {
+		args = append(args, "--no-create-home")
+	}
+	if len(cfg.Groups) > 0 {
+		args = append(args, "--groups", strings.Join(cfg.Groups, ","))
+	}
+	args = append(args, login)
+
+	useraddBin, err := exec.LookPath("useradd")
+	if err != nil {
+		return false, trace.NotFound("cannot create host user %q: %v", login, err)
+	}
+	out, err := exec.Command(useraddBin, args...).CombinedOutput()
+	if err != nil {
+		return false, trace.WrapWithMessage(err, "failed to create host user %q: %s", login, out)
+	}
+
+	return true, nil
+}
+
+// CheckHostUser checks the status of the local Unix account before a session
+// is started for it. Logins are refused with a clear error for accounts that
+// have been locked, have expired, or have a non-interactive login shell,
+// instead of spawning a shell that immediately exits.
+func CheckHostUser(login string) error {
+	_, err := user.Lookup(login)
+	if err != nil {
+		if _, ok := err.(user.UnknownUserError); ok {
+			return trace.AccessDenied("host user %q does not exist", login)
+		}
+		return trace.ConvertSystemError(err)
+	}
+
+	loginShell, err := shell.GetLoginShell(login)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	switch filepath.Base(loginShell) {
+	case "nologin", "false":
+		return trace.AccessDenied("host user %q has a non-interactive login shell %v, logins for this account are disabled", login, loginShell)
+	}
+
+	locked, expired, err := readShadowStatus(login)
+	if err != nil {
+		// The shadow database can not be read when the node runs without
+		// privileges, skip the account status checks in that case.
+		log.Debugf("Unable to read shadow status of %q: %v.", login, err)
+		return nil
+	}
+	if locked {
+		return trace.AccessDenied("host user %q is locked", login)
+	}
+	if expired {
+		return trace.AccessDenied("host user %q has expired", login)
+	}
+	return nil
+}
+
+// readShadowStatus parses the entry of login in the shadow password database
+// and reports if the account has been locked or has expired.
+func readShadowStatus(login string) (locked bool, expired bool, err error) {
+	data, err := ioutil.ReadFile(shadowFile)
+	if err != nil {
+		return false, false, trace.ConvertSystemError(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 9 || fields[0] != login {
+			continue
+		}
+		// An account is locked when the password hash has been prefixed
+		// with "!", see passwd(1).
+		locked = strings.HasPrefix(fields[1], "!")
+		// The 9th field holds the account expiration date expressed as a
+		// number of days since the epoch, empty means no expiration.
+		if fields[8] != "" {
+			expireDays, err := strconv.ParseInt(fields[8], 10, 64)
+			if err == nil {
+				expired = time.Now().After(time.Unix(expireDays*24*60*60, 0))
+			}
+		}
+		return locked, expired, nil
+	}
+	return false, false, trace.NotFound("no shadow entry found for %q", login)
+}
+
+// shadowFile is the path to the shadow password database that holds account
+// lock and expiration information.
+const shadowFile = "/etc/shadow"
@@ -0,0 +1,76 @@
+// +build linux
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"github.com/gravitational/teleport/lib/cgroup"
+
+	"github.com/gravitational/trace"
+)
+
+// SessionLimiter places processes spawned for a user session in a cgroup
+// with resource limits applied, so a single session can not exhaust the
+// CPU, memory or process table of a shared node. A nil *SessionLimiter is
+// valid and all its methods are no-ops, which is the case on platforms
+// without cgroupv2 support or when no limits are configured.
+type SessionLimiter struct {
+	cgroups *cgroup.Service
+}
+
+// NewSessionLimiter returns a session limiter enforcing the given limits.
+func NewSessionLimiter(limits *cgroup.Limits) (*SessionLimiter, error) {
+	cgroups, err := cgroup.New(&cgroup.Config{
+		Limits: limits,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &SessionLimiter{cgroups: cgroups}, nil
+}
+
+// OpenSession creates a limited cgroup for the session and places the
+// process in it.
+func (l *SessionLimiter) OpenSession(sessionID string, pid int) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.cgroups.Create(sessionID); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := l.cgroups.Place(sessionID, pid); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// CloseSession removes the cgroup of the session.
+func (l *SessionLimiter) CloseSession(sessionID string) error {
+	if l == nil {
+		return nil
+	}
+	return trace.Wrap(l.cgroups.Remove(sessionID))
+}
+
+// Close releases the cgroup hierarchy held by the limiter.
+func (l *SessionLimiter) Close() error {
+	if l == nil {
+		return nil
+	}
+	return trace.Wrap(l.cgroups.Close())
+}
@@ -0,0 +1,52 @@
+// +build !linux
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"github.com/gravitational/teleport/lib/cgroup"
+
+	"github.com/gravitational/trace"
+)
+
+// SessionLimiter applies cgroup resource limits to session processes, it
+// is only supported on Linux. A nil *SessionLimiter is valid and all its
+// methods are no-ops.
+type SessionLimiter struct {
+}
+
+// NewSessionLimiter returns an error, session resource limits require
+// cgroupv2 support which only exists on Linux.
+func NewSessionLimiter(limits *cgroup.Limits) (*SessionLimiter, error) {
+	return nil, trace.BadParameter("session resource limits are only supported on Linux")
+}
+
+// OpenSession is a no-op on this platform.
+func (l *SessionLimiter) OpenSession(sessionID string, pid int) error {
+	return nil
+}
+
+// CloseSession is a no-op on this platform.
+func (l *SessionLimiter) CloseSession(sessionID string) error {
+	return nil
+}
+
+// Close is a no-op on this platform.
+func (l *SessionLimiter) Close() error {
+	return nil
+}
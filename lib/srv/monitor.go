@@ -22,7 +22,10 @@ import (
 	"net"
 	"time"
 
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/services"
 	"golang.org/x/crypto/ssh"
 
 	"github.com/gravitational/trace"
@@ -76,6 +79,14 @@ type MonitorConfig struct {
 	Emitter events.Emitter
 	// Entry is a logging entry
 	Entry *log.Entry
+	// AccessPoint is a cached access point used to poll for locks that
+	// terminate established sessions, optional
+	AccessPoint auth.AccessPoint
+	// LockTargets are the lock targets matching the connection, locks
+	// are only enforced when both AccessPoint and LockTargets are set
+	LockTargets []services.LockTarget
+	// LockPollPeriod is how often the lock list is consulted
+	LockPollPeriod time.Duration
 }
 
 // CheckAndSetDefaults checks values and sets defaults
@@ -83,8 +94,11 @@ func (m *MonitorConfig) CheckAndSetDefaults() error {
 	if m.Context == nil {
 		return trace.BadParameter("missing parameter Context")
 	}
-	if m.DisconnectExpiredCert.IsZero() && m.ClientIdleTimeout == 0 {
-		return trace.BadParameter("either DisconnectExpiredCert or ClientIdleTimeout should be set")
+	if m.DisconnectExpiredCert.IsZero() && m.ClientIdleTimeout == 0 && len(m.LockTargets) == 0 {
+		return trace.BadParameter("either DisconnectExpiredCert, ClientIdleTimeout or LockTargets should be set")
+	}
+	if m.LockPollPeriod == 0 {
+		m.LockPollPeriod = defaults.LockPollPeriod
 	}
 	if m.Conn == nil {
 		return trace.BadParameter("missing parameter Conn")
@@ -138,6 +152,13 @@ func (w *Monitor) Start() {
 		idleTime = idleTimer.C
 	}
 
+	var lockTime <-chan time.Time
+	if w.AccessPoint != nil && len(w.LockTargets) != 0 {
+		t := time.NewTicker(w.LockPollPeriod)
+		defer t.Stop()
+		lockTime = t.C
+	}
+
 	for {
 		select {
 		// certificate has expired, disconnect
@@ -203,6 +224,43 @@ func (w *Monitor) Start() {
 			w.Entry.Debugf("Next check in %v", w.ClientIdleTimeout-now.Sub(clientLastActive))
 			idleTimer = time.NewTimer(w.ClientIdleTimeout - now.Sub(clientLastActive))
 			idleTime = idleTimer.C
+		case <-lockTime:
+			locks, err := w.AccessPoint.GetLocks()
+			if err != nil {
+				// the lock list is served from the local cache, so a
+				// failure is transient, try again on the next tick
+				w.Entry.WithError(err).Warn("Failed to fetch locks.")
+				continue
+			}
+			lock := services.LockInForce(w.Clock, locks, w.LockTargets...)
+			if lock == nil || !lock.TerminateSessions() {
+				continue
+			}
+			reason := services.AccessDeniedByLock(lock).Error()
+			event := &events.ClientDisconnect{
+				Metadata: events.Metadata{
+					Type: events.ClientDisconnectEvent,
+					Code: events.ClientDisconnectCode,
+				},
+				UserMetadata: events.UserMetadata{
+					Login: w.Login,
+					User:  w.TeleportUser,
+				},
+				ConnectionMetadata: events.ConnectionMetadata{
+					LocalAddr:  w.Conn.LocalAddr().String(),
+					RemoteAddr: w.Conn.RemoteAddr().String(),
+				},
+				ServerMetadata: events.ServerMetadata{
+					ServerID: w.ServerID,
+				},
+				Reason: reason,
+			}
+			w.Entry.Debugf("Disconnecting client: %v", reason)
+			if err := w.Emitter.EmitAuditEvent(w.Context, event); err != nil {
+				w.Entry.WithError(err).Warn("Failed to emit audit event.")
+			}
+			w.Conn.Close()
+			return
 		case <-w.Context.Done():
 			w.Entry.Debugf("Releasing associated resources - context has been closed.")
 			return
@@ -25,11 +25,11 @@ import (
 	"net"
 	"os"
 	"os/exec"
+	"os/signal"
 	"os/user"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"syscall"
 
 	"github.com/gravitational/trace"
 
@@ -49,6 +49,10 @@ type ExecCommand struct {
 	// requested, will be empty.
 	Command string `json:"command"`
 
+	// Shell overrides the login shell of the local account. Set when the
+	// roles of the user enforce a specific shell.
+	Shell string `json:"shell,omitempty"`
+
 	// DestinationAddress is the target address to dial to.
 	DestinationAddress string `json:"dst_addr"`
 
@@ -84,6 +88,10 @@ type ExecCommand struct {
 	// policy.
 	UsePAMAuth bool `json:"use_pam_auth"`
 
+	// PAMEnvironment is a list of extra environment variables from the node
+	// configuration to pass to the PAM modules.
+	PAMEnvironment map[string]string `json:"pam_environment,omitempty"`
+
 	// Environment is a list of environment variables to add to the defaults.
 	Environment []string `json:"environment"`
 
@@ -166,17 +174,10 @@ func RunCommand() (io.Writer, int, error) {
 			ServiceName: c.ServiceName,
 			UsePAMAuth:  c.UsePAMAuth,
 			Login:       c.Login,
-			// Set Teleport specific environment variables that PAM modules
-			// like pam_script.so can pick up to potentially customize the
-			// account/session.
-			Env: map[string]string{
-				"TELEPORT_USERNAME": c.Username,
-				"TELEPORT_LOGIN":    c.Login,
-				"TELEPORT_ROLES":    strings.Join(c.Roles, " "),
-			},
-			Stdin:  stdin,
-			Stdout: stdout,
-			Stderr: stderr,
+			Env:         buildPAMEnvironment(&c),
+			Stdin:       stdin,
+			Stdout:      stdout,
+			Stderr:      stderr,
 		})
 		if err != nil {
 			return errorWriter, teleport.RemoteCommandFailure, trace.Wrap(err)
@@ -206,6 +207,18 @@ func RunCommand() (io.Writer, int, error) {
 		return errorWriter, teleport.RemoteCommandFailure, trace.Wrap(err)
 	}
 
+	// Relay signals delivered to this process to the command, so SSH
+	// "signal" requests reach the command itself.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	go func() {
+		for sig := range signals {
+			if err := cmd.Process.Signal(sig); err != nil {
+				log.Debugf("Failed to relay %v to the command: %v.", sig, err)
+			}
+		}
+	}()
+
 	// Wait for the command to exit. It doesn't make sense to print an error
 	// message here because the shell has successfully started. If an error
 	// occurred during shell execution or the shell exits with an error (like
@@ -251,14 +264,7 @@ func RunForward() (io.Writer, int, error) {
 			Stdin:       os.Stdin,
 			Stdout:      ioutil.Discard,
 			Stderr:      ioutil.Discard,
-			// Set Teleport specific environment variables that PAM modules
-			// like pam_script.so can pick up to potentially customize the
-			// account/session.
-			Env: map[string]string{
-				"TELEPORT_USERNAME": c.Username,
-				"TELEPORT_LOGIN":    c.Login,
-				"TELEPORT_ROLES":    strings.Join(c.Roles, " "),
-			},
+			Env:         buildPAMEnvironment(&c),
 		})
 		if err != nil {
 			return errorWriter, teleport.RemoteCommandFailure, trace.Wrap(err)
@@ -325,6 +331,23 @@ func RunAndExit(commandType string) {
 	os.Exit(code)
 }
 
+// buildPAMEnvironment constructs the environment that will be passed to the
+// PAM modules. It always contains Teleport specific environment variables
+// that PAM modules like pam_script.so can pick up to potentially customize
+// the account/session, plus any extra environment variables requested in the
+// node configuration.
+func buildPAMEnvironment(c *ExecCommand) map[string]string {
+	env := map[string]string{
+		"TELEPORT_USERNAME": c.Username,
+		"TELEPORT_LOGIN":    c.Login,
+		"TELEPORT_ROLES":    strings.Join(c.Roles, " "),
+	}
+	for key, value := range c.PAMEnvironment {
+		env[key] = value
+	}
+	return env
+}
+
 // buildCommand constructs a command that will execute the users shell. This
 // function is run by Teleport while it's re-executing.
 func buildCommand(c *ExecCommand, tty *os.File, pty *os.File, pamEnvironment []string) (*exec.Cmd, error) {
@@ -368,6 +391,10 @@ func buildCommand(c *ExecCommand, tty *os.File, pty *os.File, pamEnvironment []s
 		log.Debugf("Failed to get login shell for %v: %v. Using default: %v.",
 			c.Login, err, shell.DefaultShell)
 	}
+	// A shell set in the roles of the user overrides the local account shell.
+	if c.Shell != "" {
+		shellPath = c.Shell
+	}
 	if c.IsTestStub {
 		shellPath = "/bin/sh"
 	}
@@ -420,54 +447,21 @@ func buildCommand(c *ExecCommand, tty *os.File, pty *os.File, pamEnvironment []s
 	// Set the home directory for the user.
 	cmd.Dir = localUser.HomeDir
 
-	// If a terminal was requested, connect std{in,out,err} to the TTY and set
-	// the controlling TTY. Otherwise, connect std{in,out,err} to
-	// os.Std{in,out,err}.
+	// If a terminal was requested, connect std{in,out,err} to the TTY.
+	// Otherwise, connect std{in,out,err} to os.Std{in,out,err}.
 	if c.Terminal {
 		cmd.Stdin = tty
 		cmd.Stdout = tty
 		cmd.Stderr = tty
-
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid:  true,
-			Setctty: true,
-			// Note: leaving Ctty empty will default it to stdin fd, which is
-			// set to our tty above.
-		}
 	} else {
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-
-		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setsid: true,
-		}
 	}
 
-	// Only set process credentials if the UID/GID of the requesting user are
-	// different than the process (Teleport).
-	//
-	// Note, the above is important because setting the credentials struct
-	// triggers calling of the SETUID and SETGID syscalls during process start.
-	// If the caller does not have permission to call those two syscalls (for
-	// example, if Teleport is started from a shell), this will prevent the
-	// process from spawning shells with the error: "operation not permitted". To
-	// workaround this, the credentials struct is only set if the credentials
-	// are different from the process itself. If the credentials are not, simply
-	// pick up the ambient credentials of the process.
-	if strconv.Itoa(os.Getuid()) != localUser.Uid || strconv.Itoa(os.Getgid()) != localUser.Gid {
-		cmd.SysProcAttr.Credential = &syscall.Credential{
-			Uid:    uint32(uid),
-			Gid:    uint32(gid),
-			Groups: groups,
-		}
-
-		log.Debugf("Creating process with UID %v, GID: %v, and Groups: %v.",
-			uid, gid, groups)
-	} else {
-		log.Debugf("Creating process with ambient credentials UID %v, GID: %v, Groups: %v.",
-			uid, gid, groups)
-	}
+	// Configure platform specific process attributes: session, controlling
+	// TTY and the credentials the command runs with.
+	setProcessAttributes(&cmd, c, localUser, uid, gid, groups)
 
 	// Perform OS-specific tweaks to the command.
 	userCommandOSTweaks(&cmd)
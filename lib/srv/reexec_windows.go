@@ -0,0 +1,39 @@
+// +build windows
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"syscall"
+)
+
+// forwardedSignals is the set of signals relayed from the re-executing
+// parent to the command, SIGUSR1 and SIGUSR2 do not exist on Windows.
+var forwardedSignals = []os.Signal{
+	syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM,
+}
+
+// setProcessAttributes is a no-op on Windows: sessions and controlling
+// terminals are POSIX concepts and running the command as the requesting
+// user requires CreateProcessAsUser, which lands with interactive session
+// support.
+func setProcessAttributes(cmd *exec.Cmd, c *ExecCommand, localUser *user.User, uid, gid int, groups []uint32) {
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeDNSName returns the stable DNS-style address of a node as understood
+// by the proxy: "<node>.<cluster>.<proxy-domain>". Pointing a wildcard DNS
+// record "*.<proxy-domain>" at the proxy makes these names resolvable, so
+// tools that require a host name (e.g. database clients connecting over
+// forwarded ports) can target nodes consistently.
+func NodeDNSName(node, cluster, proxyDomain string) string {
+	return fmt.Sprintf("%v.%v.%v", node, cluster, proxyDomain)
+}
+
+// resolveNodeDNSName decodes a DNS-style node address produced by
+// NodeDNSName. It strips the proxy public address domain from the requested
+// host and, if the last remaining label names a connected cluster, routes
+// the request to that cluster; otherwise the whole remainder is treated as
+// a node name in the cluster the request already routes to.
+func (s *Server) resolveNodeDNSName(host string) (node string, cluster string, ok bool) {
+	proxyDomain := s.proxyPublicAddr.Host()
+	if proxyDomain == "" || host == proxyDomain {
+		return "", "", false
+	}
+	suffix := "." + proxyDomain
+	if !strings.HasSuffix(host, suffix) {
+		return "", "", false
+	}
+	target := strings.TrimSuffix(host, suffix)
+	if target == "" {
+		return "", "", false
+	}
+	if idx := strings.LastIndex(target, "."); idx > 0 && s.proxyTun != nil {
+		if _, err := s.proxyTun.GetSite(target[idx+1:]); err == nil {
+			return target[:idx], target[idx+1:], true
+		}
+	}
+	return target, "", true
+}
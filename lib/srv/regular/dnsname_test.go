@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import (
+	"github.com/gravitational/teleport/lib/utils"
+
+	"gopkg.in/check.v1"
+)
+
+type NodeDNSNameSuite struct{}
+
+var _ = check.Suite(&NodeDNSNameSuite{})
+
+func (s *NodeDNSNameSuite) TestResolveNodeDNSName(c *check.C) {
+	srv := &Server{
+		proxyPublicAddr: utils.NetAddr{Addr: "proxy.example.com:3080", AddrNetwork: "tcp"},
+	}
+	testCases := []struct {
+		host string
+		node string
+		ok   bool
+	}{
+		// name under the proxy domain resolves to the node part
+		{host: "db.proxy.example.com", node: "db", ok: true},
+		// without a connected tunnel server all labels belong to the node
+		{host: "db.leaf.proxy.example.com", node: "db.leaf", ok: true},
+		// names outside of the proxy domain pass through untouched
+		{host: "db.example.org", ok: false},
+		{host: "proxy.example.com", ok: false},
+		{host: "db", ok: false},
+	}
+	for _, tc := range testCases {
+		node, cluster, ok := srv.resolveNodeDNSName(tc.host)
+		c.Assert(ok, check.Equals, tc.ok, check.Commentf("host: %v", tc.host))
+		c.Assert(node, check.Equals, tc.node, check.Commentf("host: %v", tc.host))
+		c.Assert(cluster, check.Equals, "")
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// HostResolution is the answer of an external inventory for a requested
+// host name: either the ID of the node to connect to or a label query
+// matching candidate nodes.
+type HostResolution struct {
+	// NodeID is the ID of the node the host name maps to
+	NodeID string `json:"node_id,omitempty"`
+	// Labels is a label query matching candidate nodes
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// HostResolver resolves a requested host name via an external inventory,
+// e.g. a CMDB, so organizations can dial nodes by the names of their
+// existing naming scheme.
+type HostResolver interface {
+	// ResolveHost resolves the host name. A NotFound error means the
+	// inventory does not know the host and resolution falls through.
+	ResolveHost(ctx context.Context, namespace string, host string) (*HostResolution, error)
+}
+
+// HTTPHostResolverConfig configures an HTTP host resolver.
+type HTTPHostResolverConfig struct {
+	// URL is the lookup endpoint, queried with the requested host and
+	// namespace passed as query parameters
+	URL string
+	// Timeout bounds a single lookup
+	Timeout time.Duration
+	// Client is an HTTP client override used in tests
+	Client *http.Client
+}
+
+// CheckAndSetDefaults checks and sets default values.
+func (c *HTTPHostResolverConfig) CheckAndSetDefaults() error {
+	if c.URL == "" {
+		return trace.BadParameter("missing parameter URL")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return trace.BadParameter("invalid host resolver URL %q: %v", c.URL, err)
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaults.DefaultDialTimeout
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: c.Timeout}
+	}
+	return nil
+}
+
+// NewHTTPHostResolver returns a host resolver that looks up requested
+// host names with an HTTP GET against an external inventory endpoint.
+func NewHTTPHostResolver(cfg HTTPHostResolverConfig) (*HTTPHostResolver, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &HTTPHostResolver{HTTPHostResolverConfig: cfg}, nil
+}
+
+// HTTPHostResolver resolves host names against an external HTTP
+// inventory endpoint.
+type HTTPHostResolver struct {
+	HTTPHostResolverConfig
+}
+
+// ResolveHost resolves the host name. A NotFound error means the
+// inventory does not know the host and resolution falls through.
+func (r *HTTPHostResolver) ResolveHost(ctx context.Context, namespace string, host string) (*HostResolution, error) {
+	endpoint, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	query := endpoint.Query()
+	query.Set("host", host)
+	query.Set("namespace", namespace)
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	re, err := r.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	defer re.Body.Close()
+	switch {
+	case re.StatusCode == http.StatusNotFound:
+		return nil, trace.NotFound("inventory does not know host %q", host)
+	case re.StatusCode != http.StatusOK:
+		return nil, trace.ConnectionProblem(nil, "inventory lookup of host %q failed with status %v", host, re.StatusCode)
+	}
+	var resolution HostResolution
+	if err := json.NewDecoder(re.Body).Decode(&resolution); err != nil {
+		return nil, trace.BadParameter("invalid inventory response for host %q: %v", host, err)
+	}
+	if resolution.NodeID == "" && len(resolution.Labels) == 0 {
+		return nil, trace.BadParameter("inventory returned neither a node ID nor labels for host %q", host)
+	}
+	return &resolution, nil
+}
+
+// resolveExternalHost asks the configured external inventory about the
+// requested host name and returns the matching node, nil when no resolver
+// is configured, the inventory does not know the host or no registered
+// node matches its answer.
+func (s *Server) resolveExternalHost(ctx context.Context, namespace string, host string, servers []services.Server) services.Server {
+	if s.hostResolver == nil {
+		return nil
+	}
+	resolution, err := s.hostResolver.ResolveHost(ctx, namespace, host)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			log.Warningf("External resolution of host %q failed: %v.", host, err)
+		}
+		return nil
+	}
+	if resolution.NodeID != "" {
+		for i := range servers {
+			if servers[i].GetName() == resolution.NodeID {
+				return servers[i]
+			}
+		}
+		log.Debugf("Inventory mapped host %q to unknown node %v.", host, resolution.NodeID)
+		return nil
+	}
+	var matched []services.Server
+	for i := range servers {
+		if servers[i].MatchAgainst(resolution.Labels) {
+			matched = append(matched, servers[i])
+		}
+	}
+	if len(matched) == 0 {
+		log.Debugf("No nodes matched labels %v the inventory returned for host %q.", resolution.Labels, host)
+		return nil
+	}
+	return matched[rand.Intn(len(matched))]
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"gopkg.in/check.v1"
+)
+
+type HostResolverSuite struct{}
+
+var _ = check.Suite(&HostResolverSuite{})
+
+func (s *HostResolverSuite) TestHTTPHostResolver(c *check.C) {
+	inventory := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Query().Get("namespace"), check.Equals, defaults.Namespace)
+		switch r.URL.Query().Get("host") {
+		case "billing-db":
+			json.NewEncoder(w).Encode(HostResolution{NodeID: "node-1"})
+		case "billing-web":
+			json.NewEncoder(w).Encode(HostResolution{Labels: map[string]string{"role": "web"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer inventory.Close()
+
+	resolver, err := NewHTTPHostResolver(HTTPHostResolverConfig{URL: inventory.URL})
+	c.Assert(err, check.IsNil)
+
+	ctx := context.Background()
+	resolution, err := resolver.ResolveHost(ctx, defaults.Namespace, "billing-db")
+	c.Assert(err, check.IsNil)
+	c.Assert(resolution.NodeID, check.Equals, "node-1")
+
+	resolution, err = resolver.ResolveHost(ctx, defaults.Namespace, "billing-web")
+	c.Assert(err, check.IsNil)
+	c.Assert(resolution.Labels, check.DeepEquals, map[string]string{"role": "web"})
+
+	_, err = resolver.ResolveHost(ctx, defaults.Namespace, "unknown")
+	c.Assert(trace.IsNotFound(err), check.Equals, true)
+}
+
+// staticHostResolver resolves host names from a fixed table
+type staticHostResolver struct {
+	resolutions map[string]HostResolution
+}
+
+func (r *staticHostResolver) ResolveHost(ctx context.Context, namespace string, host string) (*HostResolution, error) {
+	resolution, ok := r.resolutions[host]
+	if !ok {
+		return nil, trace.NotFound("inventory does not know host %q", host)
+	}
+	return &resolution, nil
+}
+
+func (s *HostResolverSuite) TestResolveExternalHost(c *check.C) {
+	srv := &Server{hostResolver: &staticHostResolver{
+		resolutions: map[string]HostResolution{
+			"billing-db":  {NodeID: "beta"},
+			"billing-web": {Labels: map[string]string{"role": "web"}},
+			"billing-old": {NodeID: "decommissioned"},
+		},
+	}}
+	servers := []services.Server{
+		templateTestNode("alpha", map[string]string{"role": "web"}),
+		templateTestNode("beta", map[string]string{"role": "db"}),
+	}
+
+	ctx := context.Background()
+	node := srv.resolveExternalHost(ctx, defaults.Namespace, "billing-db", servers)
+	c.Assert(node, check.NotNil)
+	c.Assert(node.GetHostname(), check.Equals, "beta")
+
+	node = srv.resolveExternalHost(ctx, defaults.Namespace, "billing-web", servers)
+	c.Assert(node, check.NotNil)
+	c.Assert(node.GetHostname(), check.Equals, "alpha")
+
+	// the inventory maps the host to a node that is not registered
+	c.Assert(srv.resolveExternalHost(ctx, defaults.Namespace, "billing-old", servers), check.IsNil)
+
+	// the inventory does not know the host
+	c.Assert(srv.resolveExternalHost(ctx, defaults.Namespace, "unknown", servers), check.IsNil)
+
+	// no resolver configured
+	c.Assert((&Server{}).resolveExternalHost(ctx, defaults.Namespace, "billing-db", servers), check.IsNil)
+}
@@ -18,10 +18,12 @@ package regular
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -69,10 +71,11 @@ type proxySubsys struct {
 // proxy subsystem
 //
 // proxy subsystem name can take the following forms:
-//  "proxy:host:22"          - standard SSH request to connect to  host:22 on the 1st cluster
-//  "proxy:@clustername"        - Teleport request to connect to an auth server for cluster with name 'clustername'
-//  "proxy:host:22@clustername" - Teleport request to connect to host:22 on cluster 'clustername'
-//  "proxy:host:22@namespace@clustername"
+//
+//	"proxy:host:22"          - standard SSH request to connect to  host:22 on the 1st cluster
+//	"proxy:@clustername"        - Teleport request to connect to an auth server for cluster with name 'clustername'
+//	"proxy:host:22@clustername" - Teleport request to connect to host:22 on cluster 'clustername'
+//	"proxy:host:22@namespace@clustername"
 func parseProxySubsysRequest(request string) (proxySubsysRequest, error) {
 	log.Debugf("parse_proxy_subsys(%q)", request)
 	var (
@@ -164,6 +167,17 @@ func (p *proxySubsysRequest) SetDefaults() {
 // and reuse the code
 func newProxySubsys(ctx *srv.ServerContext, srv *Server, req proxySubsysRequest) (*proxySubsys, error) {
 	req.SetDefaults()
+	// a DNS-style target "<node>.<cluster>.<proxy-domain>" encodes the routing
+	// in the host name itself, decode it before applying certificate routing
+	if req.clusterName == "" && req.host != "" {
+		if node, cluster, ok := srv.resolveNodeDNSName(req.host); ok {
+			log.Debugf("Proxy subsystem: resolved DNS-style target %q to node %q in cluster %q.",
+				req.host, node, cluster,
+			)
+			req.host = node
+			req.clusterName = cluster
+		}
+	}
 	if req.clusterName == "" && ctx.Identity.RouteToCluster != "" {
 		log.Debugf("Proxy subsystem: routing user %q to cluster %q based on the route to cluster extension.",
 			ctx.Identity.TeleportUser, ctx.Identity.RouteToCluster,
@@ -332,20 +346,24 @@ func (t *proxySubsys) proxyToHost(
 
 	// enumerate and try to find a server with self-registered with a matching name/IP:
 	var server services.Server
-	matches := 0
+	var matched []services.Server
 	for i := range servers {
 		// If the host parameter is a UUID and it matches the Node ID,
 		// treat this as an unambiguous match.
 		if hostIsUUID && servers[i].GetName() == t.host {
-			server = servers[i]
-			matches = 1
+			matched = []services.Server{servers[i]}
+			break
+		}
+		// The "hostname-shortid" form printed in listings for nodes with
+		// colliding hostnames is an unambiguous match as well.
+		if t.host == fmt.Sprintf("%v-%v", servers[i].GetHostname(), services.ShortNodeID(servers[i].GetName())) {
+			matched = []services.Server{servers[i]}
 			break
 		}
 		// If the server has connected over a reverse tunnel, match only on hostname.
 		if servers[i].GetUseTunnel() {
 			if t.host == servers[i].GetHostname() {
-				server = servers[i]
-				matches++
+				matched = append(matched, servers[i])
 			}
 			continue
 		}
@@ -357,16 +375,25 @@ func (t *proxySubsys) proxyToHost(
 		}
 		if t.host == ip || t.host == servers[i].GetHostname() || utils.SliceContainsStr(ips, ip) {
 			if !specifiedPort || t.port == port {
-				server = servers[i]
-				matches++
+				matched = append(matched, servers[i])
 				continue
 			}
 		}
 	}
 
-	// if we matched more than one server, then the target was ambiguous.
-	if matches > 1 {
-		return trace.NotFound(teleport.NodeIsAmbiguous)
+	// if we matched more than one server, then the target was ambiguous,
+	// surface the candidates so the caller can name one of them instead
+	if len(matched) > 1 {
+		names := services.DisambiguateHostnames(matched)
+		candidates := make([]string, 0, len(matched))
+		for _, m := range matched {
+			candidates = append(candidates, names[m.GetName()])
+		}
+		sort.Strings(candidates)
+		return trace.NotFound("%v: matching nodes: %v", teleport.NodeIsAmbiguous, strings.Join(candidates, ", "))
+	}
+	if len(matched) == 1 {
+		server = matched[0]
 	}
 
 	// If we matched zero nodes but hostname is a UUID then it isn't sane
@@ -377,10 +404,27 @@ func (t *proxySubsys) proxyToHost(
 	// node by UUID from being re-routed to an unintended target if the node
 	// is offline.  This restriction can be lifted if we decide to move to
 	// explicit UUID based resoltion in the future.
-	if hostIsUUID && matches < 1 {
+	if hostIsUUID && len(matched) < 1 {
 		return trace.NotFound("unable to locate node matching uuid-like target %s", t.host)
 	}
 
+	// If no node matched by name or address, see if the host is an alias
+	// defined by a proxy template mapping to a label query.
+	if server == nil {
+		if match := t.srv.resolveProxyTemplate(t.namespace, t.host, servers); match != nil {
+			t.log.Debugf("Resolved host %q to node %v via proxy template.", t.host, match.GetHostname())
+			server = match
+		}
+	}
+
+	// Failing that, ask the external inventory when one is configured.
+	if server == nil {
+		if match := t.srv.resolveExternalHost(context.TODO(), t.namespace, t.host, servers); match != nil {
+			t.log.Debugf("Resolved host %q to node %v via external inventory.", t.host, match.GetHostname())
+			server = match
+		}
+	}
+
 	// Create a slice of principals that will be added into the host certificate.
 	// Here t.host is either an IP address or a DNS name as the user requested.
 	principals := []string{t.host}
@@ -388,6 +432,10 @@ func (t *proxySubsys) proxyToHost(
 	// Used to store the server ID (hostUUID.clusterName) of a Teleport node.
 	var serverID string
 
+	// Set when the matched node is labeled to have its sessions terminated
+	// and recorded at the proxy.
+	var recordAtProxy bool
+
 	// Resolve the IP address to dial to because the hostname may not be
 	// DNS resolvable.
 	var serverAddr string
@@ -395,6 +443,7 @@ func (t *proxySubsys) proxyToHost(
 		// Add hostUUID.clusterName to list of principals.
 		serverID = fmt.Sprintf("%v.%v", server.GetName(), t.clusterName)
 		principals = append(principals, serverID)
+		recordAtProxy = services.NodeRecordsAtProxy(server)
 
 		// Add IP address (if it exists) of the node to list of principals.
 		serverAddr = server.GetAddr()
@@ -422,12 +471,13 @@ func (t *proxySubsys) proxyToHost(
 		Addr:        serverAddr,
 	}
 	conn, err := site.Dial(reversetunnel.DialParams{
-		From:         remoteAddr,
-		To:           toAddr,
-		GetUserAgent: t.ctx.StartAgentChannel,
-		Address:      t.host,
-		ServerID:     serverID,
-		Principals:   principals,
+		From:          remoteAddr,
+		To:            toAddr,
+		GetUserAgent:  t.ctx.StartAgentChannel,
+		Address:       t.host,
+		ServerID:      serverID,
+		Principals:    principals,
+		RecordAtProxy: recordAtProxy,
 	})
 	if err != nil {
 		return trace.Wrap(err)
@@ -0,0 +1,180 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import (
+	"math/rand"
+	"regexp"
+	"sort"
+	"sync/atomic"
+
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+const (
+	// ProxyTemplatePolicyRandom picks a random node among those matching
+	// the label query of a template.
+	ProxyTemplatePolicyRandom = "random"
+	// ProxyTemplatePolicyFirst picks the matching node with the lowest
+	// hostname, giving a stable target across connections.
+	ProxyTemplatePolicyFirst = "first"
+	// ProxyTemplatePolicyRoundRobin cycles through the matching nodes
+	// across connections.
+	ProxyTemplatePolicyRoundRobin = "round-robin"
+	// ProxyTemplatePolicyLeastSessions picks the matching node with the
+	// fewest active sessions.
+	ProxyTemplatePolicyLeastSessions = "least-sessions"
+	// ProxyTemplatePolicyNewest picks the matching node with the most
+	// recent heartbeat.
+	ProxyTemplatePolicyNewest = "newest"
+)
+
+// ProxyTemplate maps a requested host name pattern to a label query so
+// users can dial nodes by alias (e.g. "db-prod") instead of their real
+// hostnames.
+type ProxyTemplate struct {
+	// Pattern is a regular expression matched against the requested host
+	// name, e.g. "^db-(.*)$".
+	Pattern string
+	// Labels is the label query a node has to match. Values may reference
+	// capture groups of Pattern, e.g. "$1" or "${env}".
+	Labels map[string]string
+	// Policy picks a node when several match the label query: "random"
+	// (the default), "first", "round-robin", "least-sessions" or "newest".
+	Policy string
+
+	// regexp is the compiled Pattern.
+	regexp *regexp.Regexp
+	// counter tracks the position of the round-robin policy, shared by all
+	// copies of the template.
+	counter *uint64
+}
+
+// CheckAndSetDefaults checks validity of all parameters, compiles the
+// pattern and sets defaults.
+func (p *ProxyTemplate) CheckAndSetDefaults() error {
+	if p.Pattern == "" {
+		return trace.BadParameter("missing pattern in proxy template")
+	}
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return trace.BadParameter("invalid pattern %q in proxy template: %v", p.Pattern, err)
+	}
+	p.regexp = re
+	if len(p.Labels) == 0 {
+		return trace.BadParameter("missing labels in proxy template %q", p.Pattern)
+	}
+	switch p.Policy {
+	case "":
+		p.Policy = ProxyTemplatePolicyRandom
+	case ProxyTemplatePolicyRandom, ProxyTemplatePolicyFirst, ProxyTemplatePolicyRoundRobin,
+		ProxyTemplatePolicyLeastSessions, ProxyTemplatePolicyNewest:
+	default:
+		return trace.BadParameter("unsupported policy %q in proxy template %q, must be one of %q, %q, %q, %q or %q",
+			p.Policy, p.Pattern, ProxyTemplatePolicyRandom, ProxyTemplatePolicyFirst,
+			ProxyTemplatePolicyRoundRobin, ProxyTemplatePolicyLeastSessions, ProxyTemplatePolicyNewest)
+	}
+	p.counter = new(uint64)
+	return nil
+}
+
+// resolve expands the template against a requested host name and returns
+// the label query to match nodes with, or false if the host name does not
+// match the pattern.
+func (p *ProxyTemplate) resolve(host string) (map[string]string, bool) {
+	match := p.regexp.FindStringSubmatchIndex(host)
+	if match == nil {
+		return nil, false
+	}
+	labels := make(map[string]string, len(p.Labels))
+	for key, value := range p.Labels {
+		labels[key] = string(p.regexp.ExpandString(nil, value, host, match))
+	}
+	return labels, true
+}
+
+// resolveProxyTemplate matches the requested host name against the
+// configured proxy templates and picks one of the nodes matching the label
+// query of the first matching template, nil if no template applies.
+func (s *Server) resolveProxyTemplate(namespace string, host string, servers []services.Server) services.Server {
+	for _, template := range s.proxyTemplates {
+		labels, ok := template.resolve(host)
+		if !ok {
+			continue
+		}
+		var matched []services.Server
+		for i := range servers {
+			if servers[i].MatchAgainst(labels) {
+				matched = append(matched, servers[i])
+			}
+		}
+		if len(matched) == 0 {
+			log.Debugf("No nodes matched labels %v of proxy template %q.", labels, template.Pattern)
+			continue
+		}
+		// sort by hostname first so position-based policies are stable
+		// regardless of the order nodes were listed in
+		sort.Slice(matched, func(i, j int) bool {
+			return matched[i].GetHostname() < matched[j].GetHostname()
+		})
+		switch template.Policy {
+		case ProxyTemplatePolicyFirst:
+			return matched[0]
+		case ProxyTemplatePolicyRoundRobin:
+			next := atomic.AddUint64(template.counter, 1) - 1
+			return matched[next%uint64(len(matched))]
+		case ProxyTemplatePolicyLeastSessions:
+			return s.pickLeastSessions(namespace, matched)
+		case ProxyTemplatePolicyNewest:
+			newest := matched[0]
+			for _, node := range matched[1:] {
+				if node.Expiry().After(newest.Expiry()) {
+					newest = node
+				}
+			}
+			return newest
+		default:
+			return matched[rand.Intn(len(matched))]
+		}
+	}
+	return nil
+}
+
+// pickLeastSessions returns the node with the fewest active sessions,
+// falling back to the first node when the session server is unavailable.
+func (s *Server) pickLeastSessions(namespace string, matched []services.Server) services.Server {
+	counts := make(map[string]int, len(matched))
+	if s.sessionServer != nil {
+		sessions, err := s.sessionServer.GetSessions(namespace)
+		if err != nil {
+			log.Warningf("Failed to list active sessions: %v.", err)
+		} else {
+			for _, session := range sessions {
+				counts[session.ServerID]++
+			}
+		}
+	}
+	best := matched[0]
+	for _, node := range matched[1:] {
+		if counts[node.GetName()] < counts[best.GetName()] {
+			best = node
+		}
+	}
+	return best
+}
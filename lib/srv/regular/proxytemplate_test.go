@@ -0,0 +1,129 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import (
+	"time"
+
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"gopkg.in/check.v1"
+)
+
+type ProxyTemplateSuite struct{}
+
+var _ = check.Suite(&ProxyTemplateSuite{})
+
+func (s *ProxyTemplateSuite) TestResolve(c *check.C) {
+	template := ProxyTemplate{
+		Pattern: "^db-(.*)$",
+		Labels:  map[string]string{"role": "db", "env": "$1"},
+	}
+	c.Assert(template.CheckAndSetDefaults(), check.IsNil)
+	c.Assert(template.Policy, check.Equals, ProxyTemplatePolicyRandom)
+
+	labels, ok := template.resolve("db-prod")
+	c.Assert(ok, check.Equals, true)
+	c.Assert(labels, check.DeepEquals, map[string]string{"role": "db", "env": "prod"})
+
+	_, ok = template.resolve("web-prod")
+	c.Assert(ok, check.Equals, false)
+}
+
+func (s *ProxyTemplateSuite) TestResolveProxyTemplate(c *check.C) {
+	template := ProxyTemplate{
+		Pattern: "^db-(.*)$",
+		Labels:  map[string]string{"role": "db", "env": "$1"},
+		Policy:  ProxyTemplatePolicyFirst,
+	}
+	c.Assert(template.CheckAndSetDefaults(), check.IsNil)
+
+	srv := &Server{proxyTemplates: []ProxyTemplate{template}}
+	servers := []services.Server{
+		templateTestNode("beta", map[string]string{"role": "db", "env": "prod"}),
+		templateTestNode("alpha", map[string]string{"role": "db", "env": "prod"}),
+		templateTestNode("gamma", map[string]string{"role": "web", "env": "prod"}),
+	}
+
+	// the "first" policy picks the matching node with the lowest hostname
+	node := srv.resolveProxyTemplate(defaults.Namespace, "db-prod", servers)
+	c.Assert(node, check.NotNil)
+	c.Assert(node.GetHostname(), check.Equals, "alpha")
+
+	// no node carries env=staging
+	c.Assert(srv.resolveProxyTemplate(defaults.Namespace, "db-staging", servers), check.IsNil)
+
+	// host does not match the pattern at all
+	c.Assert(srv.resolveProxyTemplate(defaults.Namespace, "web-prod", servers), check.IsNil)
+}
+
+func (s *ProxyTemplateSuite) TestRoundRobinPolicy(c *check.C) {
+	template := ProxyTemplate{
+		Pattern: "^db-(.*)$",
+		Labels:  map[string]string{"role": "db", "env": "$1"},
+		Policy:  ProxyTemplatePolicyRoundRobin,
+	}
+	c.Assert(template.CheckAndSetDefaults(), check.IsNil)
+
+	srv := &Server{proxyTemplates: []ProxyTemplate{template}}
+	servers := []services.Server{
+		templateTestNode("beta", map[string]string{"role": "db", "env": "prod"}),
+		templateTestNode("alpha", map[string]string{"role": "db", "env": "prod"}),
+	}
+
+	// round-robin cycles through the matching nodes in hostname order
+	var picked []string
+	for i := 0; i < 4; i++ {
+		picked = append(picked, srv.resolveProxyTemplate(defaults.Namespace, "db-prod", servers).GetHostname())
+	}
+	c.Assert(picked, check.DeepEquals, []string{"alpha", "beta", "alpha", "beta"})
+}
+
+func (s *ProxyTemplateSuite) TestNewestPolicy(c *check.C) {
+	template := ProxyTemplate{
+		Pattern: "^db-(.*)$",
+		Labels:  map[string]string{"role": "db", "env": "$1"},
+		Policy:  ProxyTemplatePolicyNewest,
+	}
+	c.Assert(template.CheckAndSetDefaults(), check.IsNil)
+
+	srv := &Server{proxyTemplates: []ProxyTemplate{template}}
+	older := templateTestNode("alpha", map[string]string{"role": "db", "env": "prod"})
+	newer := templateTestNode("beta", map[string]string{"role": "db", "env": "prod"})
+	older.SetExpiry(time.Now().Add(time.Minute))
+	newer.SetExpiry(time.Now().Add(10 * time.Minute))
+
+	node := srv.resolveProxyTemplate(defaults.Namespace, "db-prod", []services.Server{older, newer})
+	c.Assert(node, check.NotNil)
+	c.Assert(node.GetHostname(), check.Equals, "beta")
+}
+
+func templateTestNode(hostname string, labels map[string]string) services.Server {
+	return &services.ServerV2{
+		Kind:    services.KindNode,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      hostname,
+			Namespace: "default",
+			Labels:    labels,
+		},
+		Spec: services.ServerSpecV2{
+			Hostname: hostname,
+		},
+	}
+}
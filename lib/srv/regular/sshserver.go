@@ -40,6 +40,7 @@ import (
 	"github.com/gravitational/teleport/lib/bpf"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/events"
+	"github.com/gravitational/teleport/lib/labels"
 	"github.com/gravitational/teleport/lib/limiter"
 	"github.com/gravitational/teleport/lib/pam"
 	"github.com/gravitational/teleport/lib/reversetunnel"
@@ -83,6 +84,10 @@ type Server struct {
 	cmdLabels   map[string]services.CommandLabel //dymanic server labels
 	labelsMutex *sync.Mutex
 
+	// labelProviders are external processes queried on a period for
+	// additional labels to report in heartbeats
+	labelProviders []*labels.Provider
+
 	proxyMode bool
 	proxyTun  reversetunnel.Server
 
@@ -111,6 +116,11 @@ type Server struct {
 	// before creating a new session.
 	permitUserEnvironment bool
 
+	// acceptEnv is a list of environment variable names (with optional
+	// trailing '*' wildcard) clients are allowed to set in their sessions.
+	// When empty, all variables sent by the client are accepted.
+	acceptEnv []string
+
 	// ciphers is a list of ciphers that the server supports. If omitted,
 	// the defaults will be used.
 	ciphers []string
@@ -132,6 +142,10 @@ type Server struct {
 	// pamConfig holds configuration for PAM.
 	pamConfig *pam.Config
 
+	// hostUsersConfig holds configuration for automatic creation of local
+	// Unix accounts.
+	hostUsersConfig *srv.HostUsersConfig
+
 	// dataDir is a server local data directory
 	dataDir string
 
@@ -150,6 +164,36 @@ type Server struct {
 	// ebpf is the service used for enhanced session recording.
 	ebpf bpf.BPF
 
+	// sessionLimiter applies resource limits to session processes.
+	sessionLimiter *srv.SessionLimiter
+
+	// banner is a message presented to the client before authentication.
+	banner string
+
+	// motd is a message of the day shown to the user after login.
+	motd string
+
+	// reportUtilization enables reporting of CPU, memory and disk
+	// utilization gauges in heartbeats.
+	reportUtilization bool
+
+	// proxyTemplates map requested host name patterns to label queries,
+	// used by the proxy to resolve node aliases.
+	proxyTemplates []ProxyTemplate
+
+	// hostResolver resolves requested host names via an external
+	// inventory when no node matches by name or address.
+	hostResolver HostResolver
+
+	// clockSkewTolerance extends the validity window of certificates to
+	// tolerate wall clock drift between this host and the auth server.
+	clockSkewTolerance time.Duration
+
+	// authorizationGracePeriod sets for how long the node keeps authorizing
+	// already-seen users from cached role data when the auth server is
+	// unreachable.
+	authorizationGracePeriod time.Duration
+
 	// onHeartbeat is a callback for heartbeat status.
 	onHeartbeat func(error)
 }
@@ -195,6 +239,12 @@ func (s *Server) GetBPF() bpf.BPF {
 	return s.ebpf
 }
 
+// GetSessionLimiter returns the limiter that applies resource limits to
+// session processes. May be nil if no limits are configured.
+func (s *Server) GetSessionLimiter() *srv.SessionLimiter {
+	return s.sessionLimiter
+}
+
 // isAuditedAtProxy returns true if sessions are being recorded at the proxy
 // and this is a Teleport node.
 func (s *Server) isAuditedAtProxy() bool {
@@ -204,7 +254,10 @@ func (s *Server) isAuditedAtProxy() bool {
 		return false
 	}
 
-	isRecordAtProxy := services.IsRecordAtProxy(clusterConfig.GetSessionRecording())
+	// nodes labeled for proxy recording are terminated and recorded at the
+	// proxy regardless of the cluster-wide recording mode
+	isRecordAtProxy := services.IsRecordAtProxy(clusterConfig.GetSessionRecording()) ||
+		services.NodeRecordsAtProxy(s.GetInfo())
 	isTeleportNode := s.Component() == teleport.ComponentNode
 
 	if isRecordAtProxy && isTeleportNode {
@@ -249,6 +302,7 @@ func (s *Server) Start() error {
 	if len(s.getCommandLabels()) > 0 {
 		s.updateLabels()
 	}
+	s.startLabelProviders()
 
 	// If the server requested connections to it arrive over a reverse tunnel,
 	// don't call Start() which listens on a socket, return right away.
@@ -273,6 +327,7 @@ func (s *Server) Serve(l net.Listener) error {
 	if len(s.getCommandLabels()) > 0 {
 		s.updateLabels()
 	}
+	s.startLabelProviders()
 	go s.heartbeat.Run()
 	return s.srv.Serve(l)
 }
@@ -362,6 +417,15 @@ func SetLabels(labels map[string]string,
 	}
 }
 
+// SetLabelProviders sets external label providers queried on a period for
+// additional labels to report in heartbeats.
+func SetLabelProviders(providers ...*labels.Provider) ServerOption {
+	return func(s *Server) error {
+		s.labelProviders = providers
+		return nil
+	}
+}
+
 // SetLimiter sets rate and connection limiter for this server
 func SetLimiter(limiter *limiter.Limiter) ServerOption {
 	return func(s *Server) error {
@@ -401,6 +465,15 @@ func SetPermitUserEnvironment(permitUserEnvironment bool) ServerOption {
 	}
 }
 
+// SetAcceptEnv sets the allow-list of environment variable names clients may
+// set in their sessions.
+func SetAcceptEnv(acceptEnv []string) ServerOption {
+	return func(s *Server) error {
+		s.acceptEnv = acceptEnv
+		return nil
+	}
+}
+
 func SetCiphers(ciphers []string) ServerOption {
 	return func(s *Server) error {
 		s.ciphers = ciphers
@@ -429,6 +502,15 @@ func SetPAMConfig(pamConfig *pam.Config) ServerOption {
 	}
 }
 
+// SetHostUsersConfig sets the configuration for automatic creation of local
+// Unix accounts.
+func SetHostUsersConfig(hostUsersConfig *srv.HostUsersConfig) ServerOption {
+	return func(s *Server) error {
+		s.hostUsersConfig = hostUsersConfig
+		return nil
+	}
+}
+
 func SetUseTunnel(useTunnel bool) ServerOption {
 	return func(s *Server) error {
 		s.useTunnel = useTunnel
@@ -450,6 +532,75 @@ func SetBPF(ebpf bpf.BPF) ServerOption {
 	}
 }
 
+func SetSessionLimiter(limiter *srv.SessionLimiter) ServerOption {
+	return func(s *Server) error {
+		s.sessionLimiter = limiter
+		return nil
+	}
+}
+
+// SetBanner sets a banner presented to the client before authentication.
+func SetBanner(banner string) ServerOption {
+	return func(s *Server) error {
+		s.banner = banner
+		return nil
+	}
+}
+
+// SetMOTD sets a message of the day shown to the user after login.
+func SetMOTD(motd string) ServerOption {
+	return func(s *Server) error {
+		s.motd = motd
+		return nil
+	}
+}
+
+// SetProxyTemplates sets the templates the proxy uses to resolve node
+// aliases to label queries.
+func SetProxyTemplates(templates []ProxyTemplate) ServerOption {
+	return func(s *Server) error {
+		s.proxyTemplates = templates
+		return nil
+	}
+}
+
+// SetHostResolver sets the external inventory resolver the proxy asks
+// about host names no node matches by name or address.
+func SetHostResolver(resolver HostResolver) ServerOption {
+	return func(s *Server) error {
+		s.hostResolver = resolver
+		return nil
+	}
+}
+
+// SetUtilizationReporting enables reporting of CPU, memory and disk
+// utilization gauges in this server's heartbeats.
+func SetUtilizationReporting(enabled bool) ServerOption {
+	return func(s *Server) error {
+		s.reportUtilization = enabled
+		return nil
+	}
+}
+
+// SetClockSkewTolerance sets the tolerated wall clock drift between this
+// host and the auth server when validating certificates.
+func SetClockSkewTolerance(tolerance time.Duration) ServerOption {
+	return func(s *Server) error {
+		s.clockSkewTolerance = tolerance
+		return nil
+	}
+}
+
+// SetAuthorizationGracePeriod sets for how long the node keeps authorizing
+// already-seen users from cached role data when the auth server is
+// unreachable.
+func SetAuthorizationGracePeriod(period time.Duration) ServerOption {
+	return func(s *Server) error {
+		s.authorizationGracePeriod = period
+		return nil
+	}
+}
+
 func SetOnHeartbeat(fn func(error)) ServerOption {
 	return func(s *Server) error {
 		s.onHeartbeat = fn
@@ -535,11 +686,13 @@ func New(addr utils.NetAddr,
 			trace.Component:       component,
 			trace.ComponentFields: logrus.Fields{},
 		}),
-		Server:      s,
-		Component:   component,
-		AccessPoint: s.authService,
-		FIPS:        s.fips,
-		Emitter:     s.StreamEmitter,
+		Server:                   s,
+		Component:                component,
+		AccessPoint:              s.authService,
+		FIPS:                     s.fips,
+		Emitter:                  s.StreamEmitter,
+		ClockSkewTolerance:       s.clockSkewTolerance,
+		AuthorizationGracePeriod: s.authorizationGracePeriod,
 	}
 
 	// common term handlers
@@ -558,6 +711,7 @@ func New(addr utils.NetAddr,
 		sshutils.SetKEXAlgorithms(s.kexAlgorithms),
 		sshutils.SetMACAlgorithms(s.macAlgorithms),
 		sshutils.SetFIPS(s.fips),
+		sshutils.SetBanner(s.banner),
 	)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -577,11 +731,20 @@ func New(addr utils.NetAddr,
 		Announcer:       s.authService,
 		GetServerInfo:   s.getServerInfo,
 		KeepAlivePeriod: defaults.ServerKeepAliveTTL,
-		AnnouncePeriod:  defaults.ServerAnnounceTTL/2 + utils.RandomDuration(defaults.ServerAnnounceTTL/10),
-		ServerTTL:       defaults.ServerAnnounceTTL,
-		CheckPeriod:     defaults.HeartbeatCheckPeriod,
-		Clock:           s.clock,
-		OnHeartbeat:     s.onHeartbeat,
+		GetKeepAlivePeriod: func() time.Duration {
+			// Pick up the keep-alive interval from cluster configuration on
+			// every cycle so changing it does not require restarting nodes.
+			clusterConfig, err := s.GetAccessPoint().GetClusterConfig()
+			if err != nil {
+				return defaults.ServerKeepAliveTTL
+			}
+			return clusterConfig.GetKeepAliveInterval()
+		},
+		AnnouncePeriod: defaults.ServerAnnounceTTL/2 + utils.RandomDuration(defaults.ServerAnnounceTTL/10),
+		ServerTTL:      defaults.ServerAnnounceTTL,
+		CheckPeriod:    defaults.HeartbeatCheckPeriod,
+		Clock:          s.clock,
+		OnHeartbeat:    s.onHeartbeat,
 	})
 	if err != nil {
 		s.srv.Close()
@@ -677,21 +840,33 @@ func (s *Server) GetInfo() services.Server {
 		addr = s.AdvertiseAddr()
 	}
 
+	spec := services.ServerSpecV2{
+		CmdLabels: services.LabelsToV2(s.getCommandLabels()),
+		Addr:      addr,
+		Hostname:  s.hostname,
+		UseTunnel: s.useTunnel,
+		Version:   teleport.Version,
+	}
+	if s.reportUtilization {
+		u, err := gatherUtilization()
+		if err != nil {
+			log.Debugf("Failed to gather utilization for heartbeat: %v.", err)
+		} else {
+			spec.CPUPercent = u.CPU
+			spec.MemoryPercent = u.Memory
+			spec.DiskPercent = u.Disk
+		}
+	}
+
 	return &services.ServerV2{
 		Kind:    services.KindNode,
 		Version: services.V2,
 		Metadata: services.Metadata{
 			Name:      s.ID(),
 			Namespace: s.getNamespace(),
-			Labels:    s.labels,
-		},
-		Spec: services.ServerSpecV2{
-			CmdLabels: services.LabelsToV2(s.getCommandLabels()),
-			Addr:      addr,
-			Hostname:  s.hostname,
-			UseTunnel: s.useTunnel,
-			Version:   teleport.Version,
+			Labels:    s.getStaticLabels(),
 		},
+		Spec: spec,
 	}
 }
 
@@ -718,6 +893,32 @@ func (s *Server) updateLabels() {
 	}
 }
 
+// startLabelProviders starts the periodic queries of the configured
+// external label providers. Providers are idempotent to start.
+func (s *Server) startLabelProviders() {
+	for _, provider := range s.labelProviders {
+		provider.Start(s.ctx)
+	}
+}
+
+// getStaticLabels returns the static labels merged with the labels
+// reported by the external label providers, provider labels win.
+func (s *Server) getStaticLabels() map[string]string {
+	if len(s.labelProviders) == 0 {
+		return s.labels
+	}
+	out := make(map[string]string, len(s.labels))
+	for name, value := range s.labels {
+		out[name] = value
+	}
+	for _, provider := range s.labelProviders {
+		for name, value := range provider.Labels() {
+			out[name] = value
+		}
+	}
+	return out
+}
+
 func (s *Server) syncUpdateLabels() {
 	for name, label := range s.getCommandLabels() {
 		s.updateLabel(name, label)
@@ -856,7 +1057,17 @@ func (s *Server) HandleNewConn(ctx context.Context, ccx *sshutils.ConnectionCont
 		return ctx, trace.Wrap(err)
 	}
 
+	cfg, err := s.authService.GetClusterConfig()
+	if err != nil {
+		return ctx, trace.Wrap(err)
+	}
+
 	maxConnections := identityContext.RoleSet.MaxConnections()
+	if maxConnections == 0 {
+		// no role-level limit is set, fall back to the cluster-wide
+		// default from the cluster networking configuration.
+		maxConnections = cfg.GetMaxConnections()
+	}
 
 	if maxConnections == 0 {
 		// concurrent session control is not active, nothing
@@ -864,11 +1075,6 @@ func (s *Server) HandleNewConn(ctx context.Context, ccx *sshutils.ConnectionCont
 		return ctx, nil
 	}
 
-	cfg, err := s.authService.GetClusterConfig()
-	if err != nil {
-		return ctx, trace.Wrap(err)
-	}
-
 	lock, err := services.AcquireSemaphoreLock(ctx, services.SemaphoreLockConfig{
 		Service: s.authService,
 		Expiry:  cfg.GetSessionControlTimeout(),
@@ -926,6 +1132,21 @@ func (s *Server) HandleNewConn(ctx context.Context, ccx *sshutils.ConnectionCont
 	return ctx, nil
 }
 
+// maxSessions returns the effective concurrent session limit for the
+// identity, preferring the role option and falling back to the cluster-wide
+// default from the cluster networking configuration.
+func (s *Server) maxSessions(identityContext srv.IdentityContext) int64 {
+	if max := identityContext.RoleSet.MaxSessions(); max != 0 {
+		return max
+	}
+	cfg, err := s.authService.GetClusterConfig()
+	if err != nil {
+		log.WithError(err).Warn("Failed to retrieve cluster configuration.")
+		return 0
+	}
+	return cfg.GetMaxSessions()
+}
+
 // HandleNewChan is called when new channel is opened
 func (s *Server) HandleNewChan(ctx context.Context, ccx *sshutils.ConnectionContext, nch ssh.NewChannel) {
 	identityContext, err := s.authHandlers.CreateIdentityContext(ccx.ServerConn)
@@ -977,7 +1198,7 @@ func (s *Server) HandleNewChan(ctx context.Context, ccx *sshutils.ConnectionCont
 	// commands on a server, subsystem requests, and agent forwarding.
 	case teleport.ChanSession:
 		var decr func()
-		if max := identityContext.RoleSet.MaxSessions(); max != 0 {
+		if max := s.maxSessions(identityContext); max != 0 {
 			d, ok := ccx.IncrSessions(max)
 			if !ok {
 				// user has exceeded their max concurrent ssh sessions.
@@ -1265,6 +1486,47 @@ func (s *Server) handleSessionRequests(ctx context.Context, ccx *sshutils.Connec
 	}
 }
 
+// ensureHostUser creates the local Unix account for the login if automatic
+// account creation is enabled on this node and no matching local user exists,
+// then checks that the account is in a state that allows logins.
+func (s *Server) ensureHostUser(ctx *srv.ServerContext) error {
+	created, err := srv.EnsureHostUser(ctx.Identity.Login, s.hostUsersConfig)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if created {
+		ctx.Infof("Created host user %q for Teleport user %v.",
+			ctx.Identity.Login, ctx.Identity.TeleportUser)
+	}
+
+	// Refuse logins for accounts that are locked, expired, or have a
+	// non-interactive login shell and record the refusal in the audit log.
+	if err := srv.CheckHostUser(ctx.Identity.Login); err != nil {
+		if emitErr := s.EmitAuditEvent(s.ctx, &events.AuthAttempt{
+			Metadata: events.Metadata{
+				Type: events.AuthAttemptEvent,
+				Code: events.AuthAttemptFailureCode,
+			},
+			UserMetadata: events.UserMetadata{
+				Login: ctx.Identity.Login,
+				User:  ctx.Identity.TeleportUser,
+			},
+			ConnectionMetadata: events.ConnectionMetadata{
+				LocalAddr:  ctx.ServerConn.LocalAddr().String(),
+				RemoteAddr: ctx.ServerConn.RemoteAddr().String(),
+			},
+			Status: events.Status{
+				Success: false,
+				Error:   err.Error(),
+			},
+		}); emitErr != nil {
+			log.WithError(emitErr).Warn("Failed to emit failed login audit event.")
+		}
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
 // dispatch receives an SSH request for a subsystem and disptaches the request to the
 // appropriate subsystem implementation
 func (s *Server) dispatch(ch ssh.Channel, req *ssh.Request, ctx *srv.ServerContext) error {
@@ -1295,13 +1557,29 @@ func (s *Server) dispatch(ch ssh.Channel, req *ssh.Request, ctx *srv.ServerConte
 
 	switch req.Type {
 	case sshutils.ExecRequest:
+		if err := s.ensureHostUser(ctx); err != nil {
+			return trace.Wrap(err)
+		}
 		return s.termHandlers.HandleExec(ch, req, ctx)
 	case sshutils.PTYRequest:
 		return s.termHandlers.HandlePTYReq(ch, req, ctx)
 	case sshutils.ShellRequest:
+		if err := s.ensureHostUser(ctx); err != nil {
+			return trace.Wrap(err)
+		}
+		// Show the message of the day before the shell starts. The terminal
+		// of the client is in raw mode at this point, lines have to be
+		// terminated with an explicit carriage return.
+		if s.motd != "" {
+			if _, err := io.WriteString(ch, strings.Replace(s.motd, "\n", "\r\n", -1)); err != nil {
+				log.Warnf("Failed to send MOTD: %v.", err)
+			}
+		}
 		return s.termHandlers.HandleShell(ch, req, ctx)
 	case sshutils.WindowChangeRequest:
 		return s.termHandlers.HandleWinChange(ch, req, ctx)
+	case sshutils.SignalRequest:
+		return s.termHandlers.HandleSignal(ch, req, ctx)
 	case sshutils.EnvRequest:
 		return s.handleEnv(ch, req, ctx)
 	case sshutils.SubsystemRequest:
@@ -1407,10 +1685,34 @@ func (s *Server) handleEnv(ch ssh.Channel, req *ssh.Request, ctx *srv.ServerCont
 		ctx.Error(err)
 		return trace.Wrap(err, "failed to parse env request")
 	}
+	if !s.envAllowed(e.Name) {
+		ctx.Debugf("Environment variable %q rejected by accept_env policy.", e.Name)
+		return nil
+	}
 	ctx.SetEnv(e.Name, e.Value)
 	return nil
 }
 
+// envAllowed checks the name of an environment variable sent by the client
+// against the accept_env allow-list. An empty list keeps the historic
+// behavior of accepting all variables. Entries may end with a '*' wildcard
+// to match a prefix, e.g. "LC_*".
+func (s *Server) envAllowed(name string) bool {
+	if len(s.acceptEnv) == 0 {
+		return true
+	}
+	for _, pattern := range s.acceptEnv {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
 // handleKeepAlive accepts and replies to keepalive@openssh.com requests.
 func (s *Server) handleKeepAlive(req *ssh.Request) {
 	log.Debugf("Received %q: WantReply: %v", req.Type, req.WantReply)
@@ -0,0 +1,121 @@
+// +build linux
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gravitational/trace"
+)
+
+// utilization holds point-in-time resource usage gauges of the host,
+// expressed as percentages.
+type utilization struct {
+	// CPU is the 1-minute load average normalized by the number of CPUs.
+	CPU int32
+	// Memory is the fraction of physical memory in use.
+	Memory int32
+	// Disk is the fraction of the root filesystem in use.
+	Disk int32
+}
+
+// gatherUtilization samples CPU, memory and root filesystem usage of the
+// host for heartbeat reporting.
+func gatherUtilization() (*utilization, error) {
+	var u utilization
+	var err error
+	if u.CPU, err = cpuPercent(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if u.Memory, err = memoryPercent(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if u.Disk, err = diskPercent(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &u, nil
+}
+
+// cpuPercent derives CPU usage from the 1-minute load average and the
+// number of CPUs, capped at 100.
+func cpuPercent() (int32, error) {
+	data, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, trace.BadParameter("malformed /proc/loadavg")
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	percent := load / float64(runtime.NumCPU()) * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return int32(percent), nil
+}
+
+// memoryPercent reads how much of the physical memory is in use from
+// /proc/meminfo.
+func memoryPercent() (int32, error) {
+	data, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	var total, available uint64
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = value
+		case "MemAvailable:":
+			available = value
+		}
+	}
+	if total == 0 || available > total {
+		return 0, trace.BadParameter("malformed /proc/meminfo")
+	}
+	return int32((total - available) * 100 / total), nil
+}
+
+// diskPercent reads how much of the root filesystem is in use.
+func diskPercent() (int32, error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs("/", &fs); err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	if fs.Blocks == 0 {
+		return 0, nil
+	}
+	return int32((fs.Blocks - fs.Bavail) * 100 / fs.Blocks), nil
+}
@@ -0,0 +1,38 @@
+// +build !linux
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regular
+
+import "github.com/gravitational/trace"
+
+// utilization holds point-in-time resource usage gauges of the host,
+// expressed as percentages.
+type utilization struct {
+	// CPU is the 1-minute load average normalized by the number of CPUs.
+	CPU int32
+	// Memory is the fraction of physical memory in use.
+	Memory int32
+	// Disk is the fraction of the root filesystem in use.
+	Disk int32
+}
+
+// gatherUtilization samples CPU, memory and root filesystem usage of the
+// host for heartbeat reporting.
+func gatherUtilization() (*utilization, error) {
+	return nil, trace.NotImplemented("utilization reporting is only supported on Linux")
+}
@@ -663,6 +663,10 @@ func (s *session) startInteractive(ch ssh.Channel, ctx *ServerContext) error {
 	// create a new "party" (connected client)
 	p := newParty(s, ch, ctx)
 
+	if err := s.checkRecordingRequired(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+
 	// Nodes discard events in cases when proxies are already recording them.
 	if s.registry.srv.Component() == teleport.ComponentNode &&
 		services.IsRecordAtProxy(ctx.ClusterConfig.GetSessionRecording()) {
@@ -729,6 +733,14 @@ func (s *session) startInteractive(ch ssh.Channel, ctx *ServerContext) error {
 		return trace.Wrap(err)
 	}
 
+	// Place the session in a cgroup with resource limits applied. If no
+	// limits are configured, this is a NOP.
+	err = ctx.srv.GetSessionLimiter().OpenSession(s.id.String(), s.term.PID())
+	if err != nil {
+		ctx.Errorf("Failed to apply resource limits to interactive session: %v: %v.", s.id, err)
+		return trace.Wrap(err)
+	}
+
 	// If a cgroup ID was assigned then enhanced session recording was enabled.
 	if cgroupID > 0 {
 		s.hasEnhancedRecording = true
@@ -822,6 +834,12 @@ func (s *session) startInteractive(ch ssh.Channel, ctx *ServerContext) error {
 			ctx.Errorf("Failed to close enhanced recording (interactive) session: %v: %v.", s.id, err)
 		}
 
+		// Remove the resource limits cgroup for the session.
+		err = ctx.srv.GetSessionLimiter().CloseSession(s.id.String())
+		if err != nil {
+			ctx.Errorf("Failed to remove resource limits of interactive session: %v: %v.", s.id, err)
+		}
+
 		if result != nil {
 			if err := s.registry.broadcastResult(s.id, *result); err != nil {
 				s.log.Warningf("Failed to broadcast session result: %v", err)
@@ -849,6 +867,10 @@ func (s *session) startInteractive(ch ssh.Channel, ctx *ServerContext) error {
 func (s *session) startExec(channel ssh.Channel, ctx *ServerContext) error {
 	var err error
 
+	if err := s.checkRecordingRequired(ctx); err != nil {
+		return trace.Wrap(err)
+	}
+
 	// Nodes discard events in cases when proxies are already recording them.
 	if s.registry.srv.Component() == teleport.ComponentNode &&
 		services.IsRecordAtProxy(ctx.ClusterConfig.GetSessionRecording()) {
@@ -937,6 +959,14 @@ func (s *session) startExec(channel ssh.Channel, ctx *ServerContext) error {
 		return trace.Wrap(err)
 	}
 
+	// Place the command in a cgroup with resource limits applied. If no
+	// limits are configured, this is a NOP.
+	err = ctx.srv.GetSessionLimiter().OpenSession(string(s.id), ctx.ExecRequest.PID())
+	if err != nil {
+		ctx.Errorf("Failed to apply resource limits to exec session: %v: %v.", s.id, err)
+		return trace.Wrap(err)
+	}
+
 	// If a cgroup ID was assigned then enhanced session recording was enabled.
 	if cgroupID > 0 {
 		s.hasEnhancedRecording = true
@@ -963,6 +993,12 @@ func (s *session) startExec(channel ssh.Channel, ctx *ServerContext) error {
 			ctx.Errorf("Failed to close enhanced recording (exec) session: %v: %v.", s.id, err)
 		}
 
+		// Remove the resource limits cgroup for the session.
+		err = ctx.srv.GetSessionLimiter().CloseSession(string(s.id))
+		if err != nil {
+			ctx.Errorf("Failed to remove resource limits of exec session: %v: %v.", s.id, err)
+		}
+
 		// Remove the session from the in-memory map.
 		s.registry.removeSession(s)
 
@@ -1024,6 +1060,22 @@ func (s *session) startExec(channel ssh.Channel, ctx *ServerContext) error {
 	return nil
 }
 
+// checkRecordingRequired enforces the require_session_recording role
+// option: sessions of such users may only start once a recorder capturing
+// the output has been established, so they are rejected outright when the
+// cluster has session recording turned off. Recorder setup errors already
+// fail the session for everyone, so restricted users never end up in an
+// unrecorded session.
+func (s *session) checkRecordingRequired(ctx *ServerContext) error {
+	if !ctx.Identity.RoleSet.RequireSessionRecording() {
+		return nil
+	}
+	if ctx.ClusterConfig.GetSessionRecording() == services.RecordOff {
+		return trace.AccessDenied("session recording is disabled for this cluster, but roles of user %q require recorded sessions", ctx.Identity.TeleportUser)
+	}
+	return nil
+}
+
 // newStreamer returns sync or async streamer based on the configuration
 // of the server and the session, sync streamer sends the events
 // directly to the auth server and blocks if the events can not be received,
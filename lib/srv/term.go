@@ -319,7 +319,7 @@ func (t *terminal) SetWinSize(params rsession.TerminalParams) error {
 	if t.pty == nil {
 		return trace.NotFound("no pty")
 	}
-	if err := term.SetWinsize(t.pty.Fd(), params.Winsize()); err != nil {
+	if err := setWinsize(t.pty.Fd(), params.Winsize()); err != nil {
 		return trace.Wrap(err)
 	}
 	t.params = params
@@ -635,6 +635,7 @@ func (t *remoteTerminal) prepareRemoteSession(session *ssh.Session, ctx *ServerC
 		teleport.SSHTeleportHostUUID:    ctx.srv.ID(),
 		teleport.SSHTeleportClusterName: ctx.ClusterName,
 		teleport.SSHSessionID:           string(ctx.session.id),
+		teleport.SSHTeleportTraceID:     ctx.Parent().GetTraceID(),
 	}
 
 	for k, v := range envs {
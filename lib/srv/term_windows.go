@@ -0,0 +1,30 @@
+// +build windows
+
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package srv
+
+import (
+	"github.com/docker/docker/pkg/term"
+	"github.com/gravitational/trace"
+)
+
+// setWinsize resizes the local terminal, interactive sessions on Windows
+// land together with ConPTY support.
+func setWinsize(fd uintptr, ws *term.Winsize) error {
+	return trace.NotImplemented("terminal resizing is not supported on Windows")
+}
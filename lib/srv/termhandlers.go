@@ -105,6 +105,12 @@ func (t *TermHandlers) HandleShell(ch ssh.Channel, req *ssh.Request, ctx *Server
 	// Save the request within the context.
 	ctx.request = req
 
+	// If the roles of the user restrict the commands that can be executed,
+	// interactive shells are not allowed.
+	if err := ctx.Identity.RoleSet.CheckCommandAccess(""); err != nil {
+		return trace.Wrap(err)
+	}
+
 	// Creating an empty exec request implies a interactive shell was requested.
 	ctx.ExecRequest, err = NewExecRequest(ctx, "")
 	if err != nil {
@@ -136,6 +142,40 @@ func (t *TermHandlers) HandleWinChange(ch ssh.Channel, req *ssh.Request, ctx *Se
 	return nil
 }
 
+// HandleSignal handles requests of type "signal" which deliver a signal to
+// the remote process, so Ctrl-C on the client interrupts the remote command
+// instead of dropping the connection.
+func (t *TermHandlers) HandleSignal(ch ssh.Channel, req *ssh.Request, ctx *ServerContext) error {
+	var r sshutils.SignalReq
+	if err := ssh.Unmarshal(req.Payload, &r); err != nil {
+		return trace.Wrap(err)
+	}
+
+	// A forwarding server passes the signal on to the remote session.
+	if ctx.RemoteSession != nil {
+		return trace.Wrap(ctx.RemoteSession.Signal(ssh.Signal(r.Signal)))
+	}
+
+	signal, ok := execSignals[r.Signal]
+	if !ok {
+		return trace.BadParameter("unknown signal %q", r.Signal)
+	}
+
+	// Deliver the signal to the process handling the session, it forwards
+	// the signal to the command it runs.
+	var pid int
+	switch {
+	case ctx.ExecRequest != nil && ctx.ExecRequest.PID() != 0:
+		pid = ctx.ExecRequest.PID()
+	case ctx.GetTerm() != nil && ctx.GetTerm().PID() != 0:
+		pid = ctx.GetTerm().PID()
+	default:
+		return trace.NotFound("no process to deliver signal %q to", r.Signal)
+	}
+	ctx.Debugf("Delivering signal %v to PID %v.", r.Signal, pid)
+	return trace.Wrap(killProcess(pid, signal))
+}
+
 func parseExecRequest(req *ssh.Request, ctx *ServerContext) (Exec, error) {
 	var err error
 
@@ -144,6 +184,12 @@ func parseExecRequest(req *ssh.Request, ctx *ServerContext) (Exec, error) {
 		return nil, trace.Wrap(err)
 	}
 
+	// Reject the command if the roles of the user restrict the commands that
+	// can be executed and the command is not in the allow-list.
+	if err := ctx.Identity.RoleSet.CheckCommandAccess(r.Command); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	ctx.ExecRequest, err = NewExecRequest(ctx, r.Command)
 	if err != nil {
 		return nil, trace.Wrap(err)
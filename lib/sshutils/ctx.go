@@ -28,6 +28,7 @@ import (
 	"golang.org/x/crypto/ssh/agent"
 
 	"github.com/gravitational/trace"
+	"github.com/pborman/uuid"
 )
 
 // ConnectionContext manages connection-level state.
@@ -61,6 +62,11 @@ type ConnectionContext struct {
 	// closed indicates that closers have been run.
 	closed bool
 
+	// traceID is a correlation ID shared by all log lines related to this
+	// connection. It is generated at the edge and propagated across proxy,
+	// auth and node hops to simplify debugging of failed connections.
+	traceID string
+
 	// cancel cancels the context.Context scope associated with this ConnectionContext.
 	cancel context.CancelFunc
 }
@@ -73,6 +79,7 @@ func NewConnectionContext(ctx context.Context, nconn net.Conn, sconn *ssh.Server
 		NetConn:    nconn,
 		ServerConn: sconn,
 		env:        make(map[string]string),
+		traceID:    uuid.New(),
 		cancel:     cancel,
 	}
 }
@@ -124,6 +131,21 @@ func (c *ConnectionContext) SetEnv(key, val string) {
 	c.env[key] = val
 }
 
+// GetTraceID returns the correlation ID of this connection.
+func (c *ConnectionContext) GetTraceID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.traceID
+}
+
+// SetTraceID overrides the correlation ID of this connection, used to adopt
+// the ID generated upstream by a client or proxy.
+func (c *ConnectionContext) SetTraceID(traceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.traceID = traceID
+}
+
 // GetEnv returns a environment variable within this context.
 func (c *ConnectionContext) GetEnv(key string) (string, bool) {
 	c.mu.RLock()
@@ -133,6 +133,12 @@ type SubsystemReq struct {
 	Name string
 }
 
+// SignalReq specifies the parameters for a "signal" request. The signal
+// name is in the SSH wire format, without the "SIG" prefix (RFC 4254 6.9).
+type SignalReq struct {
+	Signal string
+}
+
 // SessionEnvVar is environment variable for SSH session
 const SessionEnvVar = "TELEPORT_SESSION"
 
@@ -152,6 +158,9 @@ const (
 	// WindowChangeRequest is a request to change window.
 	WindowChangeRequest = "window-change"
 
+	// SignalRequest is a request to deliver a signal to the remote process.
+	SignalRequest = "signal"
+
 	// PTYRequest is a request for PTY.
 	PTYRequest = "pty-req"
 
@@ -23,6 +23,7 @@ import (
 	"context"
 	"crypto/subtle"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"sync"
@@ -241,6 +242,18 @@ func SetFIPS(fips bool) ServerOption {
 	}
 }
 
+// SetBanner sets a banner that is sent to the client before authentication.
+func SetBanner(banner string) ServerOption {
+	return func(s *Server) error {
+		if banner != "" {
+			s.cfg.BannerCallback = func(conn ssh.ConnMetadata) string {
+				return banner
+			}
+		}
+		return nil
+	}
+}
+
 func (s *Server) Addr() string {
 	s.RLock()
 	defer s.RUnlock()
@@ -426,6 +439,17 @@ func (s *Server) HandleConnection(conn net.Conn) {
 	user := sconn.User()
 	if err := s.limiter.RegisterRequest(user); err != nil {
 		log.Errorf(err.Error())
+		// Reject the first channel request with a distinct error, so the
+		// client can tell the connection was rate limited rather than
+		// dropped on the floor.
+		go ssh.DiscardRequests(reqs)
+		select {
+		case nch := <-chans:
+			if nch != nil {
+				nch.Reject(ssh.ResourceShortage, fmt.Sprintf("rate limit exceeded for user %v, please slow down", user))
+			}
+		case <-time.After(defaults.DefaultDialTimeout):
+		}
 		sconn.Close()
 		conn.Close()
 		return
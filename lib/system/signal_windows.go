@@ -0,0 +1,8 @@
+// +build windows
+
+package system
+
+// ResetInterruptSignalHandler is a no-op on Windows, ignored SIGINT
+// handlers inherited from sysvinit are a POSIX concern.
+func ResetInterruptSignalHandler() {
+}
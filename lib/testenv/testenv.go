@@ -0,0 +1,297 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testenv spins up a complete in-process Teleport cluster
+// (auth, proxy and node in a single process) on random ports with an
+// in-memory backend, so projects embedding Teleport libraries can
+// write end to end tests without shell scripts:
+//
+//   cluster, err := testenv.New(testenv.Config{})
+//   ...
+//   defer cluster.Close()
+//   err = cluster.Start(ctx)
+//   ...
+//   tc, err := cluster.NewClient(ctx, "alice")
+//
+package testenv
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/auth/testauthority"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/backend/lite"
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// Config is the test cluster configuration
+type Config struct {
+	// ClusterName is the name of the cluster, "testenv" by default
+	ClusterName string
+	// Dir is the data directory, a temporary directory
+	// removed on Close is used when empty
+	Dir string
+	// NodeName is the hostname of the single node, "localhost" by default
+	NodeName string
+	// Console is an optional writer for process output
+	Console io.Writer
+	// StartTimeout bounds how long Start waits for the process
+	// to become ready, one minute by default
+	StartTimeout time.Duration
+
+	// removeDir is set when the data directory was auto-created
+	// and should be removed on Close
+	removeDir bool
+}
+
+// CheckAndSetDefaults checks and sets default values
+func (cfg *Config) CheckAndSetDefaults() error {
+	if cfg.ClusterName == "" {
+		cfg.ClusterName = "testenv"
+	}
+	if cfg.NodeName == "" {
+		cfg.NodeName = "localhost"
+	}
+	if cfg.StartTimeout == 0 {
+		cfg.StartTimeout = time.Minute
+	}
+	if cfg.Dir == "" {
+		dir, err := ioutil.TempDir("", "testenv-"+cfg.ClusterName)
+		if err != nil {
+			return trace.ConvertSystemError(err)
+		}
+		cfg.Dir = dir
+		cfg.removeDir = true
+	}
+	return nil
+}
+
+// Cluster is a running in-process auth+proxy+node trio
+type Cluster struct {
+	Config
+	// Process is the running Teleport process
+	Process *service.TeleportProcess
+	// ServiceConfig is the generated process configuration,
+	// tests may tweak it between New and Start
+	ServiceConfig *service.Config
+}
+
+// New generates a single-process cluster configuration with
+// auth, proxy and node services listening on random free ports
+// and an in-memory backend
+func New(cfg Config) (*Cluster, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ports, err := utils.GetFreeTCPPorts(5)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	tconf := service.MakeDefaultConfig()
+	tconf.DataDir = cfg.Dir
+	tconf.Hostname = cfg.NodeName
+	tconf.CachePolicy.Enabled = false
+	tconf.Auth.ClusterName, err = services.NewClusterName(services.ClusterNameSpecV2{
+		ClusterName: cfg.ClusterName,
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	tconf.Auth.Enabled = true
+	tconf.Auth.SSHAddr.Addr = net.JoinHostPort("127.0.0.1", ports.Pop())
+	tconf.Auth.NoAudit = true
+	tconf.Auth.StorageConfig = backend.Config{
+		Type:   lite.GetName(),
+		Params: backend.Params{"memory": true, "poll_stream_period": 50 * time.Millisecond},
+	}
+	tconf.AuthServers = []utils.NetAddr{tconf.Auth.SSHAddr}
+
+	tconf.Proxy.Enabled = true
+	tconf.Proxy.SSHAddr.Addr = net.JoinHostPort("127.0.0.1", ports.Pop())
+	tconf.Proxy.WebAddr.Addr = net.JoinHostPort("127.0.0.1", ports.Pop())
+	tconf.Proxy.ReverseTunnelListenAddr.Addr = net.JoinHostPort("127.0.0.1", ports.Pop())
+	tconf.Proxy.DisableWebInterface = true
+
+	tconf.SSH.Enabled = true
+	tconf.SSH.Addr.Addr = net.JoinHostPort("127.0.0.1", ports.Pop())
+
+	tconf.Keygen = testauthority.New()
+	if cfg.Console != nil {
+		tconf.Console = cfg.Console
+	} else {
+		tconf.Console = ioutil.Discard
+	}
+
+	return &Cluster{
+		Config:        cfg,
+		ServiceConfig: tconf,
+	}, nil
+}
+
+// Start starts the process and blocks until all services
+// have reported ready or the start timeout elapses
+func (c *Cluster) Start(ctx context.Context) error {
+	process, err := service.NewTeleport(c.ServiceConfig)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	c.Process = process
+	if err := process.Start(); err != nil {
+		return trace.Wrap(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.StartTimeout)
+	defer cancel()
+	eventC := make(chan service.Event, 1)
+	process.WaitForEvent(ctx, service.TeleportReadyEvent, eventC)
+	select {
+	case <-eventC:
+		return nil
+	case <-ctx.Done():
+		return trace.ConnectionProblem(ctx.Err(), "timeout waiting for cluster %v to start", c.ClusterName)
+	}
+}
+
+// Close stops the process and removes the auto-created data directory
+func (c *Cluster) Close() error {
+	var errors []error
+	if c.Process != nil {
+		if err := c.Process.Close(); err != nil {
+			errors = append(errors, err)
+		} else {
+			errors = append(errors, c.Process.Wait())
+		}
+	}
+	if c.removeDir {
+		errors = append(errors, os.RemoveAll(c.Dir))
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// AuthServer returns the embedded auth server for direct
+// administrative operations in tests
+func (c *Cluster) AuthServer() *auth.Server {
+	return c.Process.GetAuthServer()
+}
+
+// NodeAddr returns the host:port of the SSH node
+func (c *Cluster) NodeAddr() string {
+	return c.ServiceConfig.SSH.Addr.Addr
+}
+
+// ProxyWebAddr returns the host:port of the proxy web listener
+func (c *Cluster) ProxyWebAddr() string {
+	return c.ServiceConfig.Proxy.WebAddr.Addr
+}
+
+// ProxySSHAddr returns the host:port of the proxy SSH listener
+func (c *Cluster) ProxySSHAddr() string {
+	return c.ServiceConfig.Proxy.SSHAddr.Addr
+}
+
+// AuthAddr returns the host:port of the auth server
+func (c *Cluster) AuthAddr() string {
+	return c.ServiceConfig.Auth.SSHAddr.Addr
+}
+
+// CreateUser creates a user with a role permitting the supplied
+// logins, an existing user is updated in place
+func (c *Cluster) CreateUser(ctx context.Context, username string, logins ...string) error {
+	authServer := c.AuthServer()
+	teleUser, err := services.NewUser(username)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	role := services.RoleForUser(teleUser)
+	if len(logins) == 0 {
+		logins = []string{username}
+	}
+	role.SetLogins(services.Allow, logins)
+	roleOptions := role.GetOptions()
+	roleOptions.ForwardAgent = services.NewBool(true)
+	role.SetOptions(roleOptions)
+	if err := authServer.UpsertRole(ctx, role); err != nil {
+		return trace.Wrap(err)
+	}
+	teleUser.AddRole(role.GetName())
+	return trace.Wrap(authServer.UpsertUser(teleUser))
+}
+
+// NewClient returns a client pre-authenticated as the supplied user
+// with server CAs and a signed session key, the user has to be
+// created with CreateUser first
+func (c *Cluster) NewClient(ctx context.Context, username string) (*client.TeleportClient, error) {
+	authServer := c.AuthServer()
+	priv, pub, err := c.ServiceConfig.Keygen.GenerateKeyPair("")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	sshCert, tlsCert, err := authServer.GenerateUserTestCerts(
+		pub, username, time.Hour, teleport.CertificateFormatStandard, "")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	hostCA, err := authServer.GetCertAuthority(services.CertAuthID{
+		Type:       services.HostCA,
+		DomainName: c.ClusterName,
+	}, false)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	keyDir, err := ioutil.TempDir(c.Dir, "keys")
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	tc, err := client.NewClient(&client.Config{
+		Username:           username,
+		HostLogin:          username,
+		Host:               c.NodeName,
+		InsecureSkipVerify: true,
+		KeysDir:            keyDir,
+		SiteName:           c.ClusterName,
+		WebProxyAddr:       c.ProxyWebAddr(),
+		SSHProxyAddr:       c.ProxySSHAddr(),
+	})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	key := &client.Key{
+		Priv:    priv,
+		Pub:     pub,
+		Cert:    sshCert,
+		TLSCert: tlsCert,
+	}
+	if _, err := tc.AddKey(c.ProxySSHAddr(), key); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := tc.AddTrustedCA(hostCA); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return tc, nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testenv
+
+import (
+	"testing"
+
+	"github.com/gravitational/teleport/lib/backend/lite"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNew verifies that the generated configuration describes a
+// single process cluster with all three services on distinct ports
+// and an in-memory backend
+func TestNew(t *testing.T) {
+	cluster, err := New(Config{})
+	assert.NoError(t, err)
+	defer cluster.Close()
+
+	tconf := cluster.ServiceConfig
+	assert.True(t, tconf.Auth.Enabled)
+	assert.True(t, tconf.Proxy.Enabled)
+	assert.True(t, tconf.SSH.Enabled)
+	assert.Equal(t, lite.GetName(), tconf.Auth.StorageConfig.Type)
+	assert.Equal(t, true, tconf.Auth.StorageConfig.Params["memory"])
+
+	addrs := map[string]bool{
+		cluster.AuthAddr():                       true,
+		cluster.ProxySSHAddr():                   true,
+		cluster.ProxyWebAddr():                   true,
+		cluster.NodeAddr():                       true,
+		tconf.Proxy.ReverseTunnelListenAddr.Addr: true,
+	}
+	assert.Len(t, addrs, 5)
+}
@@ -23,6 +23,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"net"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -40,6 +41,12 @@ type CertChecker struct {
 	// FIPS means in addition to checking the validity of the key or
 	// certificate, also check that FIPS 140-2 algorithms were used.
 	FIPS bool
+
+	// ClockSkewTolerance extends the validity window of certificates in both
+	// directions to tolerate wall clock drift between the host and the
+	// certificate authority. Zero means no tolerance. A checker with a
+	// tolerance set can not be shared between concurrent connections.
+	ClockSkewTolerance time.Duration
 }
 
 // Authenticate checks the validity of a user certificate.
@@ -48,6 +55,9 @@ func (c *CertChecker) Authenticate(conn ssh.ConnMetadata, key ssh.PublicKey) (*s
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
+	if err := c.applySkewTolerance(key); err != nil {
+		return nil, trace.Wrap(err)
+	}
 
 	perms, err := c.CertChecker.Authenticate(conn, key)
 	if err != nil {
@@ -63,6 +73,9 @@ func (c *CertChecker) CheckCert(principal string, cert *ssh.Certificate) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if err := c.applySkewTolerance(cert); err != nil {
+		return trace.Wrap(err)
+	}
 
 	return c.CertChecker.CheckCert(principal, cert)
 }
@@ -74,9 +87,82 @@ func (c *CertChecker) CheckHostKey(addr string, remote net.Addr, key ssh.PublicK
 		return trace.Wrap(err)
 	}
 
+	// ssh.CertChecker only supports exact principal matches, so if the
+	// certificate carries wildcard principals, match the target host against
+	// them and check the certificate for the matched principal instead.
+	if cert, ok := key.(*ssh.Certificate); ok && cert.CertType == ssh.HostCert {
+		if principal := matchWildcardPrincipal(cert, addr); principal != "" {
+			return c.CertChecker.CheckCert(principal, cert)
+		}
+	}
+
 	return c.CertChecker.CheckHostKey(addr, remote, key)
 }
 
+// matchWildcardPrincipal returns the wildcard principal from the certificate
+// matching the target address, if any. A wildcard only matches a single
+// leftmost label, the same way it does in TLS certificates.
+func matchWildcardPrincipal(cert *ssh.Certificate, addr string) string {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	for _, principal := range cert.ValidPrincipals {
+		if !strings.HasPrefix(principal, "*.") {
+			continue
+		}
+		// suffix keeps the leading dot so "*.db.internal" does not match
+		// "db.internal" itself or "xdb.internal".
+		suffix := principal[1:]
+		if !strings.HasSuffix(host, suffix) {
+			continue
+		}
+		label := strings.TrimSuffix(host, suffix)
+		if label == "" || strings.Contains(label, ".") {
+			continue
+		}
+		return principal
+	}
+	return ""
+}
+
+// applySkewTolerance checks the validity window of the certificate with the
+// configured clock skew tolerance applied, then pins the clock of the
+// underlying checker inside the window so the times are not re-checked
+// without the tolerance. The tolerance makes nodes with slightly drifted
+// clocks accept freshly issued certificates.
+func (c *CertChecker) applySkewTolerance(key ssh.PublicKey) error {
+	if c.ClockSkewTolerance == 0 {
+		return nil
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	pinned := now
+	if cert.ValidAfter != 0 {
+		validAfter := time.Unix(int64(cert.ValidAfter), 0)
+		if now.Add(c.ClockSkewTolerance).Before(validAfter) {
+			return trace.AccessDenied("cert is not yet valid")
+		}
+		if now.Before(validAfter) {
+			pinned = validAfter
+		}
+	}
+	if cert.ValidBefore != 0 && cert.ValidBefore != uint64(ssh.CertTimeInfinity) {
+		validBefore := time.Unix(int64(cert.ValidBefore), 0)
+		if now.Add(-c.ClockSkewTolerance).After(validBefore) {
+			return trace.AccessDenied("cert has expired")
+		}
+		if now.After(validBefore) {
+			pinned = validBefore.Add(-time.Second)
+		}
+	}
+	c.CertChecker.Clock = func() time.Time { return pinned }
+	return nil
+}
+
 func (c *CertChecker) validate(key ssh.PublicKey) error {
 	// When not in FIPS mode, accept all algorithms and key sizes.
 	if !c.FIPS {
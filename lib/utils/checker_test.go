@@ -103,3 +103,28 @@ func (s *CheckerSuite) TestValidateFIPS(c *check.C) {
 	err = checker.validate(sshKey)
 	c.Assert(err, check.NotNil)
 }
+
+// TestMatchWildcardPrincipal checks matching of wildcard host certificate
+// principals against target addresses.
+func (s *CheckerSuite) TestMatchWildcardPrincipal(c *check.C) {
+	cert := &ssh.Certificate{
+		CertType:        ssh.HostCert,
+		ValidPrincipals: []string{"replica0.db.internal", "*.db.internal"},
+	}
+	tests := []struct {
+		addr      string
+		principal string
+	}{
+		// wildcard matches a single leftmost label, with or without a port
+		{addr: "replica1.db.internal:3022", principal: "*.db.internal"},
+		{addr: "replica1.db.internal", principal: "*.db.internal"},
+		// wildcard does not match nested labels or the bare suffix
+		{addr: "a.replica1.db.internal", principal: ""},
+		{addr: "db.internal", principal: ""},
+		{addr: "xdb.internal", principal: ""},
+		{addr: "replica1.db.external", principal: ""},
+	}
+	for _, tt := range tests {
+		c.Assert(matchWildcardPrincipal(cert, tt.addr), check.Equals, tt.principal)
+	}
+}
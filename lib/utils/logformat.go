@@ -0,0 +1,58 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"strings"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// ComponentLevelFormatter wraps a logrus formatter and applies per-component
+// severity levels, dropping entries that are more verbose than the level
+// configured for their component. It is used to implement per-component
+// level overrides: the global logger level is raised to the most verbose
+// component level and this formatter filters the other components back down.
+type ComponentLevelFormatter struct {
+	// Formatter formats the entries that pass the filter
+	Formatter log.Formatter
+	// Levels maps component name prefixes (e.g. "auth", "node") to the
+	// maximum severity level logged for that component
+	Levels map[string]log.Level
+	// DefaultLevel is the maximum severity level logged for components
+	// without an override (and entries without a component)
+	DefaultLevel log.Level
+}
+
+// Format implements logrus.Formatter, returning no output for entries
+// filtered out by the per-component levels.
+func (f *ComponentLevelFormatter) Format(entry *log.Entry) ([]byte, error) {
+	level := f.DefaultLevel
+	if component, ok := entry.Data[trace.Component].(string); ok {
+		for prefix, componentLevel := range f.Levels {
+			if component == prefix || strings.HasPrefix(component, prefix+":") {
+				level = componentLevel
+				break
+			}
+		}
+	}
+	if entry.Level > level {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
@@ -18,6 +18,7 @@ package proxy
 import (
 	"bufio"
 	"context"
+	"encoding/base64"
 	"net"
 	"net/http"
 	"net/url"
@@ -26,9 +27,11 @@ import (
 	"time"
 
 	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/chaos"
 	"github.com/gravitational/trace"
 
 	"golang.org/x/crypto/ssh"
+	socks "golang.org/x/net/proxy"
 
 	"github.com/sirupsen/logrus"
 )
@@ -81,11 +84,14 @@ func (d directDial) Dial(network string, addr string, config *ssh.ClientConfig)
 
 // DialTimeout acts like Dial but takes a timeout.
 func (d directDial) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	if err := chaos.DialHook(network, address); err != nil {
+		return nil, trace.Wrap(err)
+	}
 	return net.DialTimeout(network, address, timeout)
 }
 
 type proxyDial struct {
-	proxyHost string
+	proxyURL *url.URL
 }
 
 // DialTimeout acts like Dial but takes a timeout.
@@ -97,14 +103,14 @@ func (d proxyDial) DialTimeout(network, address string, timeout time.Duration) (
 		defer cancel()
 		ctx = timeoutCtx
 	}
-	return dialProxy(ctx, d.proxyHost, address)
+	return dialProxy(ctx, d.proxyURL, address)
 }
 
 // Dial first connects to a proxy, then uses the connection to establish a new
 // SSH connection.
 func (d proxyDial) Dial(network string, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
 	// Build a proxy connection first.
-	pconn, err := dialProxy(context.Background(), d.proxyHost, addr)
+	pconn, err := dialProxy(context.Background(), d.proxyURL, addr)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -128,32 +134,46 @@ func (d proxyDial) Dial(network string, addr string, config *ssh.ClientConfig) (
 // server directly.
 func DialerFromEnvironment(addr string) Dialer {
 	// Try and get proxy addr from the environment.
-	proxyAddr := getProxyAddress(addr)
+	proxyURL := getProxyAddress(addr)
 
 	// If no proxy settings are in environment return regular ssh dialer,
 	// otherwise return a proxy dialer.
-	if proxyAddr == "" {
+	if proxyURL == nil {
 		log.Debugf("No proxy set in environment, returning direct dialer.")
 		return directDial{}
 	}
-	log.Debugf("Found proxy %q in environment, returning proxy dialer.", proxyAddr)
-	return proxyDial{proxyHost: proxyAddr}
+	log.Debugf("Found proxy %q in environment, returning proxy dialer.", proxyURL.Host)
+	return proxyDial{proxyURL: proxyURL}
 }
 
-func dialProxy(ctx context.Context, proxyAddr string, addr string) (net.Conn, error) {
+func dialProxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	// SOCKS5 proxies tunnel raw TCP, no CONNECT handshake is needed.
+	if proxyURL.Scheme == "socks5" {
+		return dialSOCKS5Proxy(ctx, proxyURL, addr)
+	}
 
 	var d net.Dialer
-	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	conn, err := d.DialContext(ctx, "tcp", proxyURL.Host)
 	if err != nil {
-		log.Warnf("Unable to dial to proxy: %v: %v.", proxyAddr, err)
+		log.Warnf("Unable to dial to proxy: %v: %v.", proxyURL.Host, err)
 		return nil, trace.ConvertSystemError(err)
 	}
 
+	header := make(http.Header)
+	// If the proxy URL carries credentials, authenticate the CONNECT request
+	// with HTTP basic auth.
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth := proxyURL.User.Username() + ":" + password
+		header.Set("Proxy-Authorization",
+			"Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
+	}
+
 	connectReq := &http.Request{
 		Method: http.MethodConnect,
 		URL:    &url.URL{Opaque: addr},
 		Host:   addr,
-		Header: make(http.Header),
+		Header: header,
 	}
 	err = connectReq.Write(conn)
 	if err != nil {
@@ -192,7 +212,36 @@ func dialProxy(ctx context.Context, proxyAddr string, addr string) (net.Conn, er
 	}, nil
 }
 
-func getProxyAddress(addr string) string {
+// dialSOCKS5Proxy tunnels the connection to addr through a SOCKS5 proxy,
+// authenticating with username/password from the proxy URL if present.
+func dialSOCKS5Proxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	var auth *socks.Auth
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &socks.Auth{
+			User:     proxyURL.User.Username(),
+			Password: password,
+		}
+	}
+	dialer, err := socks.SOCKS5("tcp", proxyURL.Host, auth, socks.Direct)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if contextDialer, ok := dialer.(socks.ContextDialer); ok {
+		conn, err := contextDialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		return conn, nil
+	}
+	conn, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	return conn, nil
+}
+
+func getProxyAddress(addr string) *url.URL {
 	envs := []string{
 		teleport.HTTPSProxy,
 		strings.ToLower(teleport.HTTPSProxy),
@@ -205,35 +254,36 @@ func getProxyAddress(addr string) string {
 		if envAddr == "" {
 			continue
 		}
-		proxyAddr, err := parse(envAddr)
+		proxyURL, err := parse(envAddr)
 		if err != nil {
 			log.Debugf("Unable to parse environment variable %q: %q.", v, envAddr)
 			continue
 		}
-		log.Debugf("Successfully parsed environment variable %q: %q to %q.", v, envAddr, proxyAddr)
+		log.Debugf("Successfully parsed environment variable %q: %q to %q.", v, envAddr, proxyURL.Host)
 		if !useProxy(addr) {
 			log.Debugf("Matched NO_PROXY override for %q: %q, going to ignore proxy variable.", v, envAddr)
-			return ""
+			return nil
 		}
-		return proxyAddr
+		return proxyURL
 	}
 
 	log.Debugf("No valid environment variables found.")
-	return ""
+	return nil
 }
 
-// parse will extract the host:port of the proxy to dial to. If the
-// value is not prefixed by "http", then it will prepend "http" and try.
-func parse(addr string) (string, error) {
+// parse will extract the proxy to dial to. If the value is not prefixed by a
+// supported scheme ("http", "https" or "socks5"), then it will prepend
+// "http" and try.
+func parse(addr string) (*url.URL, error) {
 	proxyurl, err := url.Parse(addr)
-	if err != nil || !strings.HasPrefix(proxyurl.Scheme, "http") {
+	if err != nil || !(strings.HasPrefix(proxyurl.Scheme, "http") || proxyurl.Scheme == "socks5") {
 		proxyurl, err = url.Parse("http://" + addr)
 		if err != nil {
-			return "", trace.Wrap(err)
+			return nil, trace.Wrap(err)
 		}
 	}
 
-	return proxyurl.Host, nil
+	return proxyurl, nil
 }
 
 // bufferedConn is used when part of the data on a connection has already been
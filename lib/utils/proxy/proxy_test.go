@@ -111,7 +111,11 @@ func (s *ProxySuite) TestGetProxyAddress(c *check.C) {
 		p := getProxyAddress(tt.targetAddr)
 		unsetEnv()
 
-		c.Assert(p, check.Equals, tt.proxyAddr, comment)
+		var proxyAddr string
+		if p != nil {
+			proxyAddr = p.Host
+		}
+		c.Assert(proxyAddr, check.Equals, tt.proxyAddr, comment)
 	}
 }
 
@@ -0,0 +1,189 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// NetworkSRV marks a dial address resolved with a DNS SRV lookup,
+	// e.g. "srv://_teleport._tcp.example.com" expands to the proxy set
+	// advertised in the SRV records
+	NetworkSRV = "srv"
+
+	// defaultResolverTTL is how long resolved dial addresses are cached
+	// unless overridden per address
+	defaultResolverTTL = 30 * time.Second
+)
+
+// Resolver resolves a dial address to one or more dialable candidates.
+type Resolver interface {
+	// Resolve resolves the address to dial candidates in preference order
+	Resolve(ctx context.Context, addr NetAddr) ([]NetAddr, error)
+}
+
+// DefaultResolver is the shared caching resolver used for dial addresses
+// across the process.
+var DefaultResolver Resolver = &CachingResolver{
+	CachingResolverConfig: CachingResolverConfig{
+		Resolver: SystemResolver{},
+		TTL:      defaultResolverTTL,
+		Clock:    clockwork.NewRealClock(),
+	},
+	cache: make(map[string]resolverCacheEntry),
+}
+
+// SystemResolver resolves dial addresses with the system DNS resolver.
+// SRV addresses expand to the host:port pairs of their SRV records,
+// other addresses to their A/AAAA records with the port preserved.
+type SystemResolver struct{}
+
+// Resolve resolves the address to dial candidates in preference order
+func (SystemResolver) Resolve(ctx context.Context, addr NetAddr) ([]NetAddr, error) {
+	if addr.AddrNetwork == NetworkSRV {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", addr.Host())
+		if err != nil {
+			return nil, trace.ConvertSystemError(err)
+		}
+		out := make([]NetAddr, 0, len(records))
+		for _, record := range records {
+			out = append(out, NetAddr{
+				Addr:        net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port))),
+				AddrNetwork: "tcp",
+			})
+		}
+		if len(out) == 0 {
+			return nil, trace.NotFound("no SRV records found for %v", addr.Host())
+		}
+		return out, nil
+	}
+	// unix sockets and literal IP addresses need no resolution
+	host, port, err := net.SplitHostPort(addr.Addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return []NetAddr{addr}, nil
+	}
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	out := make([]NetAddr, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, NetAddr{
+			Addr:        net.JoinHostPort(ip, port),
+			AddrNetwork: addr.AddrNetwork,
+			Path:        addr.Path,
+		})
+	}
+	return out, nil
+}
+
+// CachingResolverConfig configures a caching resolver.
+type CachingResolverConfig struct {
+	// Resolver performs the lookups on cache misses,
+	// the system resolver is used if not set
+	Resolver Resolver
+	// TTL is how long resolved addresses are cached
+	TTL time.Duration
+	// TTLOverrides maps a dial address to the cache TTL
+	// for its records, overriding TTL
+	TTLOverrides map[string]time.Duration
+	// Clock is a clock override used in tests
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults checks and sets default values.
+func (c *CachingResolverConfig) CheckAndSetDefaults() error {
+	if c.Resolver == nil {
+		c.Resolver = SystemResolver{}
+	}
+	if c.TTL == 0 {
+		c.TTL = defaultResolverTTL
+	}
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// NewCachingResolver returns a resolver that caches successful lookups,
+// so dial addresses are not resolved anew on every dial.
+func NewCachingResolver(cfg CachingResolverConfig) (*CachingResolver, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &CachingResolver{
+		CachingResolverConfig: cfg,
+		cache:                 make(map[string]resolverCacheEntry),
+	}, nil
+}
+
+// CachingResolver caches the results of another resolver with a TTL.
+type CachingResolver struct {
+	CachingResolverConfig
+
+	sync.Mutex
+	cache map[string]resolverCacheEntry
+}
+
+type resolverCacheEntry struct {
+	addrs   []NetAddr
+	expires time.Time
+}
+
+// Resolve returns cached candidates when present and falls back to the
+// wrapped resolver, caching what it returns.
+func (r *CachingResolver) Resolve(ctx context.Context, addr NetAddr) ([]NetAddr, error) {
+	key := addr.FullAddress()
+	r.Lock()
+	entry, ok := r.cache[key]
+	r.Unlock()
+	if ok && r.Clock.Now().Before(entry.expires) {
+		out := make([]NetAddr, len(entry.addrs))
+		copy(out, entry.addrs)
+		return out, nil
+	}
+	addrs, err := r.Resolver.Resolve(ctx, addr)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	r.Lock()
+	r.cache[key] = resolverCacheEntry{
+		addrs:   addrs,
+		expires: r.Clock.Now().Add(r.ttl(addr)),
+	}
+	r.Unlock()
+	out := make([]NetAddr, len(addrs))
+	copy(out, addrs)
+	return out, nil
+}
+
+// ttl returns the cache TTL for the address
+func (r *CachingResolver) ttl(addr NetAddr) time.Duration {
+	if override, ok := r.TTLOverrides[addr.Addr]; ok {
+		return override
+	}
+	return r.TTL
+}
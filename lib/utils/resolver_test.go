@@ -0,0 +1,97 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	. "gopkg.in/check.v1"
+)
+
+type ResolverSuite struct {
+}
+
+var _ = Suite(&ResolverSuite{})
+
+// countingResolver returns fixed candidates counting the lookups
+type countingResolver struct {
+	addrs   []NetAddr
+	lookups int
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, addr NetAddr) ([]NetAddr, error) {
+	r.lookups++
+	return r.addrs, nil
+}
+
+func (s *ResolverSuite) TestCachingResolver(c *C) {
+	clock := clockwork.NewFakeClock()
+	inner := &countingResolver{
+		addrs: []NetAddr{{Addr: "10.0.0.1:3024", AddrNetwork: "tcp"}},
+	}
+	resolver, err := NewCachingResolver(CachingResolverConfig{
+		Resolver: inner,
+		TTL:      time.Minute,
+		TTLOverrides: map[string]time.Duration{
+			"short.example.com:3024": time.Second,
+		},
+		Clock: clock,
+	})
+	c.Assert(err, IsNil)
+
+	addr := NetAddr{Addr: "proxy.example.com:3024", AddrNetwork: "tcp"}
+	ctx := context.Background()
+
+	// repeated resolutions within the TTL hit the cache
+	for i := 0; i < 3; i++ {
+		addrs, err := resolver.Resolve(ctx, addr)
+		c.Assert(err, IsNil)
+		c.Assert(addrs, DeepEquals, inner.addrs)
+	}
+	c.Assert(inner.lookups, Equals, 1)
+
+	// the entry expires after the TTL
+	clock.Advance(time.Minute + time.Second)
+	_, err = resolver.Resolve(ctx, addr)
+	c.Assert(err, IsNil)
+	c.Assert(inner.lookups, Equals, 2)
+
+	// per-address overrides shorten the TTL
+	short := NetAddr{Addr: "short.example.com:3024", AddrNetwork: "tcp"}
+	_, err = resolver.Resolve(ctx, short)
+	c.Assert(err, IsNil)
+	clock.Advance(2 * time.Second)
+	_, err = resolver.Resolve(ctx, short)
+	c.Assert(err, IsNil)
+	c.Assert(inner.lookups, Equals, 4)
+}
+
+func (s *ResolverSuite) TestSystemResolverLiteralAddrs(c *C) {
+	// literal IP addresses and unix sockets pass through unresolved
+	resolver := SystemResolver{}
+	ctx := context.Background()
+
+	addrs, err := resolver.Resolve(ctx, NetAddr{Addr: "10.5.3.2:3024", AddrNetwork: "tcp"})
+	c.Assert(err, IsNil)
+	c.Assert(addrs, DeepEquals, []NetAddr{{Addr: "10.5.3.2:3024", AddrNetwork: "tcp"}})
+
+	addrs, err = resolver.Resolve(ctx, NetAddr{Addr: "/var/run/teleport.sock", AddrNetwork: "unix"})
+	c.Assert(err, IsNil)
+	c.Assert(addrs, DeepEquals, []NetAddr{{Addr: "/var/run/teleport.sock", AddrNetwork: "unix"}})
+}
@@ -183,7 +183,10 @@ func NewHandler(cfg Config, opts ...HandlerOption) (*RewritingHandler, error) {
 
 	// active sessions handlers
 	h.GET("/webapi/sites/:site/namespaces/:namespace/connect", h.WithClusterAuth(h.siteNodeConnect))       // connect to an active session (via websocket)
-	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionsGet))      // get active list of sessions
+	h.POST("/webapi/sites/:site/namespaces/:namespace/exec", h.WithClusterAuth(h.siteNodeExec))           // run a non-interactive command on nodes
+
+	h.GET("/webapi/sites/:site/namespaces/:namespace/events/subscribe", h.WithClusterAuth(h.siteEventsSubscribe)) // live presence and session notifications (via websocket)
+	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionsGet))            // get active list of sessions
 	h.POST("/webapi/sites/:site/namespaces/:namespace/sessions", h.WithClusterAuth(h.siteSessionGenerate)) // create active session metadata
 	h.GET("/webapi/sites/:site/namespaces/:namespace/sessions/:sid", h.WithClusterAuth(h.siteSessionGet))  // get active session metadata
 
@@ -1087,9 +1090,9 @@ func (h *Handler) createSession(w http.ResponseWriter, r *http.Request, p httpro
 
 	switch cap.GetSecondFactor() {
 	case teleport.OFF:
-		webSession, err = h.auth.AuthWithoutOTP(req.User, req.Pass)
+		webSession, err = h.auth.AuthWithoutOTP(req.User, req.Pass, r.RemoteAddr)
 	case teleport.OTP, teleport.HOTP, teleport.TOTP:
-		webSession, err = h.auth.AuthWithOTP(req.User, req.Pass, req.SecondFactorToken)
+		webSession, err = h.auth.AuthWithOTP(req.User, req.Pass, req.SecondFactorToken, r.RemoteAddr)
 	default:
 		return nil, trace.AccessDenied("unknown second factor type: %q", cap.GetSecondFactor())
 	}
@@ -1314,7 +1317,7 @@ func (h *Handler) createSessionWithU2FSignResponse(w http.ResponseWriter, r *htt
 		return nil, trace.Wrap(err)
 	}
 
-	sess, err := h.auth.AuthWithU2FSignResponse(req.User, &req.U2FSignResponse)
+	sess, err := h.auth.AuthWithU2FSignResponse(req.User, &req.U2FSignResponse, r.RemoteAddr)
 	if err != nil {
 		return nil, trace.AccessDenied("bad auth credentials")
 	}
@@ -1919,13 +1922,13 @@ func (h *Handler) createSSHCert(w http.ResponseWriter, r *http.Request, p httpro
 
 	switch cap.GetSecondFactor() {
 	case teleport.OFF:
-		cert, err = h.auth.GetCertificateWithoutOTP(*req)
+		cert, err = h.auth.GetCertificateWithoutOTP(*req, r.RemoteAddr)
 	case teleport.OTP, teleport.HOTP, teleport.TOTP:
 		// convert legacy requests to new parameter here. remove once migration to TOTP is complete.
 		if req.HOTPToken != "" {
 			req.OTPToken = req.HOTPToken
 		}
-		cert, err = h.auth.GetCertificateWithOTP(*req)
+		cert, err = h.auth.GetCertificateWithOTP(*req, r.RemoteAddr)
 	default:
 		return nil, trace.AccessDenied("unknown second factor type: %q", cap.GetSecondFactor())
 	}
@@ -1953,7 +1956,7 @@ func (h *Handler) createSSHCertWithU2FSignResponse(w http.ResponseWriter, r *htt
 		return nil, trace.Wrap(err)
 	}
 
-	cert, err := h.auth.GetCertificateWithU2F(*req)
+	cert, err := h.auth.GetCertificateWithU2F(*req, r.RemoteAddr)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
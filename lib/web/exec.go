@@ -0,0 +1,217 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gravitational/teleport/lib/client"
+	"github.com/gravitational/teleport/lib/httplib"
+	"github.com/gravitational/teleport/lib/reversetunnel"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+)
+
+// nodeExecRequest describes a request to run a non-interactive
+// command on one or more nodes
+type nodeExecRequest struct {
+	// Server selects a single node to run on (serverId|hostname[:port]),
+	// mutually exclusive with Labels
+	Server string `json:"server_id"`
+	// Labels selects all nodes carrying the labels, the command
+	// runs on every matching node
+	Labels map[string]string `json:"labels"`
+	// Login is the Linux username to run as
+	Login string `json:"login"`
+	// Command is the command to run
+	Command string `json:"command"`
+}
+
+// execFrame is a single frame of the streamed exec response,
+// frames are written as newline-delimited JSON
+type execFrame struct {
+	// Type is one of "stdout", "stderr" or "exit"
+	Type string `json:"type"`
+	// NodeID identifies the node the frame originated from
+	NodeID string `json:"node_id"`
+	// Data carries output bytes for stdout/stderr frames
+	Data []byte `json:"data,omitempty"`
+	// Code carries the command exit code for exit frames
+	Code int `json:"code"`
+}
+
+// siteNodeExec runs a non-interactive command on nodes selected by
+// ID or labels and streams the output back
+//
+// POST /v1/webapi/sites/:site/namespaces/:namespace/exec
+//
+// {"server_id": "uuid", "login": "admin", "command": "uptime"}
+// {"labels": {"role": "db"}, "login": "admin", "command": "uptime"}
+//
+// Successful response is a stream of newline-delimited JSON frames:
+//
+// {"type": "stdout", "node_id": "uuid", "data": "<base64>"}
+// {"type": "exit", "node_id": "uuid", "code": 0}
+//
+func (h *Handler) siteNodeExec(
+	w http.ResponseWriter,
+	r *http.Request,
+	p httprouter.Params,
+	ctx *SessionContext,
+	site reversetunnel.RemoteSite) (interface{}, error) {
+
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+
+	var req *nodeExecRequest
+	if err := httplib.ReadJSON(r, &req); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if req.Login == "" {
+		return nil, trace.BadParameter("missing parameter login")
+	}
+	if req.Command == "" {
+		return nil, trace.BadParameter("missing parameter command")
+	}
+	if (req.Server == "") == (len(req.Labels) == 0) {
+		return nil, trace.BadParameter("exactly one of server_id or labels is required")
+	}
+
+	clt, err := ctx.GetUserClient(site)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	servers, err := clt.GetNodes(namespace, services.SkipValidation())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	targets, err := matchExecTargets(req, servers)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	stream := &execStream{w: w}
+
+	for _, target := range targets {
+		code, err := h.execOnNode(r, ctx, site, namespace, req, target, stream)
+		if err != nil {
+			log.Warnf("[WEB] exec on %v failed: %v", target.GetName(), err)
+			if code == 0 {
+				code = 1
+			}
+		}
+		stream.writeFrame(execFrame{Type: "exit", NodeID: target.GetName(), Code: code})
+	}
+	return nil, nil
+}
+
+// execOnNode runs the command on a single node, streaming output
+// frames as they arrive, and returns the remote exit code
+func (h *Handler) execOnNode(r *http.Request, ctx *SessionContext, site reversetunnel.RemoteSite,
+	namespace string, req *nodeExecRequest, target services.Server, stream *execStream) (int, error) {
+	clientConfig, err := makeTeleportClientConfig(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	clientConfig.HostLogin = req.Login
+	clientConfig.Namespace = namespace
+	clientConfig.SiteName = site.GetName()
+	if err := clientConfig.ParseProxyHost(h.ProxyHostPort()); err != nil {
+		return 0, trace.Wrap(err)
+	}
+	clientConfig.Host = target.GetHostname()
+	clientConfig.Stdout = stream.writer("stdout", target.GetName())
+	clientConfig.Stderr = stream.writer("stderr", target.GetName())
+	clientConfig.ClientAddr = r.RemoteAddr
+
+	tc, err := client.NewClient(clientConfig)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	err = tc.SSH(r.Context(), []string{req.Command}, false)
+	return tc.ExitStatus, trace.Wrap(err)
+}
+
+// matchExecTargets returns the nodes the request selects, requests
+// by server ID resolve to exactly one node
+func matchExecTargets(req *nodeExecRequest, servers []services.Server) ([]services.Server, error) {
+	if req.Server != "" {
+		for _, server := range servers {
+			if server.GetName() == req.Server || server.GetHostname() == req.Server {
+				return []services.Server{server}, nil
+			}
+		}
+		return nil, trace.NotFound("node %q not found", req.Server)
+	}
+	var matched []services.Server
+	for _, server := range servers {
+		if server.MatchAgainst(req.Labels) {
+			matched = append(matched, server)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, trace.NotFound("no nodes match labels %v", req.Labels)
+	}
+	return matched, nil
+}
+
+// execStream serializes frames from concurrent writers onto
+// a single chunked HTTP response
+type execStream struct {
+	sync.Mutex
+	w http.ResponseWriter
+}
+
+func (s *execStream) writeFrame(frame execFrame) {
+	s.Lock()
+	defer s.Unlock()
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return
+	}
+	s.w.Write(append(data, '\n'))
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// writer returns an io.Writer emitting frames of the given type
+func (s *execStream) writer(frameType, nodeID string) *execFrameWriter {
+	return &execFrameWriter{stream: s, frameType: frameType, nodeID: nodeID}
+}
+
+type execFrameWriter struct {
+	stream    *execStream
+	frameType string
+	nodeID    string
+}
+
+func (w *execFrameWriter) Write(data []byte) (int, error) {
+	buf := make([]byte, len(data))
+	copy(buf, data)
+	w.stream.writeFrame(execFrame{Type: w.frameType, NodeID: w.nodeID, Data: buf})
+	return len(data), nil
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2019 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package web
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/reversetunnel"
+	"github.com/gravitational/teleport/lib/services"
+	"github.com/gravitational/teleport/lib/session"
+	"github.com/gravitational/teleport/lib/web/ui"
+
+	"github.com/gravitational/trace"
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// nodeAddedFrame is pushed when a node joins the cluster
+	nodeAddedFrame = "node.added"
+	// nodeUpdatedFrame is pushed when a known node changes
+	nodeUpdatedFrame = "node.updated"
+	// nodeRemovedFrame is pushed when a node leaves the cluster
+	nodeRemovedFrame = "node.removed"
+	// sessionStartedFrame is pushed when an interactive session starts
+	sessionStartedFrame = "session.started"
+	// sessionEndedFrame is pushed when an interactive session ends
+	sessionEndedFrame = "session.ended"
+)
+
+// notificationFrame is a single JSON message pushed over the
+// notification channel
+type notificationFrame struct {
+	// Type is one of the frame type constants
+	Type string `json:"type"`
+	// Node is the affected node, set for node frames except removals
+	Node *ui.Server `json:"node,omitempty"`
+	// NodeID is the name of the affected node
+	NodeID string `json:"node_id,omitempty"`
+	// Session is the affected session, set for session.started frames
+	Session *session.Session `json:"session,omitempty"`
+	// SessionID is the ID of the affected session
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// siteEventsSubscribe opens a websocket that pushes presence and session
+// notifications to the web UI, so the nodes and sessions pages can update
+// without polling. Events are scoped by the RBAC of the logged in user:
+// the node list and session list are fetched with the user's identity.
+//
+// GET /v1/webapi/sites/:site/namespaces/:namespace/events/subscribe?access_token=bearer_token
+//
+// Successful response is a websocket stream of JSON frames, e.g.
+// {"type": "node.added", "node": {...}}
+func (h *Handler) siteEventsSubscribe(w http.ResponseWriter, r *http.Request, p httprouter.Params, ctx *SessionContext, site reversetunnel.RemoteSite) (interface{}, error) {
+	namespace := p.ByName("namespace")
+	if !services.IsValidNamespace(namespace) {
+		return nil, trace.BadParameter("invalid namespace %q", namespace)
+	}
+
+	clt, err := ctx.GetUserClient(site)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	notifier := &eventsNotifier{
+		sctx:        ctx,
+		clt:         clt,
+		namespace:   namespace,
+		clusterName: site.GetName(),
+	}
+	notifier.Serve(w, r)
+	return nil, nil
+}
+
+// eventsNotifier pushes presence and session notifications to a single
+// websocket connection
+type eventsNotifier struct {
+	// sctx is the web session context of the logged in user
+	sctx *SessionContext
+	// clt is the auth client bound to the identity of the logged in user
+	clt auth.ClientI
+	// namespace is the namespace notifications are scoped to
+	namespace string
+	// clusterName is the name of the cluster being watched
+	clusterName string
+	// ws is the websocket connection to the web UI
+	ws *websocket.Conn
+}
+
+// Serve upgrades the request to a websocket and starts pushing
+// notifications.
+func (n *eventsNotifier) Serve(w http.ResponseWriter, r *http.Request) {
+	// This allows closing of the websocket if the user logs out before
+	// closing the page, same as the terminal handler.
+	n.sctx.AddClosers(n)
+	defer n.sctx.RemoveCloser(n)
+
+	// an empty origin checker is set for the same reason as in the
+	// terminal handler: the bearer token in the URL is required, so the
+	// browser same-origin policy does not matter here.
+	server := &websocket.Server{Handler: n.handler}
+	server.ServeHTTP(w, r)
+}
+
+// Close closes the websocket connection.
+func (n *eventsNotifier) Close() error {
+	if n.ws != nil {
+		n.ws.Close()
+	}
+	return nil
+}
+
+// handler is the main push loop. Node changes are detected through a
+// watcher established with the user's identity and re-fetched through the
+// same identity, so label-based RBAC filtering applies. Session changes
+// are detected by diffing the active session list.
+func (n *eventsNotifier) handler(ws *websocket.Conn) {
+	n.ws = ws
+	defer ws.Close()
+
+	watcher, err := n.clt.NewWatcher(context.TODO(), services.Watch{
+		Name:  "web-notifications",
+		Kinds: []services.WatchKind{{Kind: services.KindNode}},
+	})
+	if err != nil {
+		log.Warningf("Failed to subscribe to node events: %v.", err)
+		return
+	}
+	defer watcher.Close()
+
+	// seed the initial state without pushing frames, the UI fetches
+	// the initial lists over the regular API
+	knownNodes := make(map[string]ui.Server)
+	if err := n.refreshNodes(knownNodes, true); err != nil {
+		log.Warningf("Failed to fetch nodes: %v.", err)
+		return
+	}
+	knownSessions := make(map[session.ID]session.Session)
+	if err := n.refreshSessions(knownSessions, true); err != nil {
+		log.Warningf("Failed to fetch sessions: %v.", err)
+		return
+	}
+
+	// node events only mark the node list as stale, the refresh happens
+	// on the ticker so a burst of heartbeats does not translate into a
+	// burst of list fetches
+	nodesStale := false
+	ticker := time.NewTicker(defaults.SessionRefreshPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-watcher.Done():
+			return
+		case event := <-watcher.Events():
+			if event.Type == backend.OpInit {
+				continue
+			}
+			nodesStale = true
+		case <-ticker.C:
+			if nodesStale {
+				if err := n.refreshNodes(knownNodes, false); err != nil {
+					log.Warningf("Failed to refresh nodes: %v.", err)
+					return
+				}
+				nodesStale = false
+			}
+			if err := n.refreshSessions(knownSessions, false); err != nil {
+				log.Warningf("Failed to refresh sessions: %v.", err)
+				return
+			}
+		}
+	}
+}
+
+// refreshNodes fetches the node list with the user's identity, diffs it
+// against the known state and pushes frames for the changes.
+func (n *eventsNotifier) refreshNodes(known map[string]ui.Server, seed bool) error {
+	nodes, err := n.clt.GetNodes(n.namespace, services.SkipValidation())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	current := make(map[string]ui.Server, len(nodes))
+	for _, server := range ui.MakeServers(n.clusterName, nodes) {
+		current[server.Name] = server
+	}
+	if !seed {
+		for name, server := range current {
+			server := server
+			previous, ok := known[name]
+			switch {
+			case !ok:
+				if err := n.send(notificationFrame{Type: nodeAddedFrame, Node: &server, NodeID: name}); err != nil {
+					return trace.Wrap(err)
+				}
+			case !reflect.DeepEqual(previous, server):
+				if err := n.send(notificationFrame{Type: nodeUpdatedFrame, Node: &server, NodeID: name}); err != nil {
+					return trace.Wrap(err)
+				}
+			}
+		}
+		for name := range known {
+			if _, ok := current[name]; !ok {
+				if err := n.send(notificationFrame{Type: nodeRemovedFrame, NodeID: name}); err != nil {
+					return trace.Wrap(err)
+				}
+			}
+		}
+	}
+	for name := range known {
+		delete(known, name)
+	}
+	for name, server := range current {
+		known[name] = server
+	}
+	return nil
+}
+
+// refreshSessions fetches the active session list with the user's
+// identity, diffs it against the known state and pushes frames for
+// started and ended sessions.
+func (n *eventsNotifier) refreshSessions(known map[session.ID]session.Session, seed bool) error {
+	sessions, err := n.clt.GetSessions(n.namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	current := make(map[session.ID]session.Session, len(sessions))
+	for i := range sessions {
+		current[sessions[i].ID] = sessions[i]
+	}
+	if !seed {
+		for id, sess := range current {
+			sess := sess
+			if _, ok := known[id]; !ok {
+				if err := n.send(notificationFrame{Type: sessionStartedFrame, Session: &sess, SessionID: string(id)}); err != nil {
+					return trace.Wrap(err)
+				}
+			}
+		}
+		for id := range known {
+			if _, ok := current[id]; !ok {
+				if err := n.send(notificationFrame{Type: sessionEndedFrame, SessionID: string(id)}); err != nil {
+					return trace.Wrap(err)
+				}
+			}
+		}
+	}
+	for id := range known {
+		delete(known, id)
+	}
+	for id, sess := range current {
+		known[id] = sess
+	}
+	return nil
+}
+
+// send pushes a single frame to the web UI.
+func (n *eventsNotifier) send(frame notificationFrame) error {
+	return trace.Wrap(websocket.JSON.Send(n.ws, frame))
+}
@@ -394,22 +394,24 @@ func (s *sessionCache) clearExpiredSessions() {
 	}
 }
 
-func (s *sessionCache) AuthWithOTP(user, pass string, otpToken string) (services.WebSession, error) {
+func (s *sessionCache) AuthWithOTP(user, pass string, otpToken string, clientAddr string) (services.WebSession, error) {
 	return s.proxyClient.AuthenticateWebUser(auth.AuthenticateUserRequest{
 		Username: user,
 		OTP: &auth.OTPCreds{
 			Password: []byte(pass),
 			Token:    otpToken,
 		},
+		ClientAddr: clientAddr,
 	})
 }
 
-func (s *sessionCache) AuthWithoutOTP(user, pass string) (services.WebSession, error) {
+func (s *sessionCache) AuthWithoutOTP(user, pass string, clientAddr string) (services.WebSession, error) {
 	return s.proxyClient.AuthenticateWebUser(auth.AuthenticateUserRequest{
 		Username: user,
 		Pass: &auth.PassCreds{
 			Password: []byte(pass),
 		},
+		ClientAddr: clientAddr,
 	})
 }
 
@@ -417,32 +419,35 @@ func (s *sessionCache) GetU2FSignRequest(user, pass string) (*u2f.SignRequest, e
 	return s.proxyClient.GetU2FSignRequest(user, []byte(pass))
 }
 
-func (s *sessionCache) AuthWithU2FSignResponse(user string, response *u2f.SignResponse) (services.WebSession, error) {
+func (s *sessionCache) AuthWithU2FSignResponse(user string, response *u2f.SignResponse, clientAddr string) (services.WebSession, error) {
 	return s.proxyClient.AuthenticateWebUser(auth.AuthenticateUserRequest{
 		Username: user,
 		U2F: &auth.U2FSignResponseCreds{
 			SignResponse: *response,
 		},
+		ClientAddr: clientAddr,
 	})
 }
 
-func (s *sessionCache) GetCertificateWithoutOTP(c client.CreateSSHCertReq) (*auth.SSHLoginResponse, error) {
+func (s *sessionCache) GetCertificateWithoutOTP(c client.CreateSSHCertReq, clientAddr string) (*auth.SSHLoginResponse, error) {
 	return s.proxyClient.AuthenticateSSHUser(auth.AuthenticateSSHRequest{
 		AuthenticateUserRequest: auth.AuthenticateUserRequest{
 			Username: c.User,
 			Pass: &auth.PassCreds{
 				Password: []byte(c.Password),
 			},
+			ClientAddr: clientAddr,
 		},
 		PublicKey:         c.PubKey,
 		CompatibilityMode: c.Compatibility,
 		TTL:               c.TTL,
 		RouteToCluster:    c.RouteToCluster,
 		KubernetesCluster: c.KubernetesCluster,
+		ClientVersion:     c.ClientVersion,
 	})
 }
 
-func (s *sessionCache) GetCertificateWithOTP(c client.CreateSSHCertReq) (*auth.SSHLoginResponse, error) {
+func (s *sessionCache) GetCertificateWithOTP(c client.CreateSSHCertReq, clientAddr string) (*auth.SSHLoginResponse, error) {
 	return s.proxyClient.AuthenticateSSHUser(auth.AuthenticateSSHRequest{
 		AuthenticateUserRequest: auth.AuthenticateUserRequest{
 			Username: c.User,
@@ -450,29 +455,33 @@ func (s *sessionCache) GetCertificateWithOTP(c client.CreateSSHCertReq) (*auth.S
 				Password: []byte(c.Password),
 				Token:    c.OTPToken,
 			},
+			ClientAddr: clientAddr,
 		},
 		PublicKey:         c.PubKey,
 		CompatibilityMode: c.Compatibility,
 		TTL:               c.TTL,
 		RouteToCluster:    c.RouteToCluster,
 		KubernetesCluster: c.KubernetesCluster,
+		ClientVersion:     c.ClientVersion,
 	})
 
 }
 
-func (s *sessionCache) GetCertificateWithU2F(c client.CreateSSHCertWithU2FReq) (*auth.SSHLoginResponse, error) {
+func (s *sessionCache) GetCertificateWithU2F(c client.CreateSSHCertWithU2FReq, clientAddr string) (*auth.SSHLoginResponse, error) {
 	return s.proxyClient.AuthenticateSSHUser(auth.AuthenticateSSHRequest{
 		AuthenticateUserRequest: auth.AuthenticateUserRequest{
 			Username: c.User,
 			U2F: &auth.U2FSignResponseCreds{
 				SignResponse: c.U2FSignResponse,
 			},
+			ClientAddr: clientAddr,
 		},
 		PublicKey:         c.PubKey,
 		CompatibilityMode: c.Compatibility,
 		TTL:               c.TTL,
 		RouteToCluster:    c.RouteToCluster,
 		KubernetesCluster: c.KubernetesCluster,
+		ClientVersion:     c.ClientVersion,
 	})
 }
 
@@ -44,6 +44,15 @@ type Server struct {
 	Addr string `json:"addr"`
 	// Labels is this server list of labels
 	Labels []Label `json:"tags"`
+	// CPUPercent is the CPU utilization gauge optionally reported by this
+	// server, 0 when reporting is disabled
+	CPUPercent int32 `json:"cpuPercent,omitempty"`
+	// MemoryPercent is the memory utilization gauge optionally reported by
+	// this server, 0 when reporting is disabled
+	MemoryPercent int32 `json:"memoryPercent,omitempty"`
+	// DiskPercent is the disk utilization gauge optionally reported by this
+	// server, 0 when reporting is disabled
+	DiskPercent int32 `json:"diskPercent,omitempty"`
 }
 
 // sortedLabels is a sort wrapper that sorts labels by name
@@ -85,12 +94,15 @@ func MakeServers(clusterName string, servers []services.Server) []Server {
 		sort.Sort(sortedLabels(uiLabels))
 
 		uiServers = append(uiServers, Server{
-			ClusterName: clusterName,
-			Labels:      uiLabels,
-			Name:        server.GetName(),
-			Hostname:    server.GetHostname(),
-			Addr:        server.GetAddr(),
-			Tunnel:      server.GetUseTunnel(),
+			ClusterName:   clusterName,
+			Labels:        uiLabels,
+			Name:          server.GetName(),
+			Hostname:      server.GetHostname(),
+			Addr:          server.GetAddr(),
+			Tunnel:        server.GetUseTunnel(),
+			CPUPercent:    server.GetCPUPercent(),
+			MemoryPercent: server.GetMemoryPercent(),
+			DiskPercent:   server.GetDiskPercent(),
 		})
 	}
 
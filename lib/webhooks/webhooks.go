@@ -0,0 +1,427 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhooks delivers node lifecycle notifications to external
+// HTTP endpoints. The auth server runs a notifier that watches node
+// resources and fires a webhook when a node registers for the first
+// time, misses heartbeats past a threshold or is deleted, enabling
+// CMDB sync and alerting integrations.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// NodeJoined fires when a node registers for the first time
+	NodeJoined = "node.joined"
+	// NodeExpired fires when a node misses heartbeats past the
+	// expiry threshold
+	NodeExpired = "node.expired"
+	// NodeDeleted fires when a node is deleted from the backend
+	NodeDeleted = "node.deleted"
+	// CertExpiring fires when a stored certificate is approaching
+	// its expiry date
+	CertExpiring = "cert.expiring"
+
+	// deliveryQueueSize is the number of notifications buffered for
+	// delivery before new notifications are dropped, delivery must
+	// never block the watch loop
+	deliveryQueueSize = 256
+
+	// deliveryTimeout is the timeout of a single webhook delivery
+	deliveryTimeout = 5 * time.Second
+)
+
+// Hook is a single webhook endpoint
+type Hook struct {
+	// URL is the endpoint notifications are posted to
+	URL string `json:"url" yaml:"url"`
+	// Events is an optional list of event names to deliver,
+	// all events are delivered when empty
+	Events []string `json:"events,omitempty" yaml:"events,omitempty"`
+}
+
+// NotifierClient is the subset of the auth API the notifier uses
+type NotifierClient interface {
+	services.Events
+	// GetNodes returns a list of registered nodes
+	GetNodes(namespace string, opts ...services.MarshalOption) ([]services.Server, error)
+}
+
+// NotifierConfig configures a node lifecycle notifier
+type NotifierConfig struct {
+	// Context is the parent context controlling the notifier lifecycle
+	Context context.Context
+	// Client is used to list nodes and subscribe to updates
+	Client NotifierClient
+	// Hooks are the endpoints notifications are delivered to
+	Hooks []Hook
+	// ExpiryThreshold is how long a node may go without a heartbeat
+	// before NodeExpired fires, defaults to the server announce TTL
+	ExpiryThreshold time.Duration
+	// HTTPClient is an optional HTTP client used for deliveries
+	HTTPClient *http.Client
+	// Clock is an optional clock override used in tests
+	Clock clockwork.Clock
+	// Entry is a logging entry
+	Entry log.FieldLogger
+}
+
+// CheckAndSetDefaults checks and sets default values
+func (cfg *NotifierConfig) CheckAndSetDefaults() error {
+	if cfg.Context == nil {
+		return trace.BadParameter("missing parameter Context")
+	}
+	if cfg.Client == nil {
+		return trace.BadParameter("missing parameter Client")
+	}
+	if len(cfg.Hooks) == 0 {
+		return trace.BadParameter("missing parameter Hooks")
+	}
+	for _, hook := range cfg.Hooks {
+		if hook.URL == "" {
+			return trace.BadParameter("missing webhook url")
+		}
+		for _, event := range hook.Events {
+			switch event {
+			case NodeJoined, NodeExpired, NodeDeleted, CertExpiring:
+			default:
+				return trace.BadParameter("unsupported webhook event %q", event)
+			}
+		}
+	}
+	if cfg.ExpiryThreshold == 0 {
+		cfg.ExpiryThreshold = defaults.ServerAnnounceTTL
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: deliveryTimeout}
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	if cfg.Entry == nil {
+		cfg.Entry = log.WithFields(log.Fields{
+			trace.Component: teleport.ComponentAuth,
+		})
+	}
+	return nil
+}
+
+// notification is the JSON payload posted to webhook endpoints
+type notification struct {
+	// Event is one of NodeJoined, NodeExpired or NodeDeleted
+	Event string `json:"event"`
+	// Time is when the event was observed
+	Time time.Time `json:"time"`
+	// Server is the affected node, for deletions only the
+	// resource name may be known
+	Server json.RawMessage `json:"server,omitempty"`
+	// ServerID is the name of the affected node
+	ServerID string `json:"server_id,omitempty"`
+	// Cert describes the certificate approaching expiry, set for
+	// cert.expiring notifications only
+	Cert *certInfo `json:"cert,omitempty"`
+}
+
+// certInfo describes a certificate approaching expiry
+type certInfo struct {
+	// Type is the cert authority type, e.g. "host" or "user"
+	Type string `json:"type"`
+	// Cluster is the name of the cluster the cert authority belongs to
+	Cluster string `json:"cluster"`
+	// NotAfter is when the soonest certificate of the authority expires
+	NotAfter time.Time `json:"not_after"`
+}
+
+// nodeState tracks a known node between heartbeats
+type nodeState struct {
+	lastSeen time.Time
+	expired  bool
+}
+
+// Notifier watches node resources and posts lifecycle notifications
+// to the configured webhook endpoints
+type Notifier struct {
+	NotifierConfig
+	log.FieldLogger
+
+	mu    sync.Mutex
+	nodes map[string]*nodeState
+
+	queueC chan notification
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewNotifier starts a node lifecycle notifier, it runs until
+// Close is called or the parent context is canceled
+func NewNotifier(cfg NotifierConfig) (*Notifier, error) {
+	if err := cfg.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	ctx, cancel := context.WithCancel(cfg.Context)
+	n := &Notifier{
+		NotifierConfig: cfg,
+		FieldLogger:    cfg.Entry,
+		nodes:          make(map[string]*nodeState),
+		queueC:         make(chan notification, deliveryQueueSize),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+	go n.watchNodes()
+	go n.checkExpiry()
+	go n.forward()
+	return n, nil
+}
+
+// Close stops the notifier
+func (n *Notifier) Close() error {
+	n.cancel()
+	return nil
+}
+
+// watchNodes re-establishes the node watch until the notifier closes
+func (n *Notifier) watchNodes() {
+	for {
+		if err := n.watch(); err != nil {
+			n.Warningf("Restarting node webhook watcher on error: %v.", trace.Unwrap(err))
+		}
+		select {
+		case <-time.After(defaults.HighResPollingPeriod):
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+// watch subscribes to node events, an initial node list seeds the
+// known set so nodes present before the notifier started do not
+// produce joined notifications
+func (n *Notifier) watch() error {
+	watcher, err := n.Client.NewWatcher(n.ctx, services.Watch{
+		Name: teleport.ComponentAuth,
+		Kinds: []services.WatchKind{
+			{
+				Kind: services.KindNode,
+			},
+		},
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer watcher.Close()
+	select {
+	case <-watcher.Done():
+		return trace.ConnectionProblem(watcher.Error(), "watcher is closed")
+	case <-n.ctx.Done():
+		return nil
+	case event := <-watcher.Events():
+		if event.Type != backend.OpInit {
+			return trace.BadParameter("expected init event, got %v instead", event.Type)
+		}
+	}
+	nodes, err := n.Client.GetNodes(defaults.Namespace, services.SkipValidation())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	n.seed(nodes)
+	for {
+		select {
+		case <-watcher.Done():
+			return trace.ConnectionProblem(watcher.Error(), "watcher is closed")
+		case <-n.ctx.Done():
+			return nil
+		case event := <-watcher.Events():
+			n.processEvent(event)
+		}
+	}
+}
+
+// seed registers already present nodes without notifications
+func (n *Notifier) seed(nodes []services.Server) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := n.Clock.Now()
+	for _, node := range nodes {
+		if _, ok := n.nodes[node.GetName()]; !ok {
+			n.nodes[node.GetName()] = &nodeState{lastSeen: now}
+		}
+	}
+}
+
+// processEvent updates node state and queues notifications
+func (n *Notifier) processEvent(event services.Event) {
+	name := event.Resource.GetName()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch event.Type {
+	case backend.OpPut:
+		state, known := n.nodes[name]
+		if !known {
+			n.nodes[name] = &nodeState{lastSeen: n.Clock.Now()}
+			n.notify(NodeJoined, event.Resource)
+			return
+		}
+		state.lastSeen = n.Clock.Now()
+		state.expired = false
+	case backend.OpDelete:
+		delete(n.nodes, name)
+		n.notify(NodeDeleted, event.Resource)
+	}
+}
+
+// checkExpiry periodically sweeps the known nodes for missed heartbeats
+func (n *Notifier) checkExpiry() {
+	ticker := time.NewTicker(defaults.HeartbeatCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n.expireSilentNodes()
+	}
+}
+
+// expireSilentNodes fires NodeExpired for nodes that went silent past
+// the threshold, a node recovers by heartbeating again
+func (n *Notifier) expireSilentNodes() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := n.Clock.Now()
+	for name, state := range n.nodes {
+		if !state.expired && now.Sub(state.lastSeen) > n.ExpiryThreshold {
+			state.expired = true
+			n.notify(NodeExpired, &services.ResourceHeader{
+				Metadata: services.Metadata{
+					Name: name,
+				},
+			})
+		}
+	}
+}
+
+// notify queues a notification for delivery, the caller holds the mutex
+func (n *Notifier) notify(event string, resource services.Resource) {
+	note := notification{
+		Event:    event,
+		Time:     n.Clock.Now().UTC(),
+		ServerID: resource.GetName(),
+	}
+	if server, ok := resource.(services.Server); ok {
+		data, err := services.GetServerMarshaler().MarshalServer(server)
+		if err != nil {
+			n.Warningf("Failed to marshal server %v: %v.", server.GetName(), err)
+		} else {
+			note.Server = data
+		}
+	}
+	select {
+	case n.queueC <- note:
+	default:
+		n.Warningf("Webhook queue is full, dropping %v notification for %v.", event, resource.GetName())
+	}
+}
+
+// NotifyCertExpiry posts a cert.expiring notification for a certificate
+// authority whose TLS certificate is approaching expiry.
+func (n *Notifier) NotifyCertExpiry(certType, cluster string, notAfter time.Time) {
+	note := notification{
+		Event: CertExpiring,
+		Time:  n.Clock.Now().UTC(),
+		Cert: &certInfo{
+			Type:     certType,
+			Cluster:  cluster,
+			NotAfter: notAfter,
+		},
+	}
+	select {
+	case n.queueC <- note:
+	default:
+		n.Warningf("Webhook queue is full, dropping %v notification for %v CA %q.", CertExpiring, certType, cluster)
+	}
+}
+
+// forward delivers queued notifications to the matching hooks
+func (n *Notifier) forward() {
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case note := <-n.queueC:
+			for _, hook := range n.Hooks {
+				if !hookMatches(hook, note.Event) {
+					continue
+				}
+				if err := n.deliver(hook, note); err != nil {
+					n.Warningf("Failed to deliver %v notification to %v: %v.", note.Event, hook.URL, err)
+				}
+			}
+		}
+	}
+}
+
+// deliver posts a single notification to a single endpoint
+func (n *Notifier) deliver(hook Hook, note notification) error {
+	body, err := json.Marshal(note)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req = req.WithContext(n.ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.HTTPClient.Do(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return trace.BadParameter("webhook returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// hookMatches returns true if the hook subscribes to the event
+func hookMatches(hook Hook, event string) bool {
+	if len(hook.Events) == 0 {
+		return true
+	}
+	for _, e := range hook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
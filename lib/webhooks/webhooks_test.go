@@ -0,0 +1,207 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWatcher is a services.Watcher fed by the test
+type fakeWatcher struct {
+	eventsC chan services.Event
+	doneC   chan struct{}
+}
+
+func (w *fakeWatcher) Events() <-chan services.Event {
+	return w.eventsC
+}
+
+func (w *fakeWatcher) Done() <-chan struct{} {
+	return w.doneC
+}
+
+func (w *fakeWatcher) Close() error {
+	return nil
+}
+
+func (w *fakeWatcher) Error() error {
+	return nil
+}
+
+// fakeClient implements NotifierClient against in-memory state
+type fakeClient struct {
+	nodes   []services.Server
+	watcher *fakeWatcher
+}
+
+func (c *fakeClient) NewWatcher(ctx context.Context, watch services.Watch) (services.Watcher, error) {
+	return c.watcher, nil
+}
+
+func (c *fakeClient) GetNodes(namespace string, opts ...services.MarshalOption) ([]services.Server, error) {
+	return c.nodes, nil
+}
+
+func newNode(name string) services.Server {
+	return &services.ServerV2{
+		Kind:    services.KindNode,
+		Version: services.V2,
+		Metadata: services.Metadata{
+			Name:      name,
+			Namespace: defaults.Namespace,
+		},
+	}
+}
+
+// testSetup wires a notifier to a fake client and an httptest endpoint,
+// notifications received by the endpoint are sent to noteC
+func testSetup(t *testing.T, clock clockwork.Clock, seed ...services.Server) (*fakeClient, *Notifier, chan notification) {
+	noteC := make(chan notification, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var note notification
+		require.NoError(t, json.Unmarshal(body, &note))
+		noteC <- note
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &fakeClient{
+		nodes: seed,
+		watcher: &fakeWatcher{
+			eventsC: make(chan services.Event, 8),
+			doneC:   make(chan struct{}),
+		},
+	}
+	notifier, err := NewNotifier(NotifierConfig{
+		Context: context.Background(),
+		Client:  client,
+		Hooks:   []Hook{{URL: srv.URL}},
+		Clock:   clock,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { notifier.Close() })
+
+	// the watch loop seeds the known set only after the init event
+	client.watcher.eventsC <- services.Event{Type: backend.OpInit}
+	return client, notifier, noteC
+}
+
+// waitForNote waits for a single notification to arrive at the endpoint
+func waitForNote(t *testing.T, noteC chan notification) notification {
+	select {
+	case note := <-noteC:
+		return note
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for webhook notification")
+		return notification{}
+	}
+}
+
+// expectNoNote makes sure no notification arrives at the endpoint
+func expectNoNote(t *testing.T, noteC chan notification) {
+	select {
+	case note := <-noteC:
+		t.Fatalf("unexpected %v notification for %v", note.Event, note.ServerID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestNodeLifecycle verifies that joined and deleted nodes produce
+// notifications while heartbeats of known nodes do not.
+func TestNodeLifecycle(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	client, _, noteC := testSetup(t, clock, newNode("existing"))
+
+	// a node the notifier has not seen before joins
+	client.watcher.eventsC <- services.Event{Type: backend.OpPut, Resource: newNode("new-node")}
+	note := waitForNote(t, noteC)
+	require.Equal(t, NodeJoined, note.Event)
+	require.Equal(t, "new-node", note.ServerID)
+	require.NotEmpty(t, note.Server)
+
+	// a heartbeat of a known node is not a join
+	client.watcher.eventsC <- services.Event{Type: backend.OpPut, Resource: newNode("new-node")}
+	expectNoNote(t, noteC)
+
+	// deletions always notify
+	client.watcher.eventsC <- services.Event{Type: backend.OpDelete, Resource: newNode("existing")}
+	note = waitForNote(t, noteC)
+	require.Equal(t, NodeDeleted, note.Event)
+	require.Equal(t, "existing", note.ServerID)
+}
+
+// TestSeedDoesNotNotify verifies that nodes present when the notifier
+// starts are seeded silently instead of reported as joined.
+func TestSeedDoesNotNotify(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	client, _, noteC := testSetup(t, clock, newNode("existing"))
+
+	// a heartbeat of the seeded node must not produce a notification
+	client.watcher.eventsC <- services.Event{Type: backend.OpPut, Resource: newNode("existing")}
+	expectNoNote(t, noteC)
+}
+
+// TestNodeExpiry verifies that nodes that go silent past the threshold
+// are reported as expired exactly once, and recover by heartbeating.
+func TestNodeExpiry(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	client, notifier, noteC := testSetup(t, clock, newNode("existing"))
+
+	// make sure the seed landed before advancing the clock
+	client.watcher.eventsC <- services.Event{Type: backend.OpPut, Resource: newNode("new-node")}
+	note := waitForNote(t, noteC)
+	require.Equal(t, NodeJoined, note.Event)
+
+	// within the threshold nothing expires
+	clock.Advance(notifier.ExpiryThreshold / 2)
+	notifier.expireSilentNodes()
+	expectNoNote(t, noteC)
+
+	// past the threshold both nodes expire, once
+	clock.Advance(notifier.ExpiryThreshold)
+	notifier.expireSilentNodes()
+	expired := map[string]bool{
+		waitForNote(t, noteC).ServerID: true,
+		waitForNote(t, noteC).ServerID: true,
+	}
+	require.Equal(t, map[string]bool{"existing": true, "new-node": true}, expired)
+	notifier.expireSilentNodes()
+	expectNoNote(t, noteC)
+
+	// a new heartbeat recovers the node so it may expire again
+	client.watcher.eventsC <- services.Event{Type: backend.OpPut, Resource: newNode("existing")}
+	expectNoNote(t, noteC)
+	clock.Advance(notifier.ExpiryThreshold * 2)
+	notifier.expireSilentNodes()
+	note = waitForNote(t, noteC)
+	require.Equal(t, NodeExpired, note.Event)
+	require.Equal(t, "existing", note.ServerID)
+}
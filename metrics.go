@@ -31,6 +31,10 @@ const (
 	// MetricGenerateRequestsHistogram measures generate requests latency
 	MetricGenerateRequestsHistogram = "auth_generate_seconds"
 
+	// MetricCertExpirySeconds measures seconds until the soonest stored
+	// certificate expiry per cert authority
+	MetricCertExpirySeconds = "auth_cert_expiry_seconds"
+
 	// MetricServerInteractiveSessions measures interactive sessions in flight
 	MetricServerInteractiveSessions = "server_interactive_sessions_total"
 
@@ -43,6 +47,22 @@ const (
 	// MetricTrustedClusters counts trusted clusters
 	MetricTrustedClusters = "trusted_clusters"
 
+	// MetricReverseTunnelDialSeconds measures latency of dials through
+	// reverse tunnels per remote cluster
+	MetricReverseTunnelDialSeconds = "reversetunnel_dial_seconds"
+
+	// MetricReverseTunnelDialFailures counts failed dials through
+	// reverse tunnels per remote cluster
+	MetricReverseTunnelDialFailures = "reversetunnel_dial_failures_total"
+
+	// MetricReverseTunnelStreams measures concurrent streams multiplexed
+	// over reverse tunnels per remote cluster
+	MetricReverseTunnelStreams = "reversetunnel_streams"
+
+	// MetricReverseTunnelBytes counts bytes copied over reverse tunnel
+	// streams per remote cluster
+	MetricReverseTunnelBytes = "reversetunnel_bytes_total"
+
 	// TagCluster is a metric tag for a cluster
 	TagCluster = "cluster"
 )
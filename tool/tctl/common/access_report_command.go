@@ -0,0 +1,187 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/asciitable"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/trace"
+)
+
+// AccessReportCommand implements the `tctl access-report` command which
+// expands all roles and users into an access matrix (user, node, allowed
+// logins) for compliance evidence. It implements CLICommand interface.
+type AccessReportCommand struct {
+	config *service.Config
+
+	// user optionally limits the report to a single user
+	user string
+	// format is the output format: text, json or csv
+	format string
+
+	accessReport *kingpin.CmdClause
+}
+
+// accessReportEntry is one row of the access matrix: a user allowed to
+// log into a node with a set of logins.
+type accessReportEntry struct {
+	// User is the Teleport user name.
+	User string `json:"user"`
+	// Node is the hostname of the node.
+	Node string `json:"node"`
+	// Labels are the labels of the node.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Logins are the OS logins the user is allowed to use on the node.
+	Logins []string `json:"logins"`
+}
+
+// Initialize allows AccessReportCommand to plug itself into the CLI parser
+func (c *AccessReportCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+	c.accessReport = app.Command("access-report", "Report which users can access which nodes with which logins")
+	c.accessReport.Flag("user", "Only report access of this user").StringVar(&c.user)
+	c.accessReport.Flag("format", fmt.Sprintf("Output format: %q, %q or %q", teleport.Text, teleport.JSON, "csv")).Default(teleport.Text).StringVar(&c.format)
+}
+
+// TryRun takes the CLI command as an argument (like "access-report") and executes it.
+func (c *AccessReportCommand) TryRun(cmd string, client auth.ClientI) (match bool, err error) {
+	switch cmd {
+	case c.accessReport.FullCommand():
+		err = c.GenerateReport(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// GenerateReport fetches all users, roles and nodes and prints the access
+// matrix in the requested format.
+func (c *AccessReportCommand) GenerateReport(client auth.ClientI) error {
+	users, err := client.GetUsers(false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	nodes, err := client.GetNodes(defaults.Namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var entries []accessReportEntry
+	for _, user := range users {
+		if c.user != "" && user.GetName() != c.user {
+			continue
+		}
+		userEntries, err := reportForUser(client, user, nodes)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		entries = append(entries, userEntries...)
+	}
+	switch c.format {
+	case teleport.Text:
+		t := asciitable.MakeTable([]string{"User", "Node", "Labels", "Logins"})
+		for _, entry := range entries {
+			t.AddRow([]string{entry.User, entry.Node, labelsString(entry.Labels), strings.Join(entry.Logins, ",")})
+		}
+		_, err = t.AsBuffer().WriteTo(os.Stdout)
+		return trace.Wrap(err)
+	case teleport.JSON:
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(out))
+		return nil
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write([]string{"user", "node", "labels", "logins"}); err != nil {
+			return trace.Wrap(err)
+		}
+		for _, entry := range entries {
+			if err := w.Write([]string{entry.User, entry.Node, labelsString(entry.Labels), strings.Join(entry.Logins, ",")}); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		w.Flush()
+		return trace.Wrap(w.Error())
+	default:
+		return trace.BadParameter("unsupported format %q", c.format)
+	}
+}
+
+// reportForUser expands the roles of a single user against all nodes and
+// returns the nodes the user can log into along with the allowed logins.
+func reportForUser(client auth.ClientI, user services.User, nodes []services.Server) ([]accessReportEntry, error) {
+	roles, err := services.FetchRoles(user.GetRoles(), client, user.GetTraits())
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	// collect the candidate logins granted by any of the user's roles,
+	// access to each node is then checked per login
+	loginSet := make(map[string]bool)
+	for _, role := range roles {
+		for _, login := range role.GetLogins(services.Allow) {
+			loginSet[login] = true
+		}
+	}
+	logins := make([]string, 0, len(loginSet))
+	for login := range loginSet {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+	var entries []accessReportEntry
+	for _, node := range nodes {
+		var allowed []string
+		for _, login := range logins {
+			if roles.CheckAccessToServer(login, node) == nil {
+				allowed = append(allowed, login)
+			}
+		}
+		if len(allowed) == 0 {
+			continue
+		}
+		entries = append(entries, accessReportEntry{
+			User:   user.GetName(),
+			Node:   node.GetHostname(),
+			Labels: node.GetAllLabels(),
+			Logins: allowed,
+		})
+	}
+	return entries, nil
+}
+
+// labelsString formats a label map as a sorted comma separated string.
+func labelsString(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", key, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
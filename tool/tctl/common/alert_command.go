@@ -0,0 +1,130 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gravitational/teleport/lib/asciitable"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/trace"
+)
+
+// AlertCommand implements the `tctl alerts` group of commands to manage
+// cluster notices stored in the backend.
+type AlertCommand struct {
+	config *service.Config
+
+	// name is the name of the alert to create or remove
+	name string
+	// message is the alert text
+	message string
+	// severity is one of "info", "warning" or "error"
+	severity string
+	// ttl is an optional duration after which the alert expires
+	ttl time.Duration
+
+	// CLI subcommands (clauses)
+	alertAdd  *kingpin.CmdClause
+	alertList *kingpin.CmdClause
+	alertRm   *kingpin.CmdClause
+}
+
+// Initialize allows AlertCommand to plug itself into the CLI parser
+func (c *AlertCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	alerts := app.Command("alerts", "Manage cluster alerts shown to users")
+
+	c.alertAdd = alerts.Command("add", "Create or update a cluster alert")
+	c.alertAdd.Arg("name", "Name of the alert").Required().StringVar(&c.name)
+	c.alertAdd.Arg("message", "Alert message").Required().StringVar(&c.message)
+	c.alertAdd.Flag("severity", "Alert severity, one of 'info', 'warning' or 'error'").Default(auth.NoticeSeverityInfo).StringVar(&c.severity)
+	c.alertAdd.Flag("ttl", "Time to live after which the alert expires, valid forever if not set").DurationVar(&c.ttl)
+
+	c.alertList = alerts.Command("ls", "List cluster alerts")
+
+	c.alertRm = alerts.Command("rm", "Delete a cluster alert").Alias("del")
+	c.alertRm.Arg("name", "Name of the alert to delete").Required().StringVar(&c.name)
+}
+
+// TryRun takes the CLI command as an argument (like "alerts ls") and executes it.
+func (c *AlertCommand) TryRun(cmd string, client auth.ClientI) (match bool, err error) {
+	switch cmd {
+	case c.alertAdd.FullCommand():
+		err = c.Add(client)
+	case c.alertList.FullCommand():
+		err = c.List(client)
+	case c.alertRm.FullCommand():
+		err = c.Remove(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Add creates or updates a cluster alert.
+func (c *AlertCommand) Add(client auth.ClientI) error {
+	notice := auth.ClusterNotice{
+		Name:     c.name,
+		Message:  c.message,
+		Severity: c.severity,
+	}
+	if c.ttl > 0 {
+		notice.Expires = time.Now().UTC().Add(c.ttl)
+	}
+	if err := client.UpsertClusterNotice(notice); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Cluster alert %q has been created\n", c.name)
+	return nil
+}
+
+// List prints all cluster alerts.
+func (c *AlertCommand) List(client auth.ClientI) error {
+	notices, err := client.GetClusterNotices()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(notices) == 0 {
+		fmt.Println("No cluster alerts")
+		return nil
+	}
+	table := asciitable.MakeTable([]string{"Name", "Severity", "Message", "Expires"})
+	for _, notice := range notices {
+		expires := "never"
+		if !notice.Expires.IsZero() {
+			expires = notice.Expires.Format(time.RFC822)
+		}
+		table.AddRow([]string{notice.Name, notice.Severity, notice.Message, expires})
+	}
+	fmt.Println(table.AsBuffer().String())
+	return nil
+}
+
+// Remove deletes a cluster alert by name.
+func (c *AlertCommand) Remove(client auth.ClientI) error {
+	if err := client.DeleteClusterNotice(c.name); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Cluster alert %q has been deleted\n", c.name)
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/config"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/trace"
+)
+
+// applyStatus describes the outcome of applying a single resource
+type applyStatus string
+
+const (
+	applyCreated   applyStatus = "created"
+	applyUpdated   applyStatus = "updated"
+	applyUnchanged applyStatus = "unchanged"
+)
+
+// ApplyCommand implements `tctl apply`, an idempotent version of
+// `tctl create` that reconciles a file or a directory of resource
+// definitions and reports what changed per resource
+type ApplyCommand struct {
+	config   *service.Config
+	filename string
+
+	applyCmd *kingpin.CmdClause
+}
+
+// Initialize allows ApplyCommand to plug itself into the CLI parser
+func (a *ApplyCommand) Initialize(app *kingpin.Application, cfg *service.Config) {
+	a.config = cfg
+	a.applyCmd = app.Command("apply", "Idempotently apply resource definitions from a YAML file or directory")
+	a.applyCmd.Flag("filename", "Resource definition file or directory").Short('f').Required().StringVar(&a.filename)
+}
+
+// TryRun takes the CLI command as an argument and executes it
+func (a *ApplyCommand) TryRun(cmd string, client auth.ClientI) (match bool, err error) {
+	switch cmd {
+	case a.applyCmd.FullCommand():
+		err = a.Apply(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Apply reconciles the resource definitions into the cluster and
+// prints the outcome per resource
+func (a *ApplyCommand) Apply(client auth.ClientI) error {
+	resources, err := config.ReadResourcesFromPath(a.filename)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if len(resources) == 0 {
+		return trace.BadParameter("no resources found in %q", a.filename)
+	}
+	for _, resource := range resources {
+		status, err := applyResource(client, resource)
+		if err != nil {
+			return trace.Wrap(err, "failed to apply %v %q", resource.GetKind(), resource.GetName())
+		}
+		fmt.Printf("%v %q has been %v\n", resource.GetKind(), resource.GetName(), status)
+	}
+	return nil
+}
+
+// applyResource upserts a single resource through the auth API and
+// reports whether it was created, updated or left unchanged
+func applyResource(client auth.ClientI, resource services.Resource) (applyStatus, error) {
+	ctx := context.TODO()
+	switch r := resource.(type) {
+	case services.Role:
+		current, err := client.GetRole(r.GetName())
+		status, err := compareCurrent(err, func() ([]byte, []byte, error) {
+			currentData, err := services.GetRoleMarshaler().MarshalRole(current)
+			if err != nil {
+				return nil, nil, trace.Wrap(err)
+			}
+			pendingData, err := services.GetRoleMarshaler().MarshalRole(r)
+			return currentData, pendingData, trace.Wrap(err)
+		})
+		if err != nil || status == applyUnchanged {
+			return status, trace.Wrap(err)
+		}
+		return status, trace.Wrap(client.UpsertRole(ctx, r))
+	case services.User:
+		current, err := client.GetUser(r.GetName(), false)
+		status, err := compareCurrent(err, func() ([]byte, []byte, error) {
+			currentData, err := services.GetUserMarshaler().MarshalUser(current)
+			if err != nil {
+				return nil, nil, trace.Wrap(err)
+			}
+			pendingData, err := services.GetUserMarshaler().MarshalUser(r)
+			return currentData, pendingData, trace.Wrap(err)
+		})
+		if err != nil || status == applyUnchanged {
+			return status, trace.Wrap(err)
+		}
+		return status, trace.Wrap(client.UpsertUser(r))
+	case services.TrustedCluster:
+		current, err := client.GetTrustedCluster(r.GetName())
+		status, err := compareCurrent(err, func() ([]byte, []byte, error) {
+			currentData, err := services.GetTrustedClusterMarshaler().Marshal(current)
+			if err != nil {
+				return nil, nil, trace.Wrap(err)
+			}
+			pendingData, err := services.GetTrustedClusterMarshaler().Marshal(r)
+			return currentData, pendingData, trace.Wrap(err)
+		})
+		if err != nil || status == applyUnchanged {
+			return status, trace.Wrap(err)
+		}
+		_, err = client.UpsertTrustedCluster(ctx, r)
+		return status, trace.Wrap(err)
+	case services.ProvisionToken:
+		current, err := client.GetToken(r.GetName())
+		status, err := compareCurrent(err, func() ([]byte, []byte, error) {
+			currentData, err := services.MarshalProvisionToken(current)
+			if err != nil {
+				return nil, nil, trace.Wrap(err)
+			}
+			pendingData, err := services.MarshalProvisionToken(r)
+			return currentData, pendingData, trace.Wrap(err)
+		})
+		if err != nil || status == applyUnchanged {
+			return status, trace.Wrap(err)
+		}
+		return status, trace.Wrap(client.UpsertToken(r))
+	default:
+		return "", trace.NotImplemented(
+			"applying resources of type %q is not supported, supported types are: role, user, trusted_cluster, token", resource.GetKind())
+	}
+}
+
+// compareCurrent turns the lookup result of the current resource and
+// a marshaling comparison into an apply status: a missing resource is
+// created, an equal one left alone, anything else updated
+func compareCurrent(getErr error, marshal func() ([]byte, []byte, error)) (applyStatus, error) {
+	if getErr != nil {
+		if trace.IsNotFound(getErr) {
+			return applyCreated, nil
+		}
+		return "", trace.Wrap(getErr)
+	}
+	currentData, pendingData, err := marshal()
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if bytes.Equal(currentData, pendingData) {
+		return applyUnchanged, nil
+	}
+	return applyUpdated, nil
+}
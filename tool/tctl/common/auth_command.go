@@ -1,6 +1,7 @@
 package common
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/gravitational/kingpin"
 	"github.com/gravitational/trace"
+	"golang.org/x/crypto/ssh"
 )
 
 // AuthCommand implements `tctl auth` group of commands
@@ -51,10 +53,12 @@ type AuthCommand struct {
 	rotateManualMode  bool
 	rotateTargetPhase string
 
-	authGenerate *kingpin.CmdClause
-	authExport   *kingpin.CmdClause
-	authSign     *kingpin.CmdClause
-	authRotate   *kingpin.CmdClause
+	authGenerate            *kingpin.CmdClause
+	authExport              *kingpin.CmdClause
+	authSign                *kingpin.CmdClause
+	authRotate              *kingpin.CmdClause
+	authRotateRecordingKey  *kingpin.CmdClause
+	authInstallIntermediate *kingpin.CmdClause
 }
 
 // Initialize allows TokenCommand to plug itself into the CLI parser
@@ -94,6 +98,13 @@ func (a *AuthCommand) Initialize(app *kingpin.Application, config *service.Confi
 	a.authRotate.Flag("manual", "Activate manual rotation , set rotation phases manually").BoolVar(&a.rotateManualMode)
 	a.authRotate.Flag("type", "Certificate authority to rotate, rotates both host and user CA by default").StringVar(&a.rotateType)
 	a.authRotate.Flag("phase", fmt.Sprintf("Target rotation phase to set, used in manual rotation, one of: %v", strings.Join(services.RotatePhases, ", "))).StringVar(&a.rotateTargetPhase)
+
+	a.authRotateRecordingKey = auth.Command("rotate-recording-key", "Rotate the session recording encryption key")
+
+	a.authInstallIntermediate = auth.Command("install-intermediate", "Install an intermediate signing key for a certificate authority, allowing the root key to be kept offline. Generate the keypair with 'tctl auth gen', to rotate the intermediate generate a new keypair and install it again.")
+	a.authInstallIntermediate.Flag("type", "certificate authority type: 'user' or 'host'").Required().StringVar(&a.authType)
+	a.authInstallIntermediate.Flag("pub-key", "path to the intermediate public key").Required().StringVar(&a.genPubPath)
+	a.authInstallIntermediate.Flag("priv-key", "path to the intermediate private key").Required().StringVar(&a.genPrivPath)
 }
 
 // TryRun takes the CLI command as an argument (like "auth gen") and executes it
@@ -108,6 +119,10 @@ func (a *AuthCommand) TryRun(cmd string, client auth.ClientI) (match bool, err e
 		err = a.GenerateAndSignKeys(client)
 	case a.authRotate.FullCommand():
 		err = a.RotateCertAuthority(client)
+	case a.authRotateRecordingKey.FullCommand():
+		err = a.RotateRecordingKey(client)
+	case a.authInstallIntermediate.FullCommand():
+		err = a.InstallIntermediate(client)
 	default:
 		return false, nil
 	}
@@ -292,6 +307,97 @@ func (a *AuthCommand) RotateCertAuthority(client auth.ClientI) error {
 	return nil
 }
 
+// RotateRecordingKey generates a new cluster recording key for session
+// recording encryption. New recordings are encrypted with the new key,
+// recordings made before the rotation stay playable.
+func (a *AuthCommand) RotateRecordingKey(client auth.ClientI) error {
+	keyID, err := client.RotateRecordingKey()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Rotated session recording key, new recordings will use key %v\n", keyID)
+	return nil
+}
+
+// InstallIntermediate installs an intermediate signing key for a certificate
+// authority. The intermediate public key is added to the list of keys trusted
+// by nodes and clients and its private key replaces the authority signing
+// keys, so the previous (root) private key is no longer stored in the cluster
+// and can be kept offline or on an HSM. Rotating the intermediate is a matter
+// of generating a fresh keypair and installing it again: the public keys of
+// previous intermediates remain trusted, so certificates they signed stay
+// valid.
+func (a *AuthCommand) InstallIntermediate(client auth.ClientI) error {
+	authType := services.CertAuthType(a.authType)
+	if err := authType.Check(); err != nil {
+		return trace.Wrap(err)
+	}
+	pubBytes, err := ioutil.ReadFile(a.genPubPath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	privBytes, err := ioutil.ReadFile(a.genPrivPath)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	signer, err := ssh.ParsePrivateKey(privBytes)
+	if err != nil {
+		return trace.BadParameter("failed to parse private key: %v", err)
+	}
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(pubBytes)
+	if err != nil {
+		return trace.BadParameter("failed to parse public key: %v", err)
+	}
+	if !bytes.Equal(signer.PublicKey().Marshal(), pubKey.Marshal()) {
+		return trace.BadParameter("public key %v does not match private key %v", a.genPubPath, a.genPrivPath)
+	}
+
+	clusterName, err := client.GetDomainName()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ca, err := client.GetCertAuthority(
+		services.CertAuthID{Type: authType, DomainName: clusterName}, true)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// keep the previous checking keys (including the root) trusted so
+	// certificates signed before the intermediate was installed stay valid
+	checkingKeys := ca.GetCheckingKeys()
+	alreadyTrusted := false
+	for _, key := range checkingKeys {
+		existing, _, _, _, err := ssh.ParseAuthorizedKey(key)
+		if err != nil {
+			continue
+		}
+		if sshutils.KeysEqual(existing, pubKey) {
+			alreadyTrusted = true
+			break
+		}
+	}
+	if !alreadyTrusted {
+		checkingKeys = append(checkingKeys, pubBytes)
+	}
+	if err := ca.SetCheckingKeys(checkingKeys); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := ca.SetSigningKeys([][]byte{privBytes}); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := client.UpsertCertAuthority(ca); err != nil {
+		return trace.Wrap(err)
+	}
+
+	fingerprint, err := sshutils.AuthorizedKeyFingerprint(pubBytes)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Installed intermediate signing key %v for %v certificate authority.\n"+
+		"The previous private key is no longer stored in the cluster and can be kept offline.\n", fingerprint, authType)
+	return nil
+}
+
 func (a *AuthCommand) generateHostKeys(clusterAPI auth.ClientI) error {
 	// only format=openssh is supported
 	if a.outputFormat != identityfile.FormatOpenSSH {
@@ -478,7 +584,7 @@ func (a *AuthCommand) checkProxyAddr(clusterAPI auth.ClientI) error {
 // base64-encoded key, comment.
 // For example:
 //
-//    cert-authority AAA... type=user&clustername=cluster-a
+//	cert-authority AAA... type=user&clustername=cluster-a
 //
 // URL encoding is used to pass the CA type and cluster name into the comment field.
 func userCAFormat(ca services.CertAuthority, keyBytes []byte) (string, error) {
@@ -490,7 +596,7 @@ func userCAFormat(ca services.CertAuthority, keyBytes []byte) (string, error) {
 // authorized_hosts format, a space-separated list of: marker, hosts, key, and comment.
 // For example:
 //
-//    @cert-authority *.cluster-a ssh-rsa AAA... type=host
+//	@cert-authority *.cluster-a ssh-rsa AAA... type=host
 //
 // URL encoding is used to pass the CA type and allowed logins into the comment field.
 func hostCAFormat(ca services.CertAuthority, keyBytes []byte, client auth.ClientI) (string, error) {
@@ -26,11 +26,13 @@ import (
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/asciitable"
+	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/sshutils"
 	"github.com/gravitational/teleport/lib/utils"
 
 	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
 )
 
 type ResourceCollection interface {
@@ -116,16 +118,27 @@ func (s *serverCollection) resources() (r []services.Resource) {
 }
 
 func (s *serverCollection) writeText(w io.Writer) error {
-	t := asciitable.MakeTable([]string{"Nodename", "UUID", "Address", "Labels"})
+	names := services.DisambiguateHostnames(s.servers)
+	t := asciitable.MakeTable([]string{"Nodename", "UUID", "Address", "Labels", "Utilization"})
 	for _, s := range s.servers {
 		t.AddRow([]string{
-			s.GetHostname(), s.GetName(), s.GetAddr(), s.LabelsString(),
+			names[s.GetName()], s.GetName(), s.GetAddr(), s.LabelsString(), serverUtilization(s),
 		})
 	}
 	_, err := t.AsBuffer().WriteTo(w)
 	return trace.Wrap(err)
 }
 
+// serverUtilization formats the utilization gauges optionally reported in a
+// server's heartbeat, empty when the server does not report them.
+func serverUtilization(s services.Server) string {
+	if s.GetCPUPercent() == 0 && s.GetMemoryPercent() == 0 && s.GetDiskPercent() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("cpu:%v%% mem:%v%% disk:%v%%",
+		s.GetCPUPercent(), s.GetMemoryPercent(), s.GetDiskPercent())
+}
+
 type userCollection struct {
 	users []services.User
 }
@@ -205,6 +218,34 @@ func (r *reverseTunnelCollection) writeText(w io.Writer) error {
 	return trace.Wrap(err)
 }
 
+type tunnelConnectionCollection struct {
+	connections []services.TunnelConnection
+}
+
+func (r *tunnelConnectionCollection) resources() (res []services.Resource) {
+	for _, resource := range r.connections {
+		res = append(res, resource)
+	}
+	return res
+}
+
+func (r *tunnelConnectionCollection) writeText(w io.Writer) error {
+	clock := clockwork.NewRealClock()
+	offlineThreshold := time.Duration(defaults.KeepAliveCountMax) * defaults.KeepAliveInterval
+	t := asciitable.MakeTable([]string{"Cluster Name", "Proxy Name", "Connections", "Last Heartbeat", "Status"})
+	for _, conn := range r.connections {
+		t.AddRow([]string{
+			conn.GetClusterName(),
+			conn.GetProxyName(),
+			strconv.Itoa(int(conn.GetConnectionCount())),
+			conn.GetLastHeartbeat().Format(time.RFC3339),
+			services.TunnelConnectionStatus(clock, conn, offlineThreshold),
+		})
+	}
+	_, err := t.AsBuffer().WriteTo(w)
+	return trace.Wrap(err)
+}
+
 type oidcCollection struct {
 	connectors []services.OIDCConnector
 }
@@ -416,6 +457,43 @@ func (c *semaphoreCollection) resources() (r []services.Resource) {
 	return r
 }
 
+type netConfigCollection struct {
+	netConfig services.ClusterNetworkingConfig
+}
+
+func (c *netConfigCollection) resources() (r []services.Resource) {
+	return []services.Resource{c.netConfig}
+}
+
+func (c *netConfigCollection) writeText(w io.Writer) error {
+	t := asciitable.MakeTable([]string{"Keep Alive Interval", "Keep Alive Count Max", "Client Idle Timeout", "Session Control Timeout", "Max Connections", "Max Sessions"})
+	t.AddRow([]string{
+		c.netConfig.GetKeepAliveInterval().String(),
+		fmt.Sprintf("%v", c.netConfig.GetKeepAliveCountMax()),
+		c.netConfig.GetClientIdleTimeout().String(),
+		c.netConfig.GetSessionControlTimeout().String(),
+		fmt.Sprintf("%v", c.netConfig.GetMaxConnections()),
+		fmt.Sprintf("%v", c.netConfig.GetMaxSessions()),
+	})
+	_, err := t.AsBuffer().WriteTo(w)
+	return trace.Wrap(err)
+}
+
+type recordingConfigCollection struct {
+	recConfig services.SessionRecordingConfig
+}
+
+func (c *recordingConfigCollection) resources() (r []services.Resource) {
+	return []services.Resource{c.recConfig}
+}
+
+func (c *recordingConfigCollection) writeText(w io.Writer) error {
+	t := asciitable.MakeTable([]string{"Mode", "Proxy Checks Host Keys"})
+	t.AddRow([]string{c.recConfig.GetMode(), c.recConfig.GetProxyChecksHostKeys()})
+	_, err := t.AsBuffer().WriteTo(w)
+	return trace.Wrap(err)
+}
+
 func (c *semaphoreCollection) writeText(w io.Writer) error {
 	t := asciitable.MakeTable([]string{"Kind", "Name", "LeaseID", "Holder", "Expires"})
 	for _, sem := range c.sems {
@@ -428,3 +506,27 @@ func (c *semaphoreCollection) writeText(w io.Writer) error {
 	_, err := t.AsBuffer().WriteTo(w)
 	return trace.Wrap(err)
 }
+
+type lockCollection struct {
+	locks []services.Lock
+}
+
+func (c *lockCollection) resources() (r []services.Resource) {
+	for _, resource := range c.locks {
+		r = append(r, resource)
+	}
+	return r
+}
+
+func (c *lockCollection) writeText(w io.Writer) error {
+	t := asciitable.MakeTable([]string{"Name", "Target", "Message", "Expires"})
+	for _, lock := range c.locks {
+		expires := "never"
+		if !lock.Expiry().IsZero() {
+			expires = lock.Expiry().Format(time.RFC822)
+		}
+		t.AddRow([]string{lock.GetName(), lock.Target().String(), lock.GetMessage(), expires})
+	}
+	_, err := t.AsBuffer().WriteTo(w)
+	return trace.Wrap(err)
+}
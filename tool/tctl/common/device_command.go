@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/teleport/lib/asciitable"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/service"
+
+	"github.com/gravitational/trace"
+)
+
+// DeviceCommand implements the `tctl devices` set of commands: listing
+// which devices user certificates were issued to and revoking all future
+// certificate issuance to a device fingerprint. It implements CLICommand
+// interface.
+type DeviceCommand struct {
+	config *service.Config
+
+	// user optionally limits the listing to a single user
+	user string
+	// fingerprint is the client key fingerprint to revoke
+	fingerprint string
+
+	deviceList   *kingpin.CmdClause
+	deviceRevoke *kingpin.CmdClause
+}
+
+// Initialize allows DeviceCommand to plug itself into the CLI parser
+func (c *DeviceCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+	devices := app.Command("devices", "Inspect and revoke devices user certificates were issued to")
+
+	c.deviceList = devices.Command("ls", "List devices user certificates were issued to")
+	c.deviceList.Flag("user", "Only list certificates issued to this user").StringVar(&c.user)
+
+	c.deviceRevoke = devices.Command("revoke", "Stop issuing certificates to a device by its client key fingerprint")
+	c.deviceRevoke.Arg("fingerprint", "Client key fingerprint, e.g. SHA256:...").Required().StringVar(&c.fingerprint)
+}
+
+// TryRun takes the CLI command as an argument (like "devices ls") and executes it.
+func (c *DeviceCommand) TryRun(cmd string, client auth.ClientI) (match bool, err error) {
+	switch cmd {
+	case c.deviceList.FullCommand():
+		err = c.List(client)
+	case c.deviceRevoke.FullCommand():
+		err = c.Revoke(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// List prints the records of issued user certificates.
+func (c *DeviceCommand) List(client auth.ClientI) error {
+	issued, err := client.GetIssuedCerts(c.user)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	t := asciitable.MakeTable([]string{"User", "Fingerprint", "Client Version", "Client Address", "Issued", "Expires"})
+	for _, cert := range issued {
+		t.AddRow([]string{
+			cert.User,
+			cert.Fingerprint,
+			cert.ClientVersion,
+			cert.ClientAddr,
+			cert.Issued.Format(time.RFC822),
+			cert.Expires.Format(time.RFC822),
+		})
+	}
+	_, err = t.AsBuffer().WriteTo(os.Stdout)
+	return trace.Wrap(err)
+}
+
+// Revoke stops certificate issuance to a device fingerprint.
+func (c *DeviceCommand) Revoke(client auth.ClientI) error {
+	if err := client.RevokeDevice(c.fingerprint); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Device %v has been revoked, no new certificates will be issued to it\n", c.fingerprint)
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/defaults"
+	"github.com/gravitational/teleport/lib/service"
+	"github.com/gravitational/teleport/lib/services"
+
+	"github.com/gravitational/kingpin"
+	"github.com/gravitational/trace"
+)
+
+// DiagCommand implements the `tctl diag` command that walks the connection
+// path between a user and a node and reports where it breaks: user and role
+// lookup, role to node label match, node heartbeat freshness and network
+// reachability.
+type DiagCommand struct {
+	config *service.Config
+
+	// user is the Teleport user attempting the connection
+	user string
+	// login is the OS login on the target node
+	login string
+	// node is the hostname, UUID or address of the target node
+	node string
+
+	diagConnect *kingpin.CmdClause
+}
+
+// Initialize allows DiagCommand to plug itself into the CLI parser
+func (c *DiagCommand) Initialize(app *kingpin.Application, config *service.Config) {
+	c.config = config
+
+	diag := app.Command("diag", "Diagnose common cluster problems")
+
+	c.diagConnect = diag.Command("connect", "Explain why a user can or can not connect to a node")
+	c.diagConnect.Arg("user", "Teleport user attempting the connection").Required().StringVar(&c.user)
+	c.diagConnect.Arg("node", "Hostname, UUID or address of the target node").Required().StringVar(&c.node)
+	c.diagConnect.Flag("login", "OS login on the target node, defaults to the user name").StringVar(&c.login)
+}
+
+// TryRun takes the CLI command as an argument (like "diag connect") and executes it.
+func (c *DiagCommand) TryRun(cmd string, client auth.ClientI) (match bool, err error) {
+	switch cmd {
+	case c.diagConnect.FullCommand():
+		err = c.Connect(client)
+	default:
+		return false, nil
+	}
+	return true, trace.Wrap(err)
+}
+
+// Connect runs the connection diagnostics and prints a step-by-step report.
+func (c *DiagCommand) Connect(client auth.ClientI) error {
+	login := c.login
+	if login == "" {
+		login = c.user
+	}
+
+	// step 1: the user has to exist
+	user, err := client.GetUser(c.user, false)
+	if err != nil {
+		printStep(false, "user %q not found: %v", c.user, err)
+		return nil
+	}
+	printStep(true, "user %q exists with roles %v", c.user, user.GetRoles())
+
+	// step 2: all roles of the user have to resolve
+	roles, err := services.FetchRoles(user.GetRoles(), client, user.GetTraits())
+	if err != nil {
+		printStep(false, "failed to fetch roles of user %q: %v", c.user, err)
+		return nil
+	}
+	printStep(true, "all roles of user %q resolve", c.user)
+
+	// step 3: the node has to be registered in the cluster
+	nodes, err := client.GetNodes(defaults.Namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var node services.Server
+	for i := range nodes {
+		if nodes[i].GetName() == c.node || nodes[i].GetHostname() == c.node || nodes[i].GetAddr() == c.node {
+			node = nodes[i]
+			break
+		}
+	}
+	if node == nil {
+		printStep(false, "node %q is not registered in the cluster, check that it is running and joined", c.node)
+		return nil
+	}
+	printStep(true, "node %q is registered as %v (%v)", c.node, node.GetHostname(), node.GetName())
+
+	// step 4: the node heartbeat has to be fresh
+	if expiry := node.Expiry(); !expiry.IsZero() {
+		if expiry.Before(time.Now().UTC()) {
+			printStep(false, "node heartbeat expired %v ago, the node may be down or disconnected", time.Since(expiry).Round(time.Second))
+			return nil
+		}
+		printStep(true, "node heartbeat is fresh, expires in %v", time.Until(expiry).Round(time.Second))
+	} else {
+		printStep(true, "node is statically registered and does not heartbeat")
+	}
+
+	// step 5: the roles have to allow login to the node labels
+	if err := roles.CheckAccessToServer(login, node); err != nil {
+		printStep(false, "roles %v do not allow login %q to node labels %v: %v",
+			user.GetRoles(), login, node.GetAllLabels(), err)
+		return nil
+	}
+	printStep(true, "roles %v allow login %q to this node", user.GetRoles(), login)
+
+	// step 6: the node has to be reachable, either over a reverse tunnel
+	// or directly over the network
+	if node.GetUseTunnel() {
+		printStep(true, "node connects back over a reverse tunnel through the proxy")
+	} else {
+		conn, err := net.DialTimeout("tcp", node.GetAddr(), defaults.DefaultDialTimeout)
+		if err != nil {
+			printStep(false, "node address %v is not reachable from this host: %v", node.GetAddr(), err)
+			return nil
+		}
+		conn.Close()
+		printStep(true, "node address %v is reachable", node.GetAddr())
+	}
+
+	fmt.Printf("\nAll checks passed, user %q should be able to connect to node %q as %q.\n", c.user, c.node, login)
+	return nil
+}
+
+// printStep prints a single diagnostic check result.
+func printStep(ok bool, format string, args ...interface{}) {
+	status := "FAIL"
+	if ok {
+		status = " OK "
+	}
+	fmt.Printf("[%v] %v\n", status, fmt.Sprintf(format, args...))
+}
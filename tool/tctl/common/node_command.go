@@ -26,6 +26,7 @@ import (
 
 	"github.com/gravitational/kingpin"
 	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/asciitable"
 	"github.com/gravitational/teleport/lib/auth"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/service"
@@ -48,10 +49,13 @@ type NodeCommand struct {
 	// token is an optional custom token supplied by client,
 	// if not specified, is autogenerated
 	token string
+	// singleUse revokes the generated token right after the first node joins
+	singleUse bool
 
 	// CLI subcommands (clauses)
-	nodeAdd  *kingpin.CmdClause
-	nodeList *kingpin.CmdClause
+	nodeAdd          *kingpin.CmdClause
+	nodeList         *kingpin.CmdClause
+	nodeJoinFailures *kingpin.CmdClause
 }
 
 // Initialize allows NodeCommand to plug itself into the CLI parser
@@ -64,12 +68,15 @@ func (c *NodeCommand) Initialize(app *kingpin.Application, config *service.Confi
 	c.nodeAdd.Flag("roles", "Comma-separated list of roles for the new node to assume [node]").Default("node").StringVar(&c.roles)
 	c.nodeAdd.Flag("ttl", "Time to live for a generated token").Default(defaults.ProvisioningTokenTTL.String()).DurationVar(&c.ttl)
 	c.nodeAdd.Flag("token", "Custom token to use, autogenerated if not provided").StringVar(&c.token)
+	c.nodeAdd.Flag("single-use", "Revoke the token right after the first node joins").BoolVar(&c.singleUse)
 	c.nodeAdd.Flag("format", "Output format, 'text' or 'json'").Hidden().Default("text").StringVar(&c.format)
 	c.nodeAdd.Alias(AddNodeHelp)
 
 	c.nodeList = nodes.Command("ls", "List all active SSH nodes within the cluster")
 	c.nodeList.Flag("namespace", "Namespace of the nodes").Default(defaults.Namespace).StringVar(&c.namespace)
 	c.nodeList.Alias(ListNodesHelp)
+
+	c.nodeJoinFailures = nodes.Command("join-failures", "List recent failed attempts of nodes to join the cluster")
 }
 
 // TryRun takes the CLI command as an argument (like "nodes ls") and executes it.
@@ -79,6 +86,8 @@ func (c *NodeCommand) TryRun(cmd string, client auth.ClientI) (match bool, err e
 		err = c.Invite(client)
 	case c.nodeList.FullCommand():
 		err = c.ListActive(client)
+	case c.nodeJoinFailures.FullCommand():
+		err = c.ListJoinFailures(client)
 
 	default:
 		return false, nil
@@ -117,7 +126,7 @@ func (c *NodeCommand) Invite(client auth.ClientI) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	token, err := client.GenerateToken(context.TODO(), auth.GenerateTokenRequest{Roles: roles, TTL: c.ttl, Token: c.token})
+	token, err := client.GenerateToken(context.TODO(), auth.GenerateTokenRequest{Roles: roles, TTL: c.ttl, Token: c.token, SingleUse: c.singleUse})
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -152,6 +161,9 @@ func (c *NodeCommand) Invite(client auth.ClientI) error {
 				int(c.ttl.Minutes()),
 				authServers[0].GetAddr(),
 			)
+			if c.singleUse {
+				fmt.Printf("  - This token is single-use and will be revoked after the first node joins\n")
+			}
 		}
 	} else {
 		// Always return a list, otherwise we'll break users tooling. See #1846 for
@@ -179,3 +191,23 @@ func (c *NodeCommand) ListActive(client auth.ClientI) error {
 	}
 	return nil
 }
+
+// ListJoinFailures retrieves the failed join attempts recorded by the auth
+// server and prints them to stdout
+func (c *NodeCommand) ListJoinFailures(client auth.ClientI) error {
+	failures, err := client.GetJoinFailures()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	t := asciitable.MakeTable([]string{"Node Name", "Host ID", "Role", "Address", "Time", "Reason"})
+	for _, failure := range failures {
+		t.AddRow([]string{
+			failure.NodeName, failure.HostID, failure.Role, failure.RemoteAddr,
+			failure.Time.Format(time.RFC3339), failure.Reason,
+		})
+	}
+	if _, err := t.AsBuffer().WriteTo(os.Stdout); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
@@ -21,9 +21,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/gravitational/teleport"
 	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/client"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/service"
 	"github.com/gravitational/teleport/lib/services"
@@ -50,6 +53,8 @@ type ResourceCommand struct {
 	namespace   string
 	withSecrets bool
 	force       bool
+	dryRun      bool
+	labels      string
 
 	// filename is the name of the resource, used for 'create'
 	filename string
@@ -76,16 +81,22 @@ Same as above, but using JSON output:
 // Initialize allows ResourceCommand to plug itself into the CLI parser
 func (rc *ResourceCommand) Initialize(app *kingpin.Application, config *service.Config) {
 	rc.CreateHandlers = map[ResourceKind]ResourceCreateHandler{
-		services.KindUser:            rc.createUser,
-		services.KindTrustedCluster:  rc.createTrustedCluster,
-		services.KindGithubConnector: rc.createGithubConnector,
-		services.KindCertAuthority:   rc.createCertAuthority,
+		services.KindUser:                    rc.createUser,
+		services.KindTrustedCluster:          rc.createTrustedCluster,
+		services.KindGithubConnector:         rc.createGithubConnector,
+		services.KindCertAuthority:           rc.createCertAuthority,
+		services.KindNamespace:               rc.createNamespace,
+		services.KindRole:                    rc.createRole,
+		services.KindLock:                    rc.createLock,
+		services.KindClusterNetworkingConfig: rc.createClusterNetworkingConfig,
+		services.KindSessionRecordingConfig:  rc.createSessionRecordingConfig,
 	}
 	rc.config = config
 
 	rc.createCmd = app.Command("create", "Create or update a Teleport resource from a YAML file")
 	rc.createCmd.Arg("filename", "resource definition file").Required().StringVar(&rc.filename)
 	rc.createCmd.Flag("force", "Overwrite the resource if already exists").Short('f').BoolVar(&rc.force)
+	rc.createCmd.Flag("dry-run", "Validate the resource without applying it and report the access impact, only supported for roles").BoolVar(&rc.dryRun)
 
 	rc.deleteCmd = app.Command("rm", "Delete a resource").Alias("del")
 	rc.deleteCmd.Arg("resource type/resource name", `Resource to delete
@@ -101,6 +112,7 @@ func (rc *ResourceCommand) Initialize(app *kingpin.Application, config *service.
 	rc.getCmd.Flag("format", "Output format: 'yaml', 'json' or 'text'").Default(teleport.YAML).StringVar(&rc.format)
 	rc.getCmd.Flag("namespace", "Namespace of the resources").Hidden().Default(defaults.Namespace).StringVar(&rc.namespace)
 	rc.getCmd.Flag("with-secrets", "Include secrets in resources like certificate authorities or OIDC connectors").Default("false").BoolVar(&rc.withSecrets)
+	rc.getCmd.Flag("labels", "Label selector, only resources with all matching labels are returned, for example: --labels=env=prod,region=us-west").StringVar(&rc.labels)
 
 	rc.getCmd.Alias(getHelp)
 }
@@ -226,6 +238,9 @@ func (rc *ResourceCommand) Create(client auth.ClientI) error {
 			}
 			return trace.BadParameter("creating resources of type %q is not supported", raw.Kind)
 		}
+		if rc.dryRun && raw.Kind != services.KindRole {
+			return trace.BadParameter("--dry-run is only supported for role resources")
+		}
 		// only return in case of error, to create multiple resources
 		// in case if yaml spec is a list
 		if err := creator(client, raw); err != nil {
@@ -312,6 +327,204 @@ func (rc *ResourceCommand) createGithubConnector(client auth.ClientI, raw servic
 	return nil
 }
 
+// createNamespace implements 'tctl create namespace.yaml' command
+func (rc *ResourceCommand) createNamespace(client auth.ClientI, raw services.UnknownResource) error {
+	ns, err := services.UnmarshalNamespace(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = client.GetNamespace(ns.GetName())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	exists := (err == nil)
+	if !rc.force && exists {
+		return trace.AlreadyExists("namespace %q already exists", ns.GetName())
+	}
+	if err := client.UpsertNamespace(*ns); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("namespace %q has been %s\n", ns.GetName(), UpsertVerb(exists, rc.force))
+	return nil
+}
+
+// createClusterNetworkingConfig implements 'tctl create netconfig.yaml' command,
+// applying the networking settings onto the stored cluster configuration.
+func (rc *ResourceCommand) createClusterNetworkingConfig(client auth.ClientI, raw services.UnknownResource) error {
+	netConfig, err := services.UnmarshalClusterNetworkingConfig(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	clusterConfig, err := client.GetClusterConfig()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	netConfig.Apply(clusterConfig)
+	if err := client.SetClusterConfig(clusterConfig); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("cluster networking configuration has been updated\n")
+	return nil
+}
+
+// createSessionRecordingConfig implements 'tctl create recconfig.yaml' command,
+// applying the recording policy onto the stored cluster configuration.
+func (rc *ResourceCommand) createSessionRecordingConfig(client auth.ClientI, raw services.UnknownResource) error {
+	recConfig, err := services.UnmarshalSessionRecordingConfig(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	clusterConfig, err := client.GetClusterConfig()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	recConfig.Apply(clusterConfig)
+	if err := client.SetClusterConfig(clusterConfig); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("session recording configuration has been updated\n")
+	return nil
+}
+
+// createRole implements 'tctl create role.yaml' command.
+func (rc *ResourceCommand) createRole(client auth.ClientI, raw services.UnknownResource) error {
+	role, err := services.GetRoleMarshaler().UnmarshalRole(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	roleName := role.GetName()
+	current, err := client.GetRole(roleName)
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	exists := (err == nil)
+	if rc.dryRun {
+		return trace.Wrap(rc.dryRunRole(client, current, role))
+	}
+	if !rc.force && exists {
+		return trace.AlreadyExists("role %q already exists", roleName)
+	}
+	if exists && role.GetResourceID() != 0 {
+		// The spec carries the revision it was fetched at: refuse to apply
+		// it over a newer revision so concurrent edits don't silently
+		// overwrite each other.
+		if role.GetResourceID() != current.GetResourceID() {
+			return trace.CompareFailed("role %q has been updated by another party since revision %v, fetch the latest version with 'tctl get role/%v' and merge the changes", roleName, role.GetResourceID(), roleName)
+		}
+		if err := client.CompareAndSwapRole(context.TODO(), role, current); err != nil {
+			if trace.IsCompareFailed(err) {
+				return trace.Wrap(err, "fetch the latest version with 'tctl get role/%v' and merge the changes", roleName)
+			}
+			return trace.Wrap(err)
+		}
+		fmt.Printf("role %q has been updated\n", roleName)
+		return nil
+	}
+	if err := client.UpsertRole(context.TODO(), role); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("role %q has been %s\n", roleName, UpsertVerb(exists, rc.force))
+	return nil
+}
+
+// createLock implements 'tctl create lock.yaml' command.
+func (rc *ResourceCommand) createLock(client auth.ClientI, raw services.UnknownResource) error {
+	lock, err := services.GetLockMarshaler().Unmarshal(raw.Raw)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = client.GetLock(lock.GetName())
+	if err != nil && !trace.IsNotFound(err) {
+		return trace.Wrap(err)
+	}
+	exists := (err == nil)
+	if !rc.force && exists {
+		return trace.AlreadyExists("lock %q already exists", lock.GetName())
+	}
+	if err := client.UpsertLock(context.TODO(), lock); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("lock %q has been %s\n", lock.GetName(), UpsertVerb(exists, rc.force))
+	return nil
+}
+
+// dryRunRole reports how the pending role differs from the version stored
+// on the auth server: which users are assigned the role and which nodes
+// would gain or lose SSH access if the pending version was applied.
+func (rc *ResourceCommand) dryRunRole(client auth.ClientI, current, pending services.Role) error {
+	if current == nil {
+		fmt.Printf("role %q is valid and would be created\n", pending.GetName())
+	} else {
+		fmt.Printf("role %q is valid and would be updated\n", pending.GetName())
+	}
+
+	// report users the change would affect
+	users, err := client.GetUsers(false)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var assigned []string
+	for _, user := range users {
+		for _, roleName := range user.GetRoles() {
+			if roleName == pending.GetName() {
+				assigned = append(assigned, user.GetName())
+				break
+			}
+		}
+	}
+	sort.Strings(assigned)
+	if len(assigned) == 0 {
+		fmt.Printf("no users are currently assigned this role\n")
+	} else {
+		fmt.Printf("users assigned this role: %v\n", strings.Join(assigned, ", "))
+	}
+
+	// compare node access granted by the stored and the pending versions
+	nodes, err := client.GetNodes(defaults.Namespace)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	var gained, lost []string
+	for _, node := range nodes {
+		before := roleAllowsNode(current, node)
+		after := roleAllowsNode(pending, node)
+		switch {
+		case after && !before:
+			gained = append(gained, node.GetHostname())
+		case before && !after:
+			lost = append(lost, node.GetHostname())
+		}
+	}
+	sort.Strings(gained)
+	sort.Strings(lost)
+	if len(gained) != 0 {
+		fmt.Printf("nodes gaining access: %v\n", strings.Join(gained, ", "))
+	}
+	if len(lost) != 0 {
+		fmt.Printf("nodes losing access: %v\n", strings.Join(lost, ", "))
+	}
+	if len(gained) == 0 && len(lost) == 0 {
+		fmt.Printf("node access is unchanged\n")
+	}
+	return nil
+}
+
+// roleAllowsNode returns true if the role grants SSH access to the node
+// with at least one of its allowed logins.
+func roleAllowsNode(role services.Role, node services.Server) bool {
+	if role == nil {
+		return false
+	}
+	set := services.NewRoleSet(role)
+	for _, login := range role.GetLogins(services.Allow) {
+		if set.CheckAccessToServer(login, node) == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // createUser implements 'tctl create user.yaml' command.
 func (rc *ResourceCommand) createUser(client auth.ClientI, raw services.UnknownResource) error {
 	user, err := services.GetUserMarshaler().UnmarshalUser(raw.Raw)
@@ -398,6 +611,11 @@ func (rc *ResourceCommand) Delete(client auth.ClientI) (err error) {
 			return trace.Wrap(err)
 		}
 		fmt.Printf("remote cluster %q has been deleted\n", rc.ref.Name)
+	case services.KindNamespace:
+		if err = client.DeleteNamespace(rc.ref.Name); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("namespace %q has been deleted\n", rc.ref.Name)
 	case services.KindSemaphore:
 		if rc.ref.SubKind == "" || rc.ref.Name == "" {
 			return trace.BadParameter(
@@ -413,6 +631,11 @@ func (rc *ResourceCommand) Delete(client auth.ClientI) (err error) {
 			return trace.Wrap(err)
 		}
 		fmt.Printf("semaphore '%s/%s' has been deleted\n", rc.ref.SubKind, rc.ref.Name)
+	case services.KindLock:
+		if err = client.DeleteLock(ctx, rc.ref.Name); err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Printf("lock %q has been deleted\n", rc.ref.Name)
 	default:
 		return trace.BadParameter("deleting resources of type %q is not supported", rc.ref.Kind)
 	}
@@ -489,7 +712,23 @@ func (rc *ResourceCommand) getCollection(client auth.ClientI) (c ResourceCollect
 		if err != nil {
 			return nil, trace.Wrap(err)
 		}
-		return &reverseTunnelCollection{tunnels: tunnels}, nil
+		selector, err := rc.labelSelector()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		var out []services.ReverseTunnel
+		for _, tunnel := range tunnels {
+			if matchLabelSelector(selector, tunnel.GetMetadata().Labels) {
+				out = append(out, tunnel)
+			}
+		}
+		return &reverseTunnelCollection{tunnels: out}, nil
+	case services.KindTunnelConnection:
+		connections, err := client.GetAllTunnelConnections()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &tunnelConnectionCollection{connections: connections}, nil
 	case services.KindCertAuthority:
 		userAuthorities, err := client.GetCertAuthorities(services.UserCA, rc.withSecrets)
 		if err != nil {
@@ -564,7 +803,17 @@ func (rc *ResourceCommand) getCollection(client auth.ClientI) (c ResourceCollect
 			if err != nil {
 				return nil, trace.Wrap(err)
 			}
-			return &remoteClusterCollection{remoteClusters: remoteClusters}, nil
+			selector, err := rc.labelSelector()
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			var out []services.RemoteCluster
+			for _, remoteCluster := range remoteClusters {
+				if matchLabelSelector(selector, remoteCluster.GetMetadata().Labels) {
+					out = append(out, remoteCluster)
+				}
+			}
+			return &remoteClusterCollection{remoteClusters: out}, nil
 		}
 		remoteCluster, err := client.GetRemoteCluster(rc.ref.Name)
 		if err != nil {
@@ -580,10 +829,67 @@ func (rc *ResourceCommand) getCollection(client auth.ClientI) (c ResourceCollect
 			return nil, trace.Wrap(err)
 		}
 		return &semaphoreCollection{sems: sems}, nil
+	case services.KindLock:
+		if rc.ref.Name == "" {
+			locks, err := client.GetLocks()
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return &lockCollection{locks: locks}, nil
+		}
+		lock, err := client.GetLock(rc.ref.Name)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &lockCollection{locks: []services.Lock{lock}}, nil
+	case services.KindClusterNetworkingConfig:
+		clusterConfig, err := client.GetClusterConfig()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		netConfig, err := services.ClusterNetworkingConfigFromClusterConfig(clusterConfig)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &netConfigCollection{netConfig: netConfig}, nil
+	case services.KindSessionRecordingConfig:
+		clusterConfig, err := client.GetClusterConfig()
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		recConfig, err := services.SessionRecordingConfigFromClusterConfig(clusterConfig)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		return &recordingConfigCollection{recConfig: recConfig}, nil
 	}
 	return nil, trace.BadParameter("'%v' is not supported", rc.ref.Kind)
 }
 
+// labelSelector parses the --labels flag into a label selector. An empty
+// flag matches everything.
+func (rc *ResourceCommand) labelSelector() (map[string]string, error) {
+	if rc.labels == "" {
+		return nil, nil
+	}
+	selector, err := client.ParseLabelSpec(rc.labels)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return selector, nil
+}
+
+// matchLabelSelector returns true if the labels include all of the labels
+// in the selector.
+func matchLabelSelector(selector map[string]string, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
 // UpsertVerb generates the correct string form of a verb based on the action taken
 func UpsertVerb(exists bool, force bool) string {
 	switch {
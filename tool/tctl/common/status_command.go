@@ -19,7 +19,9 @@ package common
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/gravitational/kingpin"
 	"github.com/gravitational/teleport"
@@ -81,11 +83,60 @@ func (c *StatusCommand) Status(client auth.ClientI) error {
 		return trace.Wrap(err)
 	}
 
+	clusterConfig, err := client.GetClusterConfig()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	authServers, err := client.GetAuthServers()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	proxies, err := client.GetProxies()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	namespaces, err := client.GetNamespaces()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	nodeCounts := make(map[string]int, len(namespaces))
+	for _, namespace := range namespaces {
+		nodes, err := client.GetNodes(namespace.Metadata.Name)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		nodeCounts[namespace.Metadata.Name] = len(nodes)
+	}
+
+	// measure the auth server round-trip time with an extra ping, the
+	// initial one includes connection setup overhead.
+	start := time.Now()
+	if _, err := client.Ping(context.TODO()); err != nil {
+		return trace.Wrap(err)
+	}
+	authLatency := time.Since(start)
+
 	authorities := append(userCAs, hostCAs...)
 	view := func() string {
 		table := asciitable.MakeHeadlessTable(2)
 		table.AddRow([]string{"Cluster", clusterName})
 		table.AddRow([]string{"Version", serverVersion})
+		table.AddRow([]string{"Recording mode", clusterConfig.GetSessionRecording()})
+		table.AddRow([]string{"Auth servers", serverSummary(authServers)})
+		table.AddRow([]string{"Proxies", serverSummary(proxies)})
+		for _, namespace := range namespaces {
+			table.AddRow([]string{
+				fmt.Sprintf("Nodes (%v)", namespace.Metadata.Name),
+				fmt.Sprintf("%v", nodeCounts[namespace.Metadata.Name]),
+			})
+		}
+		if c.config.Auth.Enabled {
+			table.AddRow([]string{"Backend", c.config.Auth.StorageConfig.Type})
+		}
+		table.AddRow([]string{"Auth latency", authLatency.Round(time.Microsecond).String()})
 		for _, ca := range authorities {
 			if ca.GetClusterName() != clusterName {
 				continue
@@ -127,3 +178,22 @@ func (c *StatusCommand) Status(client auth.ClientI) error {
 	}
 	return nil
 }
+
+// serverSummary formats a count of servers along with the distinct
+// teleport versions they are running, e.g. "2 (versions: 4.3.0, 4.3.1)".
+func serverSummary(servers []services.Server) string {
+	if len(servers) == 0 {
+		return "0"
+	}
+	seen := make(map[string]bool)
+	var versions []string
+	for _, server := range servers {
+		version := server.GetTeleportVersion()
+		if !seen[version] {
+			seen[version] = true
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return fmt.Sprintf("%v (versions: %v)", len(servers), strings.Join(versions, ", "))
+}
@@ -27,9 +27,14 @@ func main() {
 		&common.TokenCommand{},
 		&common.AuthCommand{},
 		&common.ResourceCommand{},
+		&common.ApplyCommand{},
 		&common.StatusCommand{},
 		&common.TopCommand{},
 		&common.AccessRequestCommand{},
+		&common.AccessReportCommand{},
+		&common.DeviceCommand{},
+		&common.AlertCommand{},
+		&common.DiagCommand{},
 	}
 	common.Run(commands)
 }
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/teleport/lib/asciitable"
+	"github.com/gravitational/teleport/lib/config"
+	"github.com/gravitational/teleport/lib/loadtest"
+	"github.com/gravitational/teleport/lib/service"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/gravitational/trace"
+)
+
+// loadtestFlags collects the CLI arguments of the "loadtest" command.
+type loadtestFlags struct {
+	// ConfigFile is the path to the auth server configuration file.
+	ConfigFile string
+	// Nodes is the number of synthetic nodes to register.
+	Nodes int
+	// HeartbeatInterval is how frequently every node heartbeats.
+	HeartbeatInterval time.Duration
+	// Labels is the number of labels set on every node.
+	Labels int
+	// LabelCardinality is the number of distinct values per label.
+	LabelCardinality int
+	// SessionRate is the number of session cycles per second.
+	SessionRate int
+	// Duration is the test duration.
+	Duration time.Duration
+}
+
+// onLoadtest is the handler for the "loadtest" CLI command, it registers
+// synthetic nodes against the cluster storage and reports the latency of
+// heartbeat and session operations.
+func onLoadtest(flags loadtestFlags) error {
+	conf := service.MakeDefaultConfig()
+	if err := config.Configure(&config.CommandLineFlags{ConfigFile: flags.ConfigFile}, conf); err != nil {
+		return trace.Wrap(err)
+	}
+	if !conf.Auth.Enabled {
+		return trace.BadParameter("the auth service is not enabled in %v, run this command on the auth server", flags.ConfigFile)
+	}
+	bk, err := openAuthStorage(&conf.Auth.StorageConfig)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer bk.Close()
+
+	fmt.Printf("Registering %v synthetic nodes for %v, heartbeat interval %v.\n",
+		flags.Nodes, flags.Duration, flags.HeartbeatInterval)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exitSignals := make(chan os.Signal, 1)
+	signal.Notify(exitSignals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-exitSignals
+		fmt.Printf("Signal %v received, shutting down the load test.\n", sig)
+		cancel()
+	}()
+	result, err := loadtest.Run(ctx, loadtest.Config{
+		Backend:           bk,
+		Nodes:             flags.Nodes,
+		HeartbeatInterval: flags.HeartbeatInterval,
+		Labels:            flags.Labels,
+		LabelCardinality:  flags.LabelCardinality,
+		SessionRate:       flags.SessionRate,
+		Duration:          flags.Duration,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	fmt.Printf("\n")
+	fmt.Printf("* Heartbeats originated: %v\n", result.Heartbeats)
+	fmt.Printf("* Heartbeats failed: %v\n", result.HeartbeatFailures)
+	if result.Sessions != 0 {
+		fmt.Printf("* Session cycles originated: %v\n", result.Sessions)
+		fmt.Printf("* Session cycles failed: %v\n", result.SessionFailures)
+	}
+	if result.LastError != nil {
+		fmt.Printf("* Last error: %v\n", result.LastError)
+	}
+	if err := printHistogram("Heartbeat latency", result.HeartbeatHistogram); err != nil {
+		return trace.Wrap(err)
+	}
+	if result.Sessions != 0 {
+		if err := printHistogram("Session cycle latency", result.SessionHistogram); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// printHistogram prints a latency histogram as a percentile table
+func printHistogram(title string, h *hdrhistogram.Histogram) error {
+	fmt.Printf("\n%v\n\n", title)
+	t := asciitable.MakeTable([]string{"Percentile", "Duration"})
+	for _, quantile := range []float64{25, 50, 75, 90, 95, 99, 100} {
+		t.AddRow([]string{fmt.Sprintf("%v", quantile),
+			fmt.Sprintf("%v ms", h.ValueAtQuantile(quantile)),
+		})
+	}
+	if _, err := io.Copy(os.Stdout, t.AsBuffer()); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("\n")
+	return nil
+}
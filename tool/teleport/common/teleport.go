@@ -26,6 +26,12 @@ import (
 	"strings"
 
 	"github.com/gravitational/teleport"
+	"github.com/gravitational/teleport/lib/auth"
+	"github.com/gravitational/teleport/lib/backend"
+	"github.com/gravitational/teleport/lib/backend/dynamo"
+	"github.com/gravitational/teleport/lib/backend/etcdbk"
+	"github.com/gravitational/teleport/lib/backend/firestore"
+	"github.com/gravitational/teleport/lib/backend/lite"
 	"github.com/gravitational/teleport/lib/config"
 	"github.com/gravitational/teleport/lib/defaults"
 	"github.com/gravitational/teleport/lib/service"
@@ -68,7 +74,51 @@ func Run(options Options) (executedCommand string, conf *service.Config) {
 	// define commands:
 	start := app.Command("start", "Starts the Teleport service.")
 	status := app.Command("status", "Print the status of the current SSH session.")
-	dump := app.Command("configure", "Print the sample config file into stdout.")
+	configure := app.Command("configure", "Generate and validate the configuration file.")
+	dump := configure.Command("dump", "Print the sample config file into stdout.").Default()
+	var dumpFlags config.SampleFlags
+	dump.Flag("topology",
+		"Topology to generate a config for: demo, auth, node or leaf").
+		Default("demo").EnumVar(&dumpFlags.Topology, "demo", "auth", "node", "leaf")
+	dump.Flag("cluster-name", "Name of the cluster, required for the leaf topology").
+		StringVar(&dumpFlags.ClusterName)
+	dump.Flag("auth-server", "Address of the auth server nodes connect to").
+		StringVar(&dumpFlags.AuthServer)
+	dump.Flag("token", "Join token for the node topology").
+		StringVar(&dumpFlags.AuthToken)
+	dump.Flag("ca-pin", "CA pin used by nodes to validate the auth server").
+		StringVar(&dumpFlags.CAPin)
+	check := configure.Command("check", "Validate the configuration file and exit.")
+	var checkConfigFile string
+	check.Flag("config",
+		fmt.Sprintf("Path to a configuration file [%v]", defaults.ConfigFilePath)).
+		Short('c').Default(defaults.ConfigFilePath).ExistingFileVar(&checkConfigFile)
+	renameCluster := app.Command("rename-cluster", "Rename the cluster. Requires all Teleport processes in the cluster to be stopped.")
+	var renameFlags renameClusterFlags
+	renameCluster.Flag("config",
+		fmt.Sprintf("Path to a configuration file [%v]", defaults.ConfigFilePath)).
+		Short('c').Default(defaults.ConfigFilePath).ExistingFileVar(&renameFlags.ConfigFile)
+	renameCluster.Flag("force", "Do not ask for confirmation").
+		BoolVar(&renameFlags.Force)
+	renameCluster.Arg("name", "New name of the cluster").
+		Required().StringVar(&renameFlags.NewName)
+	loadtestCmd := app.Command("loadtest", "Register synthetic nodes and simulate session churn for capacity planning.").Hidden()
+	var ltFlags loadtestFlags
+	loadtestCmd.Flag("config",
+		fmt.Sprintf("Path to a configuration file [%v]", defaults.ConfigFilePath)).
+		Short('c').Default(defaults.ConfigFilePath).ExistingFileVar(&ltFlags.ConfigFile)
+	loadtestCmd.Flag("nodes", "Number of synthetic nodes to register").
+		Default("1000").IntVar(&ltFlags.Nodes)
+	loadtestCmd.Flag("heartbeat-interval", "How frequently every synthetic node heartbeats").
+		Default("300s").DurationVar(&ltFlags.HeartbeatInterval)
+	loadtestCmd.Flag("labels", "Number of labels set on every synthetic node").
+		Default("5").IntVar(&ltFlags.Labels)
+	loadtestCmd.Flag("label-cardinality", "Number of distinct values generated per label").
+		Default("10").IntVar(&ltFlags.LabelCardinality)
+	loadtestCmd.Flag("session-rate", "Number of sessions created and torn down per second").
+		Default("0").IntVar(&ltFlags.SessionRate)
+	loadtestCmd.Flag("duration", "Test duration").
+		Default("1m").DurationVar(&ltFlags.Duration)
 	ver := app.Command("version", "Print the version.")
 	scpc := app.Command("scp", "Server-side implementation of SCP.").Hidden()
 	exec := app.Command("exec", "Used internally by Teleport to re-exec itself to run a command.").Hidden()
@@ -111,6 +161,8 @@ func Run(options Options) (executedCommand string, conf *service.Config) {
 		Short('c').ExistingFileVar(&ccf.ConfigFile)
 	start.Flag("bootstrap",
 		"Path to bootstrap file (ignored if already initialized)").ExistingFileVar(&ccf.BootstrapFile)
+	start.Flag("apply-on-startup",
+		"Path to a file or directory with resource definitions reconciled into the backend on every start").ExistingFileOrDirVar(&ccf.ApplyOnStartupFile)
 	start.Flag("config-string",
 		"Base64 encoded configuration string").Hidden().Envar(defaults.ConfigEnvar).
 		StringVar(&ccf.ConfigString)
@@ -161,6 +213,20 @@ func Run(options Options) (executedCommand string, conf *service.Config) {
 		if err = config.Configure(&ccf, conf); err != nil {
 			utils.FatalError(err)
 		}
+		// when started from a configuration file, support reloading the
+		// safe subset of the configuration in place on SIGHUP
+		if ccf.ConfigFile != "" {
+			if fileConf, err := config.ReadFromFile(ccf.ConfigFile); err == nil {
+				conf.OnReload = func() ([]string, error) {
+					nextConf, restartSections, err := config.ApplyReload(ccf.ConfigFile, fileConf)
+					if err != nil {
+						return nil, trace.Wrap(err)
+					}
+					fileConf = nextConf
+					return restartSections, nil
+				}
+			}
+		}
 		if !options.InitOnly {
 			err = OnStart(conf)
 		}
@@ -169,7 +235,13 @@ func Run(options Options) (executedCommand string, conf *service.Config) {
 	case status.FullCommand():
 		err = onStatus()
 	case dump.FullCommand():
-		err = onConfigDump()
+		err = onConfigDump(dumpFlags)
+	case check.FullCommand():
+		err = onConfigCheck(checkConfigFile)
+	case renameCluster.FullCommand():
+		err = onClusterRename(renameFlags)
+	case loadtestCmd.FullCommand():
+		err = onLoadtest(ltFlags)
 	case exec.FullCommand():
 		err = onExec()
 	case forward.FullCommand():
@@ -213,8 +285,8 @@ func onStatus() error {
 }
 
 // onConfigDump is the handler for "configure" CLI command
-func onConfigDump() error {
-	sfc, err := config.MakeSampleFileConfig()
+func onConfigDump(flags config.SampleFlags) error {
+	sfc, err := config.MakeTopologyFileConfig(flags)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -222,6 +294,97 @@ func onConfigDump() error {
 	return nil
 }
 
+// onConfigCheck is the handler for "configure check" CLI command, it
+// validates the configuration file without starting any services
+func onConfigCheck(configFilePath string) error {
+	if _, err := config.ReadFromFile(configFilePath); err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf("Configuration file %v is valid.\n", configFilePath)
+	return nil
+}
+
+// renameClusterFlags collects the CLI arguments of the "rename-cluster"
+// command.
+type renameClusterFlags struct {
+	// ConfigFile is the path to the auth server configuration file.
+	ConfigFile string
+	// NewName is the name the cluster is renamed to.
+	NewName string
+	// Force skips the interactive confirmation.
+	Force bool
+}
+
+// onClusterRename is the handler for the "rename-cluster" CLI command, it
+// migrates a stopped cluster to a new name by re-creating the cluster name
+// resource and dropping the certificate authorities issued under the old
+// name. The next auth server start re-issues them under the new name.
+func onClusterRename(flags renameClusterFlags) error {
+	conf := service.MakeDefaultConfig()
+	if err := config.Configure(&config.CommandLineFlags{ConfigFile: flags.ConfigFile}, conf); err != nil {
+		return trace.Wrap(err)
+	}
+	if !conf.Auth.Enabled {
+		return trace.BadParameter("the auth service is not enabled in %v, run this command on the auth server", flags.ConfigFile)
+	}
+	if !flags.Force {
+		fmt.Printf(`WARNING: Renaming the cluster invalidates every certificate it has issued.
+All Teleport processes in the cluster must be stopped before proceeding.
+After the rename, restart the auth server to generate new certificate
+authorities, then re-register every node and have users log in again.
+
+Rename this cluster to %q? (y/N): `, flags.NewName)
+		var response string
+		fmt.Scanln(&response)
+		if !strings.EqualFold(response, "y") && !strings.EqualFold(response, "yes") {
+			return trace.BadParameter("cluster rename aborted")
+		}
+	}
+	bk, err := openAuthStorage(&conf.Auth.StorageConfig)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer bk.Close()
+	oldName, err := auth.RenameCluster(bk, flags.NewName)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Printf(`Cluster %q has been renamed to %q.
+
+Next steps:
+ 1. Update "cluster_name" in the auth server configuration to %q.
+ 2. Start the auth server, it will generate new certificate authorities.
+ 3. Re-register every node and proxy with a new join token.
+ 4. Ask users to log in again to receive certificates for the new cluster.
+`, oldName, flags.NewName, flags.NewName)
+	return nil
+}
+
+// openAuthStorage opens the storage backend of a stopped auth server so it
+// can be migrated offline.
+func openAuthStorage(bc *backend.Config) (bk backend.Backend, err error) {
+	ctx := context.TODO()
+	switch bc.Type {
+	case lite.GetName():
+		bk, err = lite.New(ctx, bc.Params)
+	case firestore.GetName():
+		bk, err = firestore.New(ctx, bc.Params)
+	case dynamo.GetName():
+		bk, err = dynamo.New(ctx, bc.Params)
+	case etcdbk.GetName():
+		bk, err = etcdbk.New(ctx, bc.Params)
+	default:
+		err = trace.BadParameter("unsupported secrets storage type: %q", bc.Type)
+	}
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if err := bk.Migrate(ctx); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return bk, nil
+}
+
 // onSCP implements handling of 'scp' requests on the server side. When the teleport SSH daemon
 // receives an SSH "scp" request, it launches itself with 'scp' flag under the requested
 // user's privileges
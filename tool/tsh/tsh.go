@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/signal"
@@ -47,6 +48,7 @@ import (
 	"github.com/gravitational/teleport/lib/services"
 	"github.com/gravitational/teleport/lib/session"
 	"github.com/gravitational/teleport/lib/sshutils"
+	"github.com/gravitational/teleport/lib/teleagent"
 	"github.com/gravitational/teleport/lib/utils"
 	"github.com/gravitational/teleport/tool/tsh/common"
 
@@ -137,6 +139,9 @@ type CLIConf struct {
 	GopsAddr string
 	// IdentityFileIn is an argument to -i flag (path to the private key+cert file)
 	IdentityFileIn string
+	// TrustedCAKeysFile is an argument to --trusted-ca-keys flag (path to a
+	// file with SSH CA public keys to pre-seed into the local trust store)
+	TrustedCAKeysFile string
 	// Compatibility flags, --compat, specifies OpenSSH compatibility flags.
 	Compatibility string
 	// CertificateFormat defines the format of the user SSH certificate.
@@ -167,6 +172,34 @@ type CLIConf struct {
 	// Format is used to change the format of output
 	Format string
 
+	// ListAll is an argument to 'tsh ls --all', listing nodes in the root
+	// cluster and all reachable trusted clusters.
+	ListAll bool
+
+	// SendEnv is a list of local environment variable names to forward into
+	// the session, arguments to the --send-env flag.
+	SendEnv []string
+
+	// NodePickPolicy is an argument to the --pick flag, controlling node
+	// selection when multiple nodes match the label query.
+	NodePickPolicy string
+
+	// EncryptKey is an argument to 'tsh login --encrypt-key', storing the
+	// session private key encrypted with a passphrase.
+	EncryptKey bool
+
+	// AgentSocketPath is an argument to 'tsh agent --socket', the path of
+	// the unix socket to serve the SSH agent on.
+	AgentSocketPath string
+
+	// BundleOutput is an argument to 'tsh config export --out', the file
+	// to write the profile bundle to instead of stdout.
+	BundleOutput string
+
+	// BundlePath is an argument to 'tsh config import', the path of the
+	// profile bundle to import.
+	BundlePath string
+
 	// NoRemoteExec will not execute a remote command after connecting to a host,
 	// will block instead. Useful when port forwarding. Equivalent of -N for OpenSSH.
 	NoRemoteExec bool
@@ -186,6 +219,15 @@ type CLIConf struct {
 	// command/shell execution. This also requires stdin to be an interactive
 	// terminal.
 	EnableEscapeSequences bool
+
+	// EscapeChar overrides the default '~' escape character. A single
+	// character, caret notation like "^E", or "none" to disable escape
+	// sequences entirely.
+	EscapeChar string
+
+	// AutoReconnect enables automatic re-attach to the server-side session
+	// when the connection drops mid-session.
+	AutoReconnect bool
 }
 
 func main() {
@@ -232,6 +274,7 @@ func Run(args []string) {
 
 	app.Flag("ttl", "Minutes to live for a SSH session").Int32Var(&cf.MinsToLive)
 	app.Flag("identity", "Identity file").Short('i').StringVar(&cf.IdentityFileIn)
+	app.Flag("trusted-ca-keys", "Path to a file with SSH CA public keys (authorized_keys format) to trust without prompting").StringVar(&cf.TrustedCAKeysFile)
 	app.Flag("compat", "OpenSSH compatibility flag").Hidden().StringVar(&cf.Compatibility)
 	app.Flag("cert-format", "SSH certificate format").StringVar(&cf.CertificateFormat)
 	app.Flag("insecure", "Do not verify server's certificate and host name. Use only in test environments").Default("false").BoolVar(&cf.InsecureSkipVerify)
@@ -248,6 +291,9 @@ func Run(args []string) {
 	app.Flag("enable-escape-sequences", "Enable support for SSH escape sequences. Type '~?' during an SSH session to list supported sequences. Default is enabled.").
 		Default("true").
 		BoolVar(&cf.EnableEscapeSequences)
+	app.Flag("escape-char", "Override the '~' escape character for SSH sessions. Accepts a single character, caret notation like '^E' or 'none' to disable escape sequences.").
+		Short('e').
+		StringVar(&cf.EscapeChar)
 	app.Flag("bind-addr", "Override host:port used when opening a browser for cluster logins").Envar(bindAddrEnvVar).StringVar(&cf.BindAddr)
 	app.HelpFlag.Short('h')
 	ver := app.Command("version", "Print the version")
@@ -265,6 +311,9 @@ func Run(args []string) {
 	ssh.Flag("cluster", clusterHelp).Envar(clusterEnvVar).StringVar(&cf.SiteName)
 	ssh.Flag("option", "OpenSSH options in the format used in the configuration file").Short('o').AllowDuplicate().StringsVar(&cf.Options)
 	ssh.Flag("no-remote-exec", "Don't execute remote command, useful for port forwarding").Short('N').BoolVar(&cf.NoRemoteExec)
+	ssh.Flag("reconnect", "Automatically re-attach to the session if the connection drops").BoolVar(&cf.AutoReconnect)
+	ssh.Flag("send-env", "Forward a local environment variable into the session. Can be specified multiple times").AllowDuplicate().StringsVar(&cf.SendEnv)
+	ssh.Flag("pick", "Node selection policy when multiple nodes match the label query (prompt, first, random)").StringVar(&cf.NodePickPolicy)
 
 	// join
 	join := app.Command("join", "Join the active SSH session")
@@ -287,6 +336,7 @@ func Run(args []string) {
 	ls.Arg("labels", "List of labels to filter node list").StringVar(&cf.UserHost)
 	ls.Flag("verbose", "One-line output (for text format), including node UUIDs").Short('v').BoolVar(&cf.Verbose)
 	ls.Flag("format", "Format output (text, json, names)").Short('f').Default(teleport.Text).StringVar(&cf.Format)
+	ls.Flag("all", "List nodes from the root cluster and all reachable trusted clusters").BoolVar(&cf.ListAll)
 	// clusters
 	clusters := app.Command("clusters", "List available Teleport clusters")
 	clusters.Flag("quiet", "Quiet mode").Short('q').BoolVar(&cf.Quiet)
@@ -303,6 +353,7 @@ func Run(args []string) {
 	login.Flag("request-roles", "Request one or more extra roles").StringVar(&cf.DesiredRoles)
 	login.Arg("cluster", clusterHelp).StringVar(&cf.SiteName)
 	login.Flag("browser", browserHelp).StringVar(&cf.Browser)
+	login.Flag("encrypt-key", "Store the session private key encrypted with a passphrase").BoolVar(&cf.EncryptKey)
 	// TODO(awly): unhide this flag in 5.0, after 'tsh kube ...' commands are
 	// implemented.
 	login.Flag("kube-cluster", "Name of the Kubernetes cluster to login to").Hidden().StringVar(&cf.KubernetesCluster)
@@ -334,14 +385,29 @@ func Run(args []string) {
 	// about the certificate.
 	status := app.Command("status", "Display the list of proxy servers and retrieved certificates")
 
-	// On Windows, hide the "ssh", "join", "play", "scp", and "bench" commands
-	// because they all use a terminal.
+	// The agent command serves the retrieved certificates on an SSH agent
+	// socket so third party tools (git, rsync, IDEs) can use them.
+	agentCmd := app.Command("agent", "Serve the retrieved certificates on a local SSH agent socket")
+	agentCmd.Flag("socket", "Path of the unix socket to listen on").StringVar(&cf.AgentSocketPath)
+
+	// The config command shares connection profiles between teammates:
+	// export produces a bundle with proxy addresses, CA pins and default
+	// options (but no keys), import seeds ~/.tsh from such a bundle.
+	configCmd := app.Command("config", "Manage shareable connection profiles")
+	configExport := configCmd.Command("export", "Export the current profile as a shareable bundle (contains no keys)")
+	configExport.Flag("out", "Write the bundle to a file instead of stdout").Short('o').StringVar(&cf.BundleOutput)
+	configImport := configCmd.Command("import", "Import a profile bundle shared by a teammate")
+	configImport.Arg("file", "Path to the profile bundle").Required().StringVar(&cf.BundlePath)
+
+	// On Windows, hide the "ssh", "join", "play", "scp", "bench" and "agent"
+	// commands because they all use a terminal or unix sockets.
 	if runtime.GOOS == teleport.WindowsOS {
 		ssh.Hidden()
 		join.Hidden()
 		play.Hidden()
 		scp.Hidden()
 		bench.Hidden()
+		agentCmd.Hidden()
 	}
 
 	// parse CLI commands+flags:
@@ -400,6 +466,12 @@ func Run(args []string) {
 		onShow(&cf)
 	case status.FullCommand():
 		onStatus(&cf)
+	case agentCmd.FullCommand():
+		onAgent(&cf)
+	case configExport.FullCommand():
+		onConfigExport(&cf)
+	case configImport.FullCommand():
+		onConfigImport(&cf)
 	}
 }
 
@@ -730,6 +802,11 @@ func onLogout(cf *CLIConf) {
 
 // onListNodes executes 'tsh ls' command.
 func onListNodes(cf *CLIConf) {
+	if cf.ListAll {
+		onListNodesAllClusters(cf)
+		return
+	}
+
 	tc, err := makeClient(cf, true)
 	if err != nil {
 		utils.FatalError(err)
@@ -754,6 +831,39 @@ func onListNodes(cf *CLIConf) {
 
 }
 
+// onListNodesAllClusters executes 'tsh ls --all'. It lists nodes in the root
+// cluster and all reachable trusted clusters concurrently through the proxy
+// and merges the results with a cluster column.
+func onListNodesAllClusters(cf *CLIConf) {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	var clusterNodes map[string][]services.Server
+	err = client.RetryWithRelogin(cf.Context, tc, func() error {
+		clusterNodes, err = tc.ListNodesInAllClusters(cf.Context)
+		return err
+	})
+	if err != nil {
+		utils.FatalError(err)
+	}
+
+	// Sort clusters by name and nodes within each cluster by "Node Name".
+	clusters := make([]string, 0, len(clusterNodes))
+	for cluster, nodes := range clusterNodes {
+		clusters = append(clusters, cluster)
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].GetHostname() < nodes[j].GetHostname()
+		})
+	}
+	sort.Strings(clusters)
+
+	if err := printNodesWithClusters(clusters, clusterNodes, cf.Format, cf.Verbose); err != nil {
+		utils.FatalError(err)
+	}
+}
+
 func executeAccessRequest(cf *CLIConf) {
 	if cf.DesiredRoles == "" {
 		utils.FatalError(trace.BadParameter("one or more roles must be specified"))
@@ -823,14 +933,16 @@ func printNodesAsText(nodes []services.Server, verbose bool) {
 			})
 		}
 	// In normal mode chunk the labels and print two per line and allow multiple
-	// lines per node.
+	// lines per node. Nodes sharing a hostname get the short node ID appended
+	// so every listed name is an unambiguous "tsh ssh" target.
 	case false:
+		names := services.DisambiguateHostnames(nodes)
 		t = asciitable.MakeTable([]string{"Node Name", "Address", "Labels"})
 		for _, n := range nodes {
 			labelChunks := chunkLabels(n.GetAllLabels(), 2)
 			for i, v := range labelChunks {
 				if i == 0 {
-					t.AddRow([]string{n.GetHostname(), getAddr(n), strings.Join(v, ", ")})
+					t.AddRow([]string{names[n.GetName()], getAddr(n), strings.Join(v, ", ")})
 				} else {
 					t.AddRow([]string{"", "", strings.Join(v, ", ")})
 				}
@@ -841,6 +953,69 @@ func printNodesAsText(nodes []services.Server, verbose bool) {
 	fmt.Println(t.AsBuffer().String())
 }
 
+func printNodesWithClusters(clusters []string, clusterNodes map[string][]services.Server, format string, verbose bool) error {
+	switch strings.ToLower(format) {
+	case teleport.Text:
+		printNodesWithClustersAsText(clusters, clusterNodes, verbose)
+	case teleport.JSON:
+		out, err := json.MarshalIndent(clusterNodes, "", "  ")
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Println(string(out))
+	case teleport.Names:
+		for _, cluster := range clusters {
+			for _, n := range clusterNodes[cluster] {
+				fmt.Println(n.GetHostname())
+			}
+		}
+	default:
+		return trace.BadParameter("unsupported format. try 'json', 'text', or 'names'")
+	}
+
+	return nil
+}
+
+// printNodesWithClustersAsText prints the merged node list of 'tsh ls --all'
+// with an extra column identifying the cluster each node belongs to.
+func printNodesWithClustersAsText(clusters []string, clusterNodes map[string][]services.Server, verbose bool) {
+	getAddr := func(n services.Server) string {
+		if n.GetUseTunnel() {
+			return "⟵ Tunnel"
+		}
+		return n.GetAddr()
+	}
+
+	var t asciitable.Table
+	switch verbose {
+	case true:
+		t = asciitable.MakeTable([]string{"Cluster", "Node Name", "Node ID", "Address", "Labels"})
+		for _, cluster := range clusters {
+			for _, n := range clusterNodes[cluster] {
+				t.AddRow([]string{
+					cluster, n.GetHostname(), n.GetName(), getAddr(n), n.LabelsString(),
+				})
+			}
+		}
+	case false:
+		t = asciitable.MakeTable([]string{"Cluster", "Node Name", "Address", "Labels"})
+		for _, cluster := range clusters {
+			for _, n := range clusterNodes[cluster] {
+				labelChunks := chunkLabels(n.GetAllLabels(), 2)
+				for i, v := range labelChunks {
+					if i == 0 {
+						t.AddRow([]string{cluster, n.GetHostname(), getAddr(n), strings.Join(v, ", ")})
+					} else {
+						t.AddRow([]string{"", "", "", strings.Join(v, ", ")})
+					}
+				}
+			}
+		}
+	}
+
+	fmt.Println(t.AsBuffer().String())
+}
+
 // chunkLabels breaks labels into sized chunks. Used to improve readability
 // of "tsh ls".
 func chunkLabels(labels map[string]string, chunkSize int) [][]string {
@@ -1179,6 +1354,11 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 	if hostLogin != "" {
 		c.HostLogin = hostLogin
 	}
+	// replace a host alias from the profile with the real target host
+	if alias, ok := c.HostAliases[cf.UserHost]; ok {
+		log.Debugf("Replacing host alias %q with %q.", cf.UserHost, alias)
+		cf.UserHost = alias
+	}
 	c.Host = cf.UserHost
 	c.HostPort = int(cf.NodePort)
 	c.Labels = labels
@@ -1237,6 +1417,30 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 
 	c.EnableEscapeSequences = cf.EnableEscapeSequences
 
+	if cf.EscapeChar != "" {
+		escapeChar, err := parseEscapeChar(cf.EscapeChar)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if escapeChar == 0 {
+			c.EnableEscapeSequences = false
+		} else {
+			c.EscapeChar = escapeChar
+		}
+	}
+
+	c.AutoReconnect = cf.AutoReconnect
+
+	// Forward the requested local environment variables into sessions.
+	c.SendEnv = cf.SendEnv
+
+	c.NodePickPolicy = cf.NodePickPolicy
+
+	// Pre-seed pinned CA keys for zero-TOFU deployments.
+	c.TrustedCAKeysFile = cf.TrustedCAKeysFile
+
+	c.EncryptKeys = cf.EncryptKey
+
 	tc, err := client.NewClient(c)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -1255,6 +1459,21 @@ func makeClient(cf *CLIConf, useProfileLogin bool) (*client.TeleportClient, erro
 	return tc, nil
 }
 
+// parseEscapeChar parses the --escape-char flag value. It accepts a single
+// character, caret notation for control characters (e.g. "^E") or "none" to
+// disable escape sequences, in which case zero is returned.
+func parseEscapeChar(s string) (byte, error) {
+	switch {
+	case s == "none":
+		return 0, nil
+	case len(s) == 1:
+		return s[0], nil
+	case len(s) == 2 && s[0] == '^' && s[1] >= '@' && s[1] <= '_':
+		return s[1] & 0x1f, nil
+	}
+	return 0, trace.BadParameter("invalid escape character: %q", s)
+}
+
 func parseCertificateCompatibilityFlag(compatibility string, certificateFormat string) (string, error) {
 	switch {
 	// if nothing is passed in, the role will decide
@@ -1390,6 +1609,107 @@ func onStatus(cf *CLIConf) {
 	printProfiles(cf.Debug, profile, profiles)
 }
 
+// onAgent serves the retrieved certificates on an SSH agent socket so third
+// party tools like git, rsync and IDEs can authenticate with Teleport-issued
+// identities without exporting key files.
+func onAgent(cf *CLIConf) {
+	tc, err := makeClient(cf, true)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	socketPath := cf.AgentSocketPath
+	if socketPath == "" {
+		socketDir, err := ioutil.TempDir("", "tsh-agent")
+		if err != nil {
+			utils.FatalError(trace.ConvertSystemError(err))
+		}
+		socketPath = filepath.Join(socketDir, fmt.Sprintf("agent.%v.sock", os.Getpid()))
+	} else {
+		// remove a stale socket possibly left over from a previous run
+		os.Remove(socketPath)
+	}
+
+	agentServer := teleagent.NewServer(func() (teleagent.Agent, error) {
+		return teleagent.NopCloser(tc.LocalAgent()), nil
+	})
+	if err := agentServer.ListenUnixSocket(socketPath, os.Getuid(), os.Getgid(), 0600); err != nil {
+		utils.FatalError(err)
+	}
+	go func() {
+		<-cf.Context.Done()
+		agentServer.Close()
+	}()
+
+	// print the socket path the same way ssh-agent does so the output can
+	// be eval'ed by the shell
+	fmt.Printf("SSH_AUTH_SOCK=%v; export SSH_AUTH_SOCK;\n", socketPath)
+	if err := agentServer.Serve(); err != nil && !strings.Contains(err.Error(), teleport.UseOfClosedNetworkConnection) {
+		utils.FatalError(err)
+	}
+}
+
+// onConfigExport exports the current profile as a shareable bundle: proxy
+// addresses, CA pins, default options and host aliases, but no keys.
+func onConfigExport(cf *CLIConf) {
+	profileDir := client.FullProfilePath("")
+	profile, err := client.ProfileFromDir(profileDir, client.ProxyHost(cf.Proxy))
+	if err != nil {
+		if trace.IsNotFound(err) {
+			utils.FatalError(trace.NotFound("no profile to export, log in first with 'tsh login'"))
+		}
+		utils.FatalError(err)
+	}
+	// pin the TLS certificate authorities the profile already trusts
+	var certsPEM [][]byte
+	store, err := client.NewFSLocalKeyStore(profileDir)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	certsPEM, err = store.GetCertsPEM(profile.Name())
+	if err != nil && !trace.IsNotFound(err) {
+		utils.FatalError(err)
+	}
+	bundle, err := client.NewProfileBundle(profile, certsPEM)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	data, err := bundle.Encode()
+	if err != nil {
+		utils.FatalError(err)
+	}
+	if cf.BundleOutput == "" {
+		fmt.Printf("%s", data)
+		return
+	}
+	if err := ioutil.WriteFile(cf.BundleOutput, data, 0644); err != nil {
+		utils.FatalError(trace.ConvertSystemError(err))
+	}
+	fmt.Printf("Exported profile %q to %v\n", profile.Name(), cf.BundleOutput)
+}
+
+// onConfigImport seeds ~/.tsh from a profile bundle shared by a teammate.
+func onConfigImport(cf *CLIConf) {
+	bundle, err := client.ProfileBundleFromFile(cf.BundlePath)
+	if err != nil {
+		utils.FatalError(err)
+	}
+	profile := bundle.Profile()
+	profileDir := client.FullProfilePath("")
+	if err := os.MkdirAll(profileDir, 0700); err != nil {
+		utils.FatalError(trace.ConvertSystemError(err))
+	}
+	// only switch the current profile if none is active yet
+	makeCurrent := false
+	if _, err := client.GetCurrentProfileName(profileDir); trace.IsNotFound(err) {
+		makeCurrent = true
+	}
+	if err := profile.SaveToDir(profileDir, makeCurrent); err != nil {
+		utils.FatalError(err)
+	}
+	fmt.Printf("Imported profile %q. Log in with: tsh login --proxy=%v\n",
+		profile.Name(), profile.WebProxyAddr)
+}
+
 func printProfiles(debug bool, profile *client.ProfileStatus, profiles []*client.ProfileStatus) {
 	// Print the active profile.
 	if profile != nil {